@@ -0,0 +1,13 @@
+package main
+
+import (
+	logGo "log"
+	"order-service/internal/pkg/eventschema"
+)
+
+// writes the JSON Schema of every event to schemas/events, run from the repository root with make event-schema
+func main() {
+	if err := eventschema.Generate(eventschema.Dir); err != nil {
+		logGo.Fatal(err)
+	}
+}
@@ -1,30 +1,47 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	logGo "log"
 	"order-service/configs"
 	eventRepoQuery "order-service/internal/modules/event/repositories/queries"
+	eventUsecase "order-service/internal/modules/event/usecases"
 	orderHandler "order-service/internal/modules/order/handlers"
 	orderRepoCommand "order-service/internal/modules/order/repositories/commands"
 	orderRepoQuery "order-service/internal/modules/order/repositories/queries"
 	orderUsecase "order-service/internal/modules/order/usecases"
+	outboxHandler "order-service/internal/modules/outbox/handlers"
+	outboxRepoCommand "order-service/internal/modules/outbox/repositories/commands"
+	outboxRepoQuery "order-service/internal/modules/outbox/repositories/queries"
+	outboxUsecase "order-service/internal/modules/outbox/usecases"
+	pricingRepoCommand "order-service/internal/modules/pricing/repositories/commands"
+	pricingRepoQuery "order-service/internal/modules/pricing/repositories/queries"
+	pricingUsecase "order-service/internal/modules/pricing/usecases"
+	promoRepoCommand "order-service/internal/modules/promo/repositories/commands"
+	promoRepoQuery "order-service/internal/modules/promo/repositories/queries"
+	promoUsecase "order-service/internal/modules/promo/usecases"
 	roomHandler "order-service/internal/modules/room/handlers"
 	roomRepoCommand "order-service/internal/modules/room/repositories/commands"
 	roomRepoQuery "order-service/internal/modules/room/repositories/queries"
 	roomUsecase "order-service/internal/modules/room/usecases"
 	ticketRepoCommand "order-service/internal/modules/ticket/repositories/commands"
 	ticketRepoQuery "order-service/internal/modules/ticket/repositories/queries"
+	ticketUsecase "order-service/internal/modules/ticket/usecases"
 	userRepoQuery "order-service/internal/modules/user/repositories/queries"
 	"order-service/internal/pkg/apm"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/databases/mongodb"
 	graceful "order-service/internal/pkg/gs"
 	"order-service/internal/pkg/helpers"
 	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	kafkaMemory "order-service/internal/pkg/kafka/memory"
 	"order-service/internal/pkg/log"
 	"order-service/internal/pkg/redis"
 	"strconv"
 	"time"
+	// sale schedules name IANA time zones, embed them for images without a zone database
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -105,6 +122,7 @@ func main() {
 	if err := app.Listen(fmt.Sprintf(":%s", configs.GetConfig().ServicePort)); err != nil {
 		logGo.Fatal(err)
 	}
+	gs.Cleanup()
 }
 
 func setHttp(app *fiber.App, gs *graceful.GracefulShutdown) {
@@ -119,15 +137,13 @@ func setHttp(app *fiber.App, gs *graceful.GracefulShutdown) {
 	logger := log.GetLogger()
 	mongoMasterClient := mongodb.NewMongoDBLogger(mongodb.GetMasterConn(), mongodb.GetMasterDBName(), logger)
 	mongoSlaveClient := mongodb.NewMongoDBLogger(mongodb.GetSlaveConn(), mongodb.GetMasterDBName(), logger)
-	kafkaProducer, err := kafkaConfluent.NewProducer(kafkaConfluent.GetConfig().GetKafkaConfig(configs.GetConfig().ServiceName, true), logger)
-	if err != nil {
-		panic(err)
-	}
+	kafkaProducer, kafkaConsumer := setKafka(logger)
 	gs.Register(
 		mongoMasterClient,
 		mongoSlaveClient,
 		graceful.FnWithError(redisClient.Close),
 		kafkaProducer,
+		kafkaConsumer,
 	)
 
 	ticketQueryMongodbRepo := ticketRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
@@ -136,19 +152,106 @@ func setHttp(app *fiber.App, gs *graceful.GracefulShutdown) {
 	eventQueryMongodbRepo := eventRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
 	userQueryMongodbRepo := userRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
 
+	promoCommandMongodbRepo := promoRepoCommand.NewCommandMongodbRepository(mongoMasterClient, logger)
+	promoQueryMongodbRepo := promoRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
+	if resp := <-promoCommandMongodbRepo.CreatePromoIndexes(context.Background()); resp.Error != nil {
+		logger.Error(context.Background(), "cannot create promo indexes", fmt.Sprintf("%+v", resp.Error))
+	}
+	outboxCommandMongodbRepo := outboxRepoCommand.NewCommandMongodbRepository(mongoMasterClient, logger)
+	// the relay reads from the master so it never misses an event that was just committed
+	outboxQueryMongodbRepo := outboxRepoQuery.NewQueryMongodbRepository(mongoMasterClient, logger)
+	if resp := <-outboxCommandMongodbRepo.CreateOutboxIndexes(context.Background()); resp.Error != nil {
+		logger.Error(context.Background(), "cannot create outbox indexes", fmt.Sprintf("%+v", resp.Error))
+	}
+	outboxRelay := outboxUsecase.NewRelay(outboxQueryMongodbRepo, outboxCommandMongodbRepo, kafkaProducer, logger)
+
+	promoValidator := promoUsecase.NewCodeValidator(promoQueryMongodbRepo, logger)
+	saleSchedule := eventUsecase.NewSaleSchedule(logger)
+	ticketReleasePolicy := ticketUsecase.NewReleasePolicy(ticketQueryMongodbRepo, logger)
+
 	roomCommandMongodbRepo := roomRepoCommand.NewCommandMongodbRepository(mongoMasterClient, logger)
 	roomQueryMongodbRepo := roomRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
-	roomUsecase := roomUsecase.NewCommandUsecase(roomQueryMongodbRepo, roomCommandMongodbRepo, ticketQueryMongodbRepo,
-		eventQueryMongodbRepo, logger, redisClient)
+	if resp := <-roomCommandMongodbRepo.CreateQueueIndexes(context.Background()); resp.Error != nil {
+		logger.Error(context.Background(), "cannot create queue-room indexes", fmt.Sprintf("%+v", resp.Error))
+	}
+	roomAdmission := roomUsecase.NewAdmissionController(logger, redisClient)
+	roomQueueEntries := roomUsecase.NewQueueEntries(roomQueryMongodbRepo, redisClient, logger)
+	// leaving reads the held tickets from the master so a hold taken just before is seen
+	orderMasterQueryMongodbRepo := orderRepoQuery.NewQueryMongodbRepository(mongoMasterClient, logger)
+	roomUsecaseCommand := roomUsecase.NewCommandUsecase(roomQueryMongodbRepo, roomCommandMongodbRepo, ticketQueryMongodbRepo,
+		eventQueryMongodbRepo, logger, redisClient, roomAdmission, promoValidator, saleSchedule, outboxCommandMongodbRepo,
+		roomQueueEntries, orderMasterQueryMongodbRepo)
+	roomUsecaseQuery := roomUsecase.NewQueryUsecase(roomQueryMongodbRepo, roomCommandMongodbRepo, roomAdmission, roomQueueEntries,
+		helperImpl, logger)
+
+	pricingCommandMongodbRepo := pricingRepoCommand.NewCommandMongodbRepository(mongoMasterClient, logger)
+	if resp := <-pricingCommandMongodbRepo.SeedDefaultRules(context.Background()); resp.Error != nil {
+		logger.Error(context.Background(), "cannot seed pricing rules", fmt.Sprintf("%+v", resp.Error))
+	}
+	pricingQueryMongodbRepo := pricingRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
+	pricingEngine := pricingUsecase.NewRuleEngine(pricingQueryMongodbRepo, logger)
 
 	orderCommandMongodbRepo := orderRepoCommand.NewCommandMongodbRepository(mongoMasterClient, logger)
 	orderQueryMongodbRepo := orderRepoQuery.NewQueryMongodbRepository(mongoSlaveClient, logger)
-	orderUsecaseCommand := orderUsecase.NewCommandUsecase(orderCommandMongodbRepo, orderQueryMongodbRepo, roomQueryMongodbRepo,
-		ticketQueryMongodbRepo, ticketCommandMongodbRepo, eventQueryMongodbRepo, userQueryMongodbRepo, logger, redisClient)
-	orderUsecaseQuery := orderUsecase.NewQueryUsecase(orderQueryMongodbRepo, logger)
+	if resp := <-orderCommandMongodbRepo.CreateOrderIndexes(context.Background()); resp.Error != nil {
+		logger.Error(context.Background(), "cannot create order indexes", fmt.Sprintf("%+v", resp.Error))
+	}
+	orderUsecaseCommand := orderUsecase.NewCommandUsecase(orderCommandMongodbRepo, orderQueryMongodbRepo, ticketQueryMongodbRepo,
+		ticketCommandMongodbRepo, eventQueryMongodbRepo, userQueryMongodbRepo, logger, redisClient, roomAdmission, pricingEngine,
+		promoValidator, promoCommandMongodbRepo, saleSchedule, ticketReleasePolicy, outboxCommandMongodbRepo)
+	orderUsecaseQuery := orderUsecase.NewQueryUsecase(orderQueryMongodbRepo, eventQueryMongodbRepo, ticketQueryMongodbRepo, logger, redisClient)
 
 	// set module
-	roomHandler.InitRoomHttpHandler(app, roomUsecase, logger, redisClient)
+	roomHandler.InitRoomHttpHandler(app, roomUsecaseCommand, roomUsecaseQuery, roomAdmission, logger, redisClient)
 	orderHandler.InitOrderHttpHandler(app, orderUsecaseCommand, orderUsecaseQuery, logger, redisClient)
+	orderHandler.InitOrderKafkaHandler(kafkaConsumer, orderUsecaseCommand, logger)
+	if err := kafkaConsumer.Start(); err != nil {
+		panic(err)
+	}
+
+	// set worker
+	expiryInterval, err := strconv.Atoi(configs.GetConfig().Order.ExpiryInterval)
+	if err != nil || expiryInterval <= 0 {
+		expiryInterval = 30
+	}
+	expiryWorker := orderHandler.InitExpiryWorker(orderUsecaseCommand, logger, time.Duration(expiryInterval)*time.Second)
+	gs.Register(expiryWorker)
 
+	admissionInterval, err := strconv.Atoi(configs.GetConfig().Room.AdmissionInterval)
+	if err != nil || admissionInterval <= 0 {
+		admissionInterval = 30
+	}
+	admissionWorker := roomHandler.InitAdmissionWorker(roomAdmission, logger, time.Duration(admissionInterval)*time.Second)
+	gs.Register(admissionWorker)
+
+	relayInterval, err := strconv.Atoi(configs.GetConfig().Outbox.RelayInterval)
+	if err != nil || relayInterval <= 0 {
+		relayInterval = 5
+	}
+	relayWorker := outboxHandler.InitRelayWorker(outboxRelay, logger, time.Duration(relayInterval)*time.Second)
+	gs.Register(relayWorker)
+
+}
+
+// setKafka connects to the kafka cluster, or to an in-process broker when KAFKA_BROKER is memory.
+func setKafka(logger log.Logger) (kafkaConfluent.Producer, kafkaConfluent.Consumer) {
+	kafkaRetry := kafkaConfluent.NewRetryPolicy(configs.GetConfig().Kafka.ConsumerMaxRetries, configs.GetConfig().Kafka.ConsumerRetryBackoff,
+		configs.GetConfig().Kafka.ConsumerRetryMaxBackoff)
+
+	if configs.GetConfig().Kafka.Broker == constants.KafkaBrokerMemory {
+		logger.Info(context.Background(), "kafka runs in memory, messages are lost on restart", "")
+		broker := kafkaMemory.NewBroker(kafkaMemory.DefaultPartitions)
+		return broker.NewProducer(logger), broker.NewConsumer(configs.GetConfig().ServiceName, kafkaRetry, logger)
+	}
+
+	kafkaProducer, err := kafkaConfluent.NewProducer(kafkaConfluent.GetConfig().GetKafkaConfig(configs.GetConfig().ServiceName, true), logger)
+	if err != nil {
+		panic(err)
+	}
+	kafkaConsumer, err := kafkaConfluent.NewConsumer(kafkaConfluent.GetConfig().GetKafkaConfig(configs.GetConfig().ServiceName, false),
+		kafkaConfluent.GetConfig().GetKafkaConfig(configs.GetConfig().ServiceName, true), kafkaRetry, logger)
+	if err != nil {
+		panic(err)
+	}
+	return kafkaProducer, kafkaConsumer
 }
@@ -23,12 +23,15 @@ type Config struct {
 	Datadog           DatadogConfig    `envconfig:"datadog"`
 	Kafka             KafkaConfig      `envconfig:"kafka"`
 	Jwt               JwtConfig        `envconfig:"jwt"`
+	Order             OrderConfig      `envconfig:"order"`
+	Room              RoomConfig       `envconfig:"room"`
+	Pricing           PricingConfig    `envconfig:"pricing"`
+	Outbox            OutboxConfig     `envconfig:"outbox"`
 	UsernameBasicAuth string           `envconfig:"username_basic_auth"`
 	PasswordBasicAuth string           `envconfig:"password_basic_auth"`
 	ShutDownDelay     string           `envconfig:"shutdown_delay"`
 	SecretHashPass    string           `envconfig:"secret_hash_pass"`
 	IdHash            string           `envconfig:"id_hash"`
-	DayFlag           bool             `envconfig:"day_flag"`
 	AppsLimiter       bool             `envconfig:"apps_limiter"`
 }
 
@@ -83,9 +86,13 @@ type DatadogConfig struct {
 }
 
 type KafkaConfig struct {
-	KafkaUrl      string `envconfig:"kafka_url"`
-	KafkaUsername string `envconfig:"kafka_username"`
-	KafkaPassword string `envconfig:"kafka_password"`
+	Broker                  string `envconfig:"kafka_broker"`
+	KafkaUrl                string `envconfig:"kafka_url"`
+	KafkaUsername           string `envconfig:"kafka_username"`
+	KafkaPassword           string `envconfig:"kafka_password"`
+	ConsumerMaxRetries      string `envconfig:"kafka_consumer_max_retries"`
+	ConsumerRetryBackoff    string `envconfig:"kafka_consumer_retry_backoff"`
+	ConsumerRetryMaxBackoff string `envconfig:"kafka_consumer_retry_max_backoff"`
 }
 
 type JwtConfig struct {
@@ -95,6 +102,36 @@ type JwtConfig struct {
 	JwtRefreshPublicKey  string `envconfig:"public_key_refresh"`
 }
 
+type OrderConfig struct {
+	HoldDuration   string `envconfig:"order_hold_duration"`
+	ExpiryInterval string `envconfig:"order_expiry_interval"`
+	ExpiryBatch    string `envconfig:"order_expiry_batch"`
+	MaxTickets     string `envconfig:"order_max_tickets"`
+	SeatHoldTTL    string `envconfig:"order_seat_hold_ttl"`
+}
+
+type RoomConfig struct {
+	AdmissionBatch    string `envconfig:"room_admission_batch"`
+	AdmissionInterval string `envconfig:"room_admission_interval"`
+	AdmissionMode     string `envconfig:"room_admission_mode"`
+	AdmissionTokenTTL string `envconfig:"room_admission_token_ttl"`
+	QueueEntryTTL     string `envconfig:"room_queue_entry_ttl"`
+	ReentryPolicy     string `envconfig:"room_reentry_policy"`
+	ReentryCooldown   string `envconfig:"room_reentry_cooldown"`
+}
+
+type PricingConfig struct {
+	RulesCacheTTL string `envconfig:"pricing_rules_cache_ttl"`
+}
+
+type OutboxConfig struct {
+	RelayInterval   string `envconfig:"outbox_relay_interval"`
+	RelayBatch      string `envconfig:"outbox_relay_batch"`
+	RetryBackoff    string `envconfig:"outbox_retry_backoff"`
+	RetryMaxBackoff string `envconfig:"outbox_retry_max_backoff"`
+	MaxAttempts     string `envconfig:"outbox_max_attempts"`
+}
+
 func InitConfig() *Config {
 	err := godotenv.Load()
 	if err != nil {
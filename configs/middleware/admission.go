@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	helpers "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/redis"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VerifyAdmission checks the admission token issued by the waiting room, it must run after VerifyBearer.
+// The token is rejected once the user left the queue entry it was issued to.
+func (m Middlewares) VerifyAdmission() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		logger := m.logger
+		helperImpl := &helpers.JwtImpl{}
+		admission, err := helperImpl.AdmissionAuthorization(c.Get(constants.HeaderAdmissionToken))
+		if err != nil {
+			return helpers.RespError(c, logger, err)
+		}
+
+		userId, _ := c.Locals("userId").(string)
+		if admission.UserId != userId {
+			logger.Error(c.Context(), "Admission token does not belong to the user", admission.UserId)
+			return helpers.RespError(c, logger, errors.ForbiddenError("Invalid admission token"))
+		}
+
+		// the entry keeps its queue id when the user joins again, only the tokens up to the number it left with are revoked
+		revoked, err := m.redisClient.Get(c.Context(), fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueRevoked,
+			redis.HashTag(admission.EventId), admission.QueueId)).Int()
+		if err == nil && admission.QueueNumber <= revoked {
+			logger.Error(c.Context(), "Admission token of a queue entry that was left", admission.QueueId)
+			return helpers.RespError(c, logger, errors.ForbiddenError("Invalid admission token"))
+		}
+
+		c.Locals("admission", *admission)
+		return c.Next()
+	}
+}
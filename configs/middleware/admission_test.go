@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http/httptest"
+	"order-service/internal/pkg/constants"
+	helpers "order-service/internal/pkg/helpers"
+	mocklog "order-service/mocks/pkg/log"
+	mockredis "order-service/mocks/pkg/redis"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdmissionTestSuite struct {
+	suite.Suite
+	mockRedis  *mockredis.Collections
+	mockLogger *mocklog.Logger
+	app        *fiber.App
+	token      string
+}
+
+func encodedKeys(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})
+	return base64.StdEncoding.EncodeToString(private), base64.StdEncoding.EncodeToString(public)
+}
+
+func (suite *AdmissionTestSuite) SetupSuite() {
+	private, public := encodedKeys(suite.T())
+	jwt := &helpers.JwtImpl{}
+	jwt.InitConfig(private, public, private, public)
+
+	token, _, err := jwt.GenerateAdmissionToken(time.Minute, helpers.PayloadAdmission{
+		EventId:     "event",
+		UserId:      "user",
+		QueueId:     "queue",
+		QueueNumber: 50,
+	})
+	suite.Require().NoError(err)
+	suite.token = token
+}
+
+func (suite *AdmissionTestSuite) SetupTest() {
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis = &mockredis.Collections{}
+
+	middlewares := Middlewares{redisClient: suite.mockRedis, logger: suite.mockLogger}
+	suite.app = fiber.New()
+	suite.app.Post("/v1/create-order", func(c *fiber.Ctx) error {
+		c.Locals("userId", "user")
+		return c.Next()
+	}, middlewares.VerifyAdmission(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+}
+
+func TestAdmissionTestSuite(t *testing.T) {
+	suite.Run(t, new(AdmissionTestSuite))
+}
+
+func (suite *AdmissionTestSuite) request() int {
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/create-order", nil)
+	req.Header.Set(constants.HeaderAdmissionToken, suite.token)
+	resp, err := suite.app.Test(req, -1)
+	suite.Require().NoError(err)
+	return resp.StatusCode
+}
+
+func (suite *AdmissionTestSuite) TestVerifyAdmission() {
+	suite.mockRedis.On("Get", mock.Anything, "ORDER:QUEUE-REVOKED:{event}:queue").Return(redis.NewStringResult("", redis.Nil))
+
+	assert.Equal(suite.T(), fiber.StatusCreated, suite.request())
+}
+
+func (suite *AdmissionTestSuite) TestVerifyAdmissionLeftQueue() {
+	suite.mockRedis.On("Get", mock.Anything, "ORDER:QUEUE-REVOKED:{event}:queue").Return(redis.NewStringResult("50", nil))
+
+	assert.Equal(suite.T(), fiber.StatusForbidden, suite.request())
+}
+
+func (suite *AdmissionTestSuite) TestVerifyAdmissionJoinedAgain() {
+	// the entry was left with number 40 and joined again with 50
+	suite.mockRedis.On("Get", mock.Anything, "ORDER:QUEUE-REVOKED:{event}:queue").Return(redis.NewStringResult("40", nil))
+
+	assert.Equal(suite.T(), fiber.StatusCreated, suite.request())
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	helpers "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// how long a completed response can be replayed
+	idempotencyTTL = 24 * time.Hour
+	// how long a request may stay in flight before a retry is let through again
+	idempotencyLockTTL = time.Minute
+)
+
+// idempotencyRecord is what is kept in redis for one Idempotency-Key.
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	InFlight    bool   `json:"inFlight"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency replays the first response of a request carrying an Idempotency-Key, it must run after VerifyBearer.
+// Requests without the header pass through untouched.
+func (m Middlewares) Idempotency() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		logger := m.logger
+		key := c.Get(constants.HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+
+		userId, _ := c.Locals("userId").(string)
+		redisKey := fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.RedisKeyIdempotency, userId, key)
+		fingerprint := requestFingerprint(c, userId)
+
+		stored, _ := m.redisClient.Get(c.Context(), redisKey).Result()
+		if stored != "" {
+			return replayIdempotent(c, logger, stored, fingerprint)
+		}
+
+		lock, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, InFlight: true})
+		acquired, err := m.redisClient.SetNX(c.Context(), redisKey, lock, idempotencyLockTTL).Result()
+		if err != nil {
+			// redis being down should not take ordering down with it
+			logger.Error(c.Context(), "Cannot lock idempotency key", fmt.Sprintf("%+v", err))
+			return c.Next()
+		}
+		if !acquired {
+			return helpers.RespError(c, logger, errors.Conflict("request with this Idempotency-Key is still in progress"))
+		}
+
+		if err := c.Next(); err != nil {
+			m.redisClient.Del(c.Context(), redisKey)
+			return err
+		}
+
+		// only a final answer is worth replaying, anything else gets another try on retry
+		if !replayable(c.Response().StatusCode()) {
+			m.redisClient.Del(c.Context(), redisKey)
+			return nil
+		}
+
+		record, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  c.Response().StatusCode(),
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		})
+		if err := m.redisClient.Set(c.Context(), redisKey, record, idempotencyTTL).Err(); err != nil {
+			logger.Error(c.Context(), "Cannot store idempotent response", fmt.Sprintf("%+v", err))
+		}
+		return nil
+	}
+}
+
+// replayable tells whether a response is final for the request. Server failures and the rejections that depend on
+// the moment the request came in (auth, admission, a conflicting state, the queue not admitting yet, the sale not
+// being open) would answer differently on a later retry.
+func replayable(statusCode int) bool {
+	switch statusCode {
+	case fiber.StatusUnauthorized, fiber.StatusForbidden, fiber.StatusRequestTimeout, fiber.StatusConflict,
+		fiber.StatusTooEarly, fiber.StatusTooManyRequests:
+		return false
+	}
+	return statusCode >= fiber.StatusOK && statusCode < fiber.StatusMultipleChoices ||
+		statusCode >= fiber.StatusBadRequest && statusCode < fiber.StatusInternalServerError
+}
+
+// requestFingerprint ties a key to the request it was first used with.
+func requestFingerprint(c *fiber.Ctx, userId string) string {
+	hash := sha256.New()
+	hash.Write([]byte(userId))
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte(c.Path()))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayIdempotent(c *fiber.Ctx, logger log.Logger, stored string, fingerprint string) error {
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		logger.Error(c.Context(), "Cannot parse idempotent response", stored)
+		return helpers.RespError(c, logger, errors.InternalServerError("cannot parse idempotent response"))
+	}
+
+	if record.Fingerprint != fingerprint {
+		return helpers.RespError(c, logger, errors.UnprocessableEntity("Idempotency-Key was used for a different request"))
+	}
+
+	if record.InFlight {
+		return helpers.RespError(c, logger, errors.Conflict("request with this Idempotency-Key is still in progress"))
+	}
+
+	c.Set(constants.HeaderIdempotentReplayed, "true")
+	c.Set(fiber.HeaderContentType, record.ContentType)
+	return c.Status(record.StatusCode).Send(record.Body)
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"order-service/internal/pkg/constants"
+	mocklog "order-service/mocks/pkg/log"
+	mockredis "order-service/mocks/pkg/redis"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+	mockRedis  *mockredis.Collections
+	mockLogger *mocklog.Logger
+	store      map[string]string
+	mu         sync.Mutex
+	app        *fiber.App
+	// handler answers the request behind the middleware, calls counts how often it ran
+	handler func(c *fiber.Ctx) error
+	calls   int
+}
+
+func (suite *IdempotencyTestSuite) SetupTest() {
+	suite.store = map[string]string{}
+	suite.calls = 0
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Info", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+
+	// the mock keeps its keys in memory so the middleware sees what it wrote on the previous request
+	suite.mockRedis = &mockredis.Collections{}
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(func(ctx context.Context, key string) *redis.StringCmd {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		value, ok := suite.store[key]
+		if !ok {
+			return redis.NewStringResult("", redis.Nil)
+		}
+		return redis.NewStringResult(value, nil)
+	})
+	suite.mockRedis.On("SetNX", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+			suite.mu.Lock()
+			defer suite.mu.Unlock()
+			if _, ok := suite.store[key]; ok {
+				return redis.NewBoolResult(false, nil)
+			}
+			suite.store[key] = string(value.([]byte))
+			return redis.NewBoolResult(true, nil)
+		})
+	suite.mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+			suite.mu.Lock()
+			defer suite.mu.Unlock()
+			suite.store[key] = string(value.([]byte))
+			return redis.NewStatusResult("OK", nil)
+		})
+	suite.mockRedis.On("Del", mock.Anything, mock.Anything).Return(func(ctx context.Context, keys ...string) *redis.IntCmd {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		for _, key := range keys {
+			delete(suite.store, key)
+		}
+		return redis.NewIntResult(int64(len(keys)), nil)
+	})
+
+	middlewares := Middlewares{redisClient: suite.mockRedis, logger: suite.mockLogger}
+	suite.app = fiber.New()
+	suite.app.Post("/v1/create-order", func(c *fiber.Ctx) error {
+		c.Locals("userId", "user")
+		return c.Next()
+	}, middlewares.Idempotency(), func(c *fiber.Ctx) error {
+		suite.calls++
+		return suite.handler(c)
+	})
+}
+
+func TestIdempotencyTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
+
+func (suite *IdempotencyTestSuite) request(key string, body string) *http.Response {
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/create-order", bytes.NewBufferString(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(constants.HeaderIdempotencyKey, key)
+	resp, err := suite.app.Test(req, -1)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func readBody(resp *http.Response) []byte {
+	body, _ := io.ReadAll(resp.Body)
+	return body
+}
+
+func (suite *IdempotencyTestSuite) TestReplay() {
+	suite.handler = func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"orderId": "order", "call": suite.calls})
+	}
+
+	first := suite.request("key", `{"eventId":"id"}`)
+	firstBody := readBody(first)
+	second := suite.request("key", `{"eventId":"id"}`)
+
+	assert.Equal(suite.T(), 1, suite.calls)
+	assert.Equal(suite.T(), fiber.StatusCreated, second.StatusCode)
+	assert.Equal(suite.T(), first.Header.Get(fiber.HeaderContentType), second.Header.Get(fiber.HeaderContentType))
+	assert.Equal(suite.T(), "true", second.Header.Get(constants.HeaderIdempotentReplayed))
+	assert.Equal(suite.T(), firstBody, readBody(second))
+	assert.Empty(suite.T(), first.Header.Get(constants.HeaderIdempotentReplayed))
+}
+
+func (suite *IdempotencyTestSuite) TestReplayDeterministicRejection() {
+	suite.handler = func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ticket category sold out"})
+	}
+
+	first := suite.request("key", `{"eventId":"id"}`)
+	second := suite.request("key", `{"eventId":"id"}`)
+
+	assert.Equal(suite.T(), 1, suite.calls)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, second.StatusCode)
+	assert.Equal(suite.T(), readBody(first), readBody(second))
+}
+
+func (suite *IdempotencyTestSuite) TestInFlight() {
+	var inFlight *http.Response
+	suite.handler = func(c *fiber.Ctx) error {
+		// the retry arrives while the first request still holds the key
+		if inFlight == nil {
+			inFlight = suite.request("key", `{"eventId":"id"}`)
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"orderId": "order"})
+	}
+
+	first := suite.request("key", `{"eventId":"id"}`)
+
+	assert.Equal(suite.T(), fiber.StatusCreated, first.StatusCode)
+	assert.Equal(suite.T(), fiber.StatusConflict, inFlight.StatusCode)
+	assert.Equal(suite.T(), 1, suite.calls)
+}
+
+func (suite *IdempotencyTestSuite) TestDifferentPayload() {
+	suite.handler = func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"orderId": "order"})
+	}
+
+	suite.request("key", `{"eventId":"id"}`)
+	second := suite.request("key", `{"eventId":"other"}`)
+
+	assert.Equal(suite.T(), fiber.StatusUnprocessableEntity, second.StatusCode)
+	assert.Empty(suite.T(), second.Header.Get(constants.HeaderIdempotentReplayed))
+	assert.Equal(suite.T(), 1, suite.calls)
+}
+
+func (suite *IdempotencyTestSuite) TestNotStored() {
+	for _, statusCode := range []int{
+		fiber.StatusUnauthorized,
+		fiber.StatusForbidden,
+		fiber.StatusConflict,
+		fiber.StatusTooManyRequests,
+		fiber.StatusInternalServerError,
+	} {
+		suite.SetupTest()
+		suite.handler = func(c *fiber.Ctx) error {
+			return c.Status(statusCode).JSON(fiber.Map{"message": "try again later"})
+		}
+
+		suite.request("key", `{"eventId":"id"}`)
+		second := suite.request("key", `{"eventId":"id"}`)
+
+		assert.Equal(suite.T(), 2, suite.calls, statusCode)
+		assert.Equal(suite.T(), statusCode, second.StatusCode)
+		assert.Empty(suite.T(), second.Header.Get(constants.HeaderIdempotentReplayed), statusCode)
+		assert.Empty(suite.T(), suite.store, statusCode)
+	}
+}
+
+func (suite *IdempotencyTestSuite) TestWithoutKey() {
+	suite.handler = func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"orderId": "order"})
+	}
+
+	suite.request("", `{"eventId":"id"}`)
+	suite.request("", `{"eventId":"id"}`)
+
+	assert.Equal(suite.T(), 2, suite.calls)
+	suite.mockRedis.AssertNotCalled(suite.T(), "Get", mock.Anything, mock.Anything)
+}
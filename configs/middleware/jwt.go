@@ -22,11 +22,13 @@ import (
 
 type Middlewares struct {
 	redisClient redis.Collections
+	logger      log.Logger
 }
 
 func NewMiddlewares(redis redis.Collections) Middlewares {
 	return Middlewares{
 		redisClient: redis,
+		logger:      log.GetLogger(),
 	}
 }
 
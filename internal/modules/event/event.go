@@ -2,9 +2,16 @@ package event
 
 import (
 	"context"
+	"order-service/internal/modules/event/models/entity"
 	wrapper "order-service/internal/pkg/helpers"
 )
 
+// SaleSchedule guards the queue and the ticket sale of an event by its sale schedule.
+type SaleSchedule interface {
+	CheckQueueOpen(ctx context.Context, event entity.Event) error
+	CheckSaleOpen(ctx context.Context, event entity.Event, ticketTypes []string) error
+}
+
 type MongodbRepositoryQuery interface {
 	FindEventById(ctx context.Context, eventId string) <-chan wrapper.Result
 }
@@ -9,19 +9,76 @@ type Country struct {
 	Place string `json:"place" bson:"place"`
 }
 
+// QueueSetting overrides the waiting room defaults for a single event
+type QueueSetting struct {
+	ReentryPolicy   string  `json:"reentryPolicy" bson:"reentryPolicy"`
+	ReentryCooldown int     `json:"reentryCooldown" bson:"reentryCooldown"`
+	Capacity        string  `json:"capacity" bson:"capacity"`
+	CapacityLimit   int     `json:"capacityLimit" bson:"capacityLimit"`
+	CapacityRatio   float64 `json:"capacityRatio" bson:"capacityRatio"`
+}
+
+// OrderSetting limits how many tickets one user may hold for the event, zero falls back to the service config.
+// MaxTicketsPerType caps single ticket types within that total.
+type OrderSetting struct {
+	MaxTicketsPerUser int            `json:"maxTicketsPerUser" bson:"maxTicketsPerUser"`
+	MaxTicketsPerType map[string]int `json:"maxTicketsPerType" bson:"maxTicketsPerType"`
+}
+
+// PresaleSetting is the window before the general sale in which only holders of a presale code may
+// join the queue and order, a zero StartAt means the event has no presale.
+type PresaleSetting struct {
+	StartAt time.Time `json:"startAt" bson:"startAt"`
+	EndAt   time.Time `json:"endAt" bson:"endAt"`
+}
+
+// SaleWindow bounds a ticket sale, a zero StartAt or EndAt leaves that side of the window open.
+type SaleWindow struct {
+	StartAt time.Time `json:"startAt" bson:"startAt"`
+	EndAt   time.Time `json:"endAt" bson:"endAt"`
+}
+
+// SaleSetting is the sale schedule of the event. The queue opens at QueueOpenAt, or with the sale when unset,
+// and TicketTypes override the sale window of single ticket types. TimeZone is the IANA zone the schedule is
+// published in, times in error messages are shown in it.
+type SaleSetting struct {
+	TimeZone    string                `json:"timeZone" bson:"timeZone"`
+	QueueOpenAt time.Time             `json:"queueOpenAt" bson:"queueOpenAt"`
+	StartAt     time.Time             `json:"startAt" bson:"startAt"`
+	EndAt       time.Time             `json:"endAt" bson:"endAt"`
+	TicketTypes map[string]SaleWindow `json:"ticketTypes" bson:"ticketTypes"`
+}
+
+// ReleaseRule holds a ticket category back until one of its conditions is met: every category of AfterSoldOut
+// sold out, ReleaseAt passed, or the offline stock, the remaining tickets of the other categories except
+// Online, dropped below OfflineStockBelow. ContinentCode limits the offline stock to the categories sold on
+// that continent. A rule without conditions holds nothing back.
+type ReleaseRule struct {
+	TicketType        string    `json:"ticketType" bson:"ticketType"`
+	AfterSoldOut      []string  `json:"afterSoldOut" bson:"afterSoldOut"`
+	ReleaseAt         time.Time `json:"releaseAt" bson:"releaseAt"`
+	OfflineStockBelow int       `json:"offlineStockBelow" bson:"offlineStockBelow"`
+	ContinentCode     string    `json:"continentCode" bson:"continentCode"`
+}
+
 type Event struct {
-	EventId       string    `json:"eventId" bson:"eventId"`
-	Name          string    `json:"name" bson:"name"`
-	DateTime      time.Time `json:"dateTime" bson:"dateTime"`
-	Location      string    `json:"location" bson:"location"`
-	ContinentName string    `json:"continentName" bson:"continentName"`
-	ContinentCode string    `json:"continentCode" bson:"continentCode"`
-	Country       Country   `json:"country" bson:"country"`
-	Description   string    `json:"description" bson:"description"`
-	Tag           string    `json:"tag" bson:"tag"`
-	TicketIds     []string  `json:"ticketIds" bson:"ticketIds"`
-	CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
-	CreatedBy     string    `json:"createdBy" bson:"createdBy"`
-	UpdatedBy     string    `json:"updatedBy" bson:"updatedBy"`
+	EventId       string         `json:"eventId" bson:"eventId"`
+	Name          string         `json:"name" bson:"name"`
+	DateTime      time.Time      `json:"dateTime" bson:"dateTime"`
+	Location      string         `json:"location" bson:"location"`
+	ContinentName string         `json:"continentName" bson:"continentName"`
+	ContinentCode string         `json:"continentCode" bson:"continentCode"`
+	Country       Country        `json:"country" bson:"country"`
+	Description   string         `json:"description" bson:"description"`
+	Tag           string         `json:"tag" bson:"tag"`
+	TicketIds     []string       `json:"ticketIds" bson:"ticketIds"`
+	Queue         QueueSetting   `json:"queue" bson:"queue"`
+	Order         OrderSetting   `json:"order" bson:"order"`
+	Presale       PresaleSetting `json:"presale" bson:"presale"`
+	Sale          SaleSetting    `json:"sale" bson:"sale"`
+	ReleaseRules  []ReleaseRule  `json:"releaseRules" bson:"releaseRules"`
+	CreatedAt     time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt" bson:"updatedAt"`
+	CreatedBy     string         `json:"createdBy" bson:"createdBy"`
+	UpdatedBy     string         `json:"updatedBy" bson:"updatedBy"`
 }
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"order-service/internal/modules/event"
+	"order-service/internal/modules/event/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"go.elastic.co/apm"
+)
+
+var Now = time.Now
+
+type saleSchedule struct {
+	logger log.Logger
+}
+
+// NewSaleSchedule checks the sale settings stored on the event. An event without a schedule is always open.
+func NewSaleSchedule(log log.Logger) event.SaleSchedule {
+	return saleSchedule{
+		logger: log,
+	}
+}
+
+func (s saleSchedule) CheckQueueOpen(origCtx context.Context, event entity.Event) error {
+	domain := "saleSchedule-CheckQueueOpen"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	sale := event.Sale
+	now := Now()
+	openAt := sale.QueueOpenAt
+	if openAt.IsZero() {
+		openAt = sale.StartAt
+	}
+	if now.Before(openAt) {
+		return s.notOpen(ctx, event, "queue opens", openAt, now)
+	}
+
+	if closeAt := queueCloseAt(sale); !closeAt.IsZero() && !now.Before(closeAt) {
+		return s.closed(ctx, event, "ticket sale ended", closeAt)
+	}
+
+	return nil
+}
+
+func (s saleSchedule) CheckSaleOpen(origCtx context.Context, event entity.Event, ticketTypes []string) error {
+	domain := "saleSchedule-CheckSaleOpen"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	now := Now()
+	for _, ticketType := range ticketTypes {
+		window := saleWindow(event.Sale, ticketType)
+		subject := "ticket sale"
+		if _, ok := event.Sale.TicketTypes[ticketType]; ok {
+			subject = fmt.Sprintf("%s ticket sale", ticketType)
+		}
+
+		if now.Before(window.StartAt) {
+			return s.notOpen(ctx, event, subject+" opens", window.StartAt, now)
+		}
+
+		if !window.EndAt.IsZero() && !now.Before(window.EndAt) {
+			return s.closed(ctx, event, subject+" ended", window.EndAt)
+		}
+	}
+
+	return nil
+}
+
+// notOpen tells the client when to come back, the Retry-After header carries the seconds left for a countdown.
+func (s saleSchedule) notOpen(ctx context.Context, event entity.Event, what string, openAt time.Time, now time.Time) error {
+	msg := fmt.Sprintf("%s at %s", what, s.format(ctx, event.Sale, openAt))
+	s.logger.Error(ctx, msg, fmt.Sprintf("%+v", event.EventId))
+	wait := int(math.Ceil(openAt.Sub(now).Seconds()))
+	return errors.CustomErrorRetryAfter(msg, constants.ErrCodeSaleNotOpen, http.StatusForbidden, wait)
+}
+
+func (s saleSchedule) closed(ctx context.Context, event entity.Event, what string, closedAt time.Time) error {
+	msg := fmt.Sprintf("%s at %s", what, s.format(ctx, event.Sale, closedAt))
+	s.logger.Error(ctx, msg, fmt.Sprintf("%+v", event.EventId))
+	return errors.ForbiddenError(msg)
+}
+
+// format shows the time in the zone of the schedule, falling back to UTC when the zone is unknown.
+func (s saleSchedule) format(ctx context.Context, sale entity.SaleSetting, t time.Time) string {
+	location, err := time.LoadLocation(sale.TimeZone)
+	if err != nil {
+		msg := "unknown sale time zone"
+		s.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		location = time.UTC
+	}
+	return t.In(location).Format(time.RFC3339)
+}
+
+// saleWindow is the sale window of the ticket type, unset sides of an override fall back to the event window.
+func saleWindow(sale entity.SaleSetting, ticketType string) entity.SaleWindow {
+	window := entity.SaleWindow{StartAt: sale.StartAt, EndAt: sale.EndAt}
+	override, ok := sale.TicketTypes[ticketType]
+	if !ok {
+		return window
+	}
+	if !override.StartAt.IsZero() {
+		window.StartAt = override.StartAt
+	}
+	if !override.EndAt.IsZero() {
+		window.EndAt = override.EndAt
+	}
+	return window
+}
+
+// queueCloseAt is when the last ticket type stops selling, zero while any of them has no end.
+func queueCloseAt(sale entity.SaleSetting) time.Time {
+	closeAt := sale.EndAt
+	if closeAt.IsZero() {
+		return closeAt
+	}
+	for ticketType := range sale.TicketTypes {
+		endAt := saleWindow(sale, ticketType).EndAt
+		if endAt.After(closeAt) {
+			closeAt = endAt
+		}
+	}
+	return closeAt
+}
@@ -0,0 +1,190 @@
+package usecases_test
+
+import (
+	"context"
+	"net/http"
+	"order-service/internal/modules/event"
+	"order-service/internal/modules/event/models/entity"
+	uc "order-service/internal/modules/event/usecases"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var mockNow = time.Date(2024, time.March, 1, 3, 0, 0, 0, time.UTC)
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	mockLogger *mocklog.Logger
+	schedule   event.SaleSchedule
+	ctx        context.Context
+}
+
+func (suite *ScheduleTestSuite) SetupTest() {
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.ctx = context.Background()
+	suite.schedule = uc.NewSaleSchedule(suite.mockLogger)
+	uc.Now = func() time.Time { return mockNow }
+}
+
+func (suite *ScheduleTestSuite) TearDownTest() {
+	uc.Now = time.Now
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
+
+func mockSaleEvent() entity.Event {
+	return entity.Event{
+		EventId: "event",
+		Sale: entity.SaleSetting{
+			TimeZone:    "Asia/Jakarta",
+			QueueOpenAt: mockNow.Add(-time.Hour),
+			StartAt:     mockNow.Add(-time.Minute),
+			EndAt:       mockNow.Add(24 * time.Hour),
+		},
+	}
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpen() {
+	err := suite.schedule.CheckQueueOpen(suite.ctx, mockSaleEvent())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenNoSchedule() {
+	err := suite.schedule.CheckQueueOpen(suite.ctx, entity.Event{EventId: "event"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenBeforeSale() {
+	event := mockSaleEvent()
+	event.Sale.StartAt = mockNow.Add(time.Hour)
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenNotYet() {
+	event := mockSaleEvent()
+	event.Sale.QueueOpenAt = mockNow.Add(90*time.Minute + 500*time.Millisecond)
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue opens at 2024-03-01T11:30:00+07:00", err.Error())
+	errString, _ := err.(*errors.ErrorString)
+	assert.Equal(suite.T(), constants.ErrCodeSaleNotOpen, errString.Code())
+	assert.Equal(suite.T(), http.StatusForbidden, errString.HttpCode())
+	assert.Equal(suite.T(), 5401, errString.RetryAfter())
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenWithSale() {
+	event := mockSaleEvent()
+	event.Sale.QueueOpenAt = time.Time{}
+	event.Sale.StartAt = mockNow.Add(time.Minute)
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue opens at 2024-03-01T10:01:00+07:00", err.Error())
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenSaleEnded() {
+	event := mockSaleEvent()
+	event.Sale.EndAt = mockNow
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "ticket sale ended at 2024-03-01T10:00:00+07:00", err.Error())
+	errString, _ := err.(*errors.ErrorString)
+	assert.Equal(suite.T(), http.StatusForbidden, errString.Code())
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenTicketTypeStillOnSale() {
+	event := mockSaleEvent()
+	event.Sale.EndAt = mockNow
+	event.Sale.TicketTypes = map[string]entity.SaleWindow{
+		"Online": {EndAt: mockNow.Add(time.Hour)},
+	}
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckQueueOpenUnknownTimeZone() {
+	event := mockSaleEvent()
+	event.Sale.TimeZone = "Mars/Olympus"
+	event.Sale.QueueOpenAt = mockNow.Add(time.Hour)
+
+	err := suite.schedule.CheckQueueOpen(suite.ctx, event)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue opens at 2024-03-01T04:00:00Z", err.Error())
+	suite.mockLogger.AssertCalled(suite.T(), "Error", mock.Anything, "unknown sale time zone", mock.Anything)
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpen() {
+	err := suite.schedule.CheckSaleOpen(suite.ctx, mockSaleEvent(), []string{"VIP", "Gold"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpenNotYet() {
+	event := mockSaleEvent()
+	event.Sale.StartAt = mockNow.Add(time.Minute)
+
+	err := suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP"})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "ticket sale opens at 2024-03-01T10:01:00+07:00", err.Error())
+	errString, _ := err.(*errors.ErrorString)
+	assert.Equal(suite.T(), 60, errString.RetryAfter())
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpenEnded() {
+	event := mockSaleEvent()
+	event.Sale.EndAt = mockNow.Add(-time.Minute)
+
+	err := suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP"})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "ticket sale ended at 2024-03-01T09:59:00+07:00", err.Error())
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpenTicketTypeOverride() {
+	event := mockSaleEvent()
+	event.Sale.TicketTypes = map[string]entity.SaleWindow{
+		"Online": {StartAt: mockNow.Add(2 * time.Hour)},
+	}
+
+	err := suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP"})
+	assert.NoError(suite.T(), err)
+
+	err = suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP", "Online"})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online ticket sale opens at 2024-03-01T12:00:00+07:00", err.Error())
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpenTicketTypeEarlier() {
+	event := mockSaleEvent()
+	event.Sale.StartAt = mockNow.Add(time.Hour)
+	event.Sale.TicketTypes = map[string]entity.SaleWindow{
+		"VIP": {StartAt: mockNow.Add(-time.Hour), EndAt: mockNow.Add(time.Hour)},
+	}
+
+	err := suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ScheduleTestSuite) TestCheckSaleOpenTicketTypeEnded() {
+	event := mockSaleEvent()
+	event.Sale.TicketTypes = map[string]entity.SaleWindow{
+		"VIP": {EndAt: mockNow.Add(-time.Hour)},
+	}
+
+	err := suite.schedule.CheckSaleOpen(suite.ctx, event, []string{"VIP"})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "VIP ticket sale ended at 2024-03-01T09:00:00+07:00", err.Error())
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/order"
+	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/worker"
+	"time"
+)
+
+// ExpiryWorker periodically releases pending bank tickets whose payment window has passed.
+type ExpiryWorker struct {
+	OrderUsecaseCommand order.UsecaseCommand
+	Logger              log.Logger
+
+	*worker.Worker
+}
+
+func InitExpiryWorker(ouc order.UsecaseCommand, log log.Logger, interval time.Duration) *ExpiryWorker {
+	expiryWorker := &ExpiryWorker{
+		OrderUsecaseCommand: ouc,
+		Logger:              log,
+	}
+	expiryWorker.Worker = worker.Start(interval, expiryWorker.expire)
+
+	return expiryWorker
+}
+
+func (w *ExpiryWorker) expire(ctx context.Context) {
+	total, err := w.OrderUsecaseCommand.ExpireBankTickets(ctx)
+	if err != nil {
+		w.Logger.Error(ctx, "Expiry worker failed", fmt.Sprintf("%+v", err))
+		return
+	}
+
+	if total > 0 {
+		w.Logger.Info(ctx, "Expiry worker released bank tickets", fmt.Sprintf("%d", total))
+	}
+}
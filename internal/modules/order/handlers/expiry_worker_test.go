@@ -0,0 +1,58 @@
+package handlers_test
+
+import (
+	"context"
+	"order-service/internal/modules/order/handlers"
+	"order-service/internal/pkg/errors"
+	mockcert "order-service/mocks/modules/order"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExpiryWorker(t *testing.T) {
+	cUC := new(mockcert.UsecaseCommand)
+	cLog := new(mocklog.Logger)
+	called := make(chan struct{}, 1)
+	cUC.On("ExpireBankTickets", mock.Anything).Return(1, nil).Run(func(args mock.Arguments) {
+		select {
+		case called <- struct{}{}:
+		default:
+		}
+	})
+	cLog.On("Info", mock.Anything, mock.Anything, mock.Anything)
+
+	worker := handlers.InitExpiryWorker(cUC, cLog, 10*time.Millisecond)
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("expiry worker did not run")
+	}
+	assert.NoError(t, worker.Close(context.Background()))
+}
+
+func TestExpiryWorkerErr(t *testing.T) {
+	cUC := new(mockcert.UsecaseCommand)
+	cLog := new(mocklog.Logger)
+	logged := make(chan struct{}, 1)
+	cUC.On("ExpireBankTickets", mock.Anything).Return(0, errors.InternalServerError("error"))
+	cLog.On("Error", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		select {
+		case logged <- struct{}{}:
+		default:
+		}
+	})
+
+	worker := handlers.InitExpiryWorker(cUC, cLog, 10*time.Millisecond)
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("expiry worker did not log the failure")
+	}
+	assert.NoError(t, worker.Close(context.Background()))
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"order-service/internal/modules/order"
 	"order-service/internal/modules/order/models/request"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
@@ -28,12 +29,18 @@ func InitOrderHttpHandler(app *fiber.App, ouc order.UsecaseCommand, ouq order.Us
 		Logger:              log,
 		Validator:           validator.New(),
 	}
+	ownerOrAdmin := middlewares.AllowedRoles(constants.RoleUser, constants.RoleAdmin)
 	middlewares := middlewares.NewMiddlewares(redisClient)
 	route := app.Group("/api/order")
 
-	route.Post("/v1/create-order", middlewares.VerifyBearer(), handler.CreateOrder)
+	route.Post("/v1/create-order", middlewares.VerifyBearer(), middlewares.Idempotency(), middlewares.VerifyAdmission(), handler.CreateOrder)
+	route.Post("/v1/cancel", middlewares.VerifyBearer(), handler.CancelOrder)
+	route.Get("/v1/seats", middlewares.VerifyBearer(), handler.GetFreeSeats)
+	route.Post("/v1/seat-hold", middlewares.VerifyBearer(), middlewares.VerifyAdmission(), handler.HoldSeats)
+	route.Post("/v1/payment/callback", middlewares.VerifyBasicAuth(), handler.PaymentCallback)
 	route.Get("/v1/list", middlewares.VerifyBearer(), handler.GetOrderList)
 	route.Get("/v1/preorder-list", middlewares.VerifyBearer(), handler.GetPreOrderList)
+	route.Get("/v1/detail/:ticketNumber", middlewares.VerifyBearer(), ownerOrAdmin, handler.GetOrderDetail)
 }
 
 func (t OrderHttpHandler) CreateOrder(c *fiber.Ctx) error {
@@ -45,6 +52,17 @@ func (t OrderHttpHandler) CreateOrder(c *fiber.Ctx) error {
 	userId := c.Locals("userId").(string)
 	req.UserId = userId
 
+	admission, ok := c.Locals("admission").(helpers.PayloadAdmission)
+	if !ok {
+		return helpers.RespError(c, t.Logger, errors.ForbiddenError("admission token required"))
+	}
+	if req.EventId != "" && req.EventId != admission.EventId {
+		return helpers.RespError(c, t.Logger, errors.ForbiddenError("admission token is not valid for this event"))
+	}
+	req.EventId = admission.EventId
+	req.QueueId = admission.QueueId
+	req.QueueNumber = admission.QueueNumber
+
 	if err := t.Validator.Struct(req); err != nil {
 		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
 	}
@@ -55,6 +73,89 @@ func (t OrderHttpHandler) CreateOrder(c *fiber.Ctx) error {
 	return helpers.RespSuccess(c, t.Logger, resp, "Create order success")
 }
 
+func (t OrderHttpHandler) GetFreeSeats(c *fiber.Ctx) error {
+	req := new(request.SeatListReq)
+	if err := c.QueryParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	resp, err := t.OrderUsecaseQuery.FindFreeSeats(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Get free seats success")
+}
+
+// HoldSeats is limited to admitted users, like create-order, so picking seats cannot skip the queue.
+func (t OrderHttpHandler) HoldSeats(c *fiber.Ctx) error {
+	req := new(request.HoldSeatReq)
+	if err := c.BodyParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+
+	admission, ok := c.Locals("admission").(helpers.PayloadAdmission)
+	if !ok {
+		return helpers.RespError(c, t.Logger, errors.ForbiddenError("admission token required"))
+	}
+	if req.EventId != "" && req.EventId != admission.EventId {
+		return helpers.RespError(c, t.Logger, errors.ForbiddenError("admission token is not valid for this event"))
+	}
+	req.EventId = admission.EventId
+
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	resp, err := t.OrderUsecaseCommand.HoldSeats(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Hold seats success")
+}
+
+func (t OrderHttpHandler) CancelOrder(c *fiber.Ctx) error {
+	req := new(request.CancelOrderReq)
+	if err := c.BodyParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+	resp, err := t.OrderUsecaseCommand.CancelOrderTicket(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Cancel order success")
+}
+
+// PaymentCallback is called by the payment gateway, it is authenticated with basic auth instead of a user token.
+func (t OrderHttpHandler) PaymentCallback(c *fiber.Ctx) error {
+	req := new(request.PaymentResultReq)
+	if err := c.BodyParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+	resp, err := t.OrderUsecaseCommand.ProcessPaymentResult(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Payment callback success")
+}
+
 func (t OrderHttpHandler) GetOrderList(c *fiber.Ctx) error {
 	req := new(request.OrderList)
 	if err := c.QueryParser(req); err != nil {
@@ -92,3 +193,23 @@ func (t OrderHttpHandler) GetPreOrderList(c *fiber.Ctx) error {
 	}
 	return helpers.RespPagination(c, t.Logger, resp.CollectionData, resp.MetaData, "Get preorder list success")
 }
+
+// GetOrderDetail is limited to the owner of the order, admins can read any order.
+func (t OrderHttpHandler) GetOrderDetail(c *fiber.Ctx) error {
+	req := request.GetOrderReq{
+		TicketNumber: c.Params("ticketNumber"),
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+	req.Role, _ = c.Locals("userRole").(string)
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	resp, err := t.OrderUsecaseQuery.FindOrderDetail(c.Context(), req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Get order detail success")
+}
@@ -11,6 +11,7 @@ import (
 	"order-service/internal/modules/order/models/response"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
 	mockcert "order-service/mocks/modules/order"
 	mocklog "order-service/mocks/pkg/log"
 	mockredis "order-service/mocks/pkg/redis"
@@ -59,7 +60,7 @@ func TestUserHttpHandlerTestSuite(t *testing.T) {
 func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicket() {
 
 	suite.cUC.On("CreateOrderTicket", mock.Anything, mock.Anything).Return(&response.OrderResp{
-		TicketNumber: "111",
+		Tickets: []response.OrderTicket{{TicketNumber: "111"}},
 	}, nil)
 	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -75,6 +76,39 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicket() {
 
 	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
 	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/create-order")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CreateOrder(ctx)
+	assert.Nil(suite.T(), err)
+	suite.cUC.AssertCalled(suite.T(), "CreateOrderTicket", mock.Anything, mock.MatchedBy(func(req request.OrderReq) bool {
+		return req.QueueId == "id" && req.QueueNumber == 1
+	}))
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketItems() {
+
+	suite.cUC.On("CreateOrderTicket", mock.Anything, mock.Anything).Return(&response.OrderResp{
+		Tickets: []response.OrderTicket{{TicketNumber: "111"}, {TicketNumber: "112"}},
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "Gold", Quantity: 2},
+		},
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
 	ctx.Request().SetRequestURI("/v1/create-order")
 	ctx.Request().Header.SetMethod(fiber.MethodPost)
 	ctx.Request().Header.SetContentType("application/json")
@@ -82,12 +116,43 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicket() {
 
 	err := suite.handler.CreateOrder(ctx)
 	assert.Nil(suite.T(), err)
+	suite.cUC.AssertCalled(suite.T(), "CreateOrderTicket", mock.Anything, mock.MatchedBy(func(req request.OrderReq) bool {
+		return len(req.Items) == 1 && req.Items[0].Quantity == 2
+	}))
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrItems() {
+
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "Gold"},
+		},
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/create-order")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CreateOrder(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+	suite.cUC.AssertNotCalled(suite.T(), "CreateOrderTicket", mock.Anything, mock.Anything)
 }
 
 func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrBody() {
 
 	suite.cUC.On("CreateOrderTicket", mock.Anything, mock.Anything).Return(&response.OrderResp{
-		TicketNumber: "111",
+		Tickets: []response.OrderTicket{{TicketNumber: "111"}},
 	}, nil)
 	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -103,6 +168,7 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrBody() {
 
 	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
 	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
 	ctx.Request().SetRequestURI("/v1/create-order")
 	ctx.Request().Header.SetMethod(fiber.MethodPost)
 	ctx.Request().Header.SetContentType("application/json")
@@ -115,7 +181,7 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrBody() {
 func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrValidator() {
 
 	suite.cUC.On("CreateOrderTicket", mock.Anything, mock.Anything).Return(&response.OrderResp{
-		TicketNumber: "111",
+		Tickets: []response.OrderTicket{{TicketNumber: "111"}},
 	}, nil)
 	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
@@ -127,6 +193,7 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErrValidator() {
 
 	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
 	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
 	ctx.Request().SetRequestURI("/v1/create-order")
 	ctx.Request().Header.SetMethod(fiber.MethodPost)
 	ctx.Request().Header.SetContentType("application/json")
@@ -153,6 +220,7 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErr() {
 
 	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
 	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
 	ctx.Request().SetRequestURI("/v1/create-order")
 	ctx.Request().Header.SetMethod(fiber.MethodPost)
 	ctx.Request().Header.SetContentType("application/json")
@@ -162,6 +230,216 @@ func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketErr() {
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketNoAdmission() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.OrderReq{
+		TicketType: "Gold",
+		EventId:    "id",
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/create-order")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CreateOrder(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusForbidden, ctx.Response().StatusCode())
+	suite.cUC.AssertNotCalled(suite.T(), "CreateOrderTicket", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCreateOrderTicketAdmissionOtherEvent() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.OrderReq{
+		TicketType: "Gold",
+		EventId:    "other",
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/create-order")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CreateOrder(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusForbidden, ctx.Response().StatusCode())
+	suite.cUC.AssertNotCalled(suite.T(), "CreateOrderTicket", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCancelOrder() {
+
+	suite.cUC.On("CancelOrderTicket", mock.Anything, mock.Anything).Return(&response.CancelOrderResp{
+		TicketNumbers: []string{"111"},
+		PaymentStatus: "cancelled",
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.CancelOrderReq{
+		TicketNumber: "111",
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/cancel")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CancelOrder(ctx)
+	assert.Nil(suite.T(), err)
+	suite.cUC.AssertCalled(suite.T(), "CancelOrderTicket", mock.Anything, request.CancelOrderReq{
+		UserId:       "12345",
+		TicketNumber: "111",
+	})
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCancelOrderErrBody() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/cancel")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+
+	err := suite.handler.CancelOrder(ctx)
+	assert.Nil(suite.T(), err)
+	suite.cUC.AssertNotCalled(suite.T(), "CancelOrderTicket", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCancelOrderErrValidator() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	requestBody, _ := json.Marshal(request.CancelOrderReq{})
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/cancel")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CancelOrder(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestCancelOrderErr() {
+
+	suite.cUC.On("CancelOrderTicket", mock.Anything, mock.Anything).Return(nil, errors.ForbiddenError("order does not belong to user"))
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	requestBody, _ := json.Marshal(request.CancelOrderReq{
+		TicketNumber: "111",
+	})
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/cancel")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.CancelOrder(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusForbidden, ctx.Response().StatusCode())
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestPaymentCallback() {
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, mock.Anything).Return(&response.PaymentResultResp{
+		TicketNumbers: []string{"111"},
+		PaymentStatus: "paid",
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.PaymentResultReq{
+		PaymentId:    "payment",
+		TicketNumber: "111",
+		VaNumber:     "8808",
+		Bank:         "bca",
+		Amount:       500,
+		Status:       constants.PaymentResultPaid,
+	}
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Request().SetRequestURI("/v1/payment/callback")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.PaymentCallback(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusOK, ctx.Response().StatusCode())
+	suite.cUC.AssertCalled(suite.T(), "ProcessPaymentResult", mock.Anything, payload)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestPaymentCallbackErrBody() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Request().SetRequestURI("/v1/payment/callback")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+
+	err := suite.handler.PaymentCallback(ctx)
+	assert.Nil(suite.T(), err)
+	suite.cUC.AssertNotCalled(suite.T(), "ProcessPaymentResult", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestPaymentCallbackErrValidator() {
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	requestBody, _ := json.Marshal(request.PaymentResultReq{TicketNumber: "111"})
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Request().SetRequestURI("/v1/payment/callback")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.PaymentCallback(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestPaymentCallbackErr() {
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, mock.Anything).Return(nil, errors.Conflict("order is not waiting for payment"))
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	requestBody, _ := json.Marshal(request.PaymentResultReq{
+		PaymentId:    "payment",
+		TicketNumber: "111",
+		VaNumber:     "8808",
+		Bank:         "bca",
+		Amount:       500,
+		Status:       constants.PaymentResultPaid,
+	})
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Request().SetRequestURI("/v1/payment/callback")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.PaymentCallback(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusConflict, ctx.Response().StatusCode())
+}
+
 func (suite *OrderHttpHandlerTestSuite) TestGetOrderList() {
 
 	response := &response.OrderListResp{
@@ -351,3 +629,173 @@ func (suite *OrderHttpHandlerTestSuite) TestGetPreOrderListErr() {
 	err := suite.handler.GetPreOrderList(ctx)
 	assert.Nil(suite.T(), err)
 }
+
+func (suite *OrderHttpHandlerTestSuite) TestGetOrderDetail() {
+	suite.cUQ.On("FindOrderDetail", mock.Anything, mock.Anything).Return(&response.OrderDetailResp{
+		TicketNumber: "111",
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := suite.detailApp(constants.RoleAdmin).Test(httptest.NewRequest(fiber.MethodGet, "/v1/detail/111", nil))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusOK, resp.StatusCode)
+	suite.cUQ.AssertCalled(suite.T(), "FindOrderDetail", mock.Anything, request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "12345",
+		Role:         constants.RoleAdmin,
+	})
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestGetOrderDetailErr() {
+	suite.cUQ.On("FindOrderDetail", mock.Anything, mock.Anything).Return(nil, errors.ForbiddenError("order does not belong to user"))
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := suite.detailApp(constants.RoleUser).Test(httptest.NewRequest(fiber.MethodGet, "/v1/detail/111", nil))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusForbidden, resp.StatusCode)
+}
+
+// detailApp serves GetOrderDetail behind a stub of the bearer middleware.
+func (suite *OrderHttpHandlerTestSuite) detailApp(role string) *fiber.App {
+	app := fiber.New()
+	app.Get("/v1/detail/:ticketNumber", func(c *fiber.Ctx) error {
+		c.Locals("userId", "12345")
+		c.Locals("userRole", role)
+		return c.Next()
+	}, suite.handler.GetOrderDetail)
+	return app
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestGetFreeSeats() {
+
+	suite.cUQ.On("FindFreeSeats", mock.Anything, mock.Anything).Return(&response.SeatListResp{
+		EventId:     "id",
+		TicketType:  "Gold",
+		SeatNumbers: []int{1, 2},
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/seats?eventId=id&ticketType=Gold")
+	ctx.Request().Header.SetMethod(fiber.MethodGet)
+
+	err := suite.handler.GetFreeSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusOK, ctx.Response().StatusCode())
+	suite.cUQ.AssertCalled(suite.T(), "FindFreeSeats", mock.Anything, request.SeatListReq{EventId: "id", TicketType: "Gold"})
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestGetFreeSeatsErrValidation() {
+
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/seats?eventId=id")
+	ctx.Request().Header.SetMethod(fiber.MethodGet)
+
+	err := suite.handler.GetFreeSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+	suite.cUQ.AssertNotCalled(suite.T(), "FindFreeSeats", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestGetFreeSeatsErr() {
+
+	suite.cUQ.On("FindFreeSeats", mock.Anything, mock.Anything).Return(nil, errors.BadRequest("error"))
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.cLog.On("Error", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Request().SetRequestURI("/v1/seats?eventId=id&ticketType=Gold")
+	ctx.Request().Header.SetMethod(fiber.MethodGet)
+
+	err := suite.handler.GetFreeSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestHoldSeats() {
+
+	suite.cUC.On("HoldSeats", mock.Anything, mock.Anything).Return(&response.HoldSeatResp{
+		EventId:     "id",
+		TicketType:  "Gold",
+		SeatNumbers: []int{1, 2},
+	}, nil)
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.HoldSeatReq{
+		TicketType:  "Gold",
+		SeatNumbers: []int{1, 2},
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/seat-hold")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.HoldSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusOK, ctx.Response().StatusCode())
+	suite.cUC.AssertCalled(suite.T(), "HoldSeats", mock.Anything, mock.MatchedBy(func(req request.HoldSeatReq) bool {
+		return req.UserId == "12345" && req.EventId == "id" && len(req.SeatNumbers) == 2
+	}))
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestHoldSeatsErrValidation() {
+
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.HoldSeatReq{
+		TicketType:  "Gold",
+		SeatNumbers: []int{1, 1},
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/seat-hold")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.HoldSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusBadRequest, ctx.Response().StatusCode())
+	suite.cUC.AssertNotCalled(suite.T(), "HoldSeats", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderHttpHandlerTestSuite) TestHoldSeatsErrEvent() {
+
+	suite.cLog.On("Info", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	payload := request.HoldSeatReq{
+		EventId:     "other",
+		TicketType:  "Gold",
+		SeatNumbers: []int{1},
+	}
+
+	requestBody, _ := json.Marshal(payload)
+
+	ctx := suite.app.AcquireCtx(&fasthttp.RequestCtx{})
+	ctx.Locals("userId", "12345")
+	ctx.Locals("admission", helpers.PayloadAdmission{EventId: "id", UserId: "12345", QueueId: "id", QueueNumber: 1})
+	ctx.Request().SetRequestURI("/v1/seat-hold")
+	ctx.Request().Header.SetMethod(fiber.MethodPost)
+	ctx.Request().Header.SetContentType("application/json")
+	ctx.Request().SetBody(requestBody)
+
+	err := suite.handler.HoldSeats(ctx)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fiber.StatusForbidden, ctx.Response().StatusCode())
+	suite.cUC.AssertNotCalled(suite.T(), "HoldSeats", mock.Anything, mock.Anything)
+}
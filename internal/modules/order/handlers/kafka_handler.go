@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/order"
+	"order-service/internal/modules/order/models/request"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/eventschema"
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	"order-service/internal/pkg/log"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// OrderKafkaHandler consumes the payment results published by the payment service.
+type OrderKafkaHandler struct {
+	OrderUsecaseCommand order.UsecaseCommand
+	Logger              log.Logger
+	Validator           *validator.Validate
+}
+
+func InitOrderKafkaHandler(consumer kafkaConfluent.Consumer, ouc order.UsecaseCommand, log log.Logger) {
+	handler := &OrderKafkaHandler{
+		OrderUsecaseCommand: ouc,
+		Logger:              log,
+		Validator:           validator.New(),
+	}
+	consumer.Handle(constants.TopicPaymentResult, eventschema.Checked(kafkaConfluent.JSONHandler(handler.ProcessPaymentResult)))
+}
+
+// ProcessPaymentResult settles the order of a payment result. Invalid results and results the order rejects
+// go to the dead-letter topic without retries, only failures of the service itself are retried.
+func (h OrderKafkaHandler) ProcessPaymentResult(ctx context.Context, req request.PaymentResultReq, message kafkaConfluent.Message) error {
+	if err := h.Validator.Struct(req); err != nil {
+		h.Logger.Error(ctx, fmt.Sprintf("Kafka Consumer Error: invalid message of topic %s", message.Topic), err.Error())
+		return kafkaConfluent.Permanent(err)
+	}
+
+	if _, err := h.OrderUsecaseCommand.ProcessPaymentResult(ctx, req); err != nil {
+		h.Logger.Error(ctx, fmt.Sprintf("Kafka Consumer Error: cannot process message of topic %s", message.Topic), fmt.Sprintf("%+v", err))
+		if customErr, ok := err.(*errors.ErrorString); ok && customErr.Code() < 500 {
+			return kafkaConfluent.Permanent(err)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"order-service/internal/modules/order/handlers"
+	"order-service/internal/modules/order/models/request"
+	"order-service/internal/modules/order/models/response"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	"order-service/internal/pkg/kafka/memory"
+	mockcert "order-service/mocks/modules/order"
+	mockkafka "order-service/mocks/pkg/kafka"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrderKafkaHandlerTestSuite struct {
+	suite.Suite
+
+	cUC     *mockcert.UsecaseCommand
+	cLog    *mocklog.Logger
+	handler *handlers.OrderKafkaHandler
+	message kafkaConfluent.Message
+}
+
+func (suite *OrderKafkaHandlerTestSuite) SetupTest() {
+	suite.cUC = new(mockcert.UsecaseCommand)
+	suite.cLog = new(mocklog.Logger)
+	suite.handler = &handlers.OrderKafkaHandler{
+		OrderUsecaseCommand: suite.cUC,
+		Logger:              suite.cLog,
+		Validator:           validator.New(),
+	}
+	suite.message = kafkaConfluent.Message{Topic: constants.TopicPaymentResult}
+}
+
+func TestOrderKafkaHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderKafkaHandlerTestSuite))
+}
+
+func paymentResultReq(amount int) request.PaymentResultReq {
+	return request.PaymentResultReq{
+		PaymentId:    "payment",
+		TicketNumber: "111",
+		VaNumber:     "8808",
+		Bank:         "bca",
+		Amount:       amount,
+		Status:       constants.PaymentResultPaid,
+	}
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestInitOrderKafkaHandler() {
+	consumer := new(mockkafka.Consumer)
+	consumer.On("Handle", constants.TopicPaymentResult, mock.Anything)
+
+	handlers.InitOrderKafkaHandler(consumer, suite.cUC, suite.cLog)
+
+	consumer.AssertCalled(suite.T(), "Handle", constants.TopicPaymentResult, mock.Anything)
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestProcessPaymentResult() {
+	payload := paymentResultReq(500)
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, payload).Return(&response.PaymentResultResp{}, nil)
+
+	err := suite.handler.ProcessPaymentResult(context.Background(), payload, suite.message)
+
+	assert.NoError(suite.T(), err)
+	suite.cUC.AssertCalled(suite.T(), "ProcessPaymentResult", mock.Anything, payload)
+	suite.cLog.AssertNotCalled(suite.T(), "Error", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestProcessPaymentResultErrValidator() {
+	suite.cLog.On("Error", mock.Anything, mock.Anything, mock.Anything)
+
+	err := suite.handler.ProcessPaymentResult(context.Background(), request.PaymentResultReq{TicketNumber: "111"}, suite.message)
+
+	assert.True(suite.T(), kafkaConfluent.IsPermanent(err))
+	suite.cUC.AssertNotCalled(suite.T(), "ProcessPaymentResult", mock.Anything, mock.Anything)
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestProcessPaymentResultErr() {
+	payload := paymentResultReq(400)
+	suite.cLog.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, payload).Return(nil, errors.BadRequest("payment amount does not match order price"))
+
+	err := suite.handler.ProcessPaymentResult(context.Background(), payload, suite.message)
+
+	assert.True(suite.T(), kafkaConfluent.IsPermanent(err))
+	suite.cLog.AssertCalled(suite.T(), "Error", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestProcessPaymentResultErrRetry() {
+	payload := paymentResultReq(500)
+	suite.cLog.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, payload).Return(nil, errors.InternalServerError("cannot update order"))
+
+	err := suite.handler.ProcessPaymentResult(context.Background(), payload, suite.message)
+
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), kafkaConfluent.IsPermanent(err))
+}
+
+func (suite *OrderKafkaHandlerTestSuite) TestProcessPaymentResultThroughBroker() {
+	payload := paymentResultReq(500)
+	suite.cLog.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.cUC.On("ProcessPaymentResult", mock.Anything, payload).Return(&response.PaymentResultResp{}, nil)
+	broker := memory.NewBroker(1)
+	producer := broker.NewProducer(suite.cLog)
+	defer producer.Close(context.Background())
+	value, _ := json.Marshal(payload)
+	assert.NoError(suite.T(), producer.PublishSync(context.Background(), constants.TopicPaymentResult, []byte("111"), value, nil, nil))
+	assert.NoError(suite.T(), producer.PublishSync(context.Background(), constants.TopicPaymentResult, []byte("111"), []byte("tes"), nil, nil))
+	assert.NoError(suite.T(), producer.PublishSync(context.Background(), constants.TopicPaymentResult, []byte("111"), value,
+		map[string]string{kafkaConfluent.HeaderSchemaVersion: "2"}, nil))
+
+	consumer := broker.NewConsumer("order-service", kafkaConfluent.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}, suite.cLog)
+	handlers.InitOrderKafkaHandler(consumer, suite.cUC, suite.cLog)
+	assert.NoError(suite.T(), consumer.Start())
+	assert.Eventually(suite.T(), func() bool {
+		return broker.Committed("order-service", constants.TopicPaymentResult, 0) == 3
+	}, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), consumer.Close(context.Background()))
+
+	suite.cUC.AssertNumberOfCalls(suite.T(), "ProcessPaymentResult", 1)
+	parked := broker.Messages(kafkaConfluent.DeadLetterTopic(constants.TopicPaymentResult))
+	assert.Len(suite.T(), parked, 2)
+	assert.Equal(suite.T(), []byte("tes"), parked[0].Value)
+	assert.Equal(suite.T(), "2", parked[1].Headers[kafkaConfluent.HeaderSchemaVersion])
+}
@@ -3,19 +3,39 @@ package entity
 import "time"
 
 type BankTicket struct {
-	TicketNumber  string    `json:"ticketNumber" bson:"ticketNumber"`
-	SeatNumber    int       `json:"seatNumber" bson:"seatNumber"`
-	IsUsed        bool      `json:"isUsed" bson:"isUsed"`
-	UserId        string    `json:"userId" bson:"userId"`
-	QueueId       string    `json:"queueId" bson:"queueId"`
-	TicketId      string    `json:"ticketId" bson:"ticketId"`
-	EventId       string    `json:"eventId" bson:"eventId"`
-	CountryCode   string    `json:"countryCode" bson:"countryCode"`
-	Price         int       `json:"price" bson:"price"`
-	TicketType    string    `json:"ticketType" bson:"ticketType"`
-	PaymentStatus string    `json:"paymentStatus" bson:"paymentStatus"`
-	CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
+	TicketNumber   string         `json:"ticketNumber" bson:"ticketNumber"`
+	OrderId        string         `json:"orderId" bson:"orderId"`
+	SeatNumber     int            `json:"seatNumber" bson:"seatNumber"`
+	IsUsed         bool           `json:"isUsed" bson:"isUsed"`
+	UserId         string         `json:"userId" bson:"userId"`
+	QueueId        string         `json:"queueId" bson:"queueId"`
+	TicketId       string         `json:"ticketId" bson:"ticketId"`
+	EventId        string         `json:"eventId" bson:"eventId"`
+	CountryCode    string         `json:"countryCode" bson:"countryCode"`
+	Price          int            `json:"price" bson:"price"`
+	PriceBreakdown PriceBreakdown `json:"priceBreakdown" bson:"priceBreakdown"`
+	PromoCode      string         `json:"promoCode" bson:"promoCode"`
+	TicketType     string         `json:"ticketType" bson:"ticketType"`
+	PaymentStatus  OrderStatus    `json:"paymentStatus" bson:"paymentStatus"`
+	StatusHistory  []StatusChange `json:"statusHistory" bson:"statusHistory"`
+	// HeldAt is when the ticket was claimed, the hold for payment runs from here and later status changes keep it
+	HeldAt    time.Time `json:"heldAt" bson:"heldAt"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PriceBreakdown itemizes how the price of a bank ticket was reached from the ticket category price.
+type PriceBreakdown struct {
+	BasePrice   int               `json:"basePrice" bson:"basePrice"`
+	Adjustments []PriceAdjustment `json:"adjustments" bson:"adjustments"`
+}
+
+// PriceAdjustment is the amount one pricing rule added to the price, discounts are negative.
+type PriceAdjustment struct {
+	RuleId string `json:"ruleId" bson:"ruleId"`
+	Name   string `json:"name" bson:"name"`
+	Kind   string `json:"kind" bson:"kind"`
+	Amount int    `json:"amount" bson:"amount"`
 }
 
 type Country struct {
@@ -26,28 +46,29 @@ type Country struct {
 }
 
 type Order struct {
-	OrderId       string    `json:"orderId" bson:"orderId"`
-	PaymentId     string    `json:"paymentId" bson:"paymentId"`
-	MobileNumber  string    `json:"mobileNumber" bson:"mobileNumber"`
-	VaNumber      string    `json:"vaNumber" bson:"vaNumber"`
-	Bank          string    `json:"bank" bson:"bank"`
-	Email         string    `json:"email" bson:"email"`
-	FullName      string    `json:"fullName" bson:"fullName"`
-	TicketNumber  string    `json:"ticketNumber" bson:"ticketNumber"`
-	TicketType    string    `json:"ticketType" bson:"ticketType"`
-	SeatNumber    int       `json:"seatNumber" bson:"seatNumber"`
-	EventName     string    `json:"eventName" bson:"eventName"`
-	Country       Country   `json:"country" bson:"country"`
-	DateTime      time.Time `json:"dateTime" bson:"dateTime"`
-	Description   string    `json:"description" bson:"description"`
-	Tag           string    `json:"tag" bson:"tag"`
-	Amount        int       `json:"amount" bson:"amount"`
-	PaymentStatus string    `json:"paymentStatus" bson:"paymentStatus"`
-	OrderTime     time.Time `json:"orderTime" bson:"orderTime"`
-	UserId        string    `json:"userId" bson:"userId"`
-	QueueId       string    `json:"queueId" bson:"queueId"`
-	TicketId      string    `json:"ticketId" bson:"ticketId"`
-	EventId       string    `json:"eventId" bson:"eventId"`
-	CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
+	OrderId       string         `json:"orderId" bson:"orderId"`
+	PaymentId     string         `json:"paymentId" bson:"paymentId"`
+	MobileNumber  string         `json:"mobileNumber" bson:"mobileNumber"`
+	VaNumber      string         `json:"vaNumber" bson:"vaNumber"`
+	Bank          string         `json:"bank" bson:"bank"`
+	Email         string         `json:"email" bson:"email"`
+	FullName      string         `json:"fullName" bson:"fullName"`
+	TicketNumber  string         `json:"ticketNumber" bson:"ticketNumber"`
+	TicketType    string         `json:"ticketType" bson:"ticketType"`
+	SeatNumber    int            `json:"seatNumber" bson:"seatNumber"`
+	EventName     string         `json:"eventName" bson:"eventName"`
+	Country       Country        `json:"country" bson:"country"`
+	DateTime      time.Time      `json:"dateTime" bson:"dateTime"`
+	Description   string         `json:"description" bson:"description"`
+	Tag           string         `json:"tag" bson:"tag"`
+	Amount        int            `json:"amount" bson:"amount"`
+	PaymentStatus OrderStatus    `json:"paymentStatus" bson:"paymentStatus"`
+	StatusHistory []StatusChange `json:"statusHistory" bson:"statusHistory"`
+	OrderTime     time.Time      `json:"orderTime" bson:"orderTime"`
+	UserId        string         `json:"userId" bson:"userId"`
+	QueueId       string         `json:"queueId" bson:"queueId"`
+	TicketId      string         `json:"ticketId" bson:"ticketId"`
+	EventId       string         `json:"eventId" bson:"eventId"`
+	CreatedAt     time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt" bson:"updatedAt"`
 }
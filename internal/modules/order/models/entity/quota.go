@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// PurchaseQuota counts the tickets a user holds for one event, in total and per ticket type.
+// There is one document per event and user, every claim and release moves its counters.
+type PurchaseQuota struct {
+	EventId   string         `json:"eventId" bson:"eventId"`
+	UserId    string         `json:"userId" bson:"userId"`
+	Total     int            `json:"total" bson:"total"`
+	Types     map[string]int `json:"types" bson:"types"`
+	CreatedAt time.Time      `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt" bson:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// OrderStatus is the lifecycle state of a claimed bank ticket and of the order it turns into.
+type OrderStatus string
+
+const (
+	// StatusNone is a bank ticket nobody holds, claiming it starts a new lifecycle
+	StatusNone           OrderStatus = ""
+	StatusHeld           OrderStatus = "held"
+	StatusPendingPayment OrderStatus = "pending_payment"
+	StatusPaid           OrderStatus = "paid"
+	StatusCheckedIn      OrderStatus = "checked_in"
+	StatusExpired        OrderStatus = "expired"
+	StatusCancelled      OrderStatus = "cancelled"
+	StatusRefunded       OrderStatus = "refunded"
+	// StatusPending is what tickets claimed before the lifecycle was introduced still carry, it is a hold
+	StatusPending OrderStatus = "pending"
+)
+
+// AwaitingPayment are the statuses of a ticket that is held until it is paid or its hold expires.
+var AwaitingPayment = []OrderStatus{StatusHeld, StatusPendingPayment, StatusPending}
+
+// StatusChange is one entry of the status history kept on the document.
+type StatusChange struct {
+	From OrderStatus `json:"from" bson:"from"`
+	To   OrderStatus `json:"to" bson:"to"`
+	By   string      `json:"by" bson:"by"`
+	At   time.Time   `json:"at" bson:"at"`
+}
@@ -1,27 +1,108 @@
 package request
 
-import "time"
+import "order-service/internal/modules/order/models/entity"
 
-type UpdateBankTicketReq struct {
-	CountryCode   string    `json:"countryCode"`
-	TicketType    string    `json:"ticketType"`
-	Price         int       `json:"price"`
-	UserId        string    `json:"userId"`
-	QueueId       string    `json:"queueId"`
-	TicketId      string    `json:"ticketId"`
-	EventId       string    `json:"eventId"`
-	PaymentStatus string    `json:"paymentStatus"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+type ClaimBankTicketsReq struct {
+	OrderId        string                `json:"orderId"`
+	CountryCode    string                `json:"countryCode"`
+	TicketType     string                `json:"ticketType"`
+	Quantity       int                   `json:"quantity"`
+	Price          int                   `json:"price"`
+	PriceBreakdown entity.PriceBreakdown `json:"priceBreakdown"`
+	PromoCode      string                `json:"promoCode"`
+	UserId         string                `json:"userId"`
+	QueueId        string                `json:"queueId"`
+	TicketId       string                `json:"ticketId"`
+	EventId        string                `json:"eventId"`
+	SeatNumbers    []int                 `json:"seatNumbers"`
+	StatusChange   entity.StatusChange   `json:"statusChange"`
 }
 
+// PurchaseQuotaReq moves the purchase counters of a user. Tickets is the quantity per ticket type,
+// the limits are only read when reserving, zero means the ticket type has no limit of its own.
+type PurchaseQuotaReq struct {
+	EventId    string         `json:"eventId"`
+	UserId     string         `json:"userId"`
+	Tickets    map[string]int `json:"tickets"`
+	Limit      int            `json:"limit"`
+	TypeLimits map[string]int `json:"typeLimits"`
+}
+
+type ReleaseBankTicketReq struct {
+	TicketNumber string              `json:"ticketNumber"`
+	EventId      string              `json:"eventId"`
+	UserId       string              `json:"userId"`
+	StatusChange entity.StatusChange `json:"statusChange"`
+}
+
+type TransitionBankTicketReq struct {
+	TicketNumber string              `json:"ticketNumber"`
+	StatusChange entity.StatusChange `json:"statusChange"`
+}
+
+// PaymentResultReq is the payment gateway result, it arrives on the webhook or on the payment-result topic.
+// It pays the whole order, found by its id or by one of its ticket numbers.
+type PaymentResultReq struct {
+	PaymentId    string `json:"paymentId" validate:"required"`
+	OrderId      string `json:"orderId" validate:"required_without=TicketNumber"`
+	TicketNumber string `json:"ticketNumber" validate:"required_without=OrderId"`
+	VaNumber     string `json:"vaNumber" validate:"required"`
+	Bank         string `json:"bank" validate:"required"`
+	Amount       int    `json:"amount" validate:"required"`
+	Status       string `json:"status" validate:"required,oneof=paid pending failed"`
+}
+
+// OrderReq buys Quantity tickets of TicketType, or every entry of Items. All tickets are claimed together or not at all.
+// Reserved seating categories are bought by SeatNumbers, the seats have to be held by the user beforehand.
+// PromoCode is a discount code, or the access code required while the event is in presale.
 type OrderReq struct {
-	UserId     string `json:"userId" validate:"required"`
-	TicketType string `json:"ticketType" validate:"required"`
-	EventId    string `json:"eventId" validate:"required"`
+	UserId      string         `json:"userId" validate:"required"`
+	TicketType  string         `json:"ticketType" validate:"required_without=Items"`
+	Quantity    int            `json:"quantity" validate:"omitempty,min=1"`
+	SeatNumbers []int          `json:"seatNumbers" validate:"omitempty,dive,min=1"`
+	Items       []OrderItemReq `json:"items" validate:"omitempty,dive"`
+	EventId     string         `json:"eventId" validate:"required"`
+	PromoCode   string         `json:"promoCode" validate:"omitempty,max=64"`
+	QueueId     string         `json:"-"`
+	QueueNumber int            `json:"-"`
+}
+
+type OrderItemReq struct {
+	TicketType  string `json:"ticketType" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"required_without=SeatNumbers,omitempty,min=1"`
+	SeatNumbers []int  `json:"seatNumbers" validate:"omitempty,dive,min=1"`
+}
+
+type SeatListReq struct {
+	EventId    string `query:"eventId" validate:"required"`
+	TicketType string `query:"ticketType" validate:"required"`
+}
+
+type HoldSeatReq struct {
+	UserId      string `json:"-"`
+	EventId     string `json:"eventId" validate:"required"`
+	TicketType  string `json:"ticketType" validate:"required"`
+	SeatNumbers []int  `json:"seatNumbers" validate:"required,min=1,unique,dive,min=1"`
+}
+
+// FreeSeatReq looks up unclaimed bank tickets of a ticket category, SeatNumbers narrows it down to those seats.
+type FreeSeatReq struct {
+	EventId     string `json:"eventId"`
+	TicketType  string `json:"ticketType"`
+	SeatNumbers []int  `json:"seatNumbers"`
+}
+
+// CancelOrderReq cancels every ticket of the order, an order is found by its id or by one of its ticket numbers.
+type CancelOrderReq struct {
+	UserId       string `json:"userId" validate:"required"`
+	OrderId      string `json:"orderId" validate:"required_without=TicketNumber"`
+	TicketNumber string `json:"ticketNumber" validate:"required_without=OrderId"`
 }
 
 type GetOrderReq struct {
 	TicketNumber string `json:"ticketNumber" validate:"required"`
+	UserId       string `json:"-"`
+	Role         string `json:"-"`
 }
 
 type OrderList struct {
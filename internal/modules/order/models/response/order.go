@@ -6,14 +6,95 @@ import (
 )
 
 type OrderResp struct {
-	QueueId      string    `json:"queueId"`
-	UserId       string    `json:"userId"`
-	EventId      string    `json:"eventId"`
-	TicketType   string    `json:"ticketType"`
-	CountryCode  string    `json:"countryCode"`
-	OrderTime    time.Time `json:"orderTime"`
-	Price        int       `json:"price"`
-	TicketNumber string    `json:"ticketNumber"`
+	OrderId     string    `json:"orderId"`
+	QueueId     string    `json:"queueId"`
+	UserId      string    `json:"userId"`
+	EventId     string    `json:"eventId"`
+	CountryCode string    `json:"countryCode"`
+	OrderTime   time.Time `json:"orderTime"`
+	// TicketType, Price and TicketNumber describe the first ticket of the order, they are kept for the clients
+	// written before orders could hold several tickets. Read Tickets and TotalPrice instead.
+	TicketType   string        `json:"ticketType"`
+	Price        int           `json:"price"`
+	TicketNumber string        `json:"ticketNumber"`
+	TotalPrice   int           `json:"totalPrice"`
+	Tickets      []OrderTicket `json:"tickets"`
+}
+
+type OrderTicket struct {
+	TicketNumber   string         `json:"ticketNumber"`
+	TicketType     string         `json:"ticketType"`
+	SeatNumber     int            `json:"seatNumber"`
+	Price          int            `json:"price"`
+	PriceBreakdown PriceBreakdown `json:"priceBreakdown"`
+}
+
+type PriceBreakdown struct {
+	BasePrice   int               `json:"basePrice"`
+	Adjustments []PriceAdjustment `json:"adjustments"`
+}
+
+type PriceAdjustment struct {
+	RuleId string `json:"ruleId"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Amount int    `json:"amount"`
+}
+
+type SeatListResp struct {
+	EventId     string `json:"eventId"`
+	TicketType  string `json:"ticketType"`
+	SeatNumbers []int  `json:"seatNumbers"`
+}
+
+type HoldSeatResp struct {
+	EventId     string    `json:"eventId"`
+	TicketType  string    `json:"ticketType"`
+	SeatNumbers []int     `json:"seatNumbers"`
+	ExpiredAt   time.Time `json:"expiredAt"`
+}
+
+type CancelOrderResp struct {
+	OrderId       string    `json:"orderId,omitempty"`
+	TicketNumbers []string  `json:"ticketNumbers"`
+	EventId       string    `json:"eventId"`
+	PaymentStatus string    `json:"paymentStatus"`
+	CancelledAt   time.Time `json:"cancelledAt"`
+}
+
+type OrderPayment struct {
+	PaymentId     string    `json:"paymentId"`
+	VaNumber      string    `json:"vaNumber"`
+	Bank          string    `json:"bank"`
+	Amount        int       `json:"amount"`
+	PaymentStatus string    `json:"paymentStatus"`
+	OrderTime     time.Time `json:"orderTime"`
+}
+
+type OrderEvent struct {
+	EventId     string    `json:"eventId"`
+	Name        string    `json:"name"`
+	DateTime    time.Time `json:"dateTime"`
+	Location    string    `json:"location"`
+	CountryCode string    `json:"countryCode"`
+	Place       string    `json:"place"`
+	Description string    `json:"description"`
+	Tag         string    `json:"tag"`
+}
+
+type OrderDetailResp struct {
+	TicketNumber  string        `json:"ticketNumber"`
+	TicketId      string        `json:"ticketId"`
+	TicketType    string        `json:"ticketType"`
+	SeatNumber    int           `json:"seatNumber"`
+	UserId        string        `json:"userId"`
+	QueueId       string        `json:"queueId"`
+	Price         int           `json:"price"`
+	PaymentStatus string        `json:"paymentStatus"`
+	OrderTime     time.Time     `json:"orderTime"`
+	MaxWaitTime   string        `json:"maxWaitTime,omitempty"`
+	Payment       *OrderPayment `json:"payment,omitempty"`
+	Event         OrderEvent    `json:"event"`
 }
 
 type OrderList struct {
@@ -36,6 +117,7 @@ type OrderListResp struct {
 }
 
 type PreOrderList struct {
+	OrderId      string    `json:"orderId,omitempty"`
 	UserId       string    `json:"userId"`
 	TicketType   string    `json:"ticketType"`
 	TicketNumber string    `json:"ticketNumber"`
@@ -49,3 +131,13 @@ type PreOrderListResp struct {
 	CollectionData []PreOrderList
 	MetaData       constants.MetaData
 }
+
+type PaymentResultResp struct {
+	OrderId       string    `json:"orderId,omitempty"`
+	PaymentId     string    `json:"paymentId"`
+	TicketNumbers []string  `json:"ticketNumbers"`
+	EventId       string    `json:"eventId"`
+	Amount        int       `json:"amount"`
+	PaymentStatus string    `json:"paymentStatus"`
+	OrderTime     time.Time `json:"orderTime"`
+}
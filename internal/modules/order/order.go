@@ -2,26 +2,46 @@ package order
 
 import (
 	"context"
+	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	"order-service/internal/modules/order/models/response"
 	wrapper "order-service/internal/pkg/helpers"
+	"time"
 )
 
 type UsecaseCommand interface {
 	CreateOrderTicket(origCtx context.Context, payload request.OrderReq) (*response.OrderResp, error)
+	ExpireBankTickets(origCtx context.Context) (int, error)
+	CancelOrderTicket(origCtx context.Context, payload request.CancelOrderReq) (*response.CancelOrderResp, error)
+	ProcessPaymentResult(origCtx context.Context, payload request.PaymentResultReq) (*response.PaymentResultResp, error)
+	HoldSeats(origCtx context.Context, payload request.HoldSeatReq) (*response.HoldSeatResp, error)
 }
 
 type UsecaseQuery interface {
 	FindOrderList(origCtx context.Context, payload request.OrderList) (*response.OrderListResp, error)
 	FindPreOrderList(origCtx context.Context, payload request.PreOrderList) (*response.PreOrderListResp, error)
+	FindOrderDetail(origCtx context.Context, payload request.GetOrderReq) (*response.OrderDetailResp, error)
+	FindFreeSeats(origCtx context.Context, payload request.SeatListReq) (*response.SeatListResp, error)
 }
 
 type MongodbRepositoryQuery interface {
-	FindBankTicketByParam(ctx context.Context, eventId string, userId string) <-chan wrapper.Result
+	FindBankTicketByTicketNumber(ctx context.Context, ticketNumber string) <-chan wrapper.Result
+	FindBankTicketsByOrderId(ctx context.Context, orderId string) <-chan wrapper.Result
+	FindFreeSeats(ctx context.Context, payload request.FreeSeatReq) <-chan wrapper.Result
 	FindOrderByUser(ctx context.Context, payload request.OrderList) <-chan wrapper.Result
+	FindOrderByTicketNumber(ctx context.Context, ticketNumber string) <-chan wrapper.Result
 	FindBankTicketByUser(ctx context.Context, payload request.PreOrderList) <-chan wrapper.Result
+	FindExpiredBankTickets(ctx context.Context, expiredBefore time.Time, size int64) <-chan wrapper.Result
+	CountHeldBankTickets(ctx context.Context, userId string, eventId string) <-chan wrapper.Result
 }
 
 type MongodbRepositoryCommand interface {
-	UpdateBankTicket(ctx context.Context, payload request.UpdateBankTicketReq) <-chan wrapper.Result
+	ClaimBankTickets(ctx context.Context, payload request.ClaimBankTicketsReq) <-chan wrapper.Result
+	ReleaseBankTicket(ctx context.Context, payload request.ReleaseBankTicketReq) <-chan wrapper.Result
+	TransitionBankTicket(ctx context.Context, payload request.TransitionBankTicketReq) <-chan wrapper.Result
+	InsertOrder(ctx context.Context, order entity.Order) <-chan wrapper.Result
+	ReservePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan wrapper.Result
+	ReleasePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan wrapper.Result
+	CreateOrderIndexes(ctx context.Context) <-chan wrapper.Result
+	WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan wrapper.Result
 }
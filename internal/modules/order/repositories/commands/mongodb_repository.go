@@ -6,13 +6,25 @@ import (
 	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/errors"
 	wrapper "order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	indexOrderTicketNumber = "ticketNumber_unique"
+	indexBankTicketOrderId = "orderId"
+	indexPurchaseQuotaUser = "eventId_userId_unique"
+	// one payment settles every ticket of an order, the payment id is no longer unique per order document
+	indexOrderPaymentId = "paymentId_unique"
+)
+
 type commandMongodbRepository struct {
 	mongoDb mongodb.Collections
 	logger  log.Logger
@@ -25,7 +37,139 @@ func NewCommandMongodbRepository(mongodb mongodb.Collections, log log.Logger) or
 	}
 }
 
-func (c commandMongodbRepository) UpdateBankTicket(ctx context.Context, payload request.UpdateBankTicketReq) <-chan wrapper.Result {
+// ClaimBankTickets holds quantity free bank tickets of one ticket type for the user with a single bulk write,
+// exactly the given seats when SeatNumbers is set. It runs in the snapshot transaction of the order: a buyer
+// claiming a ticket another buyer took after the snapshot gets a write conflict and the transaction is run again on
+// a fresh snapshot, so concurrent buyers move on to the next free tickets. Data is nil when not enough tickets are
+// free or one of them was claimed in between, the caller has to abort its transaction so that the tickets claimed
+// so far are given back.
+func (c commandMongodbRepository) ClaimBankTickets(ctx context.Context, payload request.ClaimBankTicketsReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var bankTickets []entity.BankTicket
+
+	go func() {
+		defer close(output)
+
+		filter := bson.M{
+			"isUsed":     false,
+			"eventId":    payload.EventId,
+			"ticketType": payload.TicketType,
+		}
+		if len(payload.SeatNumbers) > 0 {
+			filter["seatNumber"] = bson.M{"$in": payload.SeatNumbers}
+		}
+
+		freeTickets := <-c.mongoDb.FindMany(mongodb.FindMany{
+			CollectionName: "bank-ticket",
+			Result:         &bankTickets,
+			Filter:         filter,
+			Limit:          int64(payload.Quantity),
+		}, ctx)
+		if freeTickets.Error != nil {
+			output <- freeTickets
+			return
+		}
+
+		if len(bankTickets) < payload.Quantity {
+			output <- wrapper.Result{Data: nil}
+			return
+		}
+
+		claim := bson.M{
+			"orderId":        payload.OrderId,
+			"isUsed":         true,
+			"userId":         payload.UserId,
+			"price":          payload.Price,
+			"priceBreakdown": payload.PriceBreakdown,
+			"promoCode":      payload.PromoCode,
+			"queueId":        payload.QueueId,
+			"ticketId":       payload.TicketId,
+			"eventId":        payload.EventId,
+			"paymentStatus":  payload.StatusChange.To,
+			// a claim starts a new order, the history of the previous holder is dropped
+			"statusHistory": []entity.StatusChange{payload.StatusChange},
+			"heldAt":        payload.StatusChange.At,
+			"updatedAt":     payload.StatusChange.At,
+		}
+		models := make([]mongo.WriteModel, 0, len(bankTickets))
+		for i := range bankTickets {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"ticketNumber": bankTickets[i].TicketNumber, "isUsed": false}).
+				SetUpdate(bson.M{"$set": claim}))
+
+			bankTickets[i].OrderId = payload.OrderId
+			bankTickets[i].IsUsed = true
+			bankTickets[i].UserId = payload.UserId
+			bankTickets[i].Price = payload.Price
+			bankTickets[i].PriceBreakdown = payload.PriceBreakdown
+			bankTickets[i].PromoCode = payload.PromoCode
+			bankTickets[i].QueueId = payload.QueueId
+			bankTickets[i].TicketId = payload.TicketId
+			bankTickets[i].PaymentStatus = payload.StatusChange.To
+			bankTickets[i].StatusHistory = []entity.StatusChange{payload.StatusChange}
+			bankTickets[i].HeldAt = payload.StatusChange.At
+			bankTickets[i].UpdatedAt = payload.StatusChange.At
+		}
+
+		resp := <-c.mongoDb.BulkWrite(mongodb.BulkWrite{
+			CollectionName: "bank-ticket",
+			Models:         models,
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		result, ok := resp.Data.(*mongo.BulkWriteResult)
+		if !ok || result.ModifiedCount < int64(len(models)) {
+			output <- wrapper.Result{Data: nil}
+			return
+		}
+
+		output <- wrapper.Result{Data: &bankTickets}
+	}()
+
+	return output
+}
+
+func (c commandMongodbRepository) ReleaseBankTicket(ctx context.Context, payload request.ReleaseBankTicketReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var bankTicket entity.BankTicket
+
+	go func() {
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "bank-ticket",
+			Result:         &bankTicket,
+			Filter: bson.M{
+				"isUsed":        true,
+				"ticketNumber":  payload.TicketNumber,
+				"eventId":       payload.EventId,
+				"userId":        payload.UserId,
+				"paymentStatus": payload.StatusChange.From,
+			},
+			Update: bson.M{
+				"$set": bson.M{
+					"isUsed":        false,
+					"userId":        "",
+					"queueId":       "",
+					"paymentStatus": payload.StatusChange.To,
+					"updatedAt":     payload.StatusChange.At,
+				},
+				"$push": bson.M{
+					"statusHistory": payload.StatusChange,
+				},
+			},
+			Upsert: false,
+		}, options.Before, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// TransitionBankTicket moves a held bank ticket to the next status, nil Data means it left the from status meanwhile.
+func (c commandMongodbRepository) TransitionBankTicket(ctx context.Context, payload request.TransitionBankTicketReq) <-chan wrapper.Result {
 	output := make(chan wrapper.Result)
 	var bankTicket entity.BankTicket
 
@@ -34,20 +178,146 @@ func (c commandMongodbRepository) UpdateBankTicket(ctx context.Context, payload
 			CollectionName: "bank-ticket",
 			Result:         &bankTicket,
 			Filter: bson.M{
-				"isUsed":     false,
-				"eventId":    payload.EventId,
-				"ticketType": payload.TicketType,
+				"isUsed":        true,
+				"ticketNumber":  payload.TicketNumber,
+				"paymentStatus": payload.StatusChange.From,
+			},
+			Update: bson.M{
+				"$set": bson.M{
+					"paymentStatus": payload.StatusChange.To,
+					"updatedAt":     payload.StatusChange.At,
+				},
+				"$push": bson.M{
+					"statusHistory": payload.StatusChange,
+				},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+func (c commandMongodbRepository) InsertOrder(ctx context.Context, order entity.Order) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = time.Now()
+
+	go func() {
+		resp := <-c.mongoDb.InsertOne(mongodb.InsertOne{
+			CollectionName: "order",
+			Document:       order,
+		}, ctx)
+		if resp.Error != nil && strings.Contains(resp.Error.Error(), indexOrderTicketNumber) {
+			resp.Error = errors.Conflict("order already exists")
+		}
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// ReservePurchaseQuota adds the tickets to the purchase counters of the user, but only while the totals stay within
+// the limits. The check and the increment are one update, so concurrent orders of the same user cannot both pass.
+// Data is nil when the limit would be exceeded.
+func (c commandMongodbRepository) ReservePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var quota entity.PurchaseQuota
+
+	go func() {
+		defer close(output)
+		now := time.Now()
+
+		// the first order of the user creates the counters, the unique index keeps it to one document
+		created := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "purchase-quota",
+			Result:         &quota,
+			Filter: bson.M{
+				"eventId": payload.EventId,
+				"userId":  payload.UserId,
+			},
+			Update: bson.M{
+				"$setOnInsert": bson.M{
+					"eventId":   payload.EventId,
+					"userId":    payload.UserId,
+					"total":     0,
+					"types":     bson.M{},
+					"createdAt": now,
+					"updatedAt": now,
+				},
+			},
+			Upsert: true,
+		}, options.After, ctx)
+		if created.Error != nil {
+			output <- created
+			return
+		}
+
+		total := 0
+		filter := bson.M{
+			"eventId": payload.EventId,
+			"userId":  payload.UserId,
+		}
+		increment := bson.M{}
+		for ticketType, quantity := range payload.Tickets {
+			total += quantity
+			increment["types."+ticketType] = quantity
+			if limit := payload.TypeLimits[ticketType]; limit > 0 {
+				// $not also matches a ticket type the user never bought
+				filter["types."+ticketType] = bson.M{"$not": bson.M{"$gt": limit - quantity}}
+			}
+		}
+		filter["total"] = bson.M{"$lte": payload.Limit - total}
+		increment["total"] = total
+
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "purchase-quota",
+			Result:         &quota,
+			Filter:         filter,
+			Update: bson.M{
+				"$inc": increment,
+				"$set": bson.M{
+					"updatedAt": now,
+				},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+	}()
+
+	return output
+}
+
+// ReleasePurchaseQuota takes released tickets off the purchase counters of the user.
+func (c commandMongodbRepository) ReleasePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var quota entity.PurchaseQuota
+
+	go func() {
+		total := 0
+		decrement := bson.M{}
+		for ticketType, quantity := range payload.Tickets {
+			total += quantity
+			decrement["types."+ticketType] = -quantity
+		}
+		decrement["total"] = -total
+
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "purchase-quota",
+			Result:         &quota,
+			Filter: bson.M{
+				"eventId": payload.EventId,
+				"userId":  payload.UserId,
+				// tickets claimed before the counters existed are not counted, they must not go below zero
+				"total": bson.M{"$gte": total},
 			},
 			Update: bson.M{
+				"$inc": decrement,
 				"$set": bson.M{
-					"isUsed":        true,
-					"userId":        payload.UserId,
-					"price":         payload.Price,
-					"queueId":       payload.QueueId,
-					"ticketId":      payload.TicketId,
-					"eventId":       payload.EventId,
-					"paymentStatus": payload.PaymentStatus,
-					"updatedAt":     payload.UpdatedAt,
+					"updatedAt": time.Now(),
 				},
 			},
 			Upsert: false,
@@ -58,3 +328,75 @@ func (c commandMongodbRepository) UpdateBankTicket(ctx context.Context, payload
 
 	return output
 }
+
+// CreateOrderIndexes makes sure a ticket can only be turned into an order once, that the tickets of an order
+// are found by its id and that a user has a single set of purchase counters per event.
+func (c commandMongodbRepository) CreateOrderIndexes(ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+
+		resp := <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "order",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "ticketNumber", Value: 1}},
+					Options: options.Index().SetName(indexOrderTicketNumber).SetUnique(true),
+				},
+			},
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		resp = <-c.mongoDb.DropIndex(mongodb.DropIndex{
+			CollectionName: "order",
+			Name:           indexOrderPaymentId,
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		resp = <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "bank-ticket",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "orderId", Value: 1}},
+					Options: options.Index().SetName(indexBankTicketOrderId),
+				},
+			},
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		resp = <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "purchase-quota",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "eventId", Value: 1}, {Key: "userId", Value: 1}},
+					Options: options.Index().SetName(indexPurchaseQuotaUser).SetUnique(true),
+				},
+			},
+		}, ctx)
+		output <- resp
+	}()
+
+	return output
+}
+
+func (c commandMongodbRepository) WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.WithTransaction(fn, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
@@ -3,8 +3,10 @@ package commands_test
 import (
 	"context"
 	"order-service/internal/modules/order"
+	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	mongoRC "order-service/internal/modules/order/repositories/commands"
+	"order-service/internal/pkg/databases/mongodb"
 	"order-service/internal/pkg/helpers"
 	mocks "order-service/mocks/pkg/databases/mongodb"
 	mocklog "order-service/mocks/pkg/log"
@@ -13,6 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CommandTestSuite struct {
@@ -37,18 +42,219 @@ func TestCommandTestSuite(t *testing.T) {
 	suite.Run(t, new(CommandTestSuite))
 }
 
-func (suite *CommandTestSuite) TestUpdateBankTicket() {
-	payload := request.UpdateBankTicketReq{
-		CountryCode: "code",
+func (suite *CommandTestSuite) TestClaimBankTickets() {
+	payload := request.ClaimBankTicketsReq{
+		OrderId:    "order",
+		EventId:    "id",
+		TicketType: "type",
+		Quantity:   2,
+		UserId:     "user",
+		Price:      50,
+	}
+
+	// Mock FindMany
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		free := args.Get(0).(mongodb.FindMany).Result.(*[]entity.BankTicket)
+		*free = []entity.BankTicket{{TicketNumber: "111"}, {TicketNumber: "112"}}
+	}).Return(resultChannel(helpers.Result{Data: "result not nil"}))
+	// Mock BulkWrite
+	suite.mockMongodb.On("BulkWrite", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{
+		Data: &mongo.BulkWriteResult{MatchedCount: 2, ModifiedCount: 2},
+	}))
+
+	// Act
+	result := <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	tickets, ok := result.Data.(*[]entity.BankTicket)
+	assert.True(suite.T(), ok)
+	assert.Len(suite.T(), *tickets, 2)
+	assert.True(suite.T(), (*tickets)[1].IsUsed)
+	assert.Equal(suite.T(), "user", (*tickets)[1].UserId)
+	assert.Equal(suite.T(), "order", (*tickets)[1].OrderId)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.MatchedBy(func(req mongodb.FindMany) bool {
+		filter := req.Filter.(bson.M)
+		return filter["isUsed"] == false && filter["ticketType"] == "type" && filter["seatNumber"] == nil && req.Limit == 2
+	}), mock.Anything)
+	// every ticket is only claimed while it is still free
+	suite.mockMongodb.AssertCalled(suite.T(), "BulkWrite", mock.MatchedBy(func(req mongodb.BulkWrite) bool {
+		if len(req.Models) != 2 {
+			return false
+		}
+		filter := req.Models[1].(*mongo.UpdateOneModel).Filter.(bson.M)
+		return filter["ticketNumber"] == "112" && filter["isUsed"] == false
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestClaimBankTicketsSeats() {
+	payload := request.ClaimBankTicketsReq{
+		EventId:     "id",
 		TicketType:  "type",
+		Quantity:    2,
+		SeatNumbers: []int{11, 12},
+	}
+
+	// Mock FindMany
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		free := args.Get(0).(mongodb.FindMany).Result.(*[]entity.BankTicket)
+		*free = []entity.BankTicket{{TicketNumber: "111", SeatNumber: 11}, {TicketNumber: "112", SeatNumber: 12}}
+	}).Return(resultChannel(helpers.Result{Data: "result not nil"}))
+	// Mock BulkWrite
+	suite.mockMongodb.On("BulkWrite", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{
+		Data: &mongo.BulkWriteResult{MatchedCount: 2, ModifiedCount: 2},
+	}))
+
+	// Act
+	result := <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	assert.NotNil(suite.T(), result.Data)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.MatchedBy(func(req mongodb.FindMany) bool {
+		seats, ok := req.Filter.(bson.M)["seatNumber"].(bson.M)
+		return ok && assert.ObjectsAreEqual(payload.SeatNumbers, seats["$in"])
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestClaimBankTicketsNotEnoughFree() {
+	payload := request.ClaimBankTicketsReq{
+		EventId:    "id",
+		TicketType: "type",
+		Quantity:   3,
+	}
+
+	// Mock FindMany
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		free := args.Get(0).(mongodb.FindMany).Result.(*[]entity.BankTicket)
+		*free = []entity.BankTicket{{TicketNumber: "111"}}
+	}).Return(resultChannel(helpers.Result{Data: "result not nil"}))
+
+	// Act
+	result := <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	assert.Nil(suite.T(), result.Data)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "BulkWrite", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestClaimBankTicketsClaimedInBetween() {
+	payload := request.ClaimBankTicketsReq{
+		EventId:    "id",
+		TicketType: "type",
+		Quantity:   2,
 	}
 
-	// Mock UpsertOne
+	// Mock FindMany
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		free := args.Get(0).(mongodb.FindMany).Result.(*[]entity.BankTicket)
+		*free = []entity.BankTicket{{TicketNumber: "111"}, {TicketNumber: "112"}}
+	}).Return(resultChannel(helpers.Result{Data: "result not nil"}))
+	// Mock BulkWrite, the second ticket is no longer free
+	suite.mockMongodb.On("BulkWrite", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{
+		Data: &mongo.BulkWriteResult{MatchedCount: 1, ModifiedCount: 1},
+	}))
+
+	// Act
+	result := <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	assert.Nil(suite.T(), result.Data)
+}
+
+func (suite *CommandTestSuite) TestClaimBankTicketsError() {
+	payload := request.ClaimBankTicketsReq{
+		EventId:    "id",
+		TicketType: "type",
+		Quantity:   2,
+	}
+
+	// Mock FindMany
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Error: mongo.ErrClientDisconnected}))
+
+	// Act
+	result := <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.Error(suite.T(), result.Error)
+	assert.Nil(suite.T(), result.Data)
+
+	// Mock BulkWrite
+	suite.mockMongodb.ExpectedCalls = nil
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		free := args.Get(0).(mongodb.FindMany).Result.(*[]entity.BankTicket)
+		*free = []entity.BankTicket{{TicketNumber: "111"}, {TicketNumber: "112"}}
+	}).Return(resultChannel(helpers.Result{Data: "result not nil"}))
+	suite.mockMongodb.On("BulkWrite", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Error: mongo.ErrClientDisconnected}))
+
+	// Act
+	result = <-suite.repository.ClaimBankTickets(suite.ctx, payload)
+
+	// Asset
+	assert.Error(suite.T(), result.Error)
+	assert.Nil(suite.T(), result.Data)
+}
+
+func (suite *CommandTestSuite) TestReleaseBankTicket() {
+	payload := request.ReleaseBankTicketReq{
+		TicketNumber: "111",
+		UserId:       "id",
+	}
+
+	// Mock FindOneAndUpdate
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.ReleaseBankTicket(suite.ctx, payload)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOneAndUpdate
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestWithTransaction() {
+	// Mock WithTransaction
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("WithTransaction", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.WithTransaction(suite.ctx, func(sessCtx context.Context) error { return nil })
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert WithTransaction
+	suite.mockMongodb.AssertCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestTransitionBankTicket() {
+	// Mock FindOneAndUpdate
 	expectedResult := make(chan helpers.Result)
 	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
 	// Act
-	result := suite.repository.UpdateBankTicket(suite.ctx, payload)
+	result := suite.repository.TransitionBankTicket(suite.ctx, request.TransitionBankTicketReq{TicketNumber: "111"})
 	// Asset
 	assert.NotNil(suite.T(), result, "Expected a result")
 
@@ -61,6 +267,118 @@ func (suite *CommandTestSuite) TestUpdateBankTicket() {
 	// Wait for the goroutine to complete
 	<-result
 
-	// Assert UpsertOne
+	// Assert FindOneAndUpdate
 	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
 }
+
+func (suite *CommandTestSuite) TestInsertOrder() {
+	// Mock InsertOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("InsertOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.InsertOrder(suite.ctx, entity.Order{PaymentId: "payment"})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert InsertOne
+	suite.mockMongodb.AssertCalled(suite.T(), "InsertOne", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestReservePurchaseQuota() {
+	payload := request.PurchaseQuotaReq{
+		EventId:    "id",
+		UserId:     "user",
+		Tickets:    map[string]int{"VIP": 2},
+		Limit:      4,
+		TypeLimits: map[string]int{"VIP": 3},
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(func(mongodb.FindOneAndUpdate, options.ReturnDocument, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: &entity.PurchaseQuota{}})
+	})
+
+	// Act
+	result := <-suite.repository.ReservePurchaseQuota(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "FindOneAndUpdate", 2)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		return req.Upsert
+	}), mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		filter := req.Filter.(bson.M)
+		return !req.Upsert &&
+			assert.ObjectsAreEqual(bson.M{"$lte": 2}, filter["total"]) &&
+			assert.ObjectsAreEqual(bson.M{"$not": bson.M{"$gt": 1}}, filter["types.VIP"])
+	}), mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestReleasePurchaseQuota() {
+	payload := request.PurchaseQuotaReq{
+		EventId: "id",
+		UserId:  "user",
+		Tickets: map[string]int{"VIP": 1},
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+
+	// Act
+	result := <-suite.repository.ReleasePurchaseQuota(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		update := req.Update.(bson.M)
+		return assert.ObjectsAreEqual(bson.M{"types.VIP": -1, "total": -1}, update["$inc"])
+	}), mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestCreateOrderIndexes() {
+	// Mock CreateIndexes
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+	// Mock DropIndex
+	suite.mockMongodb.On("DropIndex", mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Data: "paymentId_unique"}))
+
+	// Act
+	result := suite.repository.CreateOrderIndexes(suite.ctx)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert CreateIndexes
+	suite.mockMongodb.AssertCalled(suite.T(), "CreateIndexes", mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "DropIndex", mongodb.DropIndex{CollectionName: "order", Name: "paymentId_unique"}, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "CreateIndexes", mock.MatchedBy(func(req mongodb.CreateIndexes) bool {
+		return req.CollectionName == "bank-ticket"
+	}), mock.Anything)
+}
+
+func resultChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result, 1)
+	responseChan <- result
+	close(responseChan)
+
+	return responseChan
+}
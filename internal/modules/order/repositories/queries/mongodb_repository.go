@@ -8,6 +8,7 @@ import (
 	"order-service/internal/pkg/databases/mongodb"
 	wrapper "order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -24,7 +25,38 @@ func NewQueryMongodbRepository(mongodb mongodb.Collections, log log.Logger) orde
 	}
 }
 
-func (q queryMongodbRepository) FindBankTicketByParam(ctx context.Context, eventId string, userId string) <-chan wrapper.Result {
+// FindFreeSeats returns the unclaimed bank tickets of a ticket category ordered by seat number.
+func (q queryMongodbRepository) FindFreeSeats(ctx context.Context, payload request.FreeSeatReq) <-chan wrapper.Result {
+	var bankTickets []entity.BankTicket
+	output := make(chan wrapper.Result)
+
+	go func() {
+		filter := bson.M{
+			"isUsed":     false,
+			"eventId":    payload.EventId,
+			"ticketType": payload.TicketType,
+		}
+		if len(payload.SeatNumbers) > 0 {
+			filter["seatNumber"] = bson.M{"$in": payload.SeatNumbers}
+		}
+
+		resp := <-q.mongoDb.FindMany(mongodb.FindMany{
+			Result:         &bankTickets,
+			CollectionName: "bank-ticket",
+			Filter:         filter,
+			Sort: &mongodb.Sort{
+				FieldName: "seatNumber",
+				By:        mongodb.SortAscending,
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+func (q queryMongodbRepository) FindBankTicketByTicketNumber(ctx context.Context, ticketNumber string) <-chan wrapper.Result {
 	var bankTicket entity.BankTicket
 	output := make(chan wrapper.Result)
 
@@ -33,10 +65,31 @@ func (q queryMongodbRepository) FindBankTicketByParam(ctx context.Context, event
 			Result:         &bankTicket,
 			CollectionName: "bank-ticket",
 			Filter: bson.M{
-				"$and": []interface{}{
-					bson.M{"eventId": eventId},
-					bson.M{"userId": userId},
-				},
+				"ticketNumber": ticketNumber,
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// FindBankTicketsByOrderId returns the bank tickets claimed together as one order, ordered by ticket number.
+func (q queryMongodbRepository) FindBankTicketsByOrderId(ctx context.Context, orderId string) <-chan wrapper.Result {
+	var bankTickets []entity.BankTicket
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindMany(mongodb.FindMany{
+			Result:         &bankTickets,
+			CollectionName: "bank-ticket",
+			Filter: bson.M{
+				"orderId": orderId,
+			},
+			Sort: &mongodb.Sort{
+				FieldName: "ticketNumber",
+				By:        mongodb.SortAscending,
 			},
 		}, ctx)
 		output <- resp
@@ -71,6 +124,25 @@ func (q queryMongodbRepository) FindOrderByUser(ctx context.Context, payload req
 	return output
 }
 
+func (q queryMongodbRepository) FindOrderByTicketNumber(ctx context.Context, ticketNumber string) <-chan wrapper.Result {
+	var order entity.Order
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindOne(mongodb.FindOne{
+			Result:         &order,
+			CollectionName: "order",
+			Filter: bson.M{
+				"ticketNumber": ticketNumber,
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
 func (q queryMongodbRepository) FindBankTicketByUser(ctx context.Context, payload request.PreOrderList) <-chan wrapper.Result {
 	var bankTicket []entity.BankTicket
 	var countData int64
@@ -95,3 +167,57 @@ func (q queryMongodbRepository) FindBankTicketByUser(ctx context.Context, payloa
 
 	return output
 }
+
+func (q queryMongodbRepository) FindExpiredBankTickets(ctx context.Context, expiredBefore time.Time, size int64) <-chan wrapper.Result {
+	var bankTicket []entity.BankTicket
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindAllData(mongodb.FindAllData{
+			Result:         &bankTicket,
+			CollectionName: "bank-ticket",
+			Filter: bson.M{
+				"isUsed":        true,
+				"paymentStatus": bson.M{"$in": entity.AwaitingPayment},
+				// tickets claimed before heldAt was stored are held since their last update
+				"$or": []bson.M{
+					{"heldAt": bson.M{"$lt": expiredBefore}},
+					{"heldAt": bson.M{"$exists": false}, "updatedAt": bson.M{"$lt": expiredBefore}},
+				},
+			},
+			Sort: &mongodb.Sort{
+				FieldName: "heldAt",
+				By:        mongodb.SortAscending,
+			},
+			Page: 1,
+			Size: size,
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// CountHeldBankTickets counts the tickets of the event the user holds and has not paid yet.
+func (q queryMongodbRepository) CountHeldBankTickets(ctx context.Context, userId string, eventId string) <-chan wrapper.Result {
+	var count int64
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.CountData(mongodb.CountData{
+			Result:         &count,
+			CollectionName: "bank-ticket",
+			Filter: bson.M{
+				"isUsed":        true,
+				"userId":        userId,
+				"eventId":       eventId,
+				"paymentStatus": bson.M{"$in": entity.AwaitingPayment},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
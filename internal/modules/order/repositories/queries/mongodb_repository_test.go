@@ -5,14 +5,17 @@ import (
 	"order-service/internal/modules/order"
 	"order-service/internal/modules/order/models/request"
 	mongoRQ "order-service/internal/modules/order/repositories/queries"
+	"order-service/internal/pkg/databases/mongodb"
 	"order-service/internal/pkg/helpers"
 	mocks "order-service/mocks/pkg/databases/mongodb"
 	mocklog "order-service/mocks/pkg/log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type CommandTestSuite struct {
@@ -37,14 +40,14 @@ func TestCommandTestSuite(t *testing.T) {
 	suite.Run(t, new(CommandTestSuite))
 }
 
-func (suite *CommandTestSuite) TestFindBankTicketByParam() {
+func (suite *CommandTestSuite) TestFindOrderByUser() {
 
 	// Mock FindOne
 	expectedResult := make(chan helpers.Result)
-	suite.mockMongodb.On("FindOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+	suite.mockMongodb.On("FindAllData", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
 	// Act
-	result := suite.repository.FindBankTicketByParam(suite.ctx, mock.Anything, mock.Anything)
+	result := suite.repository.FindOrderByUser(suite.ctx, request.OrderList{})
 	// Asset
 	assert.NotNil(suite.T(), result, "Expected a result")
 
@@ -58,17 +61,17 @@ func (suite *CommandTestSuite) TestFindBankTicketByParam() {
 	<-result
 
 	// Assert FindOne
-	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindAllData", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandTestSuite) TestFindOrderByUser() {
+func (suite *CommandTestSuite) TestFindBankTicketByUser() {
 
 	// Mock FindOne
 	expectedResult := make(chan helpers.Result)
 	suite.mockMongodb.On("FindAllData", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
 	// Act
-	result := suite.repository.FindOrderByUser(suite.ctx, request.OrderList{})
+	result := suite.repository.FindBankTicketByUser(suite.ctx, request.PreOrderList{})
 	// Asset
 	assert.NotNil(suite.T(), result, "Expected a result")
 
@@ -85,14 +88,63 @@ func (suite *CommandTestSuite) TestFindOrderByUser() {
 	suite.mockMongodb.AssertCalled(suite.T(), "FindAllData", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandTestSuite) TestFindBankTicketByUser() {
+func (suite *CommandTestSuite) TestFindExpiredBankTickets() {
+	// Mock FindAllData
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindAllData", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
+	// Act
+	result := suite.repository.FindExpiredBankTickets(suite.ctx, time.Now(), 100)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindAllData
+	suite.mockMongodb.AssertCalled(suite.T(), "FindAllData", mock.MatchedBy(func(req mongodb.FindAllData) bool {
+		filter := req.Filter.(bson.M)
+		_, byUpdate := filter["updatedAt"]
+		return !byUpdate && filter["$or"] != nil && req.Sort.FieldName == "heldAt"
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestCountHeldBankTickets() {
+	// Mock CountData
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("CountData", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.CountHeldBankTickets(suite.ctx, "user", "event")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert CountData
+	suite.mockMongodb.AssertCalled(suite.T(), "CountData", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestFindBankTicketByTicketNumber() {
 	// Mock FindOne
 	expectedResult := make(chan helpers.Result)
-	suite.mockMongodb.On("FindAllData", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+	suite.mockMongodb.On("FindOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
 	// Act
-	result := suite.repository.FindBankTicketByUser(suite.ctx, request.PreOrderList{})
+	result := suite.repository.FindBankTicketByTicketNumber(suite.ctx, "111")
 	// Asset
 	assert.NotNil(suite.T(), result, "Expected a result")
 
@@ -106,5 +158,76 @@ func (suite *CommandTestSuite) TestFindBankTicketByUser() {
 	<-result
 
 	// Assert FindOne
-	suite.mockMongodb.AssertCalled(suite.T(), "FindAllData", mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestFindBankTicketsByOrderId() {
+	// Mock FindMany
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.FindBankTicketsByOrderId(suite.ctx, "order")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindMany
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.MatchedBy(func(req mongodb.FindMany) bool {
+		return req.CollectionName == "bank-ticket" && req.Filter.(bson.M)["orderId"] == "order"
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestFindOrderByTicketNumber() {
+	// Mock FindOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.FindOrderByTicketNumber(suite.ctx, "111")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOne
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestFindFreeSeats() {
+	// Mock FindMany
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.FindFreeSeats(suite.ctx, request.FreeSeatReq{EventId: "id", TicketType: "VIP", SeatNumbers: []int{11}})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindMany
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.Anything, mock.Anything)
 }
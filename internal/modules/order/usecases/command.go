@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"order-service/configs"
 	"order-service/internal/modules/event"
 	eventEntity "order-service/internal/modules/event/models/entity"
@@ -10,19 +11,27 @@ import (
 	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	"order-service/internal/modules/order/models/response"
+	"order-service/internal/modules/outbox"
+	outboxRequest "order-service/internal/modules/outbox/models/request"
+	"order-service/internal/modules/pricing"
+	pricingEntity "order-service/internal/modules/pricing/models/entity"
+	pricingRequest "order-service/internal/modules/pricing/models/request"
+	"order-service/internal/modules/promo"
+	promoRequest "order-service/internal/modules/promo/models/request"
 	"order-service/internal/modules/room"
-	roomEntity "order-service/internal/modules/room/models/entity"
 	"order-service/internal/modules/ticket"
 	ticketEntity "order-service/internal/modules/ticket/models/entity"
-	ticketRequest "order-service/internal/modules/ticket/models/request"
 	"order-service/internal/modules/user"
 	userEntity "order-service/internal/modules/user/models/entity"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/eventschema"
 	"order-service/internal/pkg/log"
 	"order-service/internal/pkg/redis"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"go.elastic.co/apm"
 )
 
@@ -31,32 +40,156 @@ var (
 	Now     = time.Now
 )
 
+const (
+	defaultHoldDuration = 15
+	defaultExpiryBatch  = 100
+	defaultMaxTickets   = 1
+)
+
+// orderHoldDuration is how long a pending bank ticket is held for payment before the expiry worker releases it.
+func orderHoldDuration() time.Duration {
+	minutes, err := strconv.Atoi(Configs().Order.HoldDuration)
+	if err != nil || minutes <= 0 {
+		minutes = defaultHoldDuration
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// heldAt is when the hold of the ticket started. Tickets claimed before heldAt was stored fall back to their last
+// update, which was the claim unless a payment was started.
+func heldAt(ticket entity.BankTicket) time.Time {
+	if ticket.HeldAt.IsZero() {
+		return ticket.UpdatedAt
+	}
+	return ticket.HeldAt
+}
+
+// maxTicketsPerUser is how many tickets one user may hold for the event, the event setting wins over the service config.
+func maxTicketsPerUser(event eventEntity.Event) int {
+	if event.Order.MaxTicketsPerUser > 0 {
+		return event.Order.MaxTicketsPerUser
+	}
+
+	max, err := strconv.Atoi(Configs().Order.MaxTickets)
+	if err != nil || max <= 0 {
+		max = defaultMaxTickets
+	}
+	return max
+}
+
+// orderItems returns the tickets asked for per ticket type. A request without items is one item of its ticket type,
+// picked seats set the quantity and a missing quantity means a single ticket.
+func orderItems(payload request.OrderReq) []request.OrderItemReq {
+	items := payload.Items
+	if len(items) == 0 {
+		items = []request.OrderItemReq{{TicketType: payload.TicketType, Quantity: payload.Quantity, SeatNumbers: payload.SeatNumbers}}
+	}
+
+	merged := make([]request.OrderItemReq, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if len(item.SeatNumbers) > 0 {
+			item.Quantity = len(item.SeatNumbers)
+		}
+		if item.Quantity <= 0 {
+			item.Quantity = 1
+		}
+		if i, ok := index[item.TicketType]; ok {
+			merged[i].Quantity += item.Quantity
+			merged[i].SeatNumbers = append(merged[i].SeatNumbers, item.SeatNumbers...)
+			continue
+		}
+		index[item.TicketType] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func ticketTypesOf(items []request.OrderItemReq) []string {
+	ticketTypes := make([]string, 0, len(items))
+	for _, item := range items {
+		ticketTypes = append(ticketTypes, item.TicketType)
+	}
+	return ticketTypes
+}
+
+// purchaseQuotaOf is the share of a single bank ticket in the purchase counters of its holder.
+func purchaseQuotaOf(ticket entity.BankTicket) request.PurchaseQuotaReq {
+	return request.PurchaseQuotaReq{
+		EventId: ticket.EventId,
+		UserId:  ticket.UserId,
+		Tickets: map[string]int{ticket.TicketType: 1},
+	}
+}
+
+// orderEvent is the outbox entry of an order event. Keying by the order id keeps every event of an order, for all
+// of its tickets, on one partition and in order.
+func orderEvent(eventType string, topic string, orderId string, payload interface{}) outboxRequest.OutboxEventReq {
+	return outboxRequest.OutboxEventReq{
+		Type:    eventType,
+		Topic:   topic,
+		Key:     orderId,
+		Payload: payload,
+	}
+}
+
+// orderKey is the event key of the order of the ticket, a ticket claimed before orders had an id is its own order.
+func orderKey(ticket entity.BankTicket) string {
+	if ticket.OrderId == "" {
+		return ticket.TicketNumber
+	}
+	return ticket.OrderId
+}
+
+func expiryBatch() int64 {
+	batch, err := strconv.ParseInt(Configs().Order.ExpiryBatch, 10, 64)
+	if err != nil || batch <= 0 {
+		batch = defaultExpiryBatch
+	}
+	return batch
+}
+
 type commandUsecase struct {
 	orderRepositoryCommand  order.MongodbRepositoryCommand
 	orderRepositoryQuery    order.MongodbRepositoryQuery
-	roomRepositoryQuery     room.MongodbRepositoryQuery
 	ticketRepositoryQuery   ticket.MongodbRepositoryQuery
 	ticketRepositoryCommand ticket.MongodbRepositoryCommand
 	eventRepositoryQuery    event.MongodbRepositoryQuery
 	userRepositoryQuery     user.MongodbRepositoryQuery
 	logger                  log.Logger
 	redis                   redis.Collections
+	admission               room.AdmissionController
+	pricingEngine           pricing.Engine
+	promoValidator          promo.CodeValidator
+	promoRepositoryCommand  promo.MongodbRepositoryCommand
+	saleSchedule            event.SaleSchedule
+	releasePolicy           ticket.ReleasePolicy
+	outboxRepositoryCommand outbox.MongodbRepositoryCommand
 }
 
 func NewCommandUsecase(
-	omc order.MongodbRepositoryCommand, omq order.MongodbRepositoryQuery, rmq room.MongodbRepositoryQuery,
+	omc order.MongodbRepositoryCommand, omq order.MongodbRepositoryQuery,
 	trq ticket.MongodbRepositoryQuery, trc ticket.MongodbRepositoryCommand,
-	emq event.MongodbRepositoryQuery, umq user.MongodbRepositoryQuery, log log.Logger, rc redis.Collections) order.UsecaseCommand {
+	emq event.MongodbRepositoryQuery, umq user.MongodbRepositoryQuery, log log.Logger, rc redis.Collections,
+	adm room.AdmissionController, pe pricing.Engine, pv promo.CodeValidator,
+	pmc promo.MongodbRepositoryCommand, ss event.SaleSchedule, rp ticket.ReleasePolicy,
+	obc outbox.MongodbRepositoryCommand) order.UsecaseCommand {
 	return commandUsecase{
 		orderRepositoryCommand:  omc,
 		orderRepositoryQuery:    omq,
-		roomRepositoryQuery:     rmq,
 		ticketRepositoryQuery:   trq,
 		ticketRepositoryCommand: trc,
 		eventRepositoryQuery:    emq,
 		userRepositoryQuery:     umq,
 		logger:                  log,
 		redis:                   rc,
+		admission:               adm,
+		pricingEngine:           pe,
+		promoValidator:          pv,
+		promoRepositoryCommand:  pmc,
+		saleSchedule:            ss,
+		releasePolicy:           rp,
+		outboxRepositoryCommand: obc,
 	}
 }
 
@@ -68,15 +201,6 @@ func (c commandUsecase) CreateOrderTicket(origCtx context.Context, payload reque
 	})
 	defer span.End()
 
-	if Configs().DayFlag {
-		day := Now().Weekday()
-		if day != time.Saturday && day != time.Sunday {
-			msg := "this day not Saturday or Sunday"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			return nil, errors.BadRequest("this day not Saturday or Sunday")
-		}
-	}
-
 	eventData := <-c.eventRepositoryQuery.FindEventById(ctx, payload.EventId)
 	if eventData.Error != nil {
 		msg := "Error DB connection FindEventById"
@@ -97,100 +221,104 @@ func (c commandUsecase) CreateOrderTicket(origCtx context.Context, payload reque
 		return nil, errors.InternalServerError("cannot parsing data event")
 	}
 
-	queueRoom := <-c.roomRepositoryQuery.FindOneQueueByUserId(ctx, payload.UserId, payload.EventId)
-	if queueRoom.Error != nil {
-		msg := "Error DB connection FindOneQueueByUserId"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Error))
-		return nil, queueRoom.Error
+	// the presale has its own window, guarded by the promo code check
+	items := orderItems(payload)
+	if !inPresale(*event, Now()) {
+		if err := c.saleSchedule.CheckSaleOpen(ctx, *event, ticketTypesOf(items)); err != nil {
+			return nil, err
+		}
 	}
 
-	if queueRoom.Data == nil {
-		msg := "user not in the queue"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("user not in the queue")
+	// the admission token already proved the user holds this queue entry
+	serving, err := c.admission.ServingNumber(ctx, event.EventId)
+	if err != nil {
+		return nil, err
 	}
 
-	queueData, ok := queueRoom.Data.(*roomEntity.QueueRoom)
-	if !ok {
-		msg := "cannot parsing data queue"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Data))
-		return nil, errors.InternalServerError("cannot parsing data queue")
+	if payload.QueueNumber > serving {
+		msg := "queue number not admitted yet"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.CustomErrorRetryAfter("queue number not admitted yet", constants.ErrCodeNotAdmitted,
+			http.StatusTooManyRequests, c.admission.EstimateWait(payload.QueueNumber, serving))
 	}
 
-	currentTicket := <-c.orderRepositoryQuery.FindBankTicketByParam(ctx, event.EventId, payload.UserId)
-	if currentTicket.Error != nil {
-		msg := "Error DB connection FindBankTicketByParam"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", currentTicket.Error))
-		return nil, currentTicket.Error
+	limit := maxTicketsPerUser(*event)
+	quota := request.PurchaseQuotaReq{
+		EventId:    event.EventId,
+		UserId:     payload.UserId,
+		Tickets:    make(map[string]int, len(items)),
+		Limit:      limit,
+		TypeLimits: event.Order.MaxTicketsPerType,
+	}
+	quantity := 0
+	for _, item := range items {
+		quantity += item.Quantity
+		quota.Tickets[item.TicketType] = item.Quantity
+		if typeLimit := event.Order.MaxTicketsPerType[item.TicketType]; typeLimit > 0 && item.Quantity > typeLimit {
+			msg := "purchase limit exceeded"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d %s tickets per user", typeLimit, item.TicketType))
+		}
 	}
 
-	if currentTicket.Data != nil {
-		msg := "user already order ticket"
+	if quantity > limit {
+		msg := "purchase limit exceeded"
 		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("user already order ticket")
+		return nil, errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d tickets per user", limit))
 	}
 
-	if payload.TicketType == constants.Online {
-		ticketReq := ticketRequest.TicketReq{
-			CountryCode: event.Country.Code,
-			Tag:         event.Tag,
-		}
-		totalAvailableTicket := <-c.ticketRepositoryQuery.FindTotalAvalailableTicketByCountry(ctx, ticketReq)
-		if totalAvailableTicket.Error != nil {
-			msg := "Error DB connection FindTotalAvalailableTicketByCountry"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalAvailableTicket.Error))
-			return nil, totalAvailableTicket.Error
+	promoCode, err := c.checkPromoCode(ctx, *event, payload, items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.releasePolicy.CheckReleased(ctx, *event, ticketTypesOf(items)); err != nil {
+		return nil, err
+	}
+
+	ticketDetails := make([]ticketEntity.Ticket, 0, len(items))
+	for _, item := range items {
+		ticketDetailData := <-c.ticketRepositoryQuery.FindTicketByEventId(ctx, event.EventId, item.TicketType)
+		if ticketDetailData.Error != nil {
+			msg := "Error DB connection FindTicketByEventId"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Error))
+			return nil, ticketDetailData.Error
 		}
 
-		if totalAvailableTicket.Data == nil {
-			msg := "country not found"
+		if ticketDetailData.Data == nil {
+			msg := "ticket detail not found"
 			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			return nil, errors.BadRequest("country not found")
+			return nil, errors.BadRequest("ticket detail not found")
 		}
 
-		totalAvailable, ok := totalAvailableTicket.Data.(*[]ticketEntity.AggregateTotalTicket)
+		ticketDetail, ok := ticketDetailData.Data.(*ticketEntity.Ticket)
 		if !ok {
-			msg := "cannot parsing data data"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalAvailableTicket.Data))
-			return nil, errors.InternalServerError("cannot parsing data")
-		}
-		eligibleBuy := true
-		for i, v := range *totalAvailable {
-			if i == 0 && v.TotalAvailableTicket != 0 {
-				eligibleBuy = false
-			}
+			msg := "cannot parsing data ticket"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Data))
+			return nil, errors.InternalServerError("cannot parsing data ticket")
 		}
-		if !eligibleBuy {
-			msg := "offline ticket still ready"
+
+		if ticketDetail.TotalRemaining < item.Quantity {
+			msg := "ticket category sold out"
 			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			return nil, errors.BadRequest("offline ticket still ready")
+			return nil, errors.BadRequest("ticket category sold out")
 		}
-	}
-
-	ticketDetailData := <-c.ticketRepositoryQuery.FindTicketByEventId(ctx, event.EventId, payload.TicketType)
-	if ticketDetailData.Error != nil {
-		msg := "Error DB connection FindTicketByEventId"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Error))
-		return nil, ticketDetailData.Error
-	}
-
-	if ticketDetailData.Data == nil {
-		msg := "ticket detail not found"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("ticket detail not found")
-	}
 
-	ticketDetail, ok := ticketDetailData.Data.(*ticketEntity.Ticket)
-	if !ok {
-		msg := "cannot parsing data ticket"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Data))
-		return nil, errors.InternalServerError("cannot parsing data ticket")
-	}
+		if ticketDetail.ReservedSeating != (len(item.SeatNumbers) > 0) {
+			msg := "seat numbers do not match the seating of the ticket category"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			if ticketDetail.ReservedSeating {
+				return nil, errors.BadRequest("seat numbers are required for reserved seating")
+			}
+			return nil, errors.BadRequest("ticket category has no reserved seating")
+		}
 
-	if ticketDetail.TotalRemaining == 0 {
-		msg := "ticket category sold out"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("ticket category sold out")
+		if ticketDetail.ReservedSeating {
+			if err := c.checkSeatHolds(ctx, payload.UserId, event.EventId, item); err != nil {
+				return nil, err
+			}
+		}
+		ticketDetails = append(ticketDetails, *ticketDetail)
 	}
 
 	userData := <-c.userRepositoryQuery.FindOneUserId(ctx, payload.UserId)
@@ -213,65 +341,877 @@ func (c commandUsecase) CreateOrderTicket(origCtx context.Context, payload reque
 		return nil, errors.InternalServerError("cannot parsing data user")
 	}
 
-	price := ticketDetail.TicketPrice
-	if user.Country.Code != event.Country.Code && payload.TicketType != constants.Online {
-		// discount 20% if buy different country
-		price = price * 80 / 100
+	claim, err := transition(entity.StatusNone, entity.StatusHeld, payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	orderId := uuid.NewString()
+	claimReqs := make([]request.ClaimBankTicketsReq, 0, len(items))
+	redemption := promoRequest.RedeemPromoReq{UserId: payload.UserId}
+	for i, item := range items {
+		quote, err := c.pricingEngine.Quote(ctx, pricingRequest.QuoteReq{
+			EventId:          event.EventId,
+			EventCountryCode: event.Country.Code,
+			UserCountryCode:  user.Country.Code,
+			UserContinent:    user.Country.ContinentName,
+			TicketType:       item.TicketType,
+			Quantity:         item.Quantity,
+			BasePrice:        ticketDetails[i].TicketPrice,
+			At:               claim.At,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		price, breakdown, code := quote.Price, priceBreakdown(quote), ""
+		if promoAppliesTo(promoCode, item.TicketType) {
+			if discount := promoDiscount(*promoCode, price); discount > 0 {
+				breakdown.Adjustments = append(breakdown.Adjustments, entity.PriceAdjustment{
+					RuleId: promoCode.Code,
+					Name:   promoCode.Name,
+					Kind:   adjustmentPromo,
+					Amount: -discount,
+				})
+				price -= discount
+			}
+			code = promoCode.Code
+			redemption.Code = promoCode.Code
+			redemption.Quantity += item.Quantity
+			redemption.UsageLimit = promoCode.UsageLimit
+			redemption.PerUserLimit = promoCode.PerUserLimit
+		}
+
+		claimReqs = append(claimReqs, request.ClaimBankTicketsReq{
+			OrderId:        orderId,
+			CountryCode:    event.Country.Code,
+			TicketType:     item.TicketType,
+			Quantity:       item.Quantity,
+			Price:          price,
+			PriceBreakdown: breakdown,
+			PromoCode:      code,
+			UserId:         payload.UserId,
+			QueueId:        payload.QueueId,
+			TicketId:       ticketDetails[i].TicketId,
+			EventId:        event.EventId,
+			SeatNumbers:    item.SeatNumbers,
+			StatusChange:   claim,
+		})
+	}
+
+	// count the tickets against the purchase limit, claim the bank tickets and take them off the remaining stock
+	// as one unit, any failure aborts the transaction and gives everything claimed so far back
+	var tickets []entity.BankTicket
+	transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		tickets = nil
+
+		quotaResp := <-c.orderRepositoryCommand.ReservePurchaseQuota(sessCtx, quota)
+		if quotaResp.Error != nil {
+			msg := "Error DB connection ReservePurchaseQuota"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", quotaResp.Error))
+			return quotaResp.Error
+		}
+
+		if quotaResp.Data == nil {
+			msg := "purchase limit exceeded"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d tickets per user", limit))
+		}
+
+		if redemption.Quantity > 0 {
+			promoResp := <-c.promoRepositoryCommand.RedeemPromoCode(sessCtx, redemption)
+			if promoResp.Error != nil {
+				msg := "Error DB connection RedeemPromoCode"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", promoResp.Error))
+				return promoResp.Error
+			}
+
+			if promoResp.Data == nil {
+				msg := "promo code usage limit reached"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", redemption))
+				return errors.BadRequest("promo code usage limit reached")
+			}
+		}
+
+		for _, claimReq := range claimReqs {
+			bankTicket := <-c.orderRepositoryCommand.ClaimBankTickets(sessCtx, claimReq)
+			if bankTicket.Error != nil {
+				msg := "Error DB connection ClaimBankTickets"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicket.Error))
+				return bankTicket.Error
+			}
+
+			if bankTicket.Data == nil {
+				msg := "bank ticket not available"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", claimReq))
+				return errors.BadRequest("failed to process order")
+			}
+
+			claimed, ok := bankTicket.Data.(*[]entity.BankTicket)
+			if !ok {
+				msg := "cannot parsing data bank ticket"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicket.Data))
+				return errors.InternalServerError("cannot parsing bank ticket")
+			}
+			tickets = append(tickets, *claimed...)
+
+			ticketResp := <-c.ticketRepositoryCommand.DecrementTicketDetail(sessCtx, claimReq.TicketId, claimReq.EventId, claimReq.Quantity)
+			if ticketResp.Error != nil {
+				msg := "Error DB connection DecrementTicketDetail"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketResp.Error))
+				return ticketResp.Error
+			}
+
+			if ticketResp.Data == nil {
+				msg := "ticket category sold out"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", claimReq))
+				return errors.BadRequest("ticket category sold out")
+			}
+		}
+
+		events := make([]outboxRequest.OutboxEventReq, 0, len(tickets))
+		for _, ticket := range tickets {
+			events = append(events, orderEvent(constants.EventOrderHeld, constants.TopicOrderHeld, orderId, eventschema.OrderHeld{
+				OrderId:      orderId,
+				TicketNumber: ticket.TicketNumber,
+				TicketId:     ticket.TicketId,
+				EventId:      ticket.EventId,
+				UserId:       ticket.UserId,
+				QueueId:      ticket.QueueId,
+				TicketType:   ticket.TicketType,
+				SeatNumber:   ticket.SeatNumber,
+				Price:        ticket.Price,
+				PromoCode:    ticket.PromoCode,
+				OrderTime:    claim.At,
+				ExpiredAt:    claim.At.Add(orderHoldDuration()),
+			}))
+		}
+		outboxResp := <-c.outboxRepositoryCommand.InsertOutboxEvents(sessCtx, events)
+		if outboxResp.Error != nil {
+			msg := "Error DB connection InsertOutboxEvents"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", outboxResp.Error))
+			return outboxResp.Error
+		}
+		return nil
+	})
+	if transaction.Error != nil {
+		c.releaseBankTickets(ctx, tickets)
+		return nil, transaction.Error
+	}
+
+	// the seats are sold now, their holds are no longer needed
+	for _, item := range items {
+		c.releaseSeatHolds(ctx, payload.UserId, event.EventId, item.TicketType, item.SeatNumbers)
 	}
 
-	bankTicketReq := request.UpdateBankTicketReq{
-		CountryCode:   event.Country.Code,
-		TicketType:    payload.TicketType,
-		Price:         price,
-		UserId:        payload.UserId,
-		QueueId:       queueData.QueueId,
-		TicketId:      ticketDetail.TicketId,
-		EventId:       event.EventId,
-		PaymentStatus: constants.Pending,
-		UpdatedAt:     time.Now(),
+	result := response.OrderResp{
+		OrderId:     orderId,
+		QueueId:     payload.QueueId,
+		UserId:      payload.UserId,
+		EventId:     event.EventId,
+		CountryCode: event.Country.Code,
+		OrderTime:   claim.At,
+		Tickets:     make([]response.OrderTicket, 0, len(tickets)),
+	}
+	if len(tickets) > 0 {
+		result.TicketType = tickets[0].TicketType
+		result.Price = tickets[0].Price
+		result.TicketNumber = tickets[0].TicketNumber
+	}
+	for _, ticket := range tickets {
+		result.TotalPrice += ticket.Price
+		result.Tickets = append(result.Tickets, response.OrderTicket{
+			TicketNumber: ticket.TicketNumber,
+			TicketType:   ticket.TicketType,
+			SeatNumber:   ticket.SeatNumber,
+			Price:        ticket.Price,
+			PriceBreakdown: response.PriceBreakdown{
+				BasePrice:   ticket.PriceBreakdown.BasePrice,
+				Adjustments: priceAdjustments(ticket.PriceBreakdown.Adjustments),
+			},
+		})
 	}
 
-	bankTicket := <-c.orderRepositoryCommand.UpdateBankTicket(ctx, bankTicketReq)
-	if bankTicket.Error != nil {
-		msg := "Error DB connection UpdateBankTicket"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicket.Error))
-		return nil, bankTicket.Error
+	return &result, nil
+}
+
+// priceBreakdown keeps the quote of the pricing engine on the bank ticket, the final price is stored next to it.
+func priceBreakdown(quote *pricingEntity.Breakdown) entity.PriceBreakdown {
+	result := entity.PriceBreakdown{
+		BasePrice:   quote.BasePrice,
+		Adjustments: make([]entity.PriceAdjustment, 0, len(quote.Adjustments)),
+	}
+	for _, adjustment := range quote.Adjustments {
+		result.Adjustments = append(result.Adjustments, entity.PriceAdjustment{
+			RuleId: adjustment.RuleId,
+			Name:   adjustment.Name,
+			Kind:   string(adjustment.Kind),
+			Amount: adjustment.Amount,
+		})
 	}
+	return result
+}
 
-	if bankTicket.Data == nil {
-		msg := "event not found"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("failed to process order")
+func priceAdjustments(adjustments []entity.PriceAdjustment) []response.PriceAdjustment {
+	result := make([]response.PriceAdjustment, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		result = append(result, response.PriceAdjustment{
+			RuleId: adjustment.RuleId,
+			Name:   adjustment.Name,
+			Kind:   adjustment.Kind,
+			Amount: adjustment.Amount,
+		})
 	}
+	return result
+}
 
-	ticket, ok := bankTicket.Data.(*entity.BankTicket)
-	if !ok {
-		msg := "cannot parsing data bank ticket"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicket.Data))
-		return nil, errors.InternalServerError("cannot parsing bank ticket")
+// releaseBankTickets is the compensation for a claim whose transaction did not commit. When the abort
+// already rolled the claim back the filter matches nothing, so it is safe to call unconditionally.
+func (c commandUsecase) releaseBankTickets(ctx context.Context, tickets []entity.BankTicket) {
+	// the claim left the tickets held, nothing else could have moved them before the user got the response
+	change, err := transition(entity.StatusHeld, entity.StatusCancelled, actorOrderService)
+	if err != nil {
+		msg := "cannot release bank ticket"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return
 	}
 
-	ticketPayload := ticketEntity.Ticket{
-		TicketId:       ticket.TicketId,
-		EventId:        ticket.EventId,
-		TotalRemaining: ticketDetail.TotalRemaining - 1,
+	changes := make([]entity.StatusChange, 0, len(tickets))
+	for range tickets {
+		changes = append(changes, change)
 	}
 
-	ticketResp := <-c.ticketRepositoryCommand.UpdateOneTicketDetail(ctx, ticketPayload)
-	if ticketResp.Error != nil {
-		msg := "Error DB connection UpdateOneTicketDetail"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketResp.Error))
-		return nil, ticketResp.Error
+	transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		_, err := c.releaseTickets(sessCtx, tickets, changes, orderCancelledEvent)
+		return err
+	})
+	if transaction.Error != nil {
+		msg := "Error DB connection release bank ticket"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", transaction.Error))
 	}
+}
 
-	return &response.OrderResp{
+// releaseTickets puts the tickets back on sale inside the transaction of the caller, together with their stock,
+// purchase counters, promo code uses and the event of each ticket. A ticket that left the from status of its change
+// meanwhile is skipped, the tickets that were released are returned.
+func (c commandUsecase) releaseTickets(sessCtx context.Context, tickets []entity.BankTicket, changes []entity.StatusChange,
+	event func(ticket entity.BankTicket, change entity.StatusChange) outboxRequest.OutboxEventReq) ([]entity.BankTicket, error) {
+	released := make([]entity.BankTicket, 0, len(tickets))
+	events := make([]outboxRequest.OutboxEventReq, 0, len(tickets))
+	for i, ticket := range tickets {
+		releaseResp := <-c.orderRepositoryCommand.ReleaseBankTicket(sessCtx, request.ReleaseBankTicketReq{
+			TicketNumber: ticket.TicketNumber,
+			EventId:      ticket.EventId,
+			UserId:       ticket.UserId,
+			StatusChange: changes[i],
+		})
+		if releaseResp.Error != nil {
+			return nil, releaseResp.Error
+		}
+
+		if releaseResp.Data == nil {
+			continue
+		}
+
+		ticketResp := <-c.ticketRepositoryCommand.IncrementTicketDetail(sessCtx, ticket.TicketId, ticket.EventId, 1)
+		if ticketResp.Error != nil {
+			return nil, ticketResp.Error
+		}
+
+		quotaResp := <-c.orderRepositoryCommand.ReleasePurchaseQuota(sessCtx, purchaseQuotaOf(ticket))
+		if quotaResp.Error != nil {
+			return nil, quotaResp.Error
+		}
+
+		if ticket.PromoCode != "" {
+			promoResp := <-c.promoRepositoryCommand.ReturnPromoCode(sessCtx, promoRedemptionOf(ticket))
+			if promoResp.Error != nil {
+				return nil, promoResp.Error
+			}
+		}
+		released = append(released, ticket)
+		events = append(events, event(ticket, changes[i]))
+	}
+
+	if len(events) == 0 {
+		return released, nil
+	}
+
+	outboxResp := <-c.outboxRepositoryCommand.InsertOutboxEvents(sessCtx, events)
+	if outboxResp.Error != nil {
+		return nil, outboxResp.Error
+	}
+	return released, nil
+}
+
+func orderCancelledEvent(ticket entity.BankTicket, change entity.StatusChange) outboxRequest.OutboxEventReq {
+	return orderEvent(constants.EventOrderCancelled, constants.TopicOrderCancelled, orderKey(ticket), eventschema.OrderCancelled{
+		OrderId:      ticket.OrderId,
 		TicketNumber: ticket.TicketNumber,
-		QueueId:      ticket.QueueId,
+		TicketId:     ticket.TicketId,
+		EventId:      ticket.EventId,
 		UserId:       ticket.UserId,
+		QueueId:      ticket.QueueId,
+		TicketType:   ticket.TicketType,
+		Price:        ticket.Price,
+		OrderTime:    heldAt(ticket),
+		CancelledAt:  change.At,
+	})
+}
+
+func orderExpiredEvent(ticket entity.BankTicket, change entity.StatusChange) outboxRequest.OutboxEventReq {
+	return orderEvent(constants.EventOrderExpired, constants.TopicOrderExpired, orderKey(ticket), eventschema.OrderExpired{
+		OrderId:      ticket.OrderId,
+		TicketNumber: ticket.TicketNumber,
+		TicketId:     ticket.TicketId,
 		EventId:      ticket.EventId,
+		UserId:       ticket.UserId,
+		QueueId:      ticket.QueueId,
 		TicketType:   ticket.TicketType,
-		CountryCode:  ticket.CountryCode,
 		Price:        ticket.Price,
-		OrderTime:    ticket.UpdatedAt,
+		OrderTime:    heldAt(ticket),
+		ExpiredAt:    heldAt(ticket).Add(orderHoldDuration()),
+	})
+}
+
+// findOrderTickets returns every bank ticket of an order, found by its id or by one of its ticket numbers.
+// A ticket claimed before orders had an id is an order of its own.
+func (c commandUsecase) findOrderTickets(ctx context.Context, orderId string, ticketNumber string) ([]entity.BankTicket, error) {
+	if orderId == "" {
+		bankTicketData := <-c.orderRepositoryQuery.FindBankTicketByTicketNumber(ctx, ticketNumber)
+		if bankTicketData.Error != nil {
+			msg := "Error DB connection FindBankTicketByTicketNumber"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+			return nil, bankTicketData.Error
+		}
+
+		if bankTicketData.Data == nil {
+			msg := "order not found"
+			c.logger.Error(ctx, msg, ticketNumber)
+			return nil, errors.NotFound("order not found")
+		}
+
+		ticket, ok := bankTicketData.Data.(*entity.BankTicket)
+		if !ok {
+			msg := "cannot parsing data bank ticket"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+			return nil, errors.InternalServerError("cannot parsing data bank ticket")
+		}
+
+		if ticket.OrderId == "" {
+			return []entity.BankTicket{*ticket}, nil
+		}
+		orderId = ticket.OrderId
+	}
+
+	bankTicketData := <-c.orderRepositoryQuery.FindBankTicketsByOrderId(ctx, orderId)
+	if bankTicketData.Error != nil {
+		msg := "Error DB connection FindBankTicketsByOrderId"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+		return nil, bankTicketData.Error
+	}
+
+	if bankTicketData.Data == nil {
+		msg := "order not found"
+		c.logger.Error(ctx, msg, orderId)
+		return nil, errors.NotFound("order not found")
+	}
+
+	tickets, ok := bankTicketData.Data.(*[]entity.BankTicket)
+	if !ok {
+		msg := "cannot parsing data bank ticket"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+		return nil, errors.InternalServerError("cannot parsing data bank ticket")
+	}
+
+	if len(*tickets) == 0 {
+		msg := "order not found"
+		c.logger.Error(ctx, msg, orderId)
+		return nil, errors.NotFound("order not found")
+	}
+	return *tickets, nil
+}
+
+func ticketNumbersOf(tickets []entity.BankTicket) []string {
+	ticketNumbers := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		ticketNumbers = append(ticketNumbers, ticket.TicketNumber)
+	}
+	return ticketNumbers
+}
+
+// orderAmount is what the payment of the order has to cover.
+func orderAmount(tickets []entity.BankTicket) int {
+	amount := 0
+	for _, ticket := range tickets {
+		amount += ticket.Price
+	}
+	return amount
+}
+
+func (c commandUsecase) ExpireBankTickets(origCtx context.Context) (int, error) {
+	domain := "orderUsecase-ExpireBankTickets"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	expiredBefore := Now().Add(-orderHoldDuration())
+	bankTicketData := <-c.orderRepositoryQuery.FindExpiredBankTickets(ctx, expiredBefore, expiryBatch())
+	if bankTicketData.Error != nil {
+		msg := "Error DB connection FindExpiredBankTickets"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+		return 0, bankTicketData.Error
+	}
+
+	if bankTicketData.Data == nil {
+		return 0, nil
+	}
+
+	bankTickets, ok := bankTicketData.Data.(*[]entity.BankTicket)
+	if !ok {
+		msg := "cannot parsing data bank ticket"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+		return 0, errors.InternalServerError("cannot parsing data bank ticket")
+	}
+
+	totalExpired := 0
+	expiredOrders := make(map[string]bool)
+	for _, value := range *bankTickets {
+		tickets := []entity.BankTicket{value}
+		if value.OrderId != "" {
+			// the tickets of an order expire together, also those that did not fit into this batch
+			if expiredOrders[value.OrderId] {
+				continue
+			}
+			expiredOrders[value.OrderId] = true
+
+			orderTickets, err := c.findOrderTickets(ctx, value.OrderId, "")
+			if err != nil {
+				continue
+			}
+
+			tickets = tickets[:0]
+			for _, ticket := range orderTickets {
+				if awaitingPayment(ticket.PaymentStatus) {
+					tickets = append(tickets, ticket)
+				}
+			}
+		}
+
+		changes := make([]entity.StatusChange, 0, len(tickets))
+		for _, ticket := range tickets {
+			change, err := transition(ticket.PaymentStatus, entity.StatusExpired, actorExpiryWorker)
+			if err != nil {
+				msg := "cannot expire bank ticket"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+				break
+			}
+			changes = append(changes, change)
+		}
+
+		if len(tickets) == 0 || len(changes) != len(tickets) {
+			continue
+		}
+
+		var released []entity.BankTicket
+		transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+			var err error
+			released, err = c.releaseTickets(sessCtx, tickets, changes, orderExpiredEvent)
+			return err
+		})
+		if transaction.Error != nil {
+			msg := "Error DB connection expire bank ticket"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", transaction.Error))
+			continue
+		}
+
+		// paid or released since it was read, nothing to give back
+		if len(released) == 0 {
+			continue
+		}
+		totalExpired += len(released)
+
+		// the expired hold frees a seat for the next user in the queue
+		if err := c.admission.Release(ctx, released[0].EventId, released[0].QueueId); err != nil {
+			msg := "cannot release admission"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		}
+	}
+
+	return totalExpired, nil
+}
+
+func (c commandUsecase) CancelOrderTicket(origCtx context.Context, payload request.CancelOrderReq) (*response.CancelOrderResp, error) {
+	domain := "orderUsecase-CancelOrderTicket"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	tickets, err := c.findOrderTickets(ctx, payload.OrderId, payload.TicketNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]entity.StatusChange, 0, len(tickets))
+	for _, ticket := range tickets {
+		if !ticket.IsUsed || ticket.UserId != payload.UserId {
+			msg := "order does not belong to user"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.ForbiddenError("order does not belong to user")
+		}
+
+		change, err := transition(ticket.PaymentStatus, entity.StatusCancelled, payload.UserId)
+		if err != nil {
+			msg := "order cannot be cancelled"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			return nil, errors.BadRequest("order cannot be cancelled")
+		}
+		changes = append(changes, change)
+	}
+
+	transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		released, err := c.releaseTickets(sessCtx, tickets, changes, orderCancelledEvent)
+		if err != nil {
+			msg := "Error DB connection release bank ticket"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			return err
+		}
+
+		// paid or expired since it was read
+		if len(released) != len(tickets) {
+			msg := "order cannot be cancelled"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return errors.Conflict("order cannot be cancelled")
+		}
+		return nil
+	})
+	if transaction.Error != nil {
+		return nil, transaction.Error
+	}
+
+	// the cancelled hold frees a seat for the next user in the queue
+	if err := c.admission.Release(ctx, tickets[0].EventId, tickets[0].QueueId); err != nil {
+		msg := "cannot release admission"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+	}
+
+	return &response.CancelOrderResp{
+		OrderId:       tickets[0].OrderId,
+		TicketNumbers: ticketNumbersOf(tickets),
+		EventId:       tickets[0].EventId,
+		PaymentStatus: string(entity.StatusCancelled),
+		CancelledAt:   changes[0].At,
 	}, nil
 }
+
+// ProcessPaymentResult settles the tickets of an order and materializes an order document per ticket. The gateway
+// may deliver the same result more than once, a payment that already settled the order is answered with that order.
+func (c commandUsecase) ProcessPaymentResult(origCtx context.Context, payload request.PaymentResultReq) (*response.PaymentResultResp, error) {
+	domain := "orderUsecase-ProcessPaymentResult"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	tickets, err := c.findOrderTickets(ctx, payload.OrderId, payload.TicketNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tickets of an order are paid together, one of them having an order means the whole order was paid
+	orderData := <-c.orderRepositoryQuery.FindOrderByTicketNumber(ctx, tickets[0].TicketNumber)
+	if orderData.Error != nil {
+		msg := "Error DB connection FindOrderByTicketNumber"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", orderData.Error))
+		return nil, orderData.Error
+	}
+
+	if orderData.Data != nil {
+		existing, ok := orderData.Data.(*entity.Order)
+		if !ok {
+			msg := "cannot parsing data order"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", orderData.Data))
+			return nil, errors.InternalServerError("cannot parsing data order")
+		}
+
+		if existing.PaymentId != payload.PaymentId {
+			msg := "order already paid by another payment"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.Conflict("order already paid by another payment")
+		}
+		return paymentResultResp(*existing, tickets), nil
+	}
+
+	for _, ticket := range tickets {
+		if !ticket.IsUsed || !awaitingPayment(ticket.PaymentStatus) {
+			msg := "order is not waiting for payment"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticket))
+			return nil, errors.Conflict("order is not waiting for payment")
+		}
+	}
+
+	if payload.Amount != orderAmount(tickets) {
+		msg := "payment amount does not match order price"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.BadRequest("payment amount does not match order price")
+	}
+
+	switch payload.Status {
+	case constants.PaymentResultPaid:
+	case constants.PaymentResultPending:
+		return c.awaitPayment(ctx, tickets, payload)
+	default:
+		// a failed payment keeps the hold, the user may retry until the expiry worker releases it
+		return pendingPaymentResultResp(tickets, payload), nil
+	}
+
+	changes := make([]entity.StatusChange, 0, len(tickets))
+	for _, ticket := range tickets {
+		change, err := transition(ticket.PaymentStatus, entity.StatusPaid, actorPaymentGateway)
+		if err != nil {
+			msg := "order is not waiting for payment"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			return nil, errors.Conflict("order is not waiting for payment")
+		}
+		changes = append(changes, change)
+	}
+
+	eventData := <-c.eventRepositoryQuery.FindEventById(ctx, tickets[0].EventId)
+	if eventData.Error != nil {
+		msg := "Error DB connection FindEventById"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Error))
+		return nil, eventData.Error
+	}
+
+	if eventData.Data == nil {
+		msg := "event not found"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", tickets[0]))
+		return nil, errors.NotFound("event not found")
+	}
+
+	event, ok := eventData.Data.(*eventEntity.Event)
+	if !ok {
+		msg := "cannot parsing data event"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Data))
+		return nil, errors.InternalServerError("cannot parsing data event")
+	}
+
+	userData := <-c.userRepositoryQuery.FindOneUserId(ctx, tickets[0].UserId)
+	if userData.Error != nil {
+		msg := "Error DB connection FindOneUserId"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", userData.Error))
+		return nil, userData.Error
+	}
+
+	if userData.Data == nil {
+		msg := "user not found"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", tickets[0]))
+		return nil, errors.NotFound("user not found")
+	}
+
+	user, ok := userData.Data.(*userEntity.User)
+	if !ok {
+		msg := "cannot parsing data user"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", userData.Data))
+		return nil, errors.InternalServerError("cannot parsing data user")
+	}
+
+	orderId := tickets[0].OrderId
+	if orderId == "" {
+		// claimed before orders had an id
+		orderId = uuid.NewString()
+	}
+
+	orders := make([]entity.Order, 0, len(tickets))
+	for i, ticket := range tickets {
+		orders = append(orders, entity.Order{
+			OrderId:      orderId,
+			PaymentId:    payload.PaymentId,
+			MobileNumber: user.MobileNumber,
+			VaNumber:     payload.VaNumber,
+			Bank:         payload.Bank,
+			Email:        user.Email,
+			FullName:     user.FullName,
+			TicketNumber: ticket.TicketNumber,
+			TicketType:   ticket.TicketType,
+			SeatNumber:   ticket.SeatNumber,
+			EventName:    event.Name,
+			Country: entity.Country{
+				Name:  event.Country.Name,
+				Code:  event.Country.Code,
+				City:  event.Country.City,
+				Place: event.Country.Place,
+			},
+			DateTime:      event.DateTime,
+			Description:   event.Description,
+			Tag:           event.Tag,
+			Amount:        ticket.Price,
+			PaymentStatus: changes[i].To,
+			StatusHistory: append(ticket.StatusHistory, changes[i]),
+			OrderTime:     heldAt(ticket),
+			UserId:        ticket.UserId,
+			QueueId:       ticket.QueueId,
+			TicketId:      ticket.TicketId,
+			EventId:       ticket.EventId,
+		})
+	}
+
+	// the tickets leave pending and their orders appear as one unit, a duplicate delivery
+	// that raced past the lookup above fails on the pending filter or the unique index
+	transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		events := make([]outboxRequest.OutboxEventReq, 0, len(orders))
+		for i, order := range orders {
+			payResp := <-c.orderRepositoryCommand.TransitionBankTicket(sessCtx, request.TransitionBankTicketReq{
+				TicketNumber: order.TicketNumber,
+				StatusChange: changes[i],
+			})
+			if payResp.Error != nil {
+				msg := "Error DB connection TransitionBankTicket"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payResp.Error))
+				return payResp.Error
+			}
+
+			// expired or cancelled since it was read
+			if payResp.Data == nil {
+				msg := "order is not waiting for payment"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+				return errors.Conflict("order is not waiting for payment")
+			}
+
+			orderResp := <-c.orderRepositoryCommand.InsertOrder(sessCtx, order)
+			if orderResp.Error != nil {
+				msg := "Error DB connection InsertOrder"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", orderResp.Error))
+				return orderResp.Error
+			}
+
+			events = append(events, orderEvent(constants.EventOrderPaid, constants.TopicOrderPaid, order.OrderId, eventschema.OrderPaid{
+				OrderId:      order.OrderId,
+				PaymentId:    order.PaymentId,
+				TicketNumber: order.TicketNumber,
+				TicketId:     order.TicketId,
+				EventId:      order.EventId,
+				UserId:       order.UserId,
+				QueueId:      order.QueueId,
+				TicketType:   order.TicketType,
+				SeatNumber:   order.SeatNumber,
+				Amount:       order.Amount,
+				OrderTime:    order.OrderTime,
+				PaidAt:       changes[i].At,
+			}))
+		}
+
+		outboxResp := <-c.outboxRepositoryCommand.InsertOutboxEvents(sessCtx, events)
+		if outboxResp.Error != nil {
+			msg := "Error DB connection InsertOutboxEvents"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", outboxResp.Error))
+			return outboxResp.Error
+		}
+		return nil
+	})
+	if transaction.Error != nil {
+		return nil, transaction.Error
+	}
+
+	// a settled order frees a seat for the next user in the queue
+	if err := c.admission.Release(ctx, tickets[0].EventId, tickets[0].QueueId); err != nil {
+		msg := "cannot release admission"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+	}
+
+	return paymentResultResp(orders[0], tickets), nil
+}
+
+// paymentResultResp answers with the order the tickets were settled into, the amount covers all of them.
+func paymentResultResp(order entity.Order, tickets []entity.BankTicket) *response.PaymentResultResp {
+	return &response.PaymentResultResp{
+		OrderId:       order.OrderId,
+		PaymentId:     order.PaymentId,
+		TicketNumbers: ticketNumbersOf(tickets),
+		EventId:       order.EventId,
+		Amount:        orderAmount(tickets),
+		PaymentStatus: string(order.PaymentStatus),
+		OrderTime:     order.OrderTime,
+	}
+}
+
+// awaitPayment records that the gateway issued the payment, a repeated notice leaves the tickets as they are.
+func (c commandUsecase) awaitPayment(ctx context.Context, tickets []entity.BankTicket, payload request.PaymentResultReq) (*response.PaymentResultResp, error) {
+	changes := make(map[string]entity.StatusChange, len(tickets))
+	for _, ticket := range tickets {
+		if ticket.PaymentStatus == entity.StatusPendingPayment {
+			continue
+		}
+
+		change, err := transition(ticket.PaymentStatus, entity.StatusPendingPayment, actorPaymentGateway)
+		if err != nil {
+			msg := "order is not waiting for payment"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			return nil, errors.Conflict("order is not waiting for payment")
+		}
+		changes[ticket.TicketNumber] = change
+	}
+
+	if len(changes) == 0 {
+		return pendingPaymentResultResp(tickets, payload), nil
+	}
+
+	updated := make([]entity.BankTicket, len(tickets))
+	transaction := <-c.orderRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		copy(updated, tickets)
+		for i, ticket := range tickets {
+			change, ok := changes[ticket.TicketNumber]
+			if !ok {
+				continue
+			}
+
+			bankTicketData := <-c.orderRepositoryCommand.TransitionBankTicket(sessCtx, request.TransitionBankTicketReq{
+				TicketNumber: ticket.TicketNumber,
+				StatusChange: change,
+			})
+			if bankTicketData.Error != nil {
+				msg := "Error DB connection TransitionBankTicket"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+				return bankTicketData.Error
+			}
+
+			// expired or cancelled since it was read
+			if bankTicketData.Data == nil {
+				msg := "order is not waiting for payment"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+				return errors.Conflict("order is not waiting for payment")
+			}
+
+			bankTicket, ok := bankTicketData.Data.(*entity.BankTicket)
+			if !ok {
+				msg := "cannot parsing data bank ticket"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+				return errors.InternalServerError("cannot parsing data bank ticket")
+			}
+			updated[i] = *bankTicket
+		}
+		return nil
+	})
+	if transaction.Error != nil {
+		return nil, transaction.Error
+	}
+
+	return pendingPaymentResultResp(updated, payload), nil
+}
+
+func pendingPaymentResultResp(tickets []entity.BankTicket, payload request.PaymentResultReq) *response.PaymentResultResp {
+	return &response.PaymentResultResp{
+		OrderId:       tickets[0].OrderId,
+		PaymentId:     payload.PaymentId,
+		TicketNumbers: ticketNumbersOf(tickets),
+		EventId:       tickets[0].EventId,
+		Amount:        payload.Amount,
+		PaymentStatus: string(tickets[0].PaymentStatus),
+		OrderTime:     heldAt(tickets[0]),
+	}
+}
@@ -2,21 +2,32 @@ package usecases_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"order-service/configs"
 	"order-service/internal/modules/order"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
 	"testing"
+	"time"
 
 	eventEntity "order-service/internal/modules/event/models/entity"
 	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
+	"order-service/internal/modules/order/models/response"
 	uc "order-service/internal/modules/order/usecases"
-	roomEntity "order-service/internal/modules/room/models/entity"
+	outboxRequest "order-service/internal/modules/outbox/models/request"
+	pricingEntity "order-service/internal/modules/pricing/models/entity"
+	pricingRequest "order-service/internal/modules/pricing/models/request"
 	ticketEntity "order-service/internal/modules/ticket/models/entity"
 	userEntity "order-service/internal/modules/user/models/entity"
+	"order-service/internal/pkg/eventschema"
 	mockcertEvent "order-service/mocks/modules/event"
 	mockcert "order-service/mocks/modules/order"
+	mockcertOutbox "order-service/mocks/modules/outbox"
+	mockcertPricing "order-service/mocks/modules/pricing"
+	mockcertPromo "order-service/mocks/modules/promo"
 	mockcertRoom "order-service/mocks/modules/room"
 	mockcertTicket "order-service/mocks/modules/ticket"
 	mockcertUser "order-service/mocks/modules/user"
@@ -32,13 +43,19 @@ type CommandUsecaseTestSuite struct {
 	suite.Suite
 	mockOrderRepositoryQuery    *mockcert.MongodbRepositoryQuery
 	mockOrderRepositoryCommand  *mockcert.MongodbRepositoryCommand
-	mockRoomRepositoryQuery     *mockcertRoom.MongodbRepositoryQuery
 	mockTicketRepositoryQuery   *mockcertTicket.MongodbRepositoryQuery
 	mockTicketRepositoryCommand *mockcertTicket.MongodbRepositoryCommand
 	mockEventRepositoryQuery    *mockcertEvent.MongodbRepositoryQuery
 	mockUserRepositoryQuery     *mockcertUser.MongodbRepositoryQuery
 	mockLogger                  *mocklog.Logger
 	mockRedis                   *mockredis.Collections
+	mockAdmission               *mockcertRoom.AdmissionController
+	mockPricingEngine           *mockcertPricing.Engine
+	mockPromoValidator          *mockcertPromo.CodeValidator
+	mockPromoRepositoryCommand  *mockcertPromo.MongodbRepositoryCommand
+	mockSaleSchedule            *mockcertEvent.SaleSchedule
+	mockReleasePolicy           *mockcertTicket.ReleasePolicy
+	mockOutboxRepository        *mockcertOutbox.MongodbRepositoryCommand
 	usecase                     order.UsecaseCommand
 	ctx                         context.Context
 }
@@ -46,24 +63,48 @@ type CommandUsecaseTestSuite struct {
 func (suite *CommandUsecaseTestSuite) SetupTest() {
 	suite.mockOrderRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
 	suite.mockOrderRepositoryCommand = &mockcert.MongodbRepositoryCommand{}
-	suite.mockRoomRepositoryQuery = &mockcertRoom.MongodbRepositoryQuery{}
 	suite.mockTicketRepositoryQuery = &mockcertTicket.MongodbRepositoryQuery{}
 	suite.mockTicketRepositoryCommand = &mockcertTicket.MongodbRepositoryCommand{}
 	suite.mockUserRepositoryQuery = &mockcertUser.MongodbRepositoryQuery{}
 	suite.mockEventRepositoryQuery = &mockcertEvent.MongodbRepositoryQuery{}
 	suite.mockLogger = &mocklog.Logger{}
 	suite.mockRedis = &mockredis.Collections{}
+	suite.mockAdmission = &mockcertRoom.AdmissionController{}
+	// everyone is admitted unless a test says otherwise
+	suite.mockAdmission.On("ServingNumber", mock.Anything, mock.Anything).Return(0, nil)
+	suite.mockAdmission.On("Release", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// released tickets always find the purchase counters of their holder
+	suite.mockOrderRepositoryCommand.On("ReleasePurchaseQuota", mock.Anything, mock.Anything).Return(mockPurchaseQuotaRelease)
+	// tickets sell at their base price unless a test sets pricing rules
+	suite.mockPricingEngine = &mockcertPricing.Engine{}
+	suite.mockPromoValidator = &mockcertPromo.CodeValidator{}
+	suite.mockPromoRepositoryCommand = &mockcertPromo.MongodbRepositoryCommand{}
+	suite.mockPricingEngine.On("Quote", mock.Anything, mock.Anything).Return(mockBasePriceQuote, nil)
+	// the sale of every event is open unless a test says otherwise
+	suite.mockSaleSchedule = &mockcertEvent.SaleSchedule{}
+	suite.mockSaleSchedule.On("CheckSaleOpen", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// every ticket category is released unless a test says otherwise
+	suite.mockReleasePolicy = &mockcertTicket.ReleasePolicy{}
+	suite.mockReleasePolicy.On("CheckReleased", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOutboxRepository = &mockcertOutbox.MongodbRepositoryCommand{}
+	suite.mockOutboxRepository.On("InsertOutboxEvents", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
 	suite.ctx = context.Background()
 	suite.usecase = uc.NewCommandUsecase(
 		suite.mockOrderRepositoryCommand,
 		suite.mockOrderRepositoryQuery,
-		suite.mockRoomRepositoryQuery,
 		suite.mockTicketRepositoryQuery,
 		suite.mockTicketRepositoryCommand,
 		suite.mockEventRepositoryQuery,
 		suite.mockUserRepositoryQuery,
 		suite.mockLogger,
 		suite.mockRedis,
+		suite.mockAdmission,
+		suite.mockPricingEngine,
+		suite.mockPromoValidator,
+		suite.mockPromoRepositoryCommand,
+		suite.mockSaleSchedule,
+		suite.mockReleasePolicy,
+		suite.mockOutboxRepository,
 	)
 }
 
@@ -89,14 +130,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicket() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
@@ -115,28 +150,37 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicket() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
-	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	result, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), result.OrderId)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.OrderId == result.OrderId && req.StatusChange.From == entity.StatusNone && req.StatusChange.To == entity.StatusHeld && req.StatusChange.By == "id"
+	}))
 }
 
 func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrEvent() {
@@ -202,39 +246,38 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrEventParse() {
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrQueue() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketNotAdmitted() {
 	payload := request.OrderReq{
-		UserId:     "id",
-		TicketType: "type",
-		EventId:    "id",
+		UserId:      "id",
+		TicketType:  "type",
+		EventId:     "id",
+		QueueId:     "id",
+		QueueNumber: 150,
 	}
 
 	mockEventById := helpers.Result{
 		Data: &eventEntity.Event{
 			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: errors.BadRequest("error"),
-	}
+	suite.mockAdmission.ExpectedCalls = nil
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockAdmission.On("EstimateWait", 150, 100).Return(30)
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+
+	errString, ok := err.(*errors.ErrorString)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), constants.ErrCodeNotAdmitted, errString.Code())
+	assert.Equal(suite.T(), 30, errString.RetryAfter())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReservePurchaseQuota", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrQueueNil() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrAdmission() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -244,59 +287,57 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrQueueNil() {
 	mockEventById := helpers.Result{
 		Data: &eventEntity.Event{
 			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockAdmission.ExpectedCalls = nil
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(0, errors.InternalServerError("error"))
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrQueueParse() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnline() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: "type",
+		TicketType: constants.Online,
 		EventId:    "id",
 	}
 
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
-		},
-		Error: nil,
+	event := eventEntity.Event{
+		EventId:      "id",
+		Country:      eventEntity.Country{Code: "code"},
+		ReleaseRules: []eventEntity.ReleaseRule{{TicketType: constants.Online, OfflineStockBelow: 1}},
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &entity.Country{
-			Name: "name",
-		},
-		Error: nil,
+	suite.mockMultiTicketOrder(event)
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	suite.mockReleasePolicy.AssertCalled(suite.T(), "CheckReleased", mock.Anything, event, []string{constants.Online})
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketNotReleased() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		TicketType: constants.Online,
+		EventId:    "id",
 	}
-	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
+
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockReleasePolicy.ExpectedCalls = nil
+	suite.mockReleasePolicy.On("CheckReleased", mock.Anything, mock.Anything, mock.Anything).Return(errors.BadRequest("Online tickets are not released yet"))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online tickets are not released yet", err.Error())
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrBank() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetail() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -314,26 +355,53 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrBank() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
+	mockTicketByEvent := helpers.Result{
 		Data:  nil,
-		Error: errors.InternalServerError("error"),
+		Error: errors.BadRequest("error"),
+	}
+	mockUserById := helpers.Result{
+		Data: &userEntity.User{
+			Country: userEntity.Country{
+				Code: "ID",
+			},
+		},
+		Error: nil,
+	}
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
+		},
+		Error: nil,
+	}
+	mockUpdateTicketDetail := helpers.Result{
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
+		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
-	res, _ := suite.usecase.CreateOrderTicket(suite.ctx, payload)
-	assert.Nil(suite.T(), res)
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrBankExist() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailNil() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -351,31 +419,56 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrBankExist() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
+		Error: nil,
+	}
+	mockTicketByEvent := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+	mockUserById := helpers.Result{
+		Data: &userEntity.User{
+			Country: userEntity.Country{
+				Code: "ID",
+			},
+		},
+		Error: nil,
+	}
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketNumber: "111",
+	mockUpdateTicketDetail := helpers.Result{
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
 		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnline() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailParse() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: constants.Online,
+		TicketType: "type",
 		EventId:    "id",
 	}
 
@@ -390,21 +483,13 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnline() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
+		Data: &entity.Country{
+			Name: "name",
 		},
 		Error: nil,
 	}
@@ -416,44 +501,40 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnline() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTotalOfflineTicket := helpers.Result{
-		Data: &[]ticketEntity.AggregateTotalTicket{
-			{
-				Id:                   "id",
-				TotalAvailableTicket: 0,
-			},
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
 		},
+		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
-	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicketByCountry", mock.Anything, mock.Anything).Return(mockChannel(mockTotalOfflineTicket))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
-	assert.NoError(suite.T(), err)
+	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErr() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSold() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: constants.Online,
+		TicketType: "type",
 		EventId:    "id",
 	}
 
@@ -468,21 +549,15 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErr() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
 		Data: &ticketEntity.Ticket{
 			TicketId:       "id",
 			TicketPrice:    50,
-			TotalRemaining: 10,
+			TotalRemaining: 0,
 		},
 		Error: nil,
 	}
@@ -494,40 +569,40 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErr() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
-	mockTotalOfflineTicket := helpers.Result{
-		Data:  nil,
-		Error: errors.BadRequest("error"),
-	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
-	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicketByCountry", mock.Anything, mock.Anything).Return(mockChannel(mockTotalOfflineTicket))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrNil() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUser() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: constants.Online,
+		TicketType: "type",
 		EventId:    "id",
 	}
 
@@ -542,14 +617,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrNil() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
@@ -566,42 +635,42 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrNil() {
 				Code: "ID",
 			},
 		},
-		Error: nil,
+		Error: errors.BadRequest("error"),
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTotalOfflineTicket := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
-	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicketByCountry", mock.Anything, mock.Anything).Return(mockChannel(mockTotalOfflineTicket))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrParse() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUserNil() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: constants.Online,
+		TicketType: "type",
 		EventId:    "id",
 	}
 
@@ -616,14 +685,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrParse() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
@@ -635,49 +698,43 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineErrParse() {
 		Error: nil,
 	}
 	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
-			},
-		},
+		Data:  nil,
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTotalOfflineTicket := helpers.Result{
-		Data: &entity.Country{
-			Name: "name",
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
 		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
-	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicketByCountry", mock.Anything, mock.Anything).Return(mockChannel(mockTotalOfflineTicket))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineFalse() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUserParse() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: constants.Online,
+		TicketType: "type",
 		EventId:    "id",
 	}
 
@@ -692,14 +749,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineFalse() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
@@ -711,48 +762,42 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOnlineFalse() {
 		Error: nil,
 	}
 	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
-			},
+		Data: &entity.Country{
+			Name: "name",
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTotalOfflineTicket := helpers.Result{
-		Data: &[]ticketEntity.AggregateTotalTicket{
-			{
-				Id:                   "id",
-				TotalAvailableTicket: 1,
-			},
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
 		},
+		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
-	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicketByCountry", mock.Anything, mock.Anything).Return(mockChannel(mockTotalOfflineTicket))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetail() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrClaimBank() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -770,19 +815,17 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetail() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
-		Data:  nil,
-		Error: errors.BadRequest("error"),
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TicketPrice:    50,
+			TotalRemaining: 10,
+		},
+		Error: nil,
 	}
 	mockUserById := helpers.Result{
 		Data: &userEntity.User{
@@ -792,32 +835,37 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetail() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
-		Error: nil,
+		Error: errors.BadRequest("error"),
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailNil() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrClaimBankNil() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -835,20 +883,18 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailNil() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TicketPrice:    50,
+			TotalRemaining: 10,
+		},
+		Error: nil,
+	}
 	mockUserById := helpers.Result{
 		Data: &userEntity.User{
 			Country: userEntity.Country{
@@ -857,32 +903,31 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailNil() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
-		},
+	mockClaimBankTickets := helpers.Result{
+		Data:  nil,
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailParse() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrClaimBankParse() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -900,19 +945,15 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailParse() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
-		Data: &entity.Country{
-			Name: "name",
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TicketPrice:    50,
+			TotalRemaining: 10,
 		},
 		Error: nil,
 	}
@@ -924,32 +965,33 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrDetailParse() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &entity.Country{
+			Name: "name",
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+		Data: &ticketEntity.Ticket{
+			TicketId:       "id",
+			TotalRemaining: 9,
+		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSold() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUpdateDetail() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -967,21 +1009,15 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSold() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
 		Data: &ticketEntity.Ticket{
 			TicketId:       "id",
 			TicketPrice:    50,
-			TotalRemaining: 0,
+			TotalRemaining: 10,
 		},
 		Error: nil,
 	}
@@ -993,32 +1029,37 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSold() {
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
 	mockUpdateTicketDetail := helpers.Result{
 		Data:  nil,
-		Error: nil,
+		Error: errors.BadRequest("error"),
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ReleaseBankTicket", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUser() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSoldOutOnDecrement() {
 	payload := request.OrderReq{
 		UserId:     "id",
 		TicketType: "type",
@@ -1036,21 +1077,15 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUser() {
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
+	mockPurchaseQuota := helpers.Result{
+		Data:  &entity.PurchaseQuota{},
 		Error: nil,
 	}
 	mockTicketByEvent := helpers.Result{
 		Data: &ticketEntity.Ticket{
 			TicketId:       "id",
 			TicketPrice:    50,
-			TotalRemaining: 10,
+			TotalRemaining: 1,
 		},
 		Error: nil,
 	}
@@ -1060,431 +1095,1418 @@ func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUser() {
 				Code: "ID",
 			},
 		},
-		Error: errors.BadRequest("error"),
+		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
+	mockClaimBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketId:     "id",
+				EventId:      "id",
+				TicketNumber: "111",
+			},
 		},
 		Error: nil,
 	}
-	mockUpdateTicketDetail := helpers.Result{
+	mockDecrementTicketDetail := helpers.Result{
 		Data:  nil,
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(mockPurchaseQuota))
 	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
 	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockChannel(mockClaimBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, "id", "id", 1).Return(mockChannel(mockDecrementTicketDetail))
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "ticket category sold out", err.Error())
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ReleaseBankTicket", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUserNil() {
+func (suite *CommandUsecaseTestSuite) mockMultiTicketOrder(event eventEntity.Event) {
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &event}))
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, eventId string, ticketType string) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &ticketEntity.Ticket{
+			TicketId:       ticketType + "-id",
+			TicketPrice:    50,
+			TotalRemaining: 10,
+		}})
+	})
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &userEntity.User{Country: userEntity.Country{Code: "code"}},
+	}))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(func(ctx context.Context, ticketId string, eventId string, quantity int) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &ticketEntity.Ticket{TicketId: ticketId}})
+	})
+}
+
+func mockClaimedBankTickets(ctx context.Context, payload request.ClaimBankTicketsReq) <-chan helpers.Result {
+	tickets := make([]entity.BankTicket, 0, payload.Quantity)
+	for i := 0; i < payload.Quantity; i++ {
+		tickets = append(tickets, entity.BankTicket{
+			TicketNumber:   fmt.Sprintf("%s-%d", payload.TicketType, i),
+			TicketType:     payload.TicketType,
+			TicketId:       payload.TicketId,
+			EventId:        payload.EventId,
+			UserId:         payload.UserId,
+			Price:          payload.Price,
+			PriceBreakdown: payload.PriceBreakdown,
+			PromoCode:      payload.PromoCode,
+			PaymentStatus:  payload.StatusChange.To,
+		})
+	}
+	return mockChannel(helpers.Result{Data: &tickets})
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketItems() {
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "VIP", Quantity: 2},
+			{TicketType: "Gold"},
+		},
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Country: eventEntity.Country{Code: "code"},
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4, MaxTicketsPerType: map[string]int{"VIP": 2}},
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), res.Tickets, 3)
+	assert.Equal(suite.T(), 150, res.TotalPrice)
+	// the single-ticket fields describe the first ticket
+	assert.Equal(suite.T(), res.Tickets[0].TicketNumber, res.TicketNumber)
+	assert.Equal(suite.T(), res.Tickets[0].TicketType, res.TicketType)
+	assert.Equal(suite.T(), res.Tickets[0].Price, res.Price)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ReservePurchaseQuota", mock.Anything, request.PurchaseQuotaReq{
+		EventId:    "id",
+		UserId:     "id",
+		Tickets:    map[string]int{"VIP": 2, "Gold": 1},
+		Limit:      4,
+		TypeLimits: map[string]int{"VIP": 2},
+	})
+	suite.mockTicketRepositoryCommand.AssertCalled(suite.T(), "DecrementTicketDetail", mock.Anything, "VIP-id", "id", 2)
+	suite.mockTicketRepositoryCommand.AssertCalled(suite.T(), "DecrementTicketDetail", mock.Anything, "Gold-id", "id", 1)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOrderHeld() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: "type",
 		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
 	}
 
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
-		},
-		Error: nil,
-	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
-		},
-		Error: nil,
-	}
-	mockUserById := helpers.Result{
-		Data:  nil,
-		Error: nil,
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Country: eventEntity.Country{Code: "code"},
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 2},
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+
+	result, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		if len(events) != 2 {
+			return false
+		}
+		for i, event := range events {
+			held, ok := event.Payload.(eventschema.OrderHeld)
+			if !ok || event.Type != constants.EventOrderHeld || event.Topic != constants.TopicOrderHeld || held.OrderId != result.OrderId ||
+				event.Key != result.OrderId || held.TicketNumber != fmt.Sprintf("VIP-%d", i) || held.Price != 50 ||
+				!held.ExpiredAt.After(held.OrderTime) {
+				return false
+			}
+		}
+		return true
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrOutbox() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
-		},
-		Error: nil,
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, payload request.ReleaseBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{})
+	})
+	suite.mockOutboxRepository.ExpectedCalls = nil
+	suite.mockOutboxRepository.On("InsertOutboxEvents", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), res)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketCommitUnknown() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
 	}
-	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	// the commit reports an error although the claim was written
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(sessCtx context.Context) error) <-chan helpers.Result {
+		fn(ctx)
+		return mockChannel(helpers.Result{Error: errors.InternalServerError("error")})
+	}).Once()
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{}}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &ticketEntity.Ticket{}}))
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), res)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "WithTransaction", 2)
+	suite.mockTicketRepositoryCommand.AssertCalled(suite.T(), "IncrementTicketDetail", mock.Anything, "VIP-id", "id", 1)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ReleasePurchaseQuota", mock.Anything, mock.Anything)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		return len(events) == 1 && events[0].Type == constants.EventOrderCancelled && events[0].Key == "VIP-0"
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketCommitRolledBack() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
 	}
+
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(sessCtx context.Context) error) <-chan helpers.Result {
+		fn(ctx)
+		return mockChannel(helpers.Result{Error: errors.InternalServerError("error")})
+	}).Once()
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	suite.mockTicketRepositoryCommand.AssertNotCalled(suite.T(), "IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReleasePurchaseQuota", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUserParse() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketQuantityConfigLimit() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: "type",
 		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
 	}
 
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
-		},
-		Error: nil,
-	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
-		Error: nil,
-	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
-		Error: nil,
-	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
-		},
-		Error: nil,
+	defaultConfigs := uc.Configs
+	defer func() { uc.Configs = defaultConfigs }()
+	uc.Configs = func() *configs.Config {
+		return &configs.Config{Order: configs.OrderConfig{MaxTickets: "2"}}
 	}
-	mockUserById := helpers.Result{
-		Data: &entity.Country{
-			Name: "name",
-		},
-		Error: nil,
+
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), res.Tickets, 2)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.TicketType == "VIP" && req.Quantity == 2
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOverLimit() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
-		},
-		Error: nil,
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id"})
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "purchase limit exceeded, at most 1 tickets per user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReservePurchaseQuota", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketOverTypeLimit() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
 	}
-	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: nil,
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4, MaxTicketsPerType: map[string]int{"VIP": 1}},
+	})
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "purchase limit exceeded, at most 1 VIP tickets per user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReservePurchaseQuota", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketQuotaReached() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
 	}
+
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4},
+	})
+	// earlier orders of the user already hold the rest of the limit
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: nil}))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "purchase limit exceeded, at most 4 tickets per user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUpdateBank() {
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrQuota() {
 	payload := request.OrderReq{
 		UserId:     "id",
-		TicketType: "type",
 		EventId:    "id",
+		TicketType: "VIP",
 	}
 
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id"})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Nil(suite.T(), res)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketItemsPartialClaim() {
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "VIP", Quantity: 2},
+			{TicketType: "Gold", Quantity: 1},
+		},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4},
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.TicketType == "VIP"
+	})).Return(mockClaimedBankTickets)
+	// gold ran out between the stock check and the claim
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.TicketType == "Gold"
+	})).Return(mockChannel(helpers.Result{Data: nil}))
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, payload request.ReleaseBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{})
+	})
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "failed to process order", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "ReleaseBankTicket", 2)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReleasePurchaseQuota", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTickets() {
+	mockExpiredBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber:  "111",
+				TicketId:      "id",
+				EventId:       "id",
+				UserId:        "id",
+				QueueId:       "queue",
+				PaymentStatus: entity.StatusHeld,
+				UpdatedAt:     time.Now().Add(-time.Hour),
+			},
+			{
+				TicketNumber:  "112",
+				TicketId:      "id",
+				EventId:       "id",
+				UserId:        "id2",
+				PaymentStatus: entity.StatusHeld,
+				UpdatedAt:     time.Now().Add(-time.Hour),
 			},
-			Tag: "tag",
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
+	mockReleased := helpers.Result{
+		Data:  &entity.BankTicket{TicketNumber: "111"},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
+	mockAlreadyPaid := helpers.Result{
 		Data:  nil,
 		Error: nil,
 	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
-		},
+	mockIncrementTicketDetail := helpers.Result{
+		Data:  &ticketEntity.Ticket{TicketId: "id", TotalRemaining: 10},
 		Error: nil,
 	}
-	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "111" && req.StatusChange.From == entity.StatusHeld && req.StatusChange.To == entity.StatusExpired && req.StatusChange.By == "expiry-worker"
+	})).Return(mockChannel(mockReleased))
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "112"
+	})).Return(mockChannel(mockAlreadyPaid))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "id", "id", 1).Return(mockChannel(mockIncrementTicketDetail))
+
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, total)
+	suite.mockTicketRepositoryCommand.AssertNumberOfCalls(suite.T(), "IncrementTicketDetail", 1)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, outboxEvent(constants.EventOrderExpired, "111"))
+	suite.mockOutboxRepository.AssertNumberOfCalls(suite.T(), "InsertOutboxEvents", 1)
+	suite.mockAdmission.AssertCalled(suite.T(), "Release", mock.Anything, "id", "queue")
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "ReleasePurchaseQuota", 1)
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsLegacyPending() {
+	mockExpiredBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber:  "111",
+				TicketId:      "id",
+				EventId:       "id",
+				UserId:        "id",
+				PaymentStatus: entity.StatusPending,
+				UpdatedAt:     time.Now().Add(-time.Hour),
 			},
 		},
-		Error: nil,
-	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
-		},
-		Error: errors.BadRequest("error"),
 	}
-	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.StatusChange.From == entity.StatusPending && req.StatusChange.To == entity.StatusExpired
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "id", "id", 1).Return(mockChannel(helpers.Result{Data: &ticketEntity.Ticket{}}))
+
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, total)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, outboxEvent(constants.EventOrderExpired, "111"))
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsIllegalTransition() {
+	mockExpiredBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber:  "111",
+				TicketId:      "id",
+				EventId:       "id",
+				UserId:        "id",
+				PaymentStatus: entity.StatusPaid,
+			},
+		},
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
 
-	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, total)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsErr() {
+	mockExpiredBankTickets := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
+
+	_, err := suite.usecase.ExpireBankTickets(suite.ctx)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUpdateBankNil() {
-	payload := request.OrderReq{
-		UserId:     "id",
-		TicketType: "type",
-		EventId:    "id",
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsErrParse() {
+	mockExpiredBankTickets := helpers.Result{
+		Data:  &entity.Country{},
+		Error: nil,
 	}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
 
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
+	_, err := suite.usecase.ExpireBankTickets(suite.ctx)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsErrIncrement() {
+	mockExpiredBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber: "111",
+				TicketId:     "id",
+				EventId:      "id",
+				UserId:       "id",
 			},
-			Tag: "tag",
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
+	mockReleased := helpers.Result{
+		Data:  &entity.BankTicket{TicketNumber: "111"},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
+	mockIncrementTicketDetail := helpers.Result{
 		Data:  nil,
-		Error: nil,
+		Error: errors.InternalServerError("error"),
 	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockReleased))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockIncrementTicketDetail))
+
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, total)
+	suite.mockOutboxRepository.AssertNotCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicket() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			TicketId:      "ticket",
+			EventId:       "event",
+			UserId:        "id",
+			QueueId:       "queue",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
 		Error: nil,
 	}
-	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
-			},
+	mockReleased := helpers.Result{
+		Data:  &entity.BankTicket{TicketNumber: "111"},
+		Error: nil,
+	}
+	mockIncrementTicketDetail := helpers.Result{
+		Data:  &ticketEntity.Ticket{TicketId: "ticket", TotalRemaining: 10},
+		Error: nil,
+	}
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "111" && req.EventId == "event" && req.UserId == "id" && req.StatusChange.To == entity.StatusCancelled && req.StatusChange.By == "id"
+	})).Return(mockChannel(mockReleased))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(mockChannel(mockIncrementTicketDetail))
+
+	result, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusCancelled), result.PaymentStatus)
+	suite.mockTicketRepositoryCommand.AssertNumberOfCalls(suite.T(), "IncrementTicketDetail", 1)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, outboxEvent(constants.EventOrderCancelled, "111"))
+	suite.mockAdmission.AssertCalled(suite.T(), "Release", mock.Anything, "event", "queue")
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ReleasePurchaseQuota", mock.Anything, mock.MatchedBy(func(req request.PurchaseQuotaReq) bool {
+		return req.EventId == "event" && req.UserId == "id" && len(req.Tickets) == 1
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketPendingPayment() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			TicketId:      "ticket",
+			EventId:       "event",
+			UserId:        "id",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusPendingPayment,
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.StatusChange.From == entity.StatusPendingPayment && req.StatusChange.To == entity.StatusCancelled
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{TicketId: "ticket", TotalRemaining: 10},
+	}))
+
+	result, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusCancelled), result.PaymentStatus)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketErrFind() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
 		Data:  nil,
-		Error: nil,
+		Error: errors.InternalServerError("error"),
 	}
-	mockUpdateTicketDetail := helpers.Result{
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketNotFound() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
 		Data:  nil,
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
 
-	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order not found", err.Error())
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUpdateBankParse() {
-	payload := request.OrderReq{
-		UserId:     "id",
-		TicketType: "type",
-		EventId:    "id",
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketErrParse() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
 	}
-
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
-		},
+	mockBankTicket := helpers.Result{
+		Data:  &entity.Country{},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketOtherUser() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			UserId:        "other",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
-		Data:  nil,
-		Error: nil,
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order does not belong to user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketNotCancellable() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
 	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			UserId:        "id",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusPaid,
 		},
 		Error: nil,
 	}
-	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
-			},
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order cannot be cancelled", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketErrRelease() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			UserId:        "id",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
 		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.Country{
-			Name: "name",
+	mockReleased := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockReleased))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockTicketRepositoryCommand.AssertNotCalled(suite.T(), "IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOutboxRepository.AssertNotCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketAlreadyReleased() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			UserId:        "id",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
 		Error: nil,
 	}
-	mockUpdateTicketDetail := helpers.Result{
+	mockReleased := helpers.Result{
 		Data:  nil,
 		Error: nil,
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockReleased))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
 
-	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order cannot be cancelled", err.Error())
+	suite.mockTicketRepositoryCommand.AssertNotCalled(suite.T(), "IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrUpdateDetail() {
-	payload := request.OrderReq{
-		UserId:     "id",
-		TicketType: "type",
-		EventId:    "id",
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketErrIncrement() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
 	}
-
-	mockEventById := helpers.Result{
-		Data: &eventEntity.Event{
-			EventId: "id",
-			Name:    "name",
-			Country: eventEntity.Country{
-				Code: "code",
-			},
-			Tag: "tag",
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			UserId:        "id",
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
 		Error: nil,
 	}
-	mockQueueByUser := helpers.Result{
-		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
-		},
+	mockReleased := helpers.Result{
+		Data:  &entity.BankTicket{TicketNumber: "111"},
 		Error: nil,
 	}
-	mockBankTicketByParam := helpers.Result{
+	mockIncrementTicketDetail := helpers.Result{
 		Data:  nil,
-		Error: nil,
+		Error: errors.InternalServerError("error"),
 	}
-	mockTicketByEvent := helpers.Result{
-		Data: &ticketEntity.Ticket{
-			TicketId:       "id",
-			TicketPrice:    50,
-			TotalRemaining: 10,
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockReleased))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockIncrementTicketDetail))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockOutboxRepository.AssertNotCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.Anything)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) mockPaymentResult(bankTicket helpers.Result) {
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(helpers.Result{}))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(bankTicket))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "event").Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "event",
+			Name:    "name",
+			Country: eventEntity.Country{Name: "Indonesia", Code: "ID", City: "Jakarta", Place: "GBK"},
+			Tag:     "tag",
+		},
+	}))
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, "id").Return(mockChannel(helpers.Result{
+		Data: &userEntity.User{UserId: "id", FullName: "name", Email: "mail@mail.com", MobileNumber: "0812"},
+	}))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+}
+
+func pendingBankTicket() helpers.Result {
+	return helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			TicketId:      "ticket",
+			EventId:       "event",
+			UserId:        "id",
+			QueueId:       "queue",
+			TicketType:    "Gold",
+			Price:         500,
+			IsUsed:        true,
+			PaymentStatus: entity.StatusHeld,
 		},
-		Error: nil,
 	}
-	mockUserById := helpers.Result{
-		Data: &userEntity.User{
-			Country: userEntity.Country{
-				Code: "ID",
+}
+
+func paymentResultReq() request.PaymentResultReq {
+	return request.PaymentResultReq{
+		PaymentId:    "payment",
+		TicketNumber: "111",
+		VaNumber:     "8808",
+		Bank:         "bca",
+		Amount:       500,
+		Status:       constants.PaymentResultPaid,
+	}
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResult() {
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.MatchedBy(func(req request.TransitionBankTicketReq) bool {
+		return req.TicketNumber == "111" && req.StatusChange.From == entity.StatusHeld && req.StatusChange.To == entity.StatusPaid
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.MatchedBy(func(order entity.Order) bool {
+		return order.OrderId != "" && order.PaymentId == "payment" && order.VaNumber == "8808" && order.Bank == "bca" &&
+			order.Amount == 500 && order.PaymentStatus == entity.StatusPaid && len(order.StatusHistory) == 1 && order.StatusHistory[0].By == "payment-gateway" && order.EventName == "name" &&
+			order.Country.Place == "GBK" && order.FullName == "name" && order.Email == "mail@mail.com"
+	})).Return(mockChannel(helpers.Result{Data: "Success insert data"}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPaid), result.PaymentStatus)
+	assert.NotEmpty(suite.T(), result.OrderId)
+	suite.mockAdmission.AssertCalled(suite.T(), "Release", mock.Anything, "event", "queue")
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultLegacyPending() {
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).PaymentStatus = entity.StatusPending
+	suite.mockPaymentResult(bankTicket)
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.MatchedBy(func(req request.TransitionBankTicketReq) bool {
+		return req.StatusChange.From == entity.StatusPending && req.StatusChange.To == entity.StatusPaid
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: "Success insert data"}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPaid), result.PaymentStatus)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOrderPaid() {
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: "Success insert data"}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.NoError(suite.T(), err)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		if len(events) != 1 {
+			return false
+		}
+		paid, ok := events[0].Payload.(eventschema.OrderPaid)
+		return ok && events[0].Type == constants.EventOrderPaid && events[0].Topic == constants.TopicOrderPaid &&
+			events[0].Key == result.OrderId && paid.OrderId == result.OrderId && paid.PaymentId == "payment" && paid.Amount == 500
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultErrOutbox() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: "Success insert data"}))
+	suite.mockOutboxRepository.ExpectedCalls = nil
+	suite.mockOutboxRepository.On("InsertOutboxEvents", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultAlreadyProcessed() {
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).PaymentStatus = entity.StatusPaid
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(bankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(helpers.Result{
+		Data: &entity.Order{OrderId: "order", PaymentId: "payment", TicketNumber: "111", PaymentStatus: entity.StatusPaid},
+	}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "order", result.OrderId)
+	assert.Equal(suite.T(), 500, result.Amount)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOtherPayment() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(pendingBankTicket()))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(helpers.Result{
+		Data: &entity.Order{OrderId: "order", PaymentId: "other", TicketNumber: "111"},
+	}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order already paid by another payment", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultErrFindOrder() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(pendingBankTicket()))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultNotFound() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(helpers.Result{})
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order not found", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultNotPending() {
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).PaymentStatus = entity.StatusExpired
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(bankTicket)
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order is not waiting for payment", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultAmountMismatch() {
+	payload := paymentResultReq()
+	payload.Amount = 400
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "payment amount does not match order price", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultFailedPayment() {
+	payload := paymentResultReq()
+	payload.Status = constants.PaymentResultFailed
+	suite.mockPaymentResult(pendingBankTicket())
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusHeld), result.PaymentStatus)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultPendingNotice() {
+	payload := paymentResultReq()
+	payload.Status = constants.PaymentResultPending
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.MatchedBy(func(req request.TransitionBankTicketReq) bool {
+		return req.StatusChange.From == entity.StatusHeld && req.StatusChange.To == entity.StatusPendingPayment &&
+			req.StatusChange.By == "payment-gateway"
+	})).Return(mockChannel(helpers.Result{
+		Data: &entity.BankTicket{TicketNumber: "111", PaymentStatus: entity.StatusPendingPayment},
+	}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPendingPayment), result.PaymentStatus)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "InsertOrder", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultPendingNoticeRepeated() {
+	payload := paymentResultReq()
+	payload.Status = constants.PaymentResultPending
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).PaymentStatus = entity.StatusPendingPayment
+	suite.mockPaymentResult(bankTicket)
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPendingPayment), result.PaymentStatus)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "TransitionBankTicket", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultPendingNoticeReleased() {
+	payload := paymentResultReq()
+	payload.Status = constants.PaymentResultPending
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order is not waiting for payment", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultFromPendingPayment() {
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).PaymentStatus = entity.StatusPendingPayment
+	suite.mockPaymentResult(bankTicket)
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.MatchedBy(func(req request.TransitionBankTicketReq) bool {
+		return req.StatusChange.From == entity.StatusPendingPayment && req.StatusChange.To == entity.StatusPaid
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: "Success insert data"}))
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPaid), result.PaymentStatus)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultAlreadyReleased() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order is not waiting for payment", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "InsertOrder", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultErrInsertOrder() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.Conflict("order already exists"),
+	}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultErrEvent() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockEventRepositoryQuery.ExpectedCalls = nil
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "event not found", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultErrUser() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(pendingBankTicket())
+	suite.mockUserRepositoryQuery.ExpectedCalls = nil
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, paymentResultReq())
+
+	assert.Error(suite.T(), err)
+}
+
+// orderBankTickets are two tickets held together as one order
+func orderBankTickets() helpers.Result {
+	return helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber:  "111",
+				OrderId:       "order",
+				TicketId:      "ticket",
+				EventId:       "event",
+				UserId:        "id",
+				QueueId:       "queue",
+				TicketType:    "Gold",
+				Price:         500,
+				IsUsed:        true,
+				PaymentStatus: entity.StatusHeld,
+			},
+			{
+				TicketNumber:  "112",
+				OrderId:       "order",
+				TicketId:      "ticket",
+				EventId:       "event",
+				UserId:        "id",
+				QueueId:       "queue",
+				TicketType:    "Gold",
+				Price:         300,
+				IsUsed:        true,
+				PaymentStatus: entity.StatusHeld,
 			},
 		},
-		Error: nil,
 	}
-	mockUpdateBankTicket := helpers.Result{
-		Data: &entity.BankTicket{
-			TicketId:     "id",
-			EventId:      "id",
-			TicketNumber: "111",
-		},
-		Error: nil,
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOrder() {
+	payload := paymentResultReq()
+	payload.OrderId = "order"
+	payload.TicketNumber = ""
+	payload.Amount = 800
+	suite.mockPaymentResult(helpers.Result{})
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, req request.TransitionBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: req.TicketNumber}})
+	})
+	suite.mockOrderRepositoryCommand.On("InsertOrder", mock.Anything, mock.Anything).Return(func(ctx context.Context, order entity.Order) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: "Success insert data"})
+	})
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "order", result.OrderId)
+	assert.Equal(suite.T(), 800, result.Amount)
+	assert.Equal(suite.T(), []string{"111", "112"}, result.TicketNumbers)
+	suite.mockOrderRepositoryQuery.AssertNotCalled(suite.T(), "FindBankTicketByTicketNumber", mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "TransitionBankTicket", 2)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "InsertOrder", mock.Anything, mock.MatchedBy(func(order entity.Order) bool {
+		return order.OrderId == "order" && order.TicketNumber == "112" && order.Amount == 300
+	}))
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		return len(events) == 2 && events[0].Key == "order" && events[1].Key == "order"
+	}))
+	suite.mockAdmission.AssertNumberOfCalls(suite.T(), "Release", 1)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOrderByTicketNumber() {
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).OrderId = "order"
+	payload := paymentResultReq()
+	payload.Amount = 800
+	payload.Status = constants.PaymentResultPending
+	suite.mockPaymentResult(bankTicket)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+	suite.mockOrderRepositoryCommand.On("TransitionBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, req request.TransitionBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: req.TicketNumber, PaymentStatus: entity.StatusPendingPayment}})
+	})
+
+	result, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusPendingPayment), result.PaymentStatus)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "TransitionBankTicket", 2)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "WithTransaction", 1)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOrderPartialAmount() {
+	payload := paymentResultReq()
+	payload.OrderId = "order"
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPaymentResult(helpers.Result{})
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "payment amount does not match order price", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestProcessPaymentResultOrderNotFound() {
+	payload := paymentResultReq()
+	payload.OrderId = "order"
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(helpers.Result{Data: &[]entity.BankTicket{}}))
+
+	_, err := suite.usecase.ProcessPaymentResult(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order not found", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketOrder() {
+	payload := request.CancelOrderReq{
+		UserId:  "id",
+		OrderId: "order",
 	}
-	mockUpdateTicketDetail := helpers.Result{
-		Data:  nil,
-		Error: errors.BadRequest("error"),
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, req request.ReleaseBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: req.TicketNumber}})
+	})
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(func(ctx context.Context, ticketId string, eventId string, quantity int) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &ticketEntity.Ticket{}})
+	})
+
+	result, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "order", result.OrderId)
+	assert.Equal(suite.T(), []string{"111", "112"}, result.TicketNumbers)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "WithTransaction", 1)
+	suite.mockTicketRepositoryCommand.AssertNumberOfCalls(suite.T(), "IncrementTicketDetail", 2)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		cancelled, ok := events[1].Payload.(eventschema.OrderCancelled)
+		return len(events) == 2 && ok && cancelled.OrderId == "order" && cancelled.TicketNumber == "112"
+	}))
+	suite.mockAdmission.AssertNumberOfCalls(suite.T(), "Release", 1)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketOrderPartlyReleased() {
+	payload := request.CancelOrderReq{
+		UserId:  "id",
+		OrderId: "order",
 	}
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
-	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockEventById))
-	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockQueueByUser))
-	suite.mockOrderRepositoryQuery.On("FindBankTicketByParam", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockBankTicketByParam))
-	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockTicketByEvent))
-	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(mockUserById))
-	suite.mockOrderRepositoryCommand.On("UpdateBankTicket", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateBankTicket))
-	suite.mockTicketRepositoryCommand.On("UpdateOneTicketDetail", mock.Anything, mock.Anything).Return(mockChannel(mockUpdateTicketDetail))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "111"
+	})).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "112"
+	})).Return(mockChannel(helpers.Result{}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(mockChannel(helpers.Result{Data: &ticketEntity.Ticket{}}))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order cannot be cancelled", err.Error())
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketOrderOtherUser() {
+	payload := request.CancelOrderReq{
+		UserId:       "other",
+		TicketNumber: "112",
+	}
+	bankTicket := pendingBankTicket()
+	bankTicket.Data.(*entity.BankTicket).OrderId = "order"
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "112").Return(mockChannel(bankTicket))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(orderBankTickets()))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order does not belong to user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsOrder() {
+	tickets := orderBankTickets()
+	expired := *tickets.Data.(*[]entity.BankTicket)
+	orderTickets := append(append([]entity.BankTicket{}, expired...), entity.BankTicket{
+		TicketNumber:  "113",
+		OrderId:       "order",
+		PaymentStatus: entity.StatusExpired,
+	})
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &expired}))
+	suite.mockOrderRepositoryQuery.On("FindBankTicketsByOrderId", mock.Anything, "order").Return(mockChannel(helpers.Result{Data: &orderTickets}))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(func(ctx context.Context, req request.ReleaseBankTicketReq) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: req.TicketNumber}})
+	})
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(func(ctx context.Context, ticketId string, eventId string, quantity int) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &ticketEntity.Ticket{}})
+	})
+
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, total)
+	suite.mockOrderRepositoryQuery.AssertNumberOfCalls(suite.T(), "FindBankTicketsByOrderId", 1)
+	suite.mockOrderRepositoryCommand.AssertNumberOfCalls(suite.T(), "WithTransaction", 1)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ReleaseBankTicket", mock.Anything, mock.MatchedBy(func(req request.ReleaseBankTicketReq) bool {
+		return req.TicketNumber == "113"
+	}))
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		return len(events) == 2 && events[0].Type == constants.EventOrderExpired && events[0].Key == "order" && events[1].Key == "order"
+	}))
+	suite.mockAdmission.AssertNumberOfCalls(suite.T(), "Release", 1)
+}
+
+// mockTransaction runs the transaction body directly, so the repository mocks inside it are exercised.
+func mockPurchaseQuotaRelease(ctx context.Context, payload request.PurchaseQuotaReq) <-chan helpers.Result {
+	return mockChannel(helpers.Result{
+		Data: &entity.PurchaseQuota{},
+	})
+}
+
+// outboxEvent matches a single outbox event of the type, keyed by the order, or the ticket number of a ticket
+// claimed before orders had an id
+func outboxEvent(eventType string, key string) interface{} {
+	return mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		return len(events) == 1 && events[0].Type == eventType && events[0].Key == key
+	})
+}
+
+func mockTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan helpers.Result {
+	return mockChannel(helpers.Result{
+		Error: fn(ctx),
+	})
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPricing() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Country: eventEntity.Country{Code: "ID"},
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 2},
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockPricingEngine.ExpectedCalls = nil
+	suite.mockPricingEngine.On("Quote", mock.Anything, mock.Anything).Return(&pricingEntity.Breakdown{
+		BasePrice: 50,
+		Adjustments: []pricingEntity.Adjustment{
+			{RuleId: "foreign", Name: "foreign visitor", Kind: pricingEntity.KindDiscount, Amount: -10},
+			{RuleId: "service", Name: "service fee", Kind: pricingEntity.KindFee, Amount: 5},
+		},
+		Price: 45,
+	}, nil)
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 90, res.TotalPrice)
+	assert.Equal(suite.T(), 50, res.Tickets[0].PriceBreakdown.BasePrice)
+	assert.Equal(suite.T(), []response.PriceAdjustment{
+		{RuleId: "foreign", Name: "foreign visitor", Kind: "discount", Amount: -10},
+		{RuleId: "service", Name: "service fee", Kind: "fee", Amount: 5},
+	}, res.Tickets[0].PriceBreakdown.Adjustments)
+	suite.mockPricingEngine.AssertCalled(suite.T(), "Quote", mock.Anything, mock.MatchedBy(func(req pricingRequest.QuoteReq) bool {
+		return req.EventCountryCode == "ID" && req.UserCountryCode == "code" && req.TicketType == "VIP" &&
+			req.Quantity == 2 && req.BasePrice == 50
+	}))
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.Price == 45 && req.PriceBreakdown.BasePrice == 50 && len(req.PriceBreakdown.Adjustments) == 2
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrPricing() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockPricingEngine.ExpectedCalls = nil
+	suite.mockPricingEngine.On("Quote", mock.Anything, mock.Anything).Return(nil, errors.InternalServerError("error"))
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func mockBasePriceQuote(ctx context.Context, payload pricingRequest.QuoteReq) *pricingEntity.Breakdown {
+	return &pricingEntity.Breakdown{
+		BasePrice: payload.BasePrice,
+		Price:     payload.BasePrice,
+	}
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSaleNotOpen() {
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "VIP"},
+			{TicketType: "Gold", Quantity: 2},
+		},
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockSaleSchedule.ExpectedCalls = nil
+	suite.mockSaleSchedule.On("CheckSaleOpen", mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.CustomErrorRetryAfter("Gold ticket sale opens at 2024-03-01T10:00:00+07:00", constants.ErrCodeSaleNotOpen, http.StatusForbidden, 60))
 
 	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Gold ticket sale opens at 2024-03-01T10:00:00+07:00", err.Error())
+	suite.mockSaleSchedule.AssertCalled(suite.T(), "CheckSaleOpen", mock.Anything, mock.Anything, []string{"VIP", "Gold"})
+	suite.mockAdmission.AssertNotCalled(suite.T(), "ServingNumber", mock.Anything, mock.Anything)
 }
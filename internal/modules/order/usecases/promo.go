@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	eventEntity "order-service/internal/modules/event/models/entity"
+	"order-service/internal/modules/order/models/entity"
+	"order-service/internal/modules/order/models/request"
+	promoEntity "order-service/internal/modules/promo/models/entity"
+	promoRequest "order-service/internal/modules/promo/models/request"
+	"order-service/internal/pkg/errors"
+	"time"
+)
+
+// adjustmentPromo marks the price adjustment of a discount code in the price breakdown.
+const adjustmentPromo = "promo"
+
+// inPresale reports whether only presale code holders may order tickets of the event.
+func inPresale(event eventEntity.Event, now time.Time) bool {
+	presale := event.Presale
+	return !presale.StartAt.IsZero() && !now.Before(presale.StartAt) && now.Before(presale.EndAt)
+}
+
+// checkPromoCode validates the code of the order. During the presale a presale code covering every ordered
+// ticket type is required, outside of it only discount codes are accepted. Nil means the order has no code.
+func (c commandUsecase) checkPromoCode(ctx context.Context, event eventEntity.Event, payload request.OrderReq,
+	items []request.OrderItemReq) (*promoEntity.PromoCode, error) {
+	presale := inPresale(event, Now())
+	if payload.PromoCode == "" {
+		if presale {
+			msg := "presale requires an access code"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.ForbiddenError("presale requires an access code")
+		}
+		return nil, nil
+	}
+
+	promoCode, err := c.promoValidator.Validate(ctx, promoRequest.ValidateCodeReq{
+		Code:        payload.PromoCode,
+		EventId:     event.EventId,
+		TicketTypes: ticketTypesOf(items),
+		At:          Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !presale {
+		if promoCode.Kind == promoEntity.KindPresale {
+			msg := "presale code outside of the presale"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.BadRequest("presale code can only be used during the presale")
+		}
+		return promoCode, nil
+	}
+
+	if promoCode.Kind != promoEntity.KindPresale {
+		msg := "promo code does not grant presale access"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.ForbiddenError("promo code does not grant presale access")
+	}
+
+	for _, item := range items {
+		if !promoAppliesTo(promoCode, item.TicketType) {
+			msg := "promo code does not grant presale access"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.ForbiddenError(fmt.Sprintf("promo code does not grant presale access for %s tickets", item.TicketType))
+		}
+	}
+
+	return promoCode, nil
+}
+
+// promoAppliesTo reports whether the tickets of the type are bought with the code and count as its uses.
+func promoAppliesTo(promoCode *promoEntity.PromoCode, ticketType string) bool {
+	if promoCode == nil {
+		return false
+	}
+	if len(promoCode.TicketTypes) == 0 {
+		return true
+	}
+	for _, eligible := range promoCode.TicketTypes {
+		if eligible == ticketType {
+			return true
+		}
+	}
+	return false
+}
+
+// promoDiscount is the amount a discount code takes off the price of a ticket, never more than the price.
+func promoDiscount(promoCode promoEntity.PromoCode, price int) int {
+	if promoCode.Kind != promoEntity.KindDiscount {
+		return 0
+	}
+
+	discount := promoCode.DiscountAmount
+	if promoCode.DiscountPercentage > 0 {
+		discount = price * promoCode.DiscountPercentage / 100
+	}
+	if discount > price {
+		discount = price
+	}
+	return discount
+}
+
+// promoRedemptionOf is the share of a single bank ticket in the uses of its promo code.
+func promoRedemptionOf(ticket entity.BankTicket) promoRequest.RedeemPromoReq {
+	return promoRequest.RedeemPromoReq{
+		Code:     ticket.PromoCode,
+		UserId:   ticket.UserId,
+		Quantity: 1,
+	}
+}
@@ -0,0 +1,301 @@
+package usecases_test
+
+import (
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	"time"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+	"order-service/internal/modules/order/models/entity"
+	"order-service/internal/modules/order/models/request"
+	"order-service/internal/modules/order/models/response"
+	promoEntity "order-service/internal/modules/promo/models/entity"
+	promoRequest "order-service/internal/modules/promo/models/request"
+	ticketEntity "order-service/internal/modules/ticket/models/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockPresaleEvent() eventEntity.Event {
+	return eventEntity.Event{
+		EventId: "id",
+		Country: eventEntity.Country{Code: "code"},
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4},
+		Presale: eventEntity.PresaleSetting{
+			StartAt: time.Now().Add(-time.Hour),
+			EndAt:   time.Now().Add(time.Hour),
+		},
+	}
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPromoDiscount() {
+	payload := request.OrderReq{
+		UserId:    "id",
+		EventId:   "id",
+		PromoCode: "FANS",
+		Items: []request.OrderItemReq{
+			{TicketType: "VIP", Quantity: 2},
+			{TicketType: "Gold"},
+		},
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{
+		EventId: "id",
+		Country: eventEntity.Country{Code: "code"},
+		Order:   eventEntity.OrderSetting{MaxTicketsPerUser: 4},
+	})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code:               "FANS",
+		Name:               "fan club",
+		Kind:               promoEntity.KindDiscount,
+		DiscountPercentage: 10,
+		UsageLimit:         100,
+		PerUserLimit:       2,
+		TicketTypes:        []string{"VIP"},
+	}, nil)
+	suite.mockPromoRepositoryCommand.On("RedeemPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &promoEntity.PromoCode{Code: "FANS"}}))
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), res.Tickets, 3)
+	assert.Equal(suite.T(), 140, res.TotalPrice)
+	assert.Equal(suite.T(), []response.PriceAdjustment{
+		{RuleId: "FANS", Name: "fan club", Kind: "promo", Amount: -5},
+	}, res.Tickets[0].PriceBreakdown.Adjustments)
+	assert.Empty(suite.T(), res.Tickets[2].PriceBreakdown.Adjustments)
+	suite.mockPromoValidator.AssertCalled(suite.T(), "Validate", mock.Anything, mock.MatchedBy(func(req promoRequest.ValidateCodeReq) bool {
+		return req.Code == "FANS" && req.EventId == "id" && len(req.TicketTypes) == 2
+	}))
+	suite.mockPromoRepositoryCommand.AssertCalled(suite.T(), "RedeemPromoCode", mock.Anything, promoRequest.RedeemPromoReq{
+		Code:         "FANS",
+		UserId:       "id",
+		Quantity:     2,
+		UsageLimit:   100,
+		PerUserLimit: 2,
+	})
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.TicketType == "VIP" && req.Price == 45 && req.PromoCode == "FANS"
+	}))
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.TicketType == "Gold" && req.Price == 50 && req.PromoCode == ""
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPromoUsageLimit() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		PromoCode:  "FANS",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code:               "FANS",
+		Kind:               promoEntity.KindDiscount,
+		DiscountPercentage: 10,
+		UsageLimit:         100,
+	}, nil)
+	suite.mockPromoRepositoryCommand.On("RedeemPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: nil}))
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code usage limit reached", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketErrPromoCode() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		PromoCode:  "FANS",
+	}
+
+	suite.mockMultiTicketOrder(eventEntity.Event{EventId: "id", Country: eventEntity.Country{Code: "code"}})
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(nil, errors.NotFound("promo code not found"))
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code not found", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPresale() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		Quantity:   2,
+		PromoCode:  "EARLY",
+	}
+
+	suite.mockMultiTicketOrder(mockPresaleEvent())
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code:        "EARLY",
+		Kind:        promoEntity.KindPresale,
+		UsageLimit:  10,
+		TicketTypes: []string{"VIP"},
+	}, nil)
+	suite.mockPromoRepositoryCommand.On("RedeemPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &promoEntity.PromoCode{Code: "EARLY"}}))
+
+	res, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 100, res.TotalPrice)
+	suite.mockPromoRepositoryCommand.AssertCalled(suite.T(), "RedeemPromoCode", mock.Anything, mock.MatchedBy(func(req promoRequest.RedeemPromoReq) bool {
+		return req.Code == "EARLY" && req.Quantity == 2 && req.UsageLimit == 10
+	}))
+	suite.mockSaleSchedule.AssertNotCalled(suite.T(), "CheckSaleOpen", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPresaleNoCode() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(mockPresaleEvent())
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "presale requires an access code", err.Error())
+	suite.mockPromoValidator.AssertNotCalled(suite.T(), "Validate", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPresaleDiscountCode() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		PromoCode:  "FANS",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(mockPresaleEvent())
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code: "FANS",
+		Kind: promoEntity.KindDiscount,
+	}, nil)
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code does not grant presale access", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPresaleTicketType() {
+	payload := request.OrderReq{
+		UserId:  "id",
+		EventId: "id",
+		Items: []request.OrderItemReq{
+			{TicketType: "VIP"},
+			{TicketType: "Gold"},
+		},
+		PromoCode: "EARLY",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(mockPresaleEvent())
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code:        "EARLY",
+		Kind:        promoEntity.KindPresale,
+		TicketTypes: []string{"VIP"},
+	}, nil)
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code does not grant presale access for Gold tickets", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketPresaleCodeAfterPresale() {
+	payload := request.OrderReq{
+		UserId:     "id",
+		EventId:    "id",
+		TicketType: "VIP",
+		PromoCode:  "EARLY",
+	}
+
+	event := mockPresaleEvent()
+	event.Presale.StartAt = time.Now().Add(-2 * time.Hour)
+	event.Presale.EndAt = time.Now().Add(-time.Hour)
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockMultiTicketOrder(event)
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code: "EARLY",
+		Kind: promoEntity.KindPresale,
+	}, nil)
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "presale code can only be used during the presale", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCancelOrderTicketPromoCode() {
+	payload := request.CancelOrderReq{
+		UserId:       "id",
+		TicketNumber: "111",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			TicketId:      "ticket",
+			EventId:       "event",
+			UserId:        "id",
+			IsUsed:        true,
+			PromoCode:     "FANS",
+			PaymentStatus: entity.StatusHeld,
+		},
+		Error: nil,
+	}
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "ticket", "event", 1).Return(mockChannel(helpers.Result{Data: &ticketEntity.Ticket{TicketId: "ticket"}}))
+	suite.mockPromoRepositoryCommand.On("ReturnPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &promoEntity.PromoCode{Code: "FANS"}}))
+
+	_, err := suite.usecase.CancelOrderTicket(suite.ctx, payload)
+	assert.NoError(suite.T(), err)
+	suite.mockPromoRepositoryCommand.AssertCalled(suite.T(), "ReturnPromoCode", mock.Anything, promoRequest.RedeemPromoReq{
+		Code:     "FANS",
+		UserId:   "id",
+		Quantity: 1,
+	})
+}
+
+func (suite *CommandUsecaseTestSuite) TestExpireBankTicketsPromoCode() {
+	mockExpiredBankTickets := helpers.Result{
+		Data: &[]entity.BankTicket{
+			{
+				TicketNumber:  "111",
+				TicketId:      "id",
+				EventId:       "id",
+				UserId:        "id",
+				PromoCode:     "FANS",
+				PaymentStatus: entity.StatusHeld,
+				UpdatedAt:     time.Now().Add(-time.Hour),
+			},
+		},
+		Error: nil,
+	}
+	suite.mockOrderRepositoryQuery.On("FindExpiredBankTickets", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockExpiredBankTickets))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReleaseBankTicket", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.BankTicket{TicketNumber: "111"}}))
+	suite.mockTicketRepositoryCommand.On("IncrementTicketDetail", mock.Anything, "id", "id", 1).Return(mockChannel(helpers.Result{Data: &ticketEntity.Ticket{TicketId: "id"}}))
+	suite.mockPromoRepositoryCommand.On("ReturnPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &promoEntity.PromoCode{Code: "FANS"}}))
+
+	total, err := suite.usecase.ExpireBankTickets(suite.ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, total)
+	suite.mockPromoRepositoryCommand.AssertCalled(suite.T(), "ReturnPromoCode", mock.Anything, mock.MatchedBy(func(req promoRequest.RedeemPromoReq) bool {
+		return req.Code == "FANS" && req.UserId == "id" && req.Quantity == 1
+	}))
+}
@@ -3,28 +3,39 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"order-service/internal/modules/event"
+	eventEntity "order-service/internal/modules/event/models/entity"
 	"order-service/internal/modules/order"
 	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	"order-service/internal/modules/order/models/response"
+	"order-service/internal/modules/ticket"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/redis"
 	"time"
 
 	"go.elastic.co/apm"
 )
 
 type queryUsecase struct {
-	orderRepositoryQuery order.MongodbRepositoryQuery
-	logger               log.Logger
+	orderRepositoryQuery  order.MongodbRepositoryQuery
+	eventRepositoryQuery  event.MongodbRepositoryQuery
+	ticketRepositoryQuery ticket.MongodbRepositoryQuery
+	logger                log.Logger
+	redis                 redis.Collections
 }
 
-func NewQueryUsecase(omq order.MongodbRepositoryQuery, log log.Logger) order.UsecaseQuery {
+func NewQueryUsecase(omq order.MongodbRepositoryQuery, emq event.MongodbRepositoryQuery, trq ticket.MongodbRepositoryQuery,
+	log log.Logger, rc redis.Collections) order.UsecaseQuery {
 	return queryUsecase{
-		orderRepositoryQuery: omq,
-		logger:               log,
+		orderRepositoryQuery:  omq,
+		eventRepositoryQuery:  emq,
+		ticketRepositoryQuery: trq,
+		logger:                log,
+		redis:                 rc,
 	}
 }
 
@@ -110,16 +121,16 @@ func (q queryUsecase) FindPreOrderList(origCtx context.Context, payload request.
 	var collectionData = make([]response.PreOrderList, 0)
 	for _, value := range *bankTicket {
 		var maxWaitTime string
-		if value.PaymentStatus == constants.Pending {
-			count := 15
-			then := value.UpdatedAt.Local().Add(time.Duration(+count) * time.Minute)
+		if awaitingPayment(value.PaymentStatus) {
+			then := heldAt(value).Local().Add(orderHoldDuration())
 			maxWaitTime = then.Format("2006-01-02 15:04")
 		}
 		collectionData = append(collectionData, response.PreOrderList{
+			OrderId:      value.OrderId,
 			TicketNumber: value.TicketNumber,
 			TicketType:   value.TicketType,
 			TicketPrice:  value.Price,
-			OrderTime:    value.UpdatedAt.Local(),
+			OrderTime:    heldAt(value).Local(),
 			UserId:       value.UserId,
 			EventId:      value.EventId,
 			MaxWaitTime:  maxWaitTime,
@@ -131,3 +142,127 @@ func (q queryUsecase) FindPreOrderList(origCtx context.Context, payload request.
 		MetaData:       helpers.GenerateMetaData(bankTicketData.Count, int64(len(*bankTicket)), payload.Page, payload.Size),
 	}, nil
 }
+
+func (q queryUsecase) FindOrderDetail(origCtx context.Context, payload request.GetOrderReq) (*response.OrderDetailResp, error) {
+	domain := "orderUsecase-FindOrderDetail"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	bankTicketData := <-q.orderRepositoryQuery.FindBankTicketByTicketNumber(ctx, payload.TicketNumber)
+	if bankTicketData.Error != nil {
+		msg := "Error DB connection FindBankTicketByTicketNumber"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+		return nil, bankTicketData.Error
+	}
+
+	if bankTicketData.Data == nil {
+		msg := "order not found"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.NotFound("order not found")
+	}
+
+	bankTicket, ok := bankTicketData.Data.(*entity.BankTicket)
+	if !ok {
+		msg := "cannot parsing data bank ticket"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+		return nil, errors.InternalServerError("cannot parsing data bank ticket")
+	}
+
+	orderData := <-q.orderRepositoryQuery.FindOrderByTicketNumber(ctx, payload.TicketNumber)
+	if orderData.Error != nil {
+		msg := "Error DB connection FindOrderByTicketNumber"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", orderData.Error))
+		return nil, orderData.Error
+	}
+
+	// the order document only exists once the payment went through
+	var orderDoc *entity.Order
+	if orderData.Data != nil {
+		orderDoc, ok = orderData.Data.(*entity.Order)
+		if !ok {
+			msg := "cannot parsing data order"
+			q.logger.Error(ctx, msg, fmt.Sprintf("%+v", orderData.Data))
+			return nil, errors.InternalServerError("cannot parsing data order")
+		}
+	}
+
+	owner := bankTicket.UserId
+	if orderDoc != nil {
+		owner = orderDoc.UserId
+	}
+	if payload.Role != constants.RoleAdmin && owner != payload.UserId {
+		msg := "order does not belong to user"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.ForbiddenError("order does not belong to user")
+	}
+
+	eventData := <-q.eventRepositoryQuery.FindEventById(ctx, bankTicket.EventId)
+	if eventData.Error != nil {
+		msg := "Error DB connection FindEventById"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Error))
+		return nil, eventData.Error
+	}
+
+	result := response.OrderDetailResp{
+		TicketNumber:  bankTicket.TicketNumber,
+		TicketId:      bankTicket.TicketId,
+		TicketType:    bankTicket.TicketType,
+		SeatNumber:    bankTicket.SeatNumber,
+		UserId:        owner,
+		QueueId:       bankTicket.QueueId,
+		Price:         bankTicket.Price,
+		PaymentStatus: string(bankTicket.PaymentStatus),
+		OrderTime:     heldAt(*bankTicket).Local(),
+	}
+	if awaitingPayment(bankTicket.PaymentStatus) {
+		result.MaxWaitTime = heldAt(*bankTicket).Local().Add(orderHoldDuration()).Format("2006-01-02 15:04")
+	}
+
+	if eventData.Data != nil {
+		event, ok := eventData.Data.(*eventEntity.Event)
+		if !ok {
+			msg := "cannot parsing data event"
+			q.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Data))
+			return nil, errors.InternalServerError("cannot parsing data event")
+		}
+		result.Event = response.OrderEvent{
+			EventId:     event.EventId,
+			Name:        event.Name,
+			DateTime:    event.DateTime,
+			Location:    event.Location,
+			CountryCode: event.Country.Code,
+			Place:       event.Country.Place,
+			Description: event.Description,
+			Tag:         event.Tag,
+		}
+	}
+
+	if orderDoc != nil {
+		result.PaymentStatus = string(orderDoc.PaymentStatus)
+		result.Payment = &response.OrderPayment{
+			PaymentId:     orderDoc.PaymentId,
+			VaNumber:      orderDoc.VaNumber,
+			Bank:          orderDoc.Bank,
+			Amount:        orderDoc.Amount,
+			PaymentStatus: string(orderDoc.PaymentStatus),
+			OrderTime:     orderDoc.OrderTime,
+		}
+		// the event may have been removed since, the order keeps a snapshot of it
+		if eventData.Data == nil {
+			result.Event = response.OrderEvent{
+				EventId:     orderDoc.EventId,
+				Name:        orderDoc.EventName,
+				DateTime:    orderDoc.DateTime,
+				CountryCode: orderDoc.Country.Code,
+				Place:       orderDoc.Country.Place,
+				Description: orderDoc.Description,
+				Tag:         orderDoc.Tag,
+			}
+		}
+	}
+
+	return &result, nil
+}
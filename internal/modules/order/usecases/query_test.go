@@ -4,15 +4,20 @@ import (
 	"context"
 	"order-service/internal/modules/order"
 	"testing"
+	"time"
 
+	eventEntity "order-service/internal/modules/event/models/entity"
 	"order-service/internal/modules/order/models/entity"
 	"order-service/internal/modules/order/models/request"
 	uc "order-service/internal/modules/order/usecases"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
+	mockcertEvent "order-service/mocks/modules/event"
 	mockcert "order-service/mocks/modules/order"
+	mockcertTicket "order-service/mocks/modules/ticket"
 	mocklog "order-service/mocks/pkg/log"
+	mockredis "order-service/mocks/pkg/redis"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -21,19 +26,28 @@ import (
 
 type QueryUsecaseTestSuite struct {
 	suite.Suite
-	mockOrderRepositoryQuery *mockcert.MongodbRepositoryQuery
-	mockLogger               *mocklog.Logger
-	usecase                  order.UsecaseQuery
-	ctx                      context.Context
+	mockOrderRepositoryQuery  *mockcert.MongodbRepositoryQuery
+	mockEventRepositoryQuery  *mockcertEvent.MongodbRepositoryQuery
+	mockTicketRepositoryQuery *mockcertTicket.MongodbRepositoryQuery
+	mockLogger                *mocklog.Logger
+	mockRedis                 *mockredis.Collections
+	usecase                   order.UsecaseQuery
+	ctx                       context.Context
 }
 
 func (suite *QueryUsecaseTestSuite) SetupTest() {
 	suite.mockOrderRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockEventRepositoryQuery = &mockcertEvent.MongodbRepositoryQuery{}
+	suite.mockTicketRepositoryQuery = &mockcertTicket.MongodbRepositoryQuery{}
 	suite.mockLogger = &mocklog.Logger{}
+	suite.mockRedis = &mockredis.Collections{}
 	suite.ctx = context.Background()
 	suite.usecase = uc.NewQueryUsecase(
 		suite.mockOrderRepositoryQuery,
+		suite.mockEventRepositoryQuery,
+		suite.mockTicketRepositoryQuery,
 		suite.mockLogger,
+		suite.mockRedis,
 	)
 }
 func TestQueryUsecaseTestSuite(t *testing.T) {
@@ -144,7 +158,7 @@ func (suite *QueryUsecaseTestSuite) TestFindPreOrderList() {
 				TicketNumber:  "111",
 				TicketType:    "Gold",
 				Price:         50,
-				PaymentStatus: constants.Pending,
+				PaymentStatus: entity.StatusHeld,
 			},
 		},
 		Error: nil,
@@ -168,7 +182,7 @@ func (suite *QueryUsecaseTestSuite) TestFindPreOrderListErr() {
 				TicketNumber:  "111",
 				TicketType:    "Gold",
 				Price:         50,
-				PaymentStatus: constants.Pending,
+				PaymentStatus: entity.StatusHeld,
 			},
 		},
 		Error: errors.BadRequest("error"),
@@ -218,3 +232,271 @@ func (suite *QueryUsecaseTestSuite) TestFindPreOrderListErrParse() {
 	_, err := suite.usecase.FindPreOrderList(suite.ctx, payload)
 	assert.Error(suite.T(), err)
 }
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailPending() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+		Role:         constants.RoleUser,
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			EventId:       "event",
+			UserId:        "id",
+			Price:         50,
+			PaymentStatus: entity.StatusHeld,
+			UpdatedAt:     time.Now(),
+		},
+		Error: nil,
+	}
+	mockOrder := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+	mockEvent := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "event",
+			Name:    "name",
+		},
+		Error: nil,
+	}
+
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "event").Return(mockChannel(mockEvent))
+
+	result, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(entity.StatusHeld), result.PaymentStatus)
+	assert.NotEmpty(suite.T(), result.MaxWaitTime)
+	assert.Nil(suite.T(), result.Payment)
+	assert.Equal(suite.T(), "name", result.Event.Name)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailPendingPayment() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+		Role:         constants.RoleUser,
+	}
+	// the payment was started later, the hold still runs from the claim
+	heldAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.Local)
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			EventId:       "event",
+			UserId:        "id",
+			Price:         50,
+			PaymentStatus: entity.StatusPendingPayment,
+			HeldAt:        heldAt,
+			UpdatedAt:     heldAt.Add(10 * time.Minute),
+		},
+	}
+
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(helpers.Result{}))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "event").Return(mockChannel(helpers.Result{}))
+
+	result, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), heldAt, result.OrderTime)
+	assert.Equal(suite.T(), "2024-03-01 10:15", result.MaxWaitTime)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailPaidByAdmin() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "admin",
+		Role:         constants.RoleAdmin,
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber:  "111",
+			EventId:       "event",
+			UserId:        "id",
+			PaymentStatus: entity.StatusPaid,
+		},
+		Error: nil,
+	}
+	mockOrder := helpers.Result{
+		Data: &entity.Order{
+			TicketNumber:  "111",
+			UserId:        "id",
+			PaymentId:     "payment",
+			VaNumber:      "va",
+			Bank:          "bank",
+			Amount:        50,
+			PaymentStatus: entity.StatusPaid,
+			EventName:     "snapshot",
+		},
+		Error: nil,
+	}
+	mockEvent := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "event").Return(mockChannel(mockEvent))
+
+	result, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "id", result.UserId)
+	assert.Empty(suite.T(), result.MaxWaitTime)
+	assert.Equal(suite.T(), "va", result.Payment.VaNumber)
+	assert.Equal(suite.T(), "bank", result.Payment.Bank)
+	assert.Equal(suite.T(), "snapshot", result.Event.Name)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailOtherUser() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "other",
+		Role:         constants.RoleUser,
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber: "111",
+			EventId:      "event",
+			UserId:       "id",
+		},
+		Error: nil,
+	}
+	mockOrder := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder))
+
+	_, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order does not belong to user", err.Error())
+	suite.mockEventRepositoryQuery.AssertNotCalled(suite.T(), "FindEventById", mock.Anything, mock.Anything)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailNotFound() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+	}
+	mockBankTicket := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "order not found", err.Error())
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailErrBankTicket() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+	}
+	mockBankTicket := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+
+	_, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+
+	mockBankTicket2 := helpers.Result{
+		Data:  &entity.Country{},
+		Error: nil,
+	}
+	suite.mockOrderRepositoryQuery.ExpectedCalls = nil
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket2))
+
+	_, err2 := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailErrOrder() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber: "111",
+			UserId:       "id",
+		},
+		Error: nil,
+	}
+	mockOrder := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder))
+
+	_, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+
+	mockOrder2 := helpers.Result{
+		Data:  &entity.Country{},
+		Error: nil,
+	}
+	suite.mockOrderRepositoryQuery.ExpectedCalls = nil
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder2))
+
+	_, err2 := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindOrderDetailErrEvent() {
+	payload := request.GetOrderReq{
+		TicketNumber: "111",
+		UserId:       "id",
+	}
+	mockBankTicket := helpers.Result{
+		Data: &entity.BankTicket{
+			TicketNumber: "111",
+			EventId:      "event",
+			UserId:       "id",
+		},
+		Error: nil,
+	}
+	mockOrder := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+	mockEvent := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOrderRepositoryQuery.On("FindBankTicketByTicketNumber", mock.Anything, "111").Return(mockChannel(mockBankTicket))
+	suite.mockOrderRepositoryQuery.On("FindOrderByTicketNumber", mock.Anything, "111").Return(mockChannel(mockOrder))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "event").Return(mockChannel(mockEvent))
+
+	_, err := suite.usecase.FindOrderDetail(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,377 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	eventEntity "order-service/internal/modules/event/models/entity"
+	"order-service/internal/modules/order/models/entity"
+	"order-service/internal/modules/order/models/request"
+	"order-service/internal/modules/order/models/response"
+	ticketEntity "order-service/internal/modules/ticket/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/redis"
+	"strconv"
+	"time"
+
+	"go.elastic.co/apm"
+)
+
+const defaultSeatHoldTTL = 300
+
+// seatHoldTTL is how long a picked seat stays reserved for the user before it is offered to others again.
+func seatHoldTTL() time.Duration {
+	seconds, err := strconv.Atoi(Configs().Order.SeatHoldTTL)
+	if err != nil || seconds <= 0 {
+		seconds = defaultSeatHoldTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// seatHoldKey is the redis lock of one seat, its value is the user holding it. The locks of an event share
+// a hash tag so MGet and Del over several seats stay on one cluster slot.
+func seatHoldKey(eventId string, ticketType string, seatNumber int) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%d", constants.ORDER, constants.RedisKeySeatHold, redis.HashTag(eventId), ticketType, seatNumber)
+}
+
+func seatHoldKeys(eventId string, ticketType string, seatNumbers []int) []string {
+	keys := make([]string, 0, len(seatNumbers))
+	for _, seatNumber := range seatNumbers {
+		keys = append(keys, seatHoldKey(eventId, ticketType, seatNumber))
+	}
+	return keys
+}
+
+// userSeatHoldsKey is a hash of the seats the user holds for the event, "<ticketType>:<seat>" to the unix ms the
+// hold runs out, so the holds of a user can be counted against the purchase limit.
+func userSeatHoldsKey(eventId string, userId string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.RedisKeySeatHolds, redis.HashTag(eventId), userId)
+}
+
+// seatHoldScriptKeys returns the holds of the user and the locks of the seats as script keys, and args followed
+// by the hold field of every seat.
+func seatHoldScriptKeys(eventId string, userId string, ticketType string, seatNumbers []int, args ...interface{}) ([]string, []interface{}) {
+	keys := append([]string{userSeatHoldsKey(eventId, userId)}, seatHoldKeys(eventId, ticketType, seatNumbers)...)
+	for _, seatNumber := range seatNumbers {
+		args = append(args, fmt.Sprintf("%s:%d", ticketType, seatNumber))
+	}
+	return keys, args
+}
+
+// holdSeatsScript locks the seats for the user in one step, so two users cannot both take a seat and a refresh
+// cannot overwrite a hold someone else took in between. It answers 0 when the seats are held, the 1-based position
+// of a seat held by someone else, -1 when the holds of the user would go over the user limit and -2 over the limit
+// of the ticket type. Expired holds are dropped from the hash of the user first.
+var holdSeatsScript = redis.NewScript(`
+local user, now, ttl = ARGV[1], tonumber(ARGV[2]), tonumber(ARGV[3])
+local prefix = ARGV[4] .. ':'
+local held, total, ofType = {}, 0, 0
+local holds = redis.call('HGETALL', KEYS[1])
+for i = 1, #holds, 2 do
+	if tonumber(holds[i + 1]) > now then
+		held[holds[i]] = true
+		total = total + 1
+		if string.sub(holds[i], 1, #prefix) == prefix then
+			ofType = ofType + 1
+		end
+	else
+		redis.call('HDEL', KEYS[1], holds[i])
+	end
+end
+for i = 2, #KEYS do
+	local holder = redis.call('GET', KEYS[i])
+	if holder and holder ~= user then
+		return i - 1
+	end
+	if not held[ARGV[i + 5]] then
+		total = total + 1
+		ofType = ofType + 1
+	end
+end
+if total > tonumber(ARGV[5]) then
+	return -1
+end
+if ofType > tonumber(ARGV[6]) then
+	return -2
+end
+for i = 2, #KEYS do
+	redis.call('SET', KEYS[i], user, 'PX', ttl)
+	redis.call('HSET', KEYS[1], ARGV[i + 5], now + ttl)
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+return 0
+`)
+
+// releaseSeatsScript drops the seat locks the user still holds and takes them off the holds of the user.
+var releaseSeatsScript = redis.NewScript(`
+for i = 2, #KEYS do
+	if redis.call('GET', KEYS[i]) == ARGV[1] then
+		redis.call('DEL', KEYS[i])
+	end
+	redis.call('HDEL', KEYS[1], ARGV[i])
+end
+return 0
+`)
+
+func (q queryUsecase) FindFreeSeats(origCtx context.Context, payload request.SeatListReq) (*response.SeatListResp, error) {
+	domain := "orderUsecase-FindFreeSeats"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	if _, err := q.reservedSeating(ctx, payload.EventId, payload.TicketType); err != nil {
+		return nil, err
+	}
+
+	bankTicketData := <-q.orderRepositoryQuery.FindFreeSeats(ctx, request.FreeSeatReq{
+		EventId:    payload.EventId,
+		TicketType: payload.TicketType,
+	})
+	if bankTicketData.Error != nil {
+		msg := "Error DB connection FindFreeSeats"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+		return nil, bankTicketData.Error
+	}
+
+	result := response.SeatListResp{
+		EventId:     payload.EventId,
+		TicketType:  payload.TicketType,
+		SeatNumbers: make([]int, 0),
+	}
+	if bankTicketData.Data == nil {
+		return &result, nil
+	}
+
+	bankTickets, ok := bankTicketData.Data.(*[]entity.BankTicket)
+	if !ok {
+		msg := "cannot parsing data bank ticket"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+		return nil, errors.InternalServerError("cannot parsing data bank ticket")
+	}
+
+	if len(*bankTickets) == 0 {
+		return &result, nil
+	}
+
+	seatNumbers := make([]int, 0, len(*bankTickets))
+	for _, bankTicket := range *bankTickets {
+		seatNumbers = append(seatNumbers, bankTicket.SeatNumber)
+	}
+
+	// unclaimed seats can still be held by someone who has not ordered yet
+	holders, err := q.redis.MGet(ctx, seatHoldKeys(payload.EventId, payload.TicketType, seatNumbers)...).Result()
+	if err != nil {
+		msg := "cannot get seat holds"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return nil, errors.InternalServerError("cannot get seat holds")
+	}
+
+	for i, seatNumber := range seatNumbers {
+		if i < len(holders) && holders[i] != nil {
+			continue
+		}
+		result.SeatNumbers = append(result.SeatNumbers, seatNumber)
+	}
+
+	return &result, nil
+}
+
+// reservedSeating loads the ticket category and makes sure it is sold by seat number.
+func (q queryUsecase) reservedSeating(ctx context.Context, eventId string, ticketType string) (*ticketEntity.Ticket, error) {
+	ticketDetailData := <-q.ticketRepositoryQuery.FindTicketByEventId(ctx, eventId, ticketType)
+	if ticketDetailData.Error != nil {
+		msg := "Error DB connection FindTicketByEventId"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Error))
+		return nil, ticketDetailData.Error
+	}
+
+	if ticketDetailData.Data == nil {
+		msg := "ticket detail not found"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%s %s", eventId, ticketType))
+		return nil, errors.NotFound("ticket detail not found")
+	}
+
+	ticketDetail, ok := ticketDetailData.Data.(*ticketEntity.Ticket)
+	if !ok {
+		msg := "cannot parsing data ticket"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Data))
+		return nil, errors.InternalServerError("cannot parsing data ticket")
+	}
+
+	if !ticketDetail.ReservedSeating {
+		msg := "ticket category has no reserved seating"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%s %s", eventId, ticketType))
+		return nil, errors.BadRequest("ticket category has no reserved seating")
+	}
+
+	return ticketDetail, nil
+}
+
+// HoldSeats locks the picked seats for the user until the hold ttl runs out. The seats are held all or nothing,
+// a seat that is sold or held by someone else fails the request. Seats the user already holds count against the
+// purchase limit, holding one of them again restarts its ttl.
+func (c commandUsecase) HoldSeats(origCtx context.Context, payload request.HoldSeatReq) (*response.HoldSeatResp, error) {
+	domain := "orderUsecase-HoldSeats"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	eventData := <-c.eventRepositoryQuery.FindEventById(ctx, payload.EventId)
+	if eventData.Error != nil {
+		msg := "Error DB connection FindEventById"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Error))
+		return nil, eventData.Error
+	}
+
+	if eventData.Data == nil {
+		msg := "event not found"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.NotFound("event not found")
+	}
+
+	event, ok := eventData.Data.(*eventEntity.Event)
+	if !ok {
+		msg := "cannot parsing data event"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Data))
+		return nil, errors.InternalServerError("cannot parsing data event")
+	}
+
+	// nobody may hold more seats than they are allowed to buy
+	userLimit := maxTicketsPerUser(*event)
+	limit := userLimit
+	if typeLimit := event.Order.MaxTicketsPerType[payload.TicketType]; typeLimit > 0 && typeLimit < limit {
+		limit = typeLimit
+	}
+	if len(payload.SeatNumbers) > limit {
+		msg := "purchase limit exceeded"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d %s tickets per user", limit, payload.TicketType))
+	}
+
+	ticketDetailData := <-c.ticketRepositoryQuery.FindTicketByEventId(ctx, event.EventId, payload.TicketType)
+	if ticketDetailData.Error != nil {
+		msg := "Error DB connection FindTicketByEventId"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Error))
+		return nil, ticketDetailData.Error
+	}
+
+	if ticketDetailData.Data == nil {
+		msg := "ticket detail not found"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.NotFound("ticket detail not found")
+	}
+
+	ticketDetail, ok := ticketDetailData.Data.(*ticketEntity.Ticket)
+	if !ok {
+		msg := "cannot parsing data ticket"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketDetailData.Data))
+		return nil, errors.InternalServerError("cannot parsing data ticket")
+	}
+
+	if !ticketDetail.ReservedSeating {
+		msg := "ticket category has no reserved seating"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.BadRequest("ticket category has no reserved seating")
+	}
+
+	bankTicketData := <-c.orderRepositoryQuery.FindFreeSeats(ctx, request.FreeSeatReq{
+		EventId:     event.EventId,
+		TicketType:  payload.TicketType,
+		SeatNumbers: payload.SeatNumbers,
+	})
+	if bankTicketData.Error != nil {
+		msg := "Error DB connection FindFreeSeats"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Error))
+		return nil, bankTicketData.Error
+	}
+
+	free := make(map[int]bool, len(payload.SeatNumbers))
+	if bankTicketData.Data != nil {
+		bankTickets, ok := bankTicketData.Data.(*[]entity.BankTicket)
+		if !ok {
+			msg := "cannot parsing data bank ticket"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", bankTicketData.Data))
+			return nil, errors.InternalServerError("cannot parsing data bank ticket")
+		}
+		for _, bankTicket := range *bankTickets {
+			free[bankTicket.SeatNumber] = true
+		}
+	}
+
+	for _, seatNumber := range payload.SeatNumbers {
+		if !free[seatNumber] {
+			msg := "seat is not available"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			return nil, errors.Conflict(fmt.Sprintf("seat %d is not available", seatNumber))
+		}
+	}
+
+	ttl := seatHoldTTL()
+	now := Now()
+	keys, args := seatHoldScriptKeys(event.EventId, payload.UserId, payload.TicketType, payload.SeatNumbers,
+		payload.UserId, now.UnixMilli(), ttl.Milliseconds(), payload.TicketType, userLimit, limit)
+	held, err := holdSeatsScript.Run(ctx, c.redis, keys, args...).Int()
+	if err != nil {
+		msg := "cannot hold seat"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return nil, errors.InternalServerError("cannot hold seat")
+	}
+
+	switch {
+	case held == -1:
+		msg := "purchase limit exceeded"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d tickets per user", userLimit))
+	case held == -2:
+		msg := "purchase limit exceeded"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.BadRequest(fmt.Sprintf("purchase limit exceeded, at most %d %s tickets per user", limit, payload.TicketType))
+	case held > 0:
+		msg := "seat is already held"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.Conflict(fmt.Sprintf("seat %d is already held", payload.SeatNumbers[held-1]))
+	}
+
+	return &response.HoldSeatResp{
+		EventId:     event.EventId,
+		TicketType:  payload.TicketType,
+		SeatNumbers: payload.SeatNumbers,
+		ExpiredAt:   now.Add(ttl),
+	}, nil
+}
+
+// checkSeatHolds makes sure every seat of the item is currently held by the user.
+func (c commandUsecase) checkSeatHolds(ctx context.Context, userId string, eventId string, item request.OrderItemReq) error {
+	holders, err := c.redis.MGet(ctx, seatHoldKeys(eventId, item.TicketType, item.SeatNumbers)...).Result()
+	if err != nil {
+		msg := "cannot get seat holds"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot get seat holds")
+	}
+
+	for i, seatNumber := range item.SeatNumbers {
+		if i >= len(holders) || holders[i] != userId {
+			msg := "seat is not held by user"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", item))
+			return errors.Conflict(fmt.Sprintf("seat %d is not held by user", seatNumber))
+		}
+	}
+	return nil
+}
+
+// releaseSeatHolds drops the seat locks of the user, the ttl cleans them up anyway when this fails.
+func (c commandUsecase) releaseSeatHolds(ctx context.Context, userId string, eventId string, ticketType string, seatNumbers []int) {
+	if len(seatNumbers) == 0 {
+		return
+	}
+
+	keys, args := seatHoldScriptKeys(eventId, userId, ticketType, seatNumbers, userId)
+	if err := releaseSeatsScript.Run(ctx, c.redis, keys, args...).Err(); err != nil {
+		msg := "cannot release seat holds"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+	}
+}
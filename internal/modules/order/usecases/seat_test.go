@@ -0,0 +1,365 @@
+package usecases_test
+
+import (
+	eventEntity "order-service/internal/modules/event/models/entity"
+	"order-service/internal/modules/order/models/entity"
+	"order-service/internal/modules/order/models/request"
+	ticketEntity "order-service/internal/modules/ticket/models/entity"
+	userEntity "order-service/internal/modules/user/models/entity"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *CommandUsecaseTestSuite) mockSeatHold(reserved bool, freeSeats ...int) {
+	bankTickets := make([]entity.BankTicket, 0, len(freeSeats))
+	for _, seat := range freeSeats {
+		bankTickets = append(bankTickets, entity.BankTicket{SeatNumber: seat})
+	}
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{EventId: "id", Order: eventEntity.OrderSetting{MaxTicketsPerUser: 4}},
+	}))
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{TicketId: "ticket", ReservedSeating: reserved, TotalRemaining: 10},
+	}))
+	suite.mockOrderRepositoryQuery.On("FindFreeSeats", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &bankTickets,
+	}))
+}
+
+var holdSeatKeys = []string{"ORDER:SEAT-HOLDS:{id}:user", "ORDER:SEAT-HOLD:{id}:VIP:11", "ORDER:SEAT-HOLD:{id}:VIP:12"}
+
+// mockHoldSeatsScript answers the hold script of seats 11 and 12 held by user within the given limits.
+func (suite *CommandUsecaseTestSuite) mockHoldSeatsScript(userLimit int, typeLimit int, result *redis.Cmd) {
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, holdSeatKeys,
+		"user", mock.Anything, mock.Anything, "VIP", userLimit, typeLimit, "VIP:11", "VIP:12").Return(result)
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeats() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockSeatHold(true, 11, 12)
+	suite.mockHoldSeatsScript(4, 4, redis.NewCmdResult(int64(0), nil))
+
+	result, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []int{11, 12}, result.SeatNumbers)
+	assert.False(suite.T(), result.ExpiredAt.IsZero())
+	suite.mockRedis.AssertCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, holdSeatKeys,
+		"user", mock.Anything, int64(300000), "VIP", 4, 4, "VIP:11", "VIP:12")
+	suite.mockOrderRepositoryQuery.AssertCalled(suite.T(), "FindFreeSeats", mock.Anything, request.FreeSeatReq{
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	})
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsAlreadyHeld() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(true, 11, 12)
+	suite.mockHoldSeatsScript(4, 4, redis.NewCmdResult(int64(2), nil))
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "seat 12 is already held", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsOverUserLimitWithHolds() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(true, 11, 12)
+	// the user already holds three seats of the event
+	suite.mockHoldSeatsScript(4, 4, redis.NewCmdResult(int64(-1), nil))
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "purchase limit exceeded, at most 4 tickets per user", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsOverTypeLimitWithHolds() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{EventId: "id", Order: eventEntity.OrderSetting{
+			MaxTicketsPerUser: 4,
+			MaxTicketsPerType: map[string]int{"VIP": 2},
+		}},
+	}))
+	suite.mockSeatHold(true, 11, 12)
+	suite.mockHoldSeatsScript(4, 2, redis.NewCmdResult(int64(-2), nil))
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "purchase limit exceeded, at most 2 VIP tickets per user", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsErrRedis() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(true, 11, 12)
+	suite.mockHoldSeatsScript(4, 4, redis.NewCmdResult(nil, errors.InternalServerError("error")))
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "cannot hold seat", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsSold() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(true, 11)
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "seat 12 is not available", err.Error())
+	suite.mockRedis.AssertNotCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsNotReserved() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(false, 11)
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "ticket category has no reserved seating", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsOverLimit() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12, 13, 14, 15},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatHold(true, 11, 12, 13, 14, 15)
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestHoldSeatsErrEventNil() {
+	payload := request.HoldSeatReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.usecase.HoldSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) mockSeatOrder() {
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{EventId: "id", Order: eventEntity.OrderSetting{MaxTicketsPerUser: 4}},
+	}))
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{TicketId: "ticket", TicketPrice: 50, ReservedSeating: true, TotalRemaining: 10},
+	}))
+	suite.mockUserRepositoryQuery.On("FindOneUserId", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &userEntity.User{},
+	}))
+	suite.mockOrderRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOrderRepositoryCommand.On("ReservePurchaseQuota", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &entity.PurchaseQuota{}}))
+	suite.mockOrderRepositoryCommand.On("ClaimBankTickets", mock.Anything, mock.Anything).Return(mockClaimedBankTickets)
+	suite.mockTicketRepositoryCommand.On("DecrementTicketDetail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{TicketId: "ticket"},
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSeats() {
+	payload := request.OrderReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockSeatOrder()
+	suite.mockRedis.On("MGet", mock.Anything, "ORDER:SEAT-HOLD:{id}:VIP:11", "ORDER:SEAT-HOLD:{id}:VIP:12").Return(redis.NewSliceResult([]interface{}{"user", "user"}, nil))
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, holdSeatKeys, "user", "VIP:11", "VIP:12").Return(redis.NewCmdResult(int64(0), nil))
+
+	result, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result.Tickets, 2)
+	suite.mockOrderRepositoryCommand.AssertCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.MatchedBy(func(req request.ClaimBankTicketsReq) bool {
+		return req.Quantity == 2 && assert.ObjectsAreEqual([]int{11, 12}, req.SeatNumbers)
+	}))
+	suite.mockRedis.AssertCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, holdSeatKeys, "user", "VIP:11", "VIP:12")
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSeatNotHeld() {
+	payload := request.OrderReq{
+		UserId:      "user",
+		EventId:     "id",
+		TicketType:  "VIP",
+		SeatNumbers: []int{11, 12},
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatOrder()
+	// the hold of seat 12 ran out and another user picked it up
+	suite.mockRedis.On("MGet", mock.Anything, mock.Anything, mock.Anything).Return(redis.NewSliceResult([]interface{}{"user", "other"}, nil))
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "seat 12 is not held by user", err.Error())
+	suite.mockOrderRepositoryCommand.AssertNotCalled(suite.T(), "ClaimBankTickets", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateOrderTicketSeatsRequired() {
+	payload := request.OrderReq{
+		UserId:     "user",
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockSeatOrder()
+
+	_, err := suite.usecase.CreateOrderTicket(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "seat numbers are required for reserved seating", err.Error())
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindFreeSeats() {
+	payload := request.SeatListReq{
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, "id", "VIP").Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{ReservedSeating: true},
+	}))
+	suite.mockOrderRepositoryQuery.On("FindFreeSeats", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &[]entity.BankTicket{{SeatNumber: 11}, {SeatNumber: 12}, {SeatNumber: 13}},
+	}))
+	suite.mockRedis.On("MGet", mock.Anything, "ORDER:SEAT-HOLD:{id}:VIP:11", "ORDER:SEAT-HOLD:{id}:VIP:12", "ORDER:SEAT-HOLD:{id}:VIP:13").
+		Return(redis.NewSliceResult([]interface{}{nil, "user", nil}, nil))
+
+	result, err := suite.usecase.FindFreeSeats(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []int{11, 13}, result.SeatNumbers)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindFreeSeatsSoldOut() {
+	payload := request.SeatListReq{
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{ReservedSeating: true},
+	}))
+	suite.mockOrderRepositoryQuery.On("FindFreeSeats", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &[]entity.BankTicket{},
+	}))
+
+	result, err := suite.usecase.FindFreeSeats(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), result.SeatNumbers)
+	suite.mockRedis.AssertNotCalled(suite.T(), "MGet", mock.Anything)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindFreeSeatsNotReserved() {
+	payload := request.SeatListReq{
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{},
+	}))
+
+	_, err := suite.usecase.FindFreeSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockOrderRepositoryQuery.AssertNotCalled(suite.T(), "FindFreeSeats", mock.Anything, mock.Anything)
+}
+
+func (suite *QueryUsecaseTestSuite) TestFindFreeSeatsErrRedis() {
+	payload := request.SeatListReq{
+		EventId:    "id",
+		TicketType: "VIP",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockTicketRepositoryQuery.On("FindTicketByEventId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &ticketEntity.Ticket{ReservedSeating: true},
+	}))
+	suite.mockOrderRepositoryQuery.On("FindFreeSeats", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &[]entity.BankTicket{{SeatNumber: 11}},
+	}))
+	suite.mockRedis.On("MGet", mock.Anything, mock.Anything).Return(redis.NewSliceResult(nil, errors.InternalServerError("error")))
+
+	_, err := suite.usecase.FindFreeSeats(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"fmt"
+	"order-service/internal/modules/order/models/entity"
+	"order-service/internal/pkg/errors"
+)
+
+// actors recorded in the status history for changes the ticket holder did not make
+const (
+	actorOrderService   = "order-service"
+	actorExpiryWorker   = "expiry-worker"
+	actorPaymentGateway = "payment-gateway"
+)
+
+// orderTransitions lists the statuses each status may move to, any other move is rejected.
+var orderTransitions = map[entity.OrderStatus][]entity.OrderStatus{
+	entity.StatusNone:           {entity.StatusHeld},
+	entity.StatusHeld:           {entity.StatusPendingPayment, entity.StatusPaid, entity.StatusExpired, entity.StatusCancelled},
+	entity.StatusPendingPayment: {entity.StatusPaid, entity.StatusExpired, entity.StatusCancelled},
+	entity.StatusPending:        {entity.StatusPendingPayment, entity.StatusPaid, entity.StatusExpired, entity.StatusCancelled},
+	entity.StatusPaid:           {entity.StatusCheckedIn, entity.StatusRefunded},
+}
+
+// transition is the only way an order changes status. It returns the history entry the repository
+// persists together with the new status, the repository filters on the from status so a concurrent
+// change makes the update match nothing.
+func transition(from entity.OrderStatus, to entity.OrderStatus, by string) (entity.StatusChange, error) {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return entity.StatusChange{
+				From: from,
+				To:   to,
+				By:   by,
+				At:   Now(),
+			}, nil
+		}
+	}
+	return entity.StatusChange{}, errors.Conflict(fmt.Sprintf("order cannot move from %s to %s", from, to))
+}
+
+// awaitingPayment reports whether the order still holds its ticket until the hold expires.
+func awaitingPayment(status entity.OrderStatus) bool {
+	for _, held := range entity.AwaitingPayment {
+		if status == held {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/worker"
+	"time"
+)
+
+// RelayWorker periodically publishes the pending outbox events.
+type RelayWorker struct {
+	Relay  outbox.Relay
+	Logger log.Logger
+
+	*worker.Worker
+}
+
+func InitRelayWorker(relay outbox.Relay, log log.Logger, interval time.Duration) *RelayWorker {
+	relayWorker := &RelayWorker{
+		Relay:  relay,
+		Logger: log,
+	}
+	relayWorker.Worker = worker.Start(interval, relayWorker.relay)
+
+	return relayWorker
+}
+
+func (w *RelayWorker) relay(ctx context.Context) {
+	total, err := w.Relay.RelayPending(ctx)
+	if err != nil {
+		w.Logger.Error(ctx, "Outbox relay failed", fmt.Sprintf("%+v", err))
+		return
+	}
+
+	if total > 0 {
+		w.Logger.Info(ctx, "Outbox relay published events", fmt.Sprintf("%d", total))
+	}
+}
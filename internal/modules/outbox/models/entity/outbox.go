@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// EventStatus tells whether the relay still has to publish an outbox event.
+type EventStatus string
+
+const (
+	StatusPending   EventStatus = "pending"
+	StatusDelivered EventStatus = "delivered"
+	// StatusFailed is an event the relay gave up on after too many attempts, it is left for an operator
+	StatusFailed EventStatus = "failed"
+)
+
+// OutboxEvent is a domain event stored together with the state change it describes. Payload is the JSON
+// message published on Topic, Key is the kafka message key. SchemaVersion and TraceId travel as message headers,
+// TraceId is the trace of the request that wrote the event. A failed publish is retried at NextAttemptAt. The relay
+// instance publishing the event holds it as LockedBy until LockedUntil, so other instances leave it alone.
+type OutboxEvent struct {
+	EventId       string      `json:"eventId" bson:"eventId"`
+	Type          string      `json:"type" bson:"type"`
+	Topic         string      `json:"topic" bson:"topic"`
+	Key           string      `json:"key" bson:"key"`
+	Payload       string      `json:"payload" bson:"payload"`
+	SchemaVersion int         `json:"schemaVersion" bson:"schemaVersion"`
+	TraceId       string      `json:"traceId" bson:"traceId,omitempty"`
+	Status        EventStatus `json:"status" bson:"status"`
+	Attempts      int         `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time   `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	LastError     string      `json:"lastError" bson:"lastError"`
+	LockedBy      string      `json:"lockedBy" bson:"lockedBy"`
+	LockedUntil   time.Time   `json:"lockedUntil" bson:"lockedUntil"`
+	CreatedAt     time.Time   `json:"createdAt" bson:"createdAt"`
+	DeliveredAt   time.Time   `json:"deliveredAt" bson:"deliveredAt,omitempty"`
+}
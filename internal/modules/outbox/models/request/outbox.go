@@ -0,0 +1,42 @@
+package request
+
+import (
+	"order-service/internal/modules/outbox/models/entity"
+	"time"
+)
+
+// OutboxEventReq is a domain event to publish on Topic with Key, Payload is marshalled to JSON and has to
+// match the schema of Type.
+type OutboxEventReq struct {
+	Type    string      `json:"type"`
+	Topic   string      `json:"topic"`
+	Key     string      `json:"key"`
+	Payload interface{} `json:"payload"`
+}
+
+// DeliveredEventReq marks an event delivered by the relay LockedBy, as long as its claim still holds at DeliveredAt.
+type DeliveredEventReq struct {
+	EventId     string    `json:"eventId"`
+	LockedBy    string    `json:"lockedBy"`
+	DeliveredAt time.Time `json:"deliveredAt"`
+}
+
+// FailedEventReq records a failed publish by the relay LockedBy and when the event may be tried again. Status is
+// pending while the event has attempts left and failed once it has not.
+type FailedEventReq struct {
+	EventId       string             `json:"eventId"`
+	LockedBy      string             `json:"lockedBy"`
+	Status        entity.EventStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt"`
+	LastError     string             `json:"lastError"`
+}
+
+// ClaimEventReq takes the oldest event that is due at Now and not held by another relay, it is held as LockedBy
+// until LockedUntil. Events of ExcludedKeys are skipped.
+type ClaimEventReq struct {
+	LockedBy     string    `json:"lockedBy"`
+	Now          time.Time `json:"now"`
+	LockedUntil  time.Time `json:"lockedUntil"`
+	ExcludedKeys []string  `json:"excludedKeys"`
+}
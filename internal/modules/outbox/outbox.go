@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"context"
+	"order-service/internal/modules/outbox/models/request"
+	wrapper "order-service/internal/pkg/helpers"
+	"time"
+)
+
+// Relay publishes the pending outbox events to kafka and marks them delivered. Several instances may relay at once,
+// each event is claimed by one of them.
+type Relay interface {
+	RelayPending(ctx context.Context) (int, error)
+}
+
+type MongodbRepositoryQuery interface {
+	FindEarlierPendingEvent(ctx context.Context, key string, createdAt time.Time) <-chan wrapper.Result
+}
+
+// MongodbRepositoryCommand writes the outbox. InsertOutboxEvents is meant to be called with the session context
+// of the transaction that changes the state, so the events are stored if and only if the change is.
+type MongodbRepositoryCommand interface {
+	InsertOutboxEvents(ctx context.Context, events []request.OutboxEventReq) <-chan wrapper.Result
+	MarkDelivered(ctx context.Context, payload request.DeliveredEventReq) <-chan wrapper.Result
+	MarkFailed(ctx context.Context, payload request.FailedEventReq) <-chan wrapper.Result
+	ClaimPendingEvent(ctx context.Context, payload request.ClaimEventReq) <-chan wrapper.Result
+	ReleaseClaim(ctx context.Context, eventId string, lockedBy string) <-chan wrapper.Result
+	CreateOutboxIndexes(ctx context.Context) <-chan wrapper.Result
+}
@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	"order-service/internal/modules/outbox/models/request"
+	"order-service/internal/pkg/apm"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/eventschema"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	indexOutboxEventId = "eventId_unique"
+	indexOutboxPending = "status_createdAt"
+	indexOutboxKey     = "key_status_createdAt"
+)
+
+type commandMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewCommandMongodbRepository(mongodb mongodb.Collections, log log.Logger) outbox.MongodbRepositoryCommand {
+	return &commandMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+// InsertOutboxEvents stores the events as pending in one bulk write, called with a session context the
+// write joins the transaction of the caller. The events keep the trace id of ctx, a payload that does not match
+// the schema of its event type fails the whole write.
+func (c commandMongodbRepository) InsertOutboxEvents(ctx context.Context, events []request.OutboxEventReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+		now := time.Now()
+		traceId := apm.TraceId(ctx)
+
+		outboxEvents := make([]entity.OutboxEvent, 0, len(events))
+		models := make([]mongo.WriteModel, 0, len(events))
+		for _, event := range events {
+			payload, err := json.Marshal(event.Payload)
+			if err != nil {
+				msg := "cannot marshal outbox payload"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+				output <- wrapper.Result{Error: errors.InternalServerError(msg)}
+				return
+			}
+
+			definition, ok := eventschema.Lookup(event.Type)
+			if !ok {
+				msg := "unknown outbox event type"
+				c.logger.Error(ctx, msg, event.Type)
+				output <- wrapper.Result{Error: errors.InternalServerError(msg)}
+				return
+			}
+			if err := eventschema.Validate(event.Type, payload); err != nil {
+				msg := "outbox payload does not match its schema"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+				output <- wrapper.Result{Error: errors.InternalServerError(msg)}
+				return
+			}
+
+			outboxEvent := entity.OutboxEvent{
+				EventId:       uuid.NewString(),
+				Type:          event.Type,
+				Topic:         event.Topic,
+				Key:           event.Key,
+				Payload:       string(payload),
+				SchemaVersion: definition.Version,
+				TraceId:       traceId,
+				Status:        entity.StatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			}
+			outboxEvents = append(outboxEvents, outboxEvent)
+			models = append(models, mongo.NewInsertOneModel().SetDocument(outboxEvent))
+		}
+
+		if len(models) == 0 {
+			output <- wrapper.Result{Data: &outboxEvents}
+			return
+		}
+
+		resp := <-c.mongoDb.BulkWrite(mongodb.BulkWrite{
+			CollectionName: "outbox",
+			Models:         models,
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		output <- wrapper.Result{Data: &outboxEvents}
+	}()
+
+	return output
+}
+
+// MarkDelivered settles the event only while the relay still holds its claim, like the claim itself. A relay whose
+// claim ran out leaves the event to the relay that took it over, which publishes it again.
+func (c commandMongodbRepository) MarkDelivered(ctx context.Context, payload request.DeliveredEventReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.UpdateOne(mongodb.UpdateOne{
+			CollectionName: "outbox",
+			Filter: bson.M{
+				"eventId":     payload.EventId,
+				"lockedBy":    payload.LockedBy,
+				"lockedUntil": bson.M{"$gt": payload.DeliveredAt},
+			},
+			Document: bson.M{
+				"status":      entity.StatusDelivered,
+				"deliveredAt": payload.DeliveredAt,
+				"lastError":   "",
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// MarkFailed records the failed attempt and gives up the claim, the event stays pending for its next attempt
+// unless payload parks it as failed. An event claimed by another relay meanwhile is left to that relay.
+func (c commandMongodbRepository) MarkFailed(ctx context.Context, payload request.FailedEventReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.UpdateOne(mongodb.UpdateOne{
+			CollectionName: "outbox",
+			Filter: bson.M{
+				"eventId":  payload.EventId,
+				"lockedBy": payload.LockedBy,
+			},
+			Document: bson.M{
+				"status":        payload.Status,
+				"attempts":      payload.Attempts,
+				"nextAttemptAt": payload.NextAttemptAt,
+				"lastError":     payload.LastError,
+				"lockedBy":      "",
+				"lockedUntil":   time.Time{},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// ClaimPendingEvent holds the oldest due event for the relay in one findAndModify, so two relays never claim the
+// same event. An event whose claim ran out is taken over, nil Data means nothing is due.
+func (c commandMongodbRepository) ClaimPendingEvent(ctx context.Context, payload request.ClaimEventReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var event entity.OutboxEvent
+
+	go func() {
+		filter := bson.M{
+			"status":        entity.StatusPending,
+			"nextAttemptAt": bson.M{"$lte": payload.Now},
+			"$or": []bson.M{
+				{"lockedUntil": bson.M{"$lte": payload.Now}},
+				// written before events were claimed
+				{"lockedUntil": bson.M{"$exists": false}},
+			},
+		}
+		if len(payload.ExcludedKeys) > 0 {
+			filter["key"] = bson.M{"$nin": payload.ExcludedKeys}
+		}
+
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "outbox",
+			Result:         &event,
+			Filter:         filter,
+			Update: bson.M{
+				"$set": bson.M{
+					"lockedBy":    payload.LockedBy,
+					"lockedUntil": payload.LockedUntil,
+				},
+			},
+			Sort: &mongodb.Sort{
+				FieldName: "createdAt",
+				By:        mongodb.SortAscending,
+			},
+		}, options.After, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// ReleaseClaim gives a claimed event back so any relay may take it on its next run.
+func (c commandMongodbRepository) ReleaseClaim(ctx context.Context, eventId string, lockedBy string) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.UpdateOne(mongodb.UpdateOne{
+			CollectionName: "outbox",
+			Filter: bson.M{
+				"eventId":  eventId,
+				"lockedBy": lockedBy,
+			},
+			Document: bson.M{
+				"lockedBy":    "",
+				"lockedUntil": time.Time{},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// CreateOutboxIndexes keeps event ids unique and serves the relay, which claims the oldest pending events first
+// and looks up the earlier pending events of a key.
+func (c commandMongodbRepository) CreateOutboxIndexes(ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "outbox",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "eventId", Value: 1}},
+					Options: options.Index().SetName(indexOutboxEventId).SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName(indexOutboxPending),
+				},
+				{
+					Keys:    bson.D{{Key: "key", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName(indexOutboxKey),
+				},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
@@ -0,0 +1,252 @@
+package commands_test
+
+import (
+	"context"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	"order-service/internal/modules/outbox/models/request"
+	mongoRC "order-service/internal/modules/outbox/repositories/commands"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/eventschema"
+	"order-service/internal/pkg/helpers"
+	mocks "order-service/mocks/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	mockMongodb *mocks.Collections
+	mockLogger  *mocklog.Logger
+	repository  outbox.MongodbRepositoryCommand
+	ctx         context.Context
+}
+
+func (suite *CommandTestSuite) SetupTest() {
+	suite.mockMongodb = new(mocks.Collections)
+	suite.mockLogger = &mocklog.Logger{}
+	suite.repository = mongoRC.NewCommandMongodbRepository(
+		suite.mockMongodb,
+		suite.mockLogger,
+	)
+	suite.ctx = context.Background()
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (suite *CommandTestSuite) TestInsertOutboxEvents() {
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("BulkWrite", mock.MatchedBy(func(payload mongodb.BulkWrite) bool {
+		if payload.CollectionName != "outbox" || len(payload.Models) != 2 {
+			return false
+		}
+		model, ok := payload.Models[0].(*mongo.InsertOneModel)
+		if !ok {
+			return false
+		}
+		event, ok := model.Document.(entity.OutboxEvent)
+		return ok && event.EventId != "" && event.Status == entity.StatusPending && event.Key == "ticket-1" &&
+			strings.Contains(event.Payload, `"ticketNumber":"ticket-1"`) && event.SchemaVersion == 1
+	}), mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	result := suite.repository.InsertOutboxEvents(suite.ctx, []request.OutboxEventReq{
+		{Type: constants.EventOrderHeld, Topic: constants.TopicOrderHeld, Key: "ticket-1", Payload: eventschema.OrderHeld{TicketNumber: "ticket-1"}},
+		{Type: constants.EventOrderHeld, Topic: constants.TopicOrderHeld, Key: "ticket-2", Payload: eventschema.OrderHeld{TicketNumber: "ticket-2"}},
+	})
+
+	go func() {
+		expectedResult <- helpers.Result{Data: &mongo.BulkWriteResult{InsertedCount: 2}}
+		close(expectedResult)
+	}()
+
+	resp := <-result
+	assert.NoError(suite.T(), resp.Error)
+	events, ok := resp.Data.(*[]entity.OutboxEvent)
+	assert.True(suite.T(), ok)
+	assert.Len(suite.T(), *events, 2)
+	assert.NotEqual(suite.T(), (*events)[0].EventId, (*events)[1].EventId)
+}
+
+func (suite *CommandTestSuite) TestInsertOutboxEventsErrMarshal() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+
+	resp := <-suite.repository.InsertOutboxEvents(suite.ctx, []request.OutboxEventReq{
+		{Type: constants.EventOrderHeld, Topic: constants.TopicOrderHeld, Key: "ticket-1", Payload: make(chan int)},
+	})
+
+	assert.Error(suite.T(), resp.Error)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "BulkWrite", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestInsertOutboxEventsErrUnknownType() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+
+	resp := <-suite.repository.InsertOutboxEvents(suite.ctx, []request.OutboxEventReq{
+		{Type: "OrderShipped", Topic: "order-shipped", Key: "ticket-1", Payload: eventschema.OrderHeld{}},
+	})
+
+	assert.Error(suite.T(), resp.Error)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "BulkWrite", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestInsertOutboxEventsErrSchema() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+
+	resp := <-suite.repository.InsertOutboxEvents(suite.ctx, []request.OutboxEventReq{
+		{Type: constants.EventOrderHeld, Topic: constants.TopicOrderHeld, Key: "ticket-1", Payload: eventschema.OrderHeld{TicketNumber: "ticket-1"}},
+		{Type: constants.EventOrderPaid, Topic: constants.TopicOrderPaid, Key: "ticket-1", Payload: eventschema.QueueJoined{}},
+	})
+
+	assert.Error(suite.T(), resp.Error)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "BulkWrite", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestMarkDelivered() {
+	// Mock UpdateOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("UpdateOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	now := time.Now()
+	result := suite.repository.MarkDelivered(suite.ctx, request.DeliveredEventReq{EventId: "event-1", LockedBy: "relay-1", DeliveredAt: now})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert UpdateOne
+	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.MatchedBy(func(req mongodb.UpdateOne) bool {
+		filter := req.Filter.(bson.M)
+		return filter["eventId"] == "event-1" && filter["lockedBy"] == "relay-1" &&
+			filter["lockedUntil"].(bson.M)["$gt"] == now
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestMarkFailed() {
+	// Mock UpdateOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("UpdateOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.MarkFailed(suite.ctx, request.FailedEventReq{EventId: "event-1", LockedBy: "relay-1", Attempts: 1, NextAttemptAt: time.Now(), LastError: "broker down"})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert UpdateOne
+	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.MatchedBy(func(req mongodb.UpdateOne) bool {
+		filter := req.Filter.(bson.M)
+		return filter["eventId"] == "event-1" && filter["lockedBy"] == "relay-1"
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestClaimPendingEvent() {
+	// Mock FindOneAndUpdate
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	now := time.Now()
+	result := suite.repository.ClaimPendingEvent(suite.ctx, request.ClaimEventReq{
+		LockedBy:     "relay-1",
+		Now:          now,
+		LockedUntil:  now.Add(time.Minute),
+		ExcludedKeys: []string{"ticket-1"},
+	})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOneAndUpdate
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(payload mongodb.FindOneAndUpdate) bool {
+		filter := payload.Filter.(bson.M)
+		lock := payload.Update.(bson.M)["$set"].(bson.M)
+		return filter["status"] == entity.StatusPending && filter["nextAttemptAt"].(bson.M)["$lte"] == now &&
+			len(filter["key"].(bson.M)["$nin"].([]string)) == 1 && lock["lockedBy"] == "relay-1" &&
+			payload.Sort.FieldName == "createdAt"
+	}), options.After, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestReleaseClaim() {
+	// Mock UpdateOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("UpdateOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.ReleaseClaim(suite.ctx, "event-1", "relay-1")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert UpdateOne
+	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.MatchedBy(func(payload mongodb.UpdateOne) bool {
+		filter := payload.Filter.(bson.M)
+		return filter["eventId"] == "event-1" && filter["lockedBy"] == "relay-1"
+	}), mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestCreateOutboxIndexes() {
+	// Mock CreateIndexes
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.CreateOutboxIndexes(suite.ctx)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert CreateIndexes
+	suite.mockMongodb.AssertCalled(suite.T(), "CreateIndexes", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,48 @@
+package queries
+
+import (
+	"context"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	"order-service/internal/pkg/databases/mongodb"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type queryMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewQueryMongodbRepository(mongodb mongodb.Collections, log log.Logger) outbox.MongodbRepositoryQuery {
+	return &queryMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+// FindEarlierPendingEvent returns a pending event of key written before createdAt, nil Data when there is none.
+// The events of a key are published in the order they were written, so a later one waits for it.
+func (q queryMongodbRepository) FindEarlierPendingEvent(ctx context.Context, key string, createdAt time.Time) <-chan wrapper.Result {
+	var event entity.OutboxEvent
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindOne(mongodb.FindOne{
+			Result:         &event,
+			CollectionName: "outbox",
+			Filter: bson.M{
+				"key":       key,
+				"status":    entity.StatusPending,
+				"createdAt": bson.M{"$lt": createdAt},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
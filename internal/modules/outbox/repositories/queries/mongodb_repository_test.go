@@ -0,0 +1,69 @@
+package queries_test
+
+import (
+	"context"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	mongoRQ "order-service/internal/modules/outbox/repositories/queries"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/helpers"
+	mocks "order-service/mocks/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	mockMongodb *mocks.Collections
+	mockLogger  *mocklog.Logger
+	repository  outbox.MongodbRepositoryQuery
+	ctx         context.Context
+}
+
+func (suite *CommandTestSuite) SetupTest() {
+	suite.mockMongodb = new(mocks.Collections)
+	suite.mockLogger = &mocklog.Logger{}
+	suite.repository = mongoRQ.NewQueryMongodbRepository(
+		suite.mockMongodb,
+		suite.mockLogger,
+	)
+	suite.ctx = context.Background()
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (suite *CommandTestSuite) TestFindEarlierPendingEvent() {
+	// Mock FindOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	createdAt := time.Now()
+	result := suite.repository.FindEarlierPendingEvent(suite.ctx, "ticket-1", createdAt)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOne
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.MatchedBy(func(payload mongodb.FindOne) bool {
+		filter := payload.Filter.(bson.M)
+		return filter["key"] == "ticket-1" && filter["status"] == entity.StatusPending &&
+			filter["createdAt"].(bson.M)["$lt"] == createdAt
+	}), mock.Anything)
+}
@@ -0,0 +1,234 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/configs"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	"order-service/internal/modules/outbox/models/request"
+	"order-service/internal/pkg/errors"
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	"order-service/internal/pkg/log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.elastic.co/apm"
+)
+
+var (
+	Configs = configs.GetConfig
+	Now     = time.Now
+)
+
+const (
+	defaultRelayBatch      = 100
+	defaultRetryBackoff    = 1
+	defaultRetryMaxBackoff = 300
+	defaultMaxAttempts     = 20
+	publishTimeout         = 10 * time.Second
+	// claimLease covers looking up the earlier events of the key, publishing and marking one event
+	claimLease = 3 * publishTimeout
+)
+
+type relay struct {
+	outboxRepositoryQuery   outbox.MongodbRepositoryQuery
+	outboxRepositoryCommand outbox.MongodbRepositoryCommand
+	kafkaProducer           kafkaConfluent.Producer
+	logger                  log.Logger
+	// instanceId names this relay on the events it claims
+	instanceId string
+}
+
+// NewRelay publishes outbox events in the order they were written. Delivery is at least once: an event
+// published but not marked delivered is published again once its claim runs out.
+func NewRelay(omq outbox.MongodbRepositoryQuery, omc outbox.MongodbRepositoryCommand, kp kafkaConfluent.Producer, log log.Logger) outbox.Relay {
+	return relay{
+		outboxRepositoryQuery:   omq,
+		outboxRepositoryCommand: omc,
+		kafkaProducer:           kp,
+		logger:                  log,
+		instanceId:              relayInstanceId(),
+	}
+}
+
+func relayInstanceId() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%s", hostname, uuid.NewString())
+}
+
+func relayBatch() int64 {
+	batch, err := strconv.ParseInt(Configs().Outbox.RelayBatch, 10, 64)
+	if err != nil || batch <= 0 {
+		batch = defaultRelayBatch
+	}
+	return batch
+}
+
+func maxAttempts() int {
+	attempts, err := strconv.Atoi(Configs().Outbox.MaxAttempts)
+	if err != nil || attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	return attempts
+}
+
+// retryBackoff doubles the wait after every failed attempt, starting at the configured backoff and capped at the max.
+func retryBackoff(attempts int) time.Duration {
+	base, err := strconv.Atoi(Configs().Outbox.RetryBackoff)
+	if err != nil || base <= 0 {
+		base = defaultRetryBackoff
+	}
+	max, err := strconv.Atoi(Configs().Outbox.RetryMaxBackoff)
+	if err != nil || max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+
+	backoff := time.Duration(base) * time.Second
+	for i := 1; i < attempts && backoff < time.Duration(max)*time.Second; i++ {
+		backoff *= 2
+	}
+	if backoff > time.Duration(max)*time.Second {
+		backoff = time.Duration(max) * time.Second
+	}
+	return backoff
+}
+
+// eventHeaders describes the event to consumers without decoding the payload.
+func eventHeaders(event entity.OutboxEvent) map[string]string {
+	headers := map[string]string{
+		kafkaConfluent.HeaderEventId:       event.EventId,
+		kafkaConfluent.HeaderEventType:     event.Type,
+		kafkaConfluent.HeaderSchemaVersion: strconv.Itoa(event.SchemaVersion),
+	}
+	if event.TraceId != "" {
+		headers[kafkaConfluent.HeaderTraceId] = event.TraceId
+	}
+	return headers
+}
+
+// publish waits for the broker to acknowledge the event, so it is only marked delivered once it is stored.
+func (r relay) publish(ctx context.Context, event entity.OutboxEvent) error {
+	publishCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	return r.kafkaProducer.PublishSync(publishCtx, event.Topic, []byte(event.Key), []byte(event.Payload), eventHeaders(event), nil)
+}
+
+// RelayPending claims and publishes up to a batch of due events and returns how many were delivered. An event waits
+// while an earlier event of its key is pending, so consumers see the events of a key in order. An event that
+// failed too often is parked as failed and no longer holds its key back.
+func (r relay) RelayPending(origCtx context.Context) (int, error) {
+	domain := "outboxUsecase-RelayPending"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	blocked := make([]string, 0)
+	totalDelivered := 0
+	for i := int64(0); i < relayBatch(); i++ {
+		now := Now()
+		eventData := <-r.outboxRepositoryCommand.ClaimPendingEvent(ctx, request.ClaimEventReq{
+			LockedBy:     r.instanceId,
+			Now:          now,
+			LockedUntil:  now.Add(claimLease),
+			ExcludedKeys: blocked,
+		})
+		if eventData.Error != nil {
+			msg := "Error DB connection ClaimPendingEvent"
+			r.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Error))
+			return totalDelivered, eventData.Error
+		}
+
+		if eventData.Data == nil {
+			break
+		}
+
+		event, ok := eventData.Data.(*entity.OutboxEvent)
+		if !ok {
+			msg := "cannot parsing data outbox event"
+			r.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Data))
+			return totalDelivered, errors.InternalServerError("cannot parsing data outbox event")
+		}
+
+		if !r.isNextOfKey(ctx, *event) {
+			blocked = append(blocked, event.Key)
+			continue
+		}
+
+		if err := r.publish(ctx, *event); err != nil {
+			blocked = append(blocked, event.Key)
+			msg := "cannot publish outbox event"
+			r.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			r.markFailed(ctx, *event, now, err)
+			continue
+		}
+
+		deliveredResp := <-r.outboxRepositoryCommand.MarkDelivered(ctx, request.DeliveredEventReq{
+			EventId:     event.EventId,
+			LockedBy:    r.instanceId,
+			DeliveredAt: Now(),
+		})
+		if deliveredResp.Error != nil {
+			// published already, the event is published again once the claim runs out rather than being lost
+			blocked = append(blocked, event.Key)
+			msg := "Error DB connection MarkDelivered"
+			r.logger.Error(ctx, msg, fmt.Sprintf("%+v", deliveredResp.Error))
+			continue
+		}
+		totalDelivered++
+	}
+
+	return totalDelivered, nil
+}
+
+// isNextOfKey tells whether no earlier event of the key is still pending. An event that has to wait is given back,
+// so it is claimed again once the earlier one is settled.
+func (r relay) isNextOfKey(ctx context.Context, event entity.OutboxEvent) bool {
+	earlierData := <-r.outboxRepositoryQuery.FindEarlierPendingEvent(ctx, event.Key, event.CreatedAt)
+	if earlierData.Error != nil {
+		// the claim runs out and the event is tried again
+		msg := "Error DB connection FindEarlierPendingEvent"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", earlierData.Error))
+		return false
+	}
+
+	if earlierData.Data == nil {
+		return true
+	}
+
+	releaseResp := <-r.outboxRepositoryCommand.ReleaseClaim(ctx, event.EventId, r.instanceId)
+	if releaseResp.Error != nil {
+		msg := "Error DB connection ReleaseClaim"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", releaseResp.Error))
+	}
+	return false
+}
+
+// markFailed schedules the next attempt with backoff, or parks the event once it used up its attempts.
+func (r relay) markFailed(ctx context.Context, event entity.OutboxEvent, now time.Time, publishErr error) {
+	attempts := event.Attempts + 1
+	failed := request.FailedEventReq{
+		EventId:       event.EventId,
+		LockedBy:      r.instanceId,
+		Status:        entity.StatusPending,
+		Attempts:      attempts,
+		NextAttemptAt: now.Add(retryBackoff(attempts)),
+		LastError:     publishErr.Error(),
+	}
+	if attempts >= maxAttempts() {
+		failed.Status = entity.StatusFailed
+		msg := "outbox event parked after too many attempts"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%s %s", event.EventId, event.Type))
+	}
+
+	failedResp := <-r.outboxRepositoryCommand.MarkFailed(ctx, failed)
+	if failedResp.Error != nil {
+		msg := "Error DB connection MarkFailed"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", failedResp.Error))
+	}
+}
@@ -0,0 +1,303 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/configs"
+	"order-service/internal/modules/outbox"
+	"order-service/internal/modules/outbox/models/entity"
+	"order-service/internal/modules/outbox/models/request"
+	uc "order-service/internal/modules/outbox/usecases"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	mockcert "order-service/mocks/modules/outbox"
+	mockkafka "order-service/mocks/pkg/kafka"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var mockNow = time.Date(2024, time.March, 1, 3, 0, 0, 0, time.UTC)
+
+type RelayTestSuite struct {
+	suite.Suite
+	mockOutboxRepositoryQuery   *mockcert.MongodbRepositoryQuery
+	mockOutboxRepositoryCommand *mockcert.MongodbRepositoryCommand
+	mockProducer                *mockkafka.Producer
+	mockLogger                  *mocklog.Logger
+	relay                       outbox.Relay
+	ctx                         context.Context
+}
+
+func (suite *RelayTestSuite) SetupTest() {
+	suite.mockOutboxRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockOutboxRepositoryCommand = &mockcert.MongodbRepositoryCommand{}
+	suite.mockProducer = &mockkafka.Producer{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	// no earlier event of the key is pending unless a test says otherwise
+	suite.mockOutboxRepositoryQuery.On("FindEarlierPendingEvent", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockOutboxRepositoryCommand.On("ReleaseClaim", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.ctx = context.Background()
+	suite.relay = uc.NewRelay(
+		suite.mockOutboxRepositoryQuery,
+		suite.mockOutboxRepositoryCommand,
+		suite.mockProducer,
+		suite.mockLogger,
+	)
+	uc.Now = func() time.Time { return mockNow }
+	uc.Configs = func() *configs.Config {
+		return &configs.Config{Outbox: configs.OutboxConfig{RetryBackoff: "2", RetryMaxBackoff: "10"}}
+	}
+}
+
+func (suite *RelayTestSuite) TearDownTest() {
+	uc.Now = time.Now
+	uc.Configs = configs.GetConfig
+}
+
+func TestRelayTestSuite(t *testing.T) {
+	suite.Run(t, new(RelayTestSuite))
+}
+
+func mockChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result)
+
+	go func() {
+		responseChan <- result
+		close(responseChan)
+	}()
+
+	return responseChan
+}
+
+func pendingEvent(eventId string, key string) entity.OutboxEvent {
+	return entity.OutboxEvent{
+		EventId:       eventId,
+		Type:          constants.EventOrderHeld,
+		Topic:         constants.TopicOrderHeld,
+		Key:           key,
+		Payload:       `{"ticketNumber":"` + key + `"}`,
+		SchemaVersion: 1,
+		Status:        entity.StatusPending,
+		NextAttemptAt: mockNow.Add(-time.Minute),
+		CreatedAt:     mockNow.Add(-time.Minute),
+	}
+}
+
+// mockClaims hands the events out one claim after the other, then reports nothing due
+func (suite *RelayTestSuite) mockClaims(events ...entity.OutboxEvent) {
+	for i := range events {
+		suite.mockOutboxRepositoryCommand.On("ClaimPendingEvent", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: &events[i]})).Once()
+	}
+	suite.mockOutboxRepositoryCommand.On("ClaimPendingEvent", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+}
+
+// claimedBy is the relay name the events were claimed with.
+func (suite *RelayTestSuite) claimedBy() string {
+	for _, call := range suite.mockOutboxRepositoryCommand.Calls {
+		if call.Method == "ClaimPendingEvent" {
+			return call.Arguments.Get(1).(request.ClaimEventReq).LockedBy
+		}
+	}
+	return ""
+}
+
+// delivered matches the event marked delivered under the claim of the relay.
+func (suite *RelayTestSuite) delivered(eventId string) interface{} {
+	lockedBy := suite.claimedBy()
+	return mock.MatchedBy(func(req request.DeliveredEventReq) bool {
+		return req.EventId == eventId && req.LockedBy == lockedBy && lockedBy != "" && req.DeliveredAt.Equal(mockNow)
+	})
+}
+
+func (suite *RelayTestSuite) TestRelayPending() {
+	traced := pendingEvent("event-1", "ticket-1")
+	traced.TraceId = "trace-1"
+	suite.mockClaims(traced, pendingEvent("event-2", "ticket-2"))
+	suite.mockProducer.On("PublishSync", mock.Anything, constants.TopicOrderHeld, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOutboxRepositoryCommand.On("MarkDelivered", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, delivered)
+	suite.mockOutboxRepositoryCommand.AssertNumberOfCalls(suite.T(), "ClaimPendingEvent", 3)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "ClaimPendingEvent", mock.Anything, mock.MatchedBy(func(req request.ClaimEventReq) bool {
+		return req.LockedBy != "" && req.Now.Equal(mockNow) && req.LockedUntil.Equal(mockNow.Add(30*time.Second)) && len(req.ExcludedKeys) == 0
+	}))
+	suite.mockOutboxRepositoryQuery.AssertCalled(suite.T(), "FindEarlierPendingEvent", mock.Anything, "ticket-1", traced.CreatedAt)
+	suite.mockProducer.AssertCalled(suite.T(), "PublishSync", mock.Anything, constants.TopicOrderHeld, []byte("ticket-1"), []byte(`{"ticketNumber":"ticket-1"}`),
+		map[string]string{
+			kafkaConfluent.HeaderEventId:       "event-1",
+			kafkaConfluent.HeaderEventType:     constants.EventOrderHeld,
+			kafkaConfluent.HeaderSchemaVersion: "1",
+			kafkaConfluent.HeaderTraceId:       "trace-1",
+		}, mock.Anything)
+	suite.mockProducer.AssertCalled(suite.T(), "PublishSync", mock.Anything, constants.TopicOrderHeld, []byte("ticket-2"), mock.Anything,
+		map[string]string{
+			kafkaConfluent.HeaderEventId:       "event-2",
+			kafkaConfluent.HeaderEventType:     constants.EventOrderHeld,
+			kafkaConfluent.HeaderSchemaVersion: "1",
+		}, mock.Anything)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkDelivered", mock.Anything, suite.delivered("event-1"))
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkDelivered", mock.Anything, suite.delivered("event-2"))
+}
+
+func (suite *RelayTestSuite) TestRelayPendingBatch() {
+	uc.Configs = func() *configs.Config {
+		return &configs.Config{Outbox: configs.OutboxConfig{RelayBatch: "1"}}
+	}
+	suite.mockClaims(pendingEvent("event-1", "ticket-1"), pendingEvent("event-2", "ticket-2"))
+	suite.mockProducer.On("PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOutboxRepositoryCommand.On("MarkDelivered", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, delivered)
+	suite.mockOutboxRepositoryCommand.AssertNumberOfCalls(suite.T(), "ClaimPendingEvent", 1)
+}
+
+func (suite *RelayTestSuite) TestRelayPendingEmpty() {
+	suite.mockClaims()
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, delivered)
+	suite.mockProducer.AssertNotCalled(suite.T(), "PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *RelayTestSuite) TestRelayPendingErrClaim() {
+	suite.mockOutboxRepositoryCommand.On("ClaimPendingEvent", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, delivered)
+}
+
+func (suite *RelayTestSuite) TestRelayPendingErrParse() {
+	suite.mockOutboxRepositoryCommand.On("ClaimPendingEvent", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Data: "wrong"}))
+
+	_, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.Error(suite.T(), err)
+}
+
+// a failed publish is retried with backoff and holds back the later events of the same key
+func (suite *RelayTestSuite) TestRelayPendingErrPublish() {
+	failed := pendingEvent("event-1", "ticket-1")
+	failed.Attempts = 2
+	suite.mockClaims(failed, pendingEvent("event-3", "ticket-2"))
+	suite.mockProducer.On("PublishSync", mock.Anything, constants.TopicOrderHeld, []byte("ticket-1"), mock.Anything, mock.Anything, mock.Anything).Return(errors.InternalServerError("broker down"))
+	suite.mockProducer.On("PublishSync", mock.Anything, constants.TopicOrderHeld, []byte("ticket-2"), mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOutboxRepositoryCommand.On("MarkFailed", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockOutboxRepositoryCommand.On("MarkDelivered", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, delivered)
+	suite.mockProducer.AssertNumberOfCalls(suite.T(), "PublishSync", 2)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkFailed", mock.Anything, request.FailedEventReq{
+		EventId:       "event-1",
+		LockedBy:      suite.claimedBy(),
+		Status:        entity.StatusPending,
+		Attempts:      3,
+		NextAttemptAt: mockNow.Add(8 * time.Second),
+		LastError:     "broker down",
+	})
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "ClaimPendingEvent", mock.Anything, mock.MatchedBy(func(req request.ClaimEventReq) bool {
+		return len(req.ExcludedKeys) == 1 && req.ExcludedKeys[0] == "ticket-1"
+	}))
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkDelivered", mock.Anything, suite.delivered("event-3"))
+}
+
+func (suite *RelayTestSuite) TestRelayPendingBackoffCapped() {
+	failed := pendingEvent("event-1", "ticket-1")
+	failed.Attempts = 10
+	suite.mockClaims(failed)
+	suite.mockProducer.On("PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.InternalServerError("broker down"))
+	suite.mockOutboxRepositoryCommand.On("MarkFailed", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkFailed", mock.Anything, mock.MatchedBy(func(payload request.FailedEventReq) bool {
+		return payload.Attempts == 11 && payload.NextAttemptAt.Equal(mockNow.Add(10*time.Second)) && payload.Status == entity.StatusPending
+	}))
+}
+
+func (suite *RelayTestSuite) TestRelayPendingParked() {
+	uc.Configs = func() *configs.Config {
+		return &configs.Config{Outbox: configs.OutboxConfig{MaxAttempts: "5"}}
+	}
+	failed := pendingEvent("event-1", "ticket-1")
+	failed.Attempts = 4
+	suite.mockClaims(failed)
+	suite.mockProducer.On("PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.InternalServerError("broker down"))
+	suite.mockOutboxRepositoryCommand.On("MarkFailed", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "MarkFailed", mock.Anything, mock.MatchedBy(func(payload request.FailedEventReq) bool {
+		return payload.Attempts == 5 && payload.Status == entity.StatusFailed && payload.LastError == "broker down"
+	}))
+}
+
+// an event waits while an earlier event of its key is pending, even when that one is not due or held by another relay
+func (suite *RelayTestSuite) TestRelayPendingEarlierPending() {
+	suite.mockClaims(pendingEvent("event-2", "ticket-1"))
+	suite.mockOutboxRepositoryQuery.ExpectedCalls = nil
+	suite.mockOutboxRepositoryQuery.On("FindEarlierPendingEvent", mock.Anything, "ticket-1", mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &entity.OutboxEvent{EventId: "event-1", Key: "ticket-1"},
+	}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, delivered)
+	suite.mockProducer.AssertNotCalled(suite.T(), "PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "ReleaseClaim", mock.Anything, "event-2", mock.Anything)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "ClaimPendingEvent", mock.Anything, mock.MatchedBy(func(req request.ClaimEventReq) bool {
+		return len(req.ExcludedKeys) == 1 && req.ExcludedKeys[0] == "ticket-1"
+	}))
+}
+
+func (suite *RelayTestSuite) TestRelayPendingErrEarlierPending() {
+	suite.mockClaims(pendingEvent("event-2", "ticket-1"))
+	suite.mockOutboxRepositoryQuery.ExpectedCalls = nil
+	suite.mockOutboxRepositoryQuery.On("FindEarlierPendingEvent", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, delivered)
+	suite.mockProducer.AssertNotCalled(suite.T(), "PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	suite.mockOutboxRepositoryCommand.AssertNotCalled(suite.T(), "ReleaseClaim", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *RelayTestSuite) TestRelayPendingErrMarkDelivered() {
+	suite.mockClaims(pendingEvent("event-1", "ticket-1"))
+	suite.mockProducer.On("PublishSync", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOutboxRepositoryCommand.On("MarkDelivered", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	delivered, err := suite.relay.RelayPending(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, delivered)
+	suite.mockProducer.AssertNumberOfCalls(suite.T(), "PublishSync", 1)
+	suite.mockOutboxRepositoryCommand.AssertCalled(suite.T(), "ClaimPendingEvent", mock.Anything, mock.MatchedBy(func(req request.ClaimEventReq) bool {
+		return len(req.ExcludedKeys) == 1 && req.ExcludedKeys[0] == "ticket-1"
+	}))
+}
@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+// RuleKind tells whether a rule lowers or raises the price.
+type RuleKind string
+
+const (
+	KindDiscount RuleKind = "discount"
+	KindFee      RuleKind = "fee"
+)
+
+// RuleMode tells how the value of a rule is read.
+type RuleMode string
+
+const (
+	// ModePercentage takes Value percent of the running price
+	ModePercentage RuleMode = "percentage"
+	// ModeFixed takes Value as an amount per ticket
+	ModeFixed RuleMode = "fixed"
+)
+
+// Rule is one step of the price calculation. Active rules run in ascending priority, every rule whose
+// condition matches adjusts the running price, and a Final rule stops the rules after it.
+type Rule struct {
+	RuleId    string    `json:"ruleId" bson:"ruleId"`
+	Name      string    `json:"name" bson:"name"`
+	Priority  int       `json:"priority" bson:"priority"`
+	Kind      RuleKind  `json:"kind" bson:"kind"`
+	Mode      RuleMode  `json:"mode" bson:"mode"`
+	Value     int       `json:"value" bson:"value"`
+	Condition Condition `json:"condition" bson:"condition"`
+	Final     bool      `json:"final" bson:"final"`
+	Active    bool      `json:"active" bson:"active"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Condition limits a rule, empty fields match everything. The quantity bounds count the tickets
+// of the same ticket type in the order.
+type Condition struct {
+	EventIds            []string  `json:"eventIds" bson:"eventIds"`
+	UserCountries       []string  `json:"userCountries" bson:"userCountries"`
+	UserContinents      []string  `json:"userContinents" bson:"userContinents"`
+	ForeignUser         bool      `json:"foreignUser" bson:"foreignUser"`
+	TicketTypes         []string  `json:"ticketTypes" bson:"ticketTypes"`
+	ExcludedTicketTypes []string  `json:"excludedTicketTypes" bson:"excludedTicketTypes"`
+	StartAt             time.Time `json:"startAt" bson:"startAt,omitempty"`
+	EndAt               time.Time `json:"endAt" bson:"endAt,omitempty"`
+	MinQuantity         int       `json:"minQuantity" bson:"minQuantity"`
+	MaxQuantity         int       `json:"maxQuantity" bson:"maxQuantity"`
+}
+
+// Breakdown is the price of one ticket, the base price plus the adjustment of every applied rule.
+type Breakdown struct {
+	BasePrice   int          `json:"basePrice"`
+	Adjustments []Adjustment `json:"adjustments"`
+	Price       int          `json:"price"`
+}
+
+// Adjustment is the amount one rule added to the price, discounts are negative.
+type Adjustment struct {
+	RuleId string   `json:"ruleId"`
+	Name   string   `json:"name"`
+	Kind   RuleKind `json:"kind"`
+	Amount int      `json:"amount"`
+}
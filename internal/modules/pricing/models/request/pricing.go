@@ -0,0 +1,15 @@
+package request
+
+import "time"
+
+// QuoteReq describes one ticket type of an order, the price quoted is per ticket.
+type QuoteReq struct {
+	EventId          string    `json:"eventId"`
+	EventCountryCode string    `json:"eventCountryCode"`
+	UserCountryCode  string    `json:"userCountryCode"`
+	UserContinent    string    `json:"userContinent"`
+	TicketType       string    `json:"ticketType"`
+	Quantity         int       `json:"quantity"`
+	BasePrice        int       `json:"basePrice"`
+	At               time.Time `json:"at"`
+}
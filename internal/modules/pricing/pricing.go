@@ -0,0 +1,21 @@
+package pricing
+
+import (
+	"context"
+	"order-service/internal/modules/pricing/models/entity"
+	"order-service/internal/modules/pricing/models/request"
+	wrapper "order-service/internal/pkg/helpers"
+)
+
+// Engine prices a ticket by running the pricing rules over its base price.
+type Engine interface {
+	Quote(ctx context.Context, payload request.QuoteReq) (*entity.Breakdown, error)
+}
+
+type MongodbRepositoryCommand interface {
+	SeedDefaultRules(ctx context.Context) <-chan wrapper.Result
+}
+
+type MongodbRepositoryQuery interface {
+	FindActiveRules(ctx context.Context) <-chan wrapper.Result
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"order-service/internal/modules/pricing"
+	"order-service/internal/modules/pricing/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/databases/mongodb"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const indexPricingRuleId = "ruleId_unique"
+
+// defaultRules are the rules every deployment starts with. The foreign visitor discount is the 20% discount
+// orders used to apply in code to buyers from another country than the event, online tickets excluded.
+var defaultRules = []entity.Rule{
+	{
+		RuleId:   "foreign-visitor",
+		Name:     "foreign visitor discount",
+		Priority: 10,
+		Kind:     entity.KindDiscount,
+		Mode:     entity.ModePercentage,
+		Value:    20,
+		Condition: entity.Condition{
+			ForeignUser:         true,
+			ExcludedTicketTypes: []string{constants.Online},
+		},
+		Active: true,
+	},
+}
+
+type commandMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewCommandMongodbRepository(mongodb mongodb.Collections, log log.Logger) pricing.MongodbRepositoryCommand {
+	return &commandMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+// SeedDefaultRules inserts the default rules that are missing from the pricing-rule collection. A rule that
+// already exists is left as it is, so a rule changed or deactivated by an operator is not brought back.
+func (c commandMongodbRepository) SeedDefaultRules(ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+
+		// the unique ruleId keeps instances starting together from inserting the same rule twice
+		resp := <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "pricing-rule",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "ruleId", Value: 1}},
+					Options: options.Index().SetName(indexPricingRuleId).SetUnique(true),
+				},
+			},
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		now := time.Now()
+		for _, rule := range defaultRules {
+			rule.CreatedAt = now
+			rule.UpdatedAt = now
+
+			var result entity.Rule
+			resp = <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+				CollectionName: "pricing-rule",
+				Filter:         bson.M{"ruleId": rule.RuleId},
+				Update:         bson.M{"$setOnInsert": rule},
+				Result:         &result,
+				Upsert:         true,
+			}, options.After, ctx)
+			if resp.Error != nil {
+				output <- resp
+				return
+			}
+		}
+
+		output <- resp
+	}()
+
+	return output
+}
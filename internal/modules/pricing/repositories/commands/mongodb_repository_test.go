@@ -0,0 +1,83 @@
+package commands_test
+
+import (
+	"context"
+	"order-service/internal/modules/pricing"
+	"order-service/internal/modules/pricing/models/entity"
+	mongoRC "order-service/internal/modules/pricing/repositories/commands"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mocks "order-service/mocks/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	mockMongodb *mocks.Collections
+	mockLogger  *mocklog.Logger
+	repository  pricing.MongodbRepositoryCommand
+	ctx         context.Context
+}
+
+func (suite *CommandTestSuite) SetupTest() {
+	suite.mockMongodb = new(mocks.Collections)
+	suite.mockLogger = &mocklog.Logger{}
+	suite.repository = mongoRC.NewCommandMongodbRepository(
+		suite.mockMongodb,
+		suite.mockLogger,
+	)
+	suite.ctx = context.Background()
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (suite *CommandTestSuite) TestSeedDefaultRules() {
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return(func(mongodb.CreateIndexes, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: []string{"index"}})
+	})
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, options.After, mock.Anything).Return(func(mongodb.FindOneAndUpdate, options.ReturnDocument, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: &entity.Rule{}})
+	})
+
+	result := <-suite.repository.SeedDefaultRules(suite.ctx)
+
+	assert.NoError(suite.T(), result.Error)
+	// an existing rule is never overwritten, only a missing one is inserted
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		update := req.Update.(bson.M)
+		rule, ok := update["$setOnInsert"].(entity.Rule)
+		return req.Upsert && len(update) == 1 && ok &&
+			req.Filter.(bson.M)["ruleId"] == "foreign-visitor" &&
+			rule.Kind == entity.KindDiscount && rule.Mode == entity.ModePercentage && rule.Value == 20 &&
+			rule.Condition.ForeignUser && rule.Condition.ExcludedTicketTypes[0] == "Online" && rule.Active
+	}), options.After, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestSeedDefaultRulesErrIndex() {
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return(func(mongodb.CreateIndexes, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Error: errors.InternalServerError("error")})
+	})
+
+	result := <-suite.repository.SeedDefaultRules(suite.ctx)
+
+	assert.Error(suite.T(), result.Error)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func resultChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result, 1)
+	responseChan <- result
+	close(responseChan)
+
+	return responseChan
+}
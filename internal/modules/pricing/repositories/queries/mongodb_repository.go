@@ -0,0 +1,48 @@
+package queries
+
+import (
+	"context"
+	"order-service/internal/modules/pricing"
+	"order-service/internal/modules/pricing/models/entity"
+	"order-service/internal/pkg/databases/mongodb"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type queryMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewQueryMongodbRepository(mongodb mongodb.Collections, log log.Logger) pricing.MongodbRepositoryQuery {
+	return &queryMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+// FindActiveRules returns the active pricing rules in the order they are applied.
+func (q queryMongodbRepository) FindActiveRules(ctx context.Context) <-chan wrapper.Result {
+	var rules []entity.Rule
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindMany(mongodb.FindMany{
+			Result:         &rules,
+			CollectionName: "pricing-rule",
+			Filter: bson.M{
+				"active": true,
+			},
+			Sort: &mongodb.Sort{
+				FieldName: "priority",
+				By:        mongodb.SortAscending,
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
@@ -0,0 +1,61 @@
+package queries_test
+
+import (
+	"context"
+	"order-service/internal/modules/pricing"
+	mongoRQ "order-service/internal/modules/pricing/repositories/queries"
+	"order-service/internal/pkg/helpers"
+	mocks "order-service/mocks/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	mockMongodb *mocks.Collections
+	mockLogger  *mocklog.Logger
+	repository  pricing.MongodbRepositoryQuery
+	ctx         context.Context
+}
+
+func (suite *CommandTestSuite) SetupTest() {
+	suite.mockMongodb = new(mocks.Collections)
+	suite.mockLogger = &mocklog.Logger{}
+	suite.repository = mongoRQ.NewQueryMongodbRepository(
+		suite.mockMongodb,
+		suite.mockLogger,
+	)
+	suite.ctx = context.Background()
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (suite *CommandTestSuite) TestFindActiveRules() {
+
+	// Mock FindMany
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.FindActiveRules(suite.ctx)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindMany
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.Anything, mock.Anything)
+}
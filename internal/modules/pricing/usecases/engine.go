@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/configs"
+	"order-service/internal/modules/pricing"
+	"order-service/internal/modules/pricing/models/entity"
+	"order-service/internal/modules/pricing/models/request"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.elastic.co/apm"
+)
+
+const defaultRulesCacheTTL = 60
+
+var (
+	Configs = configs.GetConfig
+	Now     = time.Now
+)
+
+type ruleEngine struct {
+	pricingRepositoryQuery pricing.MongodbRepositoryQuery
+	logger                 log.Logger
+
+	mu       sync.RWMutex
+	rules    []entity.Rule
+	loadedAt time.Time
+}
+
+// NewRuleEngine prices tickets with the rules of the pricing-rule collection. The rules are kept in
+// memory and reloaded once the cache ttl has passed, so a changed rule is picked up by every
+// instance within that ttl.
+func NewRuleEngine(prq pricing.MongodbRepositoryQuery, log log.Logger) pricing.Engine {
+	return &ruleEngine{
+		pricingRepositoryQuery: prq,
+		logger:                 log,
+	}
+}
+
+// rulesCacheTTL is how long the loaded rules are used before they are read again.
+func rulesCacheTTL() time.Duration {
+	seconds, err := strconv.Atoi(Configs().Pricing.RulesCacheTTL)
+	if err != nil || seconds <= 0 {
+		seconds = defaultRulesCacheTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (e *ruleEngine) Quote(origCtx context.Context, payload request.QuoteReq) (*entity.Breakdown, error) {
+	domain := "pricingEngine-Quote"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	rules, err := e.activeRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := entity.Breakdown{
+		BasePrice:   payload.BasePrice,
+		Adjustments: make([]entity.Adjustment, 0),
+		Price:       payload.BasePrice,
+	}
+	for _, rule := range rules {
+		if !matches(rule.Condition, payload) {
+			continue
+		}
+
+		amount := adjustment(rule, result.Price)
+		if amount != 0 {
+			result.Adjustments = append(result.Adjustments, entity.Adjustment{
+				RuleId: rule.RuleId,
+				Name:   rule.Name,
+				Kind:   rule.Kind,
+				Amount: amount,
+			})
+			result.Price += amount
+		}
+
+		if rule.Final {
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// activeRules returns the cached rules, reading them again when the cache is empty or expired.
+func (e *ruleEngine) activeRules(ctx context.Context) ([]entity.Rule, error) {
+	e.mu.RLock()
+	rules, loadedAt := e.rules, e.loadedAt
+	e.mu.RUnlock()
+	if !loadedAt.IsZero() && Now().Sub(loadedAt) < rulesCacheTTL() {
+		return rules, nil
+	}
+
+	resp := <-e.pricingRepositoryQuery.FindActiveRules(ctx)
+	if resp.Error != nil {
+		msg := "Error DB connection FindActiveRules"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", resp.Error))
+		return nil, resp.Error
+	}
+
+	loaded, ok := resp.Data.(*[]entity.Rule)
+	if !ok {
+		msg := "cannot parsing data pricing rule"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", resp.Data))
+		return nil, errors.InternalServerError("cannot parsing data")
+	}
+
+	e.mu.Lock()
+	e.rules, e.loadedAt = *loaded, Now()
+	e.mu.Unlock()
+
+	return *loaded, nil
+}
+
+// adjustment is the signed amount the rule adds to the running price. A discount never takes the
+// price below zero.
+func adjustment(rule entity.Rule, price int) int {
+	amount := rule.Value
+	if rule.Mode == entity.ModePercentage {
+		amount = price * rule.Value / 100
+	}
+
+	switch rule.Kind {
+	case entity.KindDiscount:
+		if amount > price {
+			amount = price
+		}
+		return -amount
+	case entity.KindFee:
+		return amount
+	}
+	return 0
+}
+
+func matches(condition entity.Condition, payload request.QuoteReq) bool {
+	if len(condition.EventIds) > 0 && !contains(condition.EventIds, payload.EventId) {
+		return false
+	}
+	if len(condition.UserCountries) > 0 && !contains(condition.UserCountries, payload.UserCountryCode) {
+		return false
+	}
+	if len(condition.UserContinents) > 0 && !contains(condition.UserContinents, payload.UserContinent) {
+		return false
+	}
+	if condition.ForeignUser && strings.EqualFold(payload.UserCountryCode, payload.EventCountryCode) {
+		return false
+	}
+	if len(condition.TicketTypes) > 0 && !contains(condition.TicketTypes, payload.TicketType) {
+		return false
+	}
+	if contains(condition.ExcludedTicketTypes, payload.TicketType) {
+		return false
+	}
+	if !condition.StartAt.IsZero() && payload.At.Before(condition.StartAt) {
+		return false
+	}
+	if !condition.EndAt.IsZero() && !payload.At.Before(condition.EndAt) {
+		return false
+	}
+	if condition.MinQuantity > 0 && payload.Quantity < condition.MinQuantity {
+		return false
+	}
+	if condition.MaxQuantity > 0 && payload.Quantity > condition.MaxQuantity {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/configs"
+	"order-service/internal/modules/pricing"
+	"order-service/internal/modules/pricing/models/entity"
+	"order-service/internal/modules/pricing/models/request"
+	uc "order-service/internal/modules/pricing/usecases"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mockcert "order-service/mocks/modules/pricing"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type EngineTestSuite struct {
+	suite.Suite
+	mockPricingRepositoryQuery *mockcert.MongodbRepositoryQuery
+	mockLogger                 *mocklog.Logger
+	engine                     pricing.Engine
+	now                        time.Time
+	ctx                        context.Context
+}
+
+func (suite *EngineTestSuite) SetupTest() {
+	suite.mockPricingRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.now = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	uc.Now = func() time.Time { return suite.now }
+	uc.Configs = func() *configs.Config { return &configs.Config{} }
+	suite.ctx = context.Background()
+	suite.engine = uc.NewRuleEngine(suite.mockPricingRepositoryQuery, suite.mockLogger)
+}
+
+func (suite *EngineTestSuite) TearDownTest() {
+	uc.Now = time.Now
+	uc.Configs = configs.GetConfig
+}
+
+func TestEngineTestSuite(t *testing.T) {
+	suite.Run(t, new(EngineTestSuite))
+}
+
+func mockChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result)
+
+	go func() {
+		responseChan <- result
+		close(responseChan)
+	}()
+
+	return responseChan
+}
+
+func (suite *EngineTestSuite) mockRules(rules ...entity.Rule) {
+	suite.mockPricingRepositoryQuery.On("FindActiveRules", mock.Anything).Return(func(ctx context.Context) <-chan helpers.Result {
+		return mockChannel(helpers.Result{Data: &rules})
+	})
+}
+
+func quoteReq() request.QuoteReq {
+	return request.QuoteReq{
+		EventId:          "event",
+		EventCountryCode: "ID",
+		UserCountryCode:  "SG",
+		UserContinent:    "Asia",
+		TicketType:       "VIP",
+		Quantity:         1,
+		BasePrice:        1000,
+		At:               time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func (suite *EngineTestSuite) TestQuoteNoRules() {
+	suite.mockRules()
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1000, result.BasePrice)
+	assert.Equal(suite.T(), 1000, result.Price)
+	assert.Empty(suite.T(), result.Adjustments)
+}
+
+func (suite *EngineTestSuite) TestQuoteForeignDiscount() {
+	// the former cross-country discount expressed as a rule
+	suite.mockRules(entity.Rule{
+		RuleId: "foreign",
+		Name:   "foreign visitor",
+		Kind:   entity.KindDiscount,
+		Mode:   entity.ModePercentage,
+		Value:  20,
+		Condition: entity.Condition{
+			ForeignUser:         true,
+			ExcludedTicketTypes: []string{"Online"},
+		},
+	})
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 800, result.Price)
+	assert.Equal(suite.T(), []entity.Adjustment{
+		{RuleId: "foreign", Name: "foreign visitor", Kind: entity.KindDiscount, Amount: -200},
+	}, result.Adjustments)
+
+	local := quoteReq()
+	local.UserCountryCode = "id"
+	result, err = suite.engine.Quote(suite.ctx, local)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1000, result.Price)
+
+	online := quoteReq()
+	online.TicketType = "Online"
+	result, err = suite.engine.Quote(suite.ctx, online)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1000, result.Price)
+}
+
+func (suite *EngineTestSuite) TestQuoteRulesInOrder() {
+	suite.mockRules(
+		entity.Rule{RuleId: "half", Kind: entity.KindDiscount, Mode: entity.ModePercentage, Value: 50},
+		entity.Rule{RuleId: "fixed", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 100},
+		entity.Rule{RuleId: "fee", Kind: entity.KindFee, Mode: entity.ModePercentage, Value: 10},
+	)
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+
+	assert.NoError(suite.T(), err)
+	// 1000 - 500 - 100 + 40
+	assert.Equal(suite.T(), 440, result.Price)
+	assert.Len(suite.T(), result.Adjustments, 3)
+	assert.Equal(suite.T(), 40, result.Adjustments[2].Amount)
+}
+
+func (suite *EngineTestSuite) TestQuoteFinalRule() {
+	suite.mockRules(
+		entity.Rule{RuleId: "vip", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 100, Final: true,
+			Condition: entity.Condition{TicketTypes: []string{"VIP"}}},
+		entity.Rule{RuleId: "fee", Kind: entity.KindFee, Mode: entity.ModeFixed, Value: 25},
+	)
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 900, result.Price)
+
+	gold := quoteReq()
+	gold.TicketType = "Gold"
+	result, err = suite.engine.Quote(suite.ctx, gold)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1025, result.Price)
+}
+
+func (suite *EngineTestSuite) TestQuoteDiscountFloor() {
+	suite.mockRules(entity.Rule{RuleId: "fixed", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 5000})
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, result.Price)
+	assert.Equal(suite.T(), -1000, result.Adjustments[0].Amount)
+}
+
+func (suite *EngineTestSuite) TestQuoteConditions() {
+	suite.mockRules(
+		entity.Rule{RuleId: "country", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 1,
+			Condition: entity.Condition{UserCountries: []string{"SG"}}},
+		entity.Rule{RuleId: "continent", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 2,
+			Condition: entity.Condition{UserContinents: []string{"Europe"}}},
+		entity.Rule{RuleId: "event", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 4,
+			Condition: entity.Condition{EventIds: []string{"event"}}},
+		entity.Rule{RuleId: "window", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 8,
+			Condition: entity.Condition{
+				StartAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				EndAt:   time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			}},
+		entity.Rule{RuleId: "early", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 16,
+			Condition: entity.Condition{EndAt: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)}},
+		entity.Rule{RuleId: "group", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 32,
+			Condition: entity.Condition{MinQuantity: 4}},
+		entity.Rule{RuleId: "single", Kind: entity.KindDiscount, Mode: entity.ModeFixed, Value: 64,
+			Condition: entity.Condition{MaxQuantity: 1}},
+	)
+
+	result, err := suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	// country, event, early and single match
+	assert.Equal(suite.T(), 1000-1-4-16-64, result.Price)
+
+	group := quoteReq()
+	group.Quantity = 4
+	group.UserContinent = "europe"
+	result, err = suite.engine.Quote(suite.ctx, group)
+	assert.NoError(suite.T(), err)
+	// country, continent, event, early and group match
+	assert.Equal(suite.T(), 1000-1-2-4-16-32, result.Price)
+}
+
+func (suite *EngineTestSuite) TestQuoteCachedRules() {
+	suite.mockRules(entity.Rule{RuleId: "fee", Kind: entity.KindFee, Mode: entity.ModeFixed, Value: 10})
+
+	_, err := suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	suite.now = suite.now.Add(30 * time.Second)
+	_, err = suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	suite.mockPricingRepositoryQuery.AssertNumberOfCalls(suite.T(), "FindActiveRules", 1)
+
+	suite.now = suite.now.Add(time.Minute)
+	_, err = suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	suite.mockPricingRepositoryQuery.AssertNumberOfCalls(suite.T(), "FindActiveRules", 2)
+}
+
+func (suite *EngineTestSuite) TestQuoteCacheTTLConfig() {
+	uc.Configs = func() *configs.Config {
+		return &configs.Config{Pricing: configs.PricingConfig{RulesCacheTTL: "600"}}
+	}
+	suite.mockRules()
+
+	_, err := suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	suite.now = suite.now.Add(5 * time.Minute)
+	_, err = suite.engine.Quote(suite.ctx, quoteReq())
+	assert.NoError(suite.T(), err)
+	suite.mockPricingRepositoryQuery.AssertNumberOfCalls(suite.T(), "FindActiveRules", 1)
+}
+
+func (suite *EngineTestSuite) TestQuoteErrRules() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPricingRepositoryQuery.On("FindActiveRules", mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.engine.Quote(suite.ctx, quoteReq())
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *EngineTestSuite) TestQuoteErrParse() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPricingRepositoryQuery.On("FindActiveRules", mock.Anything).Return(mockChannel(helpers.Result{
+		Data: "rules",
+	}))
+
+	_, err := suite.engine.Quote(suite.ctx, quoteReq())
+
+	assert.Error(suite.T(), err)
+}
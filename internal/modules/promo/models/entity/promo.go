@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// CodeKind tells what a promo code grants.
+type CodeKind string
+
+const (
+	// KindDiscount lowers the price of the eligible tickets
+	KindDiscount CodeKind = "discount"
+	// KindPresale lets the holder join the queue and order while the event is in presale
+	KindPresale CodeKind = "presale"
+)
+
+// PromoCode is a discount or presale access code. The limits count tickets, zero means no limit, and
+// empty EventIds or TicketTypes make the code valid for every event or ticket type. A discount code
+// takes DiscountPercentage percent off the ticket price, or DiscountAmount when no percentage is set.
+type PromoCode struct {
+	Code               string    `json:"code" bson:"code"`
+	Name               string    `json:"name" bson:"name"`
+	Kind               CodeKind  `json:"kind" bson:"kind"`
+	DiscountPercentage int       `json:"discountPercentage" bson:"discountPercentage"`
+	DiscountAmount     int       `json:"discountAmount" bson:"discountAmount"`
+	UsageLimit         int       `json:"usageLimit" bson:"usageLimit"`
+	PerUserLimit       int       `json:"perUserLimit" bson:"perUserLimit"`
+	Used               int       `json:"used" bson:"used"`
+	EventIds           []string  `json:"eventIds" bson:"eventIds"`
+	TicketTypes        []string  `json:"ticketTypes" bson:"ticketTypes"`
+	StartAt            time.Time `json:"startAt" bson:"startAt,omitempty"`
+	EndAt              time.Time `json:"endAt" bson:"endAt,omitempty"`
+	Active             bool      `json:"active" bson:"active"`
+	CreatedAt          time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Redemption counts the tickets one user bought with a code.
+type Redemption struct {
+	Code      string    `json:"code" bson:"code"`
+	UserId    string    `json:"userId" bson:"userId"`
+	Count     int       `json:"count" bson:"count"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
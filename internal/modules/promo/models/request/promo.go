@@ -0,0 +1,21 @@
+package request
+
+import "time"
+
+// ValidateCodeReq checks a code for an event at a point in time. When TicketTypes is set at least
+// one of them has to be eligible.
+type ValidateCodeReq struct {
+	Code        string    `json:"code"`
+	EventId     string    `json:"eventId"`
+	TicketTypes []string  `json:"ticketTypes"`
+	At          time.Time `json:"at"`
+}
+
+// RedeemPromoReq counts Quantity tickets against the limits of a code, the limits are only read when redeeming.
+type RedeemPromoReq struct {
+	Code         string `json:"code"`
+	UserId       string `json:"userId"`
+	Quantity     int    `json:"quantity"`
+	UsageLimit   int    `json:"usageLimit"`
+	PerUserLimit int    `json:"perUserLimit"`
+}
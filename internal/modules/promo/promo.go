@@ -0,0 +1,24 @@
+package promo
+
+import (
+	"context"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/modules/promo/models/request"
+	wrapper "order-service/internal/pkg/helpers"
+)
+
+// CodeValidator resolves a promo code and checks it may be used for an event right now. It does not
+// count a use, redeeming happens together with the claim of the tickets.
+type CodeValidator interface {
+	Validate(ctx context.Context, payload request.ValidateCodeReq) (*entity.PromoCode, error)
+}
+
+type MongodbRepositoryQuery interface {
+	FindPromoCode(ctx context.Context, code string) <-chan wrapper.Result
+}
+
+type MongodbRepositoryCommand interface {
+	RedeemPromoCode(ctx context.Context, payload request.RedeemPromoReq) <-chan wrapper.Result
+	ReturnPromoCode(ctx context.Context, payload request.RedeemPromoReq) <-chan wrapper.Result
+	CreatePromoIndexes(ctx context.Context) <-chan wrapper.Result
+}
@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"context"
+	"order-service/internal/modules/promo"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/modules/promo/models/request"
+	"order-service/internal/pkg/databases/mongodb"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	indexPromoCode           = "code_unique"
+	indexPromoRedemptionUser = "code_userId_unique"
+)
+
+type commandMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewCommandMongodbRepository(mongodb mongodb.Collections, log log.Logger) promo.MongodbRepositoryCommand {
+	return &commandMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+// RedeemPromoCode counts the tickets against the per-user and the total limit of the code. Each check is part
+// of its update, so concurrent orders cannot both take the last use. Data is nil when a limit would be
+// exceeded, the caller has to abort its transaction so that a counter already moved is rolled back.
+func (c commandMongodbRepository) RedeemPromoCode(ctx context.Context, payload request.RedeemPromoReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var redemption entity.Redemption
+	var promoCode entity.PromoCode
+
+	go func() {
+		defer close(output)
+		now := time.Now()
+
+		// the first use of the user creates the counter, the unique index keeps it to one document
+		created := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "promo-redemption",
+			Result:         &redemption,
+			Filter: bson.M{
+				"code":   payload.Code,
+				"userId": payload.UserId,
+			},
+			Update: bson.M{
+				"$setOnInsert": bson.M{
+					"code":      payload.Code,
+					"userId":    payload.UserId,
+					"count":     0,
+					"createdAt": now,
+					"updatedAt": now,
+				},
+			},
+			Upsert: true,
+		}, options.After, ctx)
+		if created.Error != nil {
+			output <- created
+			return
+		}
+
+		filter := bson.M{
+			"code":   payload.Code,
+			"userId": payload.UserId,
+		}
+		if payload.PerUserLimit > 0 {
+			filter["count"] = bson.M{"$lte": payload.PerUserLimit - payload.Quantity}
+		}
+		redeemed := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "promo-redemption",
+			Result:         &redemption,
+			Filter:         filter,
+			Update: bson.M{
+				"$inc": bson.M{"count": payload.Quantity},
+				"$set": bson.M{"updatedAt": now},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		if redeemed.Error != nil || redeemed.Data == nil {
+			output <- redeemed
+			return
+		}
+
+		filter = bson.M{
+			"code":   payload.Code,
+			"active": true,
+		}
+		if payload.UsageLimit > 0 {
+			filter["used"] = bson.M{"$lte": payload.UsageLimit - payload.Quantity}
+		}
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "promo-code",
+			Result:         &promoCode,
+			Filter:         filter,
+			Update: bson.M{
+				"$inc": bson.M{"used": payload.Quantity},
+				"$set": bson.M{"updatedAt": now},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+	}()
+
+	return output
+}
+
+// ReturnPromoCode gives the uses of released tickets back to the user and to the code.
+func (c commandMongodbRepository) ReturnPromoCode(ctx context.Context, payload request.RedeemPromoReq) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var redemption entity.Redemption
+	var promoCode entity.PromoCode
+
+	go func() {
+		defer close(output)
+		now := time.Now()
+
+		returned := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "promo-redemption",
+			Result:         &redemption,
+			Filter: bson.M{
+				"code":   payload.Code,
+				"userId": payload.UserId,
+				"count":  bson.M{"$gte": payload.Quantity},
+			},
+			Update: bson.M{
+				"$inc": bson.M{"count": -payload.Quantity},
+				"$set": bson.M{"updatedAt": now},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		if returned.Error != nil || returned.Data == nil {
+			output <- returned
+			return
+		}
+
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "promo-code",
+			Result:         &promoCode,
+			Filter: bson.M{
+				"code": payload.Code,
+				"used": bson.M{"$gte": payload.Quantity},
+			},
+			Update: bson.M{
+				"$inc": bson.M{"used": -payload.Quantity},
+				"$set": bson.M{"updatedAt": now},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+	}()
+
+	return output
+}
+
+// CreatePromoIndexes keeps codes unique and a user to a single redemption counter per code.
+func (c commandMongodbRepository) CreatePromoIndexes(ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+
+		resp := <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "promo-code",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "code", Value: 1}},
+					Options: options.Index().SetName(indexPromoCode).SetUnique(true),
+				},
+			},
+		}, ctx)
+		if resp.Error != nil {
+			output <- resp
+			return
+		}
+
+		resp = <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "promo-redemption",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "code", Value: 1}, {Key: "userId", Value: 1}},
+					Options: options.Index().SetName(indexPromoRedemptionUser).SetUnique(true),
+				},
+			},
+		}, ctx)
+		output <- resp
+	}()
+
+	return output
+}
@@ -0,0 +1,188 @@
+package commands_test
+
+import (
+	"context"
+	"order-service/internal/modules/promo"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/modules/promo/models/request"
+	mongoRC "order-service/internal/modules/promo/repositories/commands"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/helpers"
+	mocks "order-service/mocks/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommandTestSuite struct {
+	suite.Suite
+	mockMongodb *mocks.Collections
+	mockLogger  *mocklog.Logger
+	repository  promo.MongodbRepositoryCommand
+	ctx         context.Context
+}
+
+func (suite *CommandTestSuite) SetupTest() {
+	suite.mockMongodb = new(mocks.Collections)
+	suite.mockLogger = &mocklog.Logger{}
+	suite.repository = mongoRC.NewCommandMongodbRepository(
+		suite.mockMongodb,
+		suite.mockLogger,
+	)
+	suite.ctx = context.Background()
+}
+
+func TestCommandTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandTestSuite))
+}
+
+func (suite *CommandTestSuite) TestRedeemPromoCode() {
+	payload := request.RedeemPromoReq{
+		Code:         "FANS",
+		UserId:       "user",
+		Quantity:     2,
+		UsageLimit:   100,
+		PerUserLimit: 4,
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(func(mongodb.FindOneAndUpdate, options.ReturnDocument, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: &entity.PromoCode{}})
+	})
+
+	// Act
+	result := <-suite.repository.RedeemPromoCode(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	assert.NotNil(suite.T(), result.Data)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "FindOneAndUpdate", 3)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		return req.CollectionName == "promo-redemption" && req.Upsert
+	}), mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		filter := req.Filter.(bson.M)
+		return req.CollectionName == "promo-redemption" && !req.Upsert &&
+			assert.ObjectsAreEqual(bson.M{"$lte": 2}, filter["count"])
+	}), mock.Anything, mock.Anything)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		filter := req.Filter.(bson.M)
+		return req.CollectionName == "promo-code" &&
+			assert.ObjectsAreEqual(bson.M{"$lte": 98}, filter["used"])
+	}), mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestRedeemPromoCodeUnlimited() {
+	payload := request.RedeemPromoReq{
+		Code:     "FANS",
+		UserId:   "user",
+		Quantity: 2,
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(func(mongodb.FindOneAndUpdate, options.ReturnDocument, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: &entity.PromoCode{}})
+	})
+
+	// Act
+	result := <-suite.repository.RedeemPromoCode(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertNotCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		filter := req.Filter.(bson.M)
+		return filter["count"] != nil || filter["used"] != nil
+	}), mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestRedeemPromoCodePerUserLimit() {
+	payload := request.RedeemPromoReq{
+		Code:         "FANS",
+		UserId:       "user",
+		Quantity:     2,
+		PerUserLimit: 2,
+	}
+
+	// Mock FindOneAndUpdate, the counter of the user is already at its limit
+	suite.mockMongodb.On("FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		return req.Upsert
+	}), mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Data: &entity.Redemption{Count: 2}}))
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Data: nil}))
+
+	// Act
+	result := <-suite.repository.RedeemPromoCode(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	assert.Nil(suite.T(), result.Data)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "FindOneAndUpdate", 2)
+}
+
+func (suite *CommandTestSuite) TestReturnPromoCode() {
+	payload := request.RedeemPromoReq{
+		Code:     "FANS",
+		UserId:   "user",
+		Quantity: 1,
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(func(mongodb.FindOneAndUpdate, options.ReturnDocument, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: &entity.PromoCode{}})
+	})
+
+	// Act
+	result := <-suite.repository.ReturnPromoCode(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "FindOneAndUpdate", 2)
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.MatchedBy(func(req mongodb.FindOneAndUpdate) bool {
+		update := req.Update.(bson.M)
+		return req.CollectionName == "promo-code" && assert.ObjectsAreEqual(bson.M{"used": -1}, update["$inc"])
+	}), mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestReturnPromoCodeNotRedeemed() {
+	payload := request.RedeemPromoReq{
+		Code:     "FANS",
+		UserId:   "user",
+		Quantity: 1,
+	}
+
+	// Mock FindOneAndUpdate
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(resultChannel(helpers.Result{Data: nil}))
+
+	// Act
+	result := <-suite.repository.ReturnPromoCode(suite.ctx, payload)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "FindOneAndUpdate", 1)
+}
+
+func (suite *CommandTestSuite) TestCreatePromoIndexes() {
+	// Mock CreateIndexes
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return(func(mongodb.CreateIndexes, context.Context) <-chan helpers.Result {
+		return resultChannel(helpers.Result{Data: []string{"index"}})
+	})
+
+	// Act
+	result := <-suite.repository.CreatePromoIndexes(suite.ctx)
+
+	// Asset
+	assert.NoError(suite.T(), result.Error)
+	suite.mockMongodb.AssertNumberOfCalls(suite.T(), "CreateIndexes", 2)
+}
+
+func resultChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result, 1)
+	responseChan <- result
+	close(responseChan)
+
+	return responseChan
+}
@@ -0,0 +1,43 @@
+package queries
+
+import (
+	"context"
+	"order-service/internal/modules/promo"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/pkg/databases/mongodb"
+	wrapper "order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type queryMongodbRepository struct {
+	mongoDb mongodb.Collections
+	logger  log.Logger
+}
+
+func NewQueryMongodbRepository(mongodb mongodb.Collections, log log.Logger) promo.MongodbRepositoryQuery {
+	return &queryMongodbRepository{
+		mongoDb: mongodb,
+		logger:  log,
+	}
+}
+
+func (q queryMongodbRepository) FindPromoCode(ctx context.Context, code string) <-chan wrapper.Result {
+	var promoCode entity.PromoCode
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindOne(mongodb.FindOne{
+			Result:         &promoCode,
+			CollectionName: "promo-code",
+			Filter: bson.M{
+				"code": code,
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/promo"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/modules/promo/models/request"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"time"
+
+	"go.elastic.co/apm"
+)
+
+type codeValidator struct {
+	promoRepositoryQuery promo.MongodbRepositoryQuery
+	logger               log.Logger
+}
+
+// NewCodeValidator checks codes against the promo-code collection.
+func NewCodeValidator(pmq promo.MongodbRepositoryQuery, log log.Logger) promo.CodeValidator {
+	return codeValidator{
+		promoRepositoryQuery: pmq,
+		logger:               log,
+	}
+}
+
+func (v codeValidator) Validate(origCtx context.Context, payload request.ValidateCodeReq) (*entity.PromoCode, error) {
+	domain := "promoValidator-Validate"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	promoData := <-v.promoRepositoryQuery.FindPromoCode(ctx, payload.Code)
+	if promoData.Error != nil {
+		msg := "Error DB connection FindPromoCode"
+		v.logger.Error(ctx, msg, fmt.Sprintf("%+v", promoData.Error))
+		return nil, promoData.Error
+	}
+
+	if promoData.Data == nil {
+		msg := "promo code not found"
+		v.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.NotFound("promo code not found")
+	}
+
+	promoCode, ok := promoData.Data.(*entity.PromoCode)
+	if !ok {
+		msg := "cannot parsing data promo code"
+		v.logger.Error(ctx, msg, fmt.Sprintf("%+v", promoData.Data))
+		return nil, errors.InternalServerError("cannot parsing data promo code")
+	}
+
+	if err := checkCode(*promoCode, payload); err != nil {
+		v.logger.Error(ctx, err.Error(), fmt.Sprintf("%+v", payload))
+		return nil, err
+	}
+
+	return promoCode, nil
+}
+
+func checkCode(promoCode entity.PromoCode, payload request.ValidateCodeReq) error {
+	if !promoCode.Active {
+		return errors.NotFound("promo code not found")
+	}
+
+	if !promoCode.StartAt.IsZero() && payload.At.Before(promoCode.StartAt) {
+		return errors.BadRequest("promo code is not valid yet")
+	}
+
+	if !promoCode.EndAt.IsZero() && !payload.At.Before(promoCode.EndAt) {
+		return errors.BadRequest("promo code has expired")
+	}
+
+	if len(promoCode.EventIds) > 0 && !contains(promoCode.EventIds, payload.EventId) {
+		return errors.BadRequest("promo code is not valid for this event")
+	}
+
+	if len(promoCode.TicketTypes) > 0 && len(payload.TicketTypes) > 0 {
+		eligible := false
+		for _, ticketType := range payload.TicketTypes {
+			eligible = eligible || contains(promoCode.TicketTypes, ticketType)
+		}
+		if !eligible {
+			return errors.BadRequest("promo code is not valid for these ticket types")
+		}
+	}
+
+	// redeeming checks the limit again, this only turns away codes that are used up already
+	if promoCode.UsageLimit > 0 && promoCode.Used >= promoCode.UsageLimit {
+		return errors.BadRequest("promo code usage limit reached")
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/internal/modules/promo"
+	"order-service/internal/modules/promo/models/entity"
+	"order-service/internal/modules/promo/models/request"
+	uc "order-service/internal/modules/promo/usecases"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mockcert "order-service/mocks/modules/promo"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidatorTestSuite struct {
+	suite.Suite
+	mockPromoRepositoryQuery *mockcert.MongodbRepositoryQuery
+	mockLogger               *mocklog.Logger
+	validator                promo.CodeValidator
+	ctx                      context.Context
+}
+
+func (suite *ValidatorTestSuite) SetupTest() {
+	suite.mockPromoRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.ctx = context.Background()
+	suite.validator = uc.NewCodeValidator(suite.mockPromoRepositoryQuery, suite.mockLogger)
+}
+
+func TestValidatorTestSuite(t *testing.T) {
+	suite.Run(t, new(ValidatorTestSuite))
+}
+
+func mockChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result)
+
+	go func() {
+		responseChan <- result
+		close(responseChan)
+	}()
+
+	return responseChan
+}
+
+func (suite *ValidatorTestSuite) mockCode(promoCode entity.PromoCode) {
+	suite.mockPromoRepositoryQuery.On("FindPromoCode", mock.Anything, "FANS").Return(mockChannel(helpers.Result{Data: &promoCode}))
+}
+
+func validateReq() request.ValidateCodeReq {
+	return request.ValidateCodeReq{
+		Code:        "FANS",
+		EventId:     "event",
+		TicketTypes: []string{"VIP", "Gold"},
+		At:          time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func (suite *ValidatorTestSuite) TestValidate() {
+	suite.mockCode(entity.PromoCode{
+		Code:        "FANS",
+		Kind:        entity.KindPresale,
+		UsageLimit:  10,
+		Used:        9,
+		EventIds:    []string{"event"},
+		TicketTypes: []string{"VIP"},
+		StartAt:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:       time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+		Active:      true,
+	})
+
+	result, err := suite.validator.Validate(suite.ctx, validateReq())
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), entity.KindPresale, result.Kind)
+}
+
+func (suite *ValidatorTestSuite) TestValidateInvalid() {
+	cases := map[string]entity.PromoCode{
+		"promo code not found":                           {Code: "FANS"},
+		"promo code is not valid yet":                    {Code: "FANS", Active: true, StartAt: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		"promo code has expired":                         {Code: "FANS", Active: true, EndAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)},
+		"promo code is not valid for this event":         {Code: "FANS", Active: true, EventIds: []string{"other"}},
+		"promo code is not valid for these ticket types": {Code: "FANS", Active: true, TicketTypes: []string{"Online"}},
+		"promo code usage limit reached":                 {Code: "FANS", Active: true, UsageLimit: 10, Used: 10},
+	}
+
+	for message, promoCode := range cases {
+		suite.SetupTest()
+		suite.mockCode(promoCode)
+
+		_, err := suite.validator.Validate(suite.ctx, validateReq())
+
+		assert.Error(suite.T(), err, message)
+		assert.Equal(suite.T(), message, err.Error())
+	}
+}
+
+func (suite *ValidatorTestSuite) TestValidateWithoutTicketTypes() {
+	suite.mockCode(entity.PromoCode{Code: "FANS", Active: true, TicketTypes: []string{"VIP"}})
+	payload := validateReq()
+	payload.TicketTypes = nil
+
+	_, err := suite.validator.Validate(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ValidatorTestSuite) TestValidateErrNotFound() {
+	suite.mockPromoRepositoryQuery.On("FindPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.validator.Validate(suite.ctx, validateReq())
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code not found", err.Error())
+}
+
+func (suite *ValidatorTestSuite) TestValidateErrDB() {
+	suite.mockPromoRepositoryQuery.On("FindPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.validator.Validate(suite.ctx, validateReq())
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ValidatorTestSuite) TestValidateErrParse() {
+	suite.mockPromoRepositoryQuery.On("FindPromoCode", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: "code",
+	}))
+
+	_, err := suite.validator.Validate(suite.ctx, validateReq())
+
+	assert.Error(suite.T(), err)
+}
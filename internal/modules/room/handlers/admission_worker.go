@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/room"
+	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/worker"
+	"time"
+)
+
+// AdmissionWorker periodically admits the next batch of every active waiting room.
+type AdmissionWorker struct {
+	Admission room.AdmissionController
+	Logger    log.Logger
+
+	*worker.Worker
+}
+
+func InitAdmissionWorker(adm room.AdmissionController, log log.Logger, interval time.Duration) *AdmissionWorker {
+	admissionWorker := &AdmissionWorker{
+		Admission: adm,
+		Logger:    log,
+	}
+	admissionWorker.Worker = worker.Start(interval, admissionWorker.admit)
+
+	return admissionWorker
+}
+
+func (w *AdmissionWorker) admit(ctx context.Context) {
+	total, err := w.Admission.AdmitNextBatch(ctx)
+	if err != nil {
+		w.Logger.Error(ctx, "Admission worker failed", fmt.Sprintf("%+v", err))
+		return
+	}
+
+	if total > 0 {
+		w.Logger.Info(ctx, "Admission worker advanced events", fmt.Sprintf("%d", total))
+	}
+}
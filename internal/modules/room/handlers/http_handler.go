@@ -1,36 +1,63 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"order-service/internal/modules/room"
 	"order-service/internal/modules/room/models/request"
+	"order-service/internal/modules/room/models/response"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
 	"order-service/internal/pkg/redis"
+	"time"
 
 	middlewares "order-service/configs/middleware"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// statusStreamInterval is how often the serving cursor of a streamed event is checked.
+	statusStreamInterval = 3 * time.Second
+	// statusKeepAliveInterval is how often a stream reads its status from the usecase, which keeps the queue
+	// entry from expiring, well within the entry ttl.
+	statusKeepAliveInterval = 5 * time.Minute
 )
 
 type RoomHttpHandler struct {
 	RoomUsecaseCommand room.UsecaseCommand
+	RoomUsecaseQuery   room.UsecaseQuery
+	Admission          room.AdmissionController
+	StatusBroadcaster  *StatusBroadcaster
 	Logger             log.Logger
 	Validator          *validator.Validate
 }
 
-func InitRoomHttpHandler(app *fiber.App, ruc room.UsecaseCommand, log log.Logger, redisClient redis.Collections) {
+func InitRoomHttpHandler(app *fiber.App, ruc room.UsecaseCommand, ruq room.UsecaseQuery, adm room.AdmissionController, log log.Logger, redisClient redis.Collections) {
 	handler := &RoomHttpHandler{
 		RoomUsecaseCommand: ruc,
+		RoomUsecaseQuery:   ruq,
+		Admission:          adm,
+		StatusBroadcaster:  InitStatusBroadcaster(adm, log, statusStreamInterval),
 		Logger:             log,
 		Validator:          validator.New(),
 	}
+	adminOnly := middlewares.AllowedRoles(constants.RoleAdmin)
 	middlewares := middlewares.NewMiddlewares(redisClient)
 	route := app.Group("/api/room")
 
-	route.Post("/v1/create-queue", middlewares.VerifyBearer(), handler.CreateQueueRoom)
+	route.Post("/v1/create-queue", middlewares.VerifyBearer(), middlewares.Idempotency(), handler.CreateQueueRoom)
+	route.Delete("/v1/queue", middlewares.VerifyBearer(), handler.LeaveQueueRoom)
+	route.Get("/v1/status", middlewares.VerifyBearer(), handler.GetQueueStatus)
+	route.Get("/v1/status/stream", middlewares.VerifyBearer(), handler.StreamQueueStatus)
+	route.Put("/v1/admin/capacity/:eventId", middlewares.VerifyBearer(), adminOnly, handler.RecomputeQueueLimit)
+	route.Delete("/v1/admin/capacity/:eventId", middlewares.VerifyBearer(), adminOnly, handler.InvalidateQueueLimit)
 }
 
 func (t RoomHttpHandler) CreateQueueRoom(c *fiber.Ctx) error {
@@ -52,3 +79,154 @@ func (t RoomHttpHandler) CreateQueueRoom(c *fiber.Ctx) error {
 	}
 	return helpers.RespSuccess(c, t.Logger, resp, "Create queue room success")
 }
+
+func (t RoomHttpHandler) LeaveQueueRoom(c *fiber.Ctx) error {
+	req := new(request.QueueReq)
+	if err := c.BodyParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+	if err := t.RoomUsecaseCommand.LeaveQueueRoom(c.Context(), *req); err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, nil, "Leave queue room success")
+}
+
+// RecomputeQueueLimit refreshes the cached queue limit of the event after its inventory changed.
+func (t RoomHttpHandler) RecomputeQueueLimit(c *fiber.Ctx) error {
+	req := request.QueueCapacityReq{
+		EventId: c.Params("eventId"),
+	}
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	resp, err := t.RoomUsecaseCommand.RecomputeQueueLimit(c.Context(), req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Recompute queue limit success")
+}
+
+// InvalidateQueueLimit drops the cached queue limit, the next join computes it again.
+func (t RoomHttpHandler) InvalidateQueueLimit(c *fiber.Ctx) error {
+	req := request.QueueCapacityReq{
+		EventId: c.Params("eventId"),
+	}
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	if err := t.RoomUsecaseCommand.InvalidateQueueLimit(c.Context(), req); err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, nil, "Invalidate queue limit success")
+}
+
+func (t RoomHttpHandler) GetQueueStatus(c *fiber.Ctx) error {
+	req := new(request.QueueStatusReq)
+	if err := c.QueryParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	resp, err := t.RoomUsecaseQuery.GetQueueStatus(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+	return helpers.RespSuccess(c, t.Logger, resp, "Get queue status success")
+}
+
+// StreamQueueStatus pushes the queue status as Server-Sent Events until the user is admitted
+// or the client goes away. The stream follows the serving cursor through the StatusBroadcaster and works out
+// the status from it, the usecase is only asked again for the admission token or to keep the entry alive.
+func (t RoomHttpHandler) StreamQueueStatus(c *fiber.Ctx) error {
+	req := new(request.QueueStatusReq)
+	if err := c.QueryParser(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest("bad request"))
+	}
+
+	userId := c.Locals("userId").(string)
+	req.UserId = userId
+	if err := t.Validator.Struct(req); err != nil {
+		return helpers.RespError(c, t.Logger, errors.BadRequest(err.Error()))
+	}
+
+	// fail fast with a regular response when the user has no queue entry
+	resp, err := t.RoomUsecaseQuery.GetQueueStatus(c.Context(), *req)
+	if err != nil {
+		return helpers.RespCustomError(c, t.Logger, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	payload := *req
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		// the request context must not be used once the handler has returned
+		ctx := context.Background()
+		updates, unsubscribe := t.StatusBroadcaster.Subscribe(payload.EventId, resp.ServingNumber)
+		defer unsubscribe()
+		keepAlive := time.NewTicker(statusKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			if err := writeEvent(w, "status", resp); err != nil {
+				return
+			}
+			if resp.Admitted {
+				return
+			}
+
+			select {
+			case serving := <-updates:
+				if serving < resp.QueueNumber {
+					resp = t.waitingStatus(*resp, serving)
+					continue
+				}
+			case <-keepAlive.C:
+			}
+			resp, err = t.RoomUsecaseQuery.GetQueueStatus(ctx, payload)
+			if err != nil {
+				t.Logger.Error(ctx, "cannot stream queue status", fmt.Sprintf("%+v", err))
+				writeEvent(w, "error", fiber.Map{"message": err.Error()})
+				return
+			}
+		}
+	}))
+
+	return nil
+}
+
+// waitingStatus moves the status of a user still waiting to the serving cursor.
+func (t RoomHttpHandler) waitingStatus(status response.QueueStatusResp, serving int) *response.QueueStatusResp {
+	status.ServingNumber = serving
+	status.PeopleAhead = status.QueueNumber - serving - 1
+	status.EstimatedWait = t.Admission.EstimateWait(status.QueueNumber, serving)
+	return &status
+}
+
+func writeEvent(w *bufio.Writer, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body); err != nil {
+		return err
+	}
+	// a failed flush means the client disconnected
+	return w.Flush()
+}
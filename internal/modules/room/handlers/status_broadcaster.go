@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/room"
+	"order-service/internal/pkg/log"
+	"sync"
+	"time"
+)
+
+// StatusBroadcaster watches the serving cursor of the events followed by SSE clients. Every event has one loop
+// however many clients follow it, and the cursor is only sent to them when it moves.
+type StatusBroadcaster struct {
+	Admission room.AdmissionController
+	Logger    log.Logger
+	Interval  time.Duration
+
+	mu     sync.Mutex
+	events map[string]*servingWatch
+}
+
+type servingWatch struct {
+	serving     int
+	subscribers map[chan int]struct{}
+}
+
+func InitStatusBroadcaster(adm room.AdmissionController, log log.Logger, interval time.Duration) *StatusBroadcaster {
+	return &StatusBroadcaster{
+		Admission: adm,
+		Logger:    log,
+		Interval:  interval,
+		events:    make(map[string]*servingWatch),
+	}
+}
+
+// Subscribe follows the serving cursor of the event from serving, the cursor the client has already seen. The
+// channel only keeps the latest cursor, a slow client skips the ones in between. The loop of the event starts
+// with its first subscriber and stops once the last one called the returned unsubscribe.
+func (b *StatusBroadcaster) Subscribe(eventId string, serving int) (<-chan int, func()) {
+	updates := make(chan int, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	watch, ok := b.events[eventId]
+	if !ok {
+		watch = &servingWatch{serving: serving, subscribers: make(map[chan int]struct{})}
+		b.events[eventId] = watch
+		go b.watch(eventId, watch)
+	}
+	watch.subscribers[updates] = struct{}{}
+
+	return updates, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(watch.subscribers, updates)
+	}
+}
+
+func (b *StatusBroadcaster) watch(eventId string, watch *servingWatch) {
+	ctx := context.Background()
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		if len(watch.subscribers) == 0 {
+			delete(b.events, eventId)
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		serving, err := b.Admission.ServingNumber(ctx, eventId)
+		if err != nil {
+			b.Logger.Error(ctx, "Status broadcaster failed", fmt.Sprintf("%s %+v", eventId, err))
+			continue
+		}
+
+		b.mu.Lock()
+		if serving != watch.serving {
+			watch.serving = serving
+			for updates := range watch.subscribers {
+				// only the broadcaster sends, so once the stale cursor is drained the send cannot block
+				select {
+				case <-updates:
+				default:
+				}
+				updates <- serving
+			}
+		}
+		b.mu.Unlock()
+	}
+}
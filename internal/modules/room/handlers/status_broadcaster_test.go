@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"order-service/internal/modules/room/handlers"
+	"order-service/internal/pkg/errors"
+	mockcert "order-service/mocks/modules/room"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func receive(t *testing.T, updates <-chan int) int {
+	select {
+	case serving := <-updates:
+		return serving
+	case <-time.After(time.Second):
+		t.Fatal("status broadcaster did not send the cursor")
+		return 0
+	}
+}
+
+func TestStatusBroadcaster(t *testing.T) {
+	cAdm := new(mockcert.AdmissionController)
+	cLog := new(mocklog.Logger)
+	cAdm.On("ServingNumber", mock.Anything, "event").Return(20, nil)
+
+	broadcaster := handlers.InitStatusBroadcaster(cAdm, cLog, 10*time.Millisecond)
+	first, unsubscribeFirst := broadcaster.Subscribe("event", 10)
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := broadcaster.Subscribe("event", 10)
+	defer unsubscribeSecond()
+
+	assert.Equal(t, 20, receive(t, first))
+	assert.Equal(t, 20, receive(t, second))
+
+	// the cursor did not move, nothing more is sent
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, first)
+	assert.Empty(t, second)
+}
+
+func TestStatusBroadcasterOneLoopPerEvent(t *testing.T) {
+	cAdm := new(mockcert.AdmissionController)
+	cLog := new(mocklog.Logger)
+	ticks := make(chan struct{}, 100)
+	cAdm.On("ServingNumber", mock.Anything, "event").Return(10, nil).Run(func(args mock.Arguments) {
+		ticks <- struct{}{}
+	})
+
+	broadcaster := handlers.InitStatusBroadcaster(cAdm, cLog, 20*time.Millisecond)
+	for i := 0; i < 50; i++ {
+		_, unsubscribe := broadcaster.Subscribe("event", 10)
+		defer unsubscribe()
+	}
+
+	time.Sleep(110 * time.Millisecond)
+	// one read per tick however many clients follow the event
+	assert.LessOrEqual(t, len(ticks), 6)
+	assert.NotZero(t, len(ticks))
+}
+
+func TestStatusBroadcasterStopsWithoutSubscribers(t *testing.T) {
+	cAdm := new(mockcert.AdmissionController)
+	cLog := new(mocklog.Logger)
+	cAdm.On("ServingNumber", mock.Anything, "event").Return(10, nil)
+
+	broadcaster := handlers.InitStatusBroadcaster(cAdm, cLog, 10*time.Millisecond)
+	_, unsubscribe := broadcaster.Subscribe("event", 10)
+	unsubscribe()
+
+	time.Sleep(50 * time.Millisecond)
+	cAdm.AssertNotCalled(t, "ServingNumber", mock.Anything, mock.Anything)
+}
+
+func TestStatusBroadcasterErr(t *testing.T) {
+	cAdm := new(mockcert.AdmissionController)
+	cLog := new(mocklog.Logger)
+	logged := make(chan struct{}, 1)
+	cAdm.On("ServingNumber", mock.Anything, "event").Return(0, errors.InternalServerError("error"))
+	cLog.On("Error", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		select {
+		case logged <- struct{}{}:
+		default:
+		}
+	})
+
+	broadcaster := handlers.InitStatusBroadcaster(cAdm, cLog, 10*time.Millisecond)
+	updates, unsubscribe := broadcaster.Subscribe("event", 10)
+	defer unsubscribe()
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("status broadcaster did not log the failure")
+	}
+	assert.Empty(t, updates)
+}
@@ -8,6 +8,9 @@ type QueueRoom struct {
 	EventId     string    `json:"eventId" bson:"eventId"`
 	QueueNumber int       `json:"queueNumber" bson:"queueNumber"`
 	CountryCode string    `json:"countryCode" bson:"countryCode"`
+	Status      string    `json:"status" bson:"status"`
+	ExpiredAt   time.Time `json:"expiredAt" bson:"expiredAt"`
+	LeftAt      time.Time `json:"leftAt" bson:"leftAt"`
 	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
 }
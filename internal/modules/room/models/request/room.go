@@ -1,6 +1,17 @@
 package request
 
+// QueueReq joins the queue of an event, PromoCode is the access code required while the event is in presale.
 type QueueReq struct {
-	UserId  string `json:"userId" validate:"required"`
+	UserId    string `json:"userId" validate:"required"`
+	EventId   string `json:"eventId" validate:"required"`
+	PromoCode string `json:"promoCode" validate:"omitempty,max=64"`
+}
+
+type QueueCapacityReq struct {
 	EventId string `json:"eventId" validate:"required"`
 }
+
+type QueueStatusReq struct {
+	UserId  string `query:"userId"`
+	EventId string `query:"eventId" validate:"required"`
+}
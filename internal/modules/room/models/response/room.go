@@ -5,3 +5,21 @@ type QueueResp struct {
 	QueueNumber int    `json:"queueNumber" bson:"queueNumber"`
 	CountryCode string `json:"countryCode" bson:"countryCode"`
 }
+
+type QueueStatusResp struct {
+	EventId       string `json:"eventId"`
+	QueueNumber   int    `json:"queueNumber"`
+	ServingNumber int    `json:"servingNumber"`
+	PeopleAhead   int    `json:"peopleAhead"`
+	EstimatedWait int    `json:"estimatedWait"`
+	Admitted      bool   `json:"admitted"`
+	// AdmissionToken is only issued once the user is admitted, it is sent as X-Admission-Token on create-order
+	AdmissionToken     string `json:"admissionToken,omitempty"`
+	AdmissionExpiredAt string `json:"admissionExpiredAt,omitempty"`
+}
+
+type QueueCapacityResp struct {
+	EventId    string `json:"eventId"`
+	Policy     string `json:"policy"`
+	QueueLimit int    `json:"queueLimit"`
+}
@@ -4,12 +4,26 @@ import (
 	"context"
 	room "order-service/internal/modules/room"
 	"order-service/internal/modules/room/models/entity"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/errors"
 	wrapper "order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	indexEventUser  = "eventId_userId_unique"
+	indexEventQueue = "eventId_queueNumber_unique"
+	indexExpiredAt  = "expiredAt_ttl"
+	// expired entries are kept for a day so the re-entry policy can still see them
+	queueRetention = 24 * time.Hour
 )
 
 type commandMongodbRepository struct {
@@ -24,9 +38,12 @@ func NewCommandMongodbRepository(mongodb mongodb.Collections, log log.Logger) ro
 	}
 }
 
+// InsertOneRoom stores a new queue entry, an id is generated unless the caller already picked one.
 func (c commandMongodbRepository) InsertOneRoom(ctx context.Context, room entity.QueueRoom) <-chan wrapper.Result {
 	output := make(chan wrapper.Result)
-	room.QueueId = uuid.NewString()
+	if room.QueueId == "" {
+		room.QueueId = uuid.NewString()
+	}
 	room.CreatedAt = time.Now()
 	room.UpdatedAt = time.Now()
 
@@ -35,6 +52,122 @@ func (c commandMongodbRepository) InsertOneRoom(ctx context.Context, room entity
 			CollectionName: "queue-room",
 			Document:       room,
 		}, ctx)
+		if resp.Error != nil {
+			switch {
+			case strings.Contains(resp.Error.Error(), indexEventUser):
+				resp.Error = errors.Conflict("user already in the queue")
+			case strings.Contains(resp.Error.Error(), indexEventQueue):
+				resp.Error = errors.Conflict("queue number already taken, please retry")
+			}
+		}
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+func (c commandMongodbRepository) CreateQueueIndexes(ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.CreateIndexes(mongodb.CreateIndexes{
+			CollectionName: "queue-room",
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "eventId", Value: 1}, {Key: "userId", Value: 1}},
+					Options: options.Index().SetName(indexEventUser).SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "eventId", Value: 1}, {Key: "queueNumber", Value: 1}},
+					Options: options.Index().SetName(indexEventQueue).SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "expiredAt", Value: 1}},
+					Options: options.Index().SetName(indexExpiredAt).SetExpireAfterSeconds(int32(queueRetention.Seconds())),
+				},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// LeaveQueueRoom marks the active entry of the user as left, expiredAt is kept so the re-entry policy can use it.
+func (c commandMongodbRepository) LeaveQueueRoom(ctx context.Context, userId string, eventId string) <-chan wrapper.Result {
+	var room entity.QueueRoom
+	output := make(chan wrapper.Result)
+	now := time.Now()
+
+	go func() {
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			Result:         &room,
+			CollectionName: "queue-room",
+			Filter: bson.M{
+				"userId":    userId,
+				"eventId":   eventId,
+				"status":    bson.M{"$ne": constants.QueueLeft},
+				"expiredAt": bson.M{"$gt": now},
+			},
+			Update: bson.M{
+				"$set": bson.M{
+					"status":    constants.QueueLeft,
+					"leftAt":    now,
+					"updatedAt": now,
+				},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// ReenterQueueRoom reactivates a left or expired entry with a new queue number, the unique index
+// on eventId and userId keeps a single entry per user.
+func (c commandMongodbRepository) ReenterQueueRoom(ctx context.Context, room entity.QueueRoom) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.UpdateOne(mongodb.UpdateOne{
+			CollectionName: "queue-room",
+			Filter: bson.M{
+				"queueId": room.QueueId,
+			},
+			Document: bson.M{
+				"queueNumber": room.QueueNumber,
+				"countryCode": room.CountryCode,
+				"status":      constants.QueueActive,
+				"expiredAt":   room.ExpiredAt,
+				"leftAt":      time.Time{},
+				"updatedAt":   time.Now(),
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+func (c commandMongodbRepository) RefreshQueueRoom(ctx context.Context, queueId string, expiredAt time.Time) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.UpdateOne(mongodb.UpdateOne{
+			CollectionName: "queue-room",
+			Filter: bson.M{
+				"queueId": queueId,
+				"status":  constants.QueueActive,
+			},
+			Document: bson.M{
+				"expiredAt": expiredAt,
+			},
+		}, ctx)
 		output <- resp
 		close(output)
 	}()
@@ -58,3 +191,15 @@ func (c commandMongodbRepository) InsertOneRoom(ctx context.Context, room entity
 
 // 	return output
 // }
+
+func (c commandMongodbRepository) WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-c.mongoDb.WithTransaction(fn, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
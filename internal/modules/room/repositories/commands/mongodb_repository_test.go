@@ -5,10 +5,13 @@ import (
 	"order-service/internal/modules/room"
 	userEntity "order-service/internal/modules/room/models/entity"
 	mongoRC "order-service/internal/modules/room/repositories/commands"
+	"order-service/internal/pkg/databases/mongodb"
+	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
 	mocks "order-service/mocks/pkg/databases/mongodb"
 	mocklog "order-service/mocks/pkg/log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -63,3 +66,158 @@ func (suite *CommandTestSuite) TestUpsertOneUserTemp() {
 	// Assert UpsertOne
 	suite.mockMongodb.AssertCalled(suite.T(), "InsertOne", mock.Anything, mock.Anything)
 }
+
+func (suite *CommandTestSuite) TestCreateQueueIndexes() {
+	// Mock CreateIndexes
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("CreateIndexes", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.CreateQueueIndexes(suite.ctx)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert CreateIndexes
+	suite.mockMongodb.AssertCalled(suite.T(), "CreateIndexes", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestInsertOneRoomDuplicate() {
+	testUser := userEntity.QueueRoom{
+		QueueId: "id",
+	}
+
+	// Mock InsertOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("InsertOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.InsertOneRoom(suite.ctx, testUser)
+
+	// Simulate a duplicate key on the event and user index
+	go func() {
+		expectedResult <- helpers.Result{Error: errors.Conflict("E11000 duplicate key error collection: queue-room index: eventId_userId_unique dup key")}
+		close(expectedResult)
+	}()
+
+	resp := <-result
+	assert.Equal(suite.T(), "user already in the queue", resp.Error.Error())
+}
+
+func (suite *CommandTestSuite) TestInsertOneRoomKeepsQueueId() {
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("InsertOne", mock.MatchedBy(func(payload mongodb.InsertOne) bool {
+		room, ok := payload.Document.(userEntity.QueueRoom)
+		return ok && room.QueueId == "id"
+	}), mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	result := suite.repository.InsertOneRoom(suite.ctx, userEntity.QueueRoom{QueueId: "id"})
+
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	resp := <-result
+	assert.NoError(suite.T(), resp.Error)
+}
+
+func (suite *CommandTestSuite) TestLeaveQueueRoom() {
+	// Mock FindOneAndUpdate
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.LeaveQueueRoom(suite.ctx, "id", "id")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOneAndUpdate
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestReenterQueueRoom() {
+	// Mock UpdateOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("UpdateOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.ReenterQueueRoom(suite.ctx, userEntity.QueueRoom{QueueId: "id"})
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert UpdateOne
+	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestRefreshQueueRoom() {
+	// Mock UpdateOne
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("UpdateOne", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.RefreshQueueRoom(suite.ctx, "id", time.Now())
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert UpdateOne
+	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestWithTransaction() {
+	// Mock WithTransaction
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("WithTransaction", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.WithTransaction(suite.ctx, func(sessCtx context.Context) error { return nil })
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert WithTransaction
+	suite.mockMongodb.AssertCalled(suite.T(), "WithTransaction", mock.Anything, mock.Anything)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	room "order-service/internal/modules/room"
 	"order-service/internal/modules/room/models/entity"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/databases/mongodb"
 	wrapper "order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -35,7 +37,7 @@ func (q queryMongodbRepository) FindOneLastQueue(ctx context.Context, eventId st
 				"eventId": eventId,
 			},
 			Sort: &mongodb.Sort{
-				FieldName: "seatNumber",
+				FieldName: "queueNumber",
 				By:        mongodb.SortDescending,
 			},
 		}, ctx)
@@ -65,3 +67,25 @@ func (q queryMongodbRepository) FindOneQueueByUserId(ctx context.Context, userId
 
 	return output
 }
+
+// FindActiveQueues returns the entries still holding a spot, left and expired entries free theirs.
+func (q queryMongodbRepository) FindActiveQueues(ctx context.Context, eventId string) <-chan wrapper.Result {
+	var rooms []entity.QueueRoom
+	output := make(chan wrapper.Result)
+
+	go func() {
+		resp := <-q.mongoDb.FindMany(mongodb.FindMany{
+			Result:         &rooms,
+			CollectionName: "queue-room",
+			Filter: bson.M{
+				"eventId":   eventId,
+				"status":    bson.M{"$ne": constants.QueueLeft},
+				"expiredAt": bson.M{"$gt": time.Now()},
+			},
+		}, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
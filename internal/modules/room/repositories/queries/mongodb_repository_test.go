@@ -83,3 +83,26 @@ func (suite *CommandTestSuite) TestFindOneQueueByUserId() {
 	// Assert FindOne
 	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.Anything, mock.Anything)
 }
+
+func (suite *CommandTestSuite) TestFindActiveQueues() {
+	// Mock FindMany
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.FindActiveQueues(suite.ctx, "id")
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindMany
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.Anything, mock.Anything)
+}
@@ -2,24 +2,58 @@ package room
 
 import (
 	"context"
+	eventEntity "order-service/internal/modules/event/models/entity"
 	"order-service/internal/modules/room/models/entity"
 	"order-service/internal/modules/room/models/request"
 	"order-service/internal/modules/room/models/response"
 	wrapper "order-service/internal/pkg/helpers"
+	"time"
 )
 
 type UsecaseCommand interface {
 	CreateQueueRoom(origCtx context.Context, payload request.QueueReq) (*response.QueueResp, error)
+	LeaveQueueRoom(origCtx context.Context, payload request.QueueReq) error
+	RecomputeQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) (*response.QueueCapacityResp, error)
+	InvalidateQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) error
 }
 
 type UsecaseQuery interface {
+	GetQueueStatus(origCtx context.Context, payload request.QueueStatusReq) (*response.QueueStatusResp, error)
+}
+
+// AdmissionController moves the per-event "now serving" cursor of the waiting room.
+type AdmissionController interface {
+	Open(ctx context.Context, eventId string) error
+	ServingNumber(ctx context.Context, eventId string) (int, error)
+	EstimateWait(queueNumber int, servingNumber int) int
+	AdmitNextBatch(ctx context.Context) (int, error)
+	Release(ctx context.Context, eventId string, queueId string) error
+	Revoke(ctx context.Context, eventId string, queueId string, queueNumber int) error
+}
+
+// QueueEntries keeps the entries holding a spot in the queue of an event, the queue limit is checked against it.
+type QueueEntries interface {
+	Reserve(ctx context.Context, eventId string, userId string, expiredAt time.Time, limit int) (bool, error)
+	Refresh(ctx context.Context, eventId string, userId string, expiredAt time.Time) error
+	Remove(ctx context.Context, eventId string, userId string) error
 }
 
 type MongodbRepositoryQuery interface {
 	FindOneLastQueue(ctx context.Context, eventId string) <-chan wrapper.Result
 	FindOneQueueByUserId(ctx context.Context, userId string, eventId string) <-chan wrapper.Result
+	FindActiveQueues(ctx context.Context, eventId string) <-chan wrapper.Result
 }
 
 type MongodbRepositoryCommand interface {
 	InsertOneRoom(ctx context.Context, room entity.QueueRoom) <-chan wrapper.Result
+	CreateQueueIndexes(ctx context.Context) <-chan wrapper.Result
+	LeaveQueueRoom(ctx context.Context, userId string, eventId string) <-chan wrapper.Result
+	ReenterQueueRoom(ctx context.Context, room entity.QueueRoom) <-chan wrapper.Result
+	RefreshQueueRoom(ctx context.Context, queueId string, expiredAt time.Time) <-chan wrapper.Result
+	WithTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan wrapper.Result
+}
+
+// QueueCapacityPolicy decides how many users may hold a spot in the queue of an event.
+type QueueCapacityPolicy interface {
+	Limit(ctx context.Context, event eventEntity.Event) (int, error)
 }
@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/configs"
+	"order-service/internal/modules/room"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAdmissionBatch    = 100
+	defaultAdmissionInterval = 30
+	queueKeyTTL              = 4 * 30 * 24 * time.Hour
+)
+
+// releaseScript advances the cursor once per queue entry. An entry may end more than once, every ticket of an
+// order expires and a user may leave after the hold expired, without admitting more than one user.
+var releaseScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[1]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// advanceScript admits the next batch of an event unless every issued queue number is admitted already or a
+// batch was admitted less than ARGV[2] ms ago. The stamp is shared by every replica running the admission worker,
+// so the event advances once per interval however many of them tick.
+var advanceScript = redis.NewScript(`
+local issued = tonumber(redis.call('GET', KEYS[3]) or '')
+if not issued then
+	return 0
+end
+local serving = tonumber(redis.call('GET', KEYS[2]) or '0')
+if serving >= issued then
+	return 0
+end
+if not redis.call('SET', KEYS[1], 1, 'NX', 'PX', ARGV[2]) then
+	return 0
+end
+redis.call('INCRBY', KEYS[2], ARGV[1])
+return 1
+`)
+
+type admissionController struct {
+	logger log.Logger
+	redis  redis.Collections
+}
+
+// NewAdmissionController admits queue numbers in batches. In time mode a batch is admitted on
+// every AdmitNextBatch call, in order mode every released order admits the next user.
+func NewAdmissionController(log log.Logger, rc redis.Collections) room.AdmissionController {
+	return admissionController{
+		logger: log,
+		redis:  rc,
+	}
+}
+
+// admissionBatch is the number of queue entries admitted at once.
+func admissionBatch() int {
+	batch, err := strconv.Atoi(configs.GetConfig().Room.AdmissionBatch)
+	if err != nil || batch <= 0 {
+		batch = defaultAdmissionBatch
+	}
+	return batch
+}
+
+// admissionInterval is the delay between two admitted batches.
+func admissionInterval() time.Duration {
+	seconds, err := strconv.Atoi(configs.GetConfig().Room.AdmissionInterval)
+	if err != nil || seconds <= 0 {
+		seconds = defaultAdmissionInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func admissionMode() string {
+	if configs.GetConfig().Room.AdmissionMode == constants.AdmissionOrder {
+		return constants.AdmissionOrder
+	}
+	return constants.AdmissionTime
+}
+
+// The per-event queue keys hash on the event id, so a redis cluster keeps the keys of one event on one slot.
+func servingKey(eventId string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.ORDER, constants.QueueServing, redis.HashTag(eventId))
+}
+
+func queueCounterKey(eventId string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.ORDER, constants.QueueCounter, redis.HashTag(eventId))
+}
+
+func advancedKey(eventId string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.ORDER, constants.QueueAdvanced, redis.HashTag(eventId))
+}
+
+func releasedKey(eventId string, queueId string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueReleased, redis.HashTag(eventId), queueId)
+}
+
+func revokedKey(eventId string, queueId string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueRevoked, redis.HashTag(eventId), queueId)
+}
+
+// Open registers the event on the waiting room and admits the first batch, it is safe to call on every join.
+func (a admissionController) Open(ctx context.Context, eventId string) error {
+	if err := a.redis.SAdd(ctx, fmt.Sprintf("%s:%s", constants.ORDER, constants.QueueActiveEvents), eventId).Err(); err != nil {
+		msg := "cannot register queue event"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot open admission")
+	}
+
+	if err := a.redis.SetNX(ctx, servingKey(eventId), admissionBatch(), 4*30*24*time.Hour).Err(); err != nil {
+		msg := "cannot seed serving cursor"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot open admission")
+	}
+
+	return nil
+}
+
+// ServingNumber returns the highest admitted queue number, zero when admission has not started.
+func (a admissionController) ServingNumber(ctx context.Context, eventId string) (int, error) {
+	cursor, _ := a.redis.Get(ctx, servingKey(eventId)).Result()
+	if cursor == "" {
+		return 0, nil
+	}
+
+	serving, err := strconv.Atoi(cursor)
+	if err != nil {
+		msg := "cannot parsing redis data"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", cursor))
+		return 0, errors.InternalServerError("cannot parsing redis data")
+	}
+
+	return serving, nil
+}
+
+// EstimateWait returns the seconds left before the queue number is admitted, assuming one batch per interval.
+func (a admissionController) EstimateWait(queueNumber int, servingNumber int) int {
+	remaining := queueNumber - servingNumber
+	if remaining <= 0 {
+		return 0
+	}
+	batches := (remaining + admissionBatch() - 1) / admissionBatch()
+	return batches * int(admissionInterval().Seconds())
+}
+
+// AdmitNextBatch advances the cursor of every active event by one batch, it does nothing in order mode.
+// Events whose cursor already covers every issued queue number, or that advanced within the interval on any
+// replica, are left untouched.
+func (a admissionController) AdmitNextBatch(ctx context.Context) (int, error) {
+	if admissionMode() != constants.AdmissionTime {
+		return 0, nil
+	}
+
+	events, err := a.redis.SMembers(ctx, fmt.Sprintf("%s:%s", constants.ORDER, constants.QueueActiveEvents)).Result()
+	if err != nil {
+		msg := "cannot get active queue events"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return 0, errors.InternalServerError("cannot get active queue events")
+	}
+
+	// a tick may come a little early, the stamp ends slightly before the interval so no batch is skipped
+	interval := admissionInterval()
+	stamp := interval - interval/10
+
+	var advanced int
+	for _, eventId := range events {
+		keys := []string{advancedKey(eventId), servingKey(eventId), queueCounterKey(eventId)}
+		done, err := advanceScript.Run(ctx, a.redis, keys, admissionBatch(), stamp.Milliseconds()).Int()
+		if err != nil {
+			msg := "cannot advance serving cursor"
+			a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+			continue
+		}
+		advanced += done
+	}
+
+	return advanced, nil
+}
+
+// Release admits one more user when the queue entry is done ordering, it does nothing in time mode.
+// Only the first release of an entry counts until the user joins again.
+func (a admissionController) Release(ctx context.Context, eventId string, queueId string) error {
+	if admissionMode() != constants.AdmissionOrder {
+		return nil
+	}
+
+	var err error
+	if queueId == "" {
+		// tickets claimed before orders carried their queue entry
+		err = a.redis.IncrBy(ctx, servingKey(eventId), 1).Err()
+	} else {
+		err = releaseScript.Run(ctx, a.redis, []string{releasedKey(eventId, queueId), servingKey(eventId)},
+			int(queueKeyTTL.Seconds())).Err()
+	}
+	if err != nil {
+		msg := "cannot advance serving cursor"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot release admission")
+	}
+
+	return nil
+}
+
+// Revoke records the queue number the entry left with, VerifyAdmission rejects the admission tokens issued up to
+// that number. A re-entry keeps the queue id but draws a higher number, so its new tokens stay valid.
+func (a admissionController) Revoke(ctx context.Context, eventId string, queueId string, queueNumber int) error {
+	if err := a.redis.Set(ctx, revokedKey(eventId, queueId), queueNumber, admissionTokenTTL()).Err(); err != nil {
+		msg := "cannot revoke admission"
+		a.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot revoke admission")
+	}
+
+	return nil
+}
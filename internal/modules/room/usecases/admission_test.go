@@ -0,0 +1,209 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/configs"
+	"order-service/internal/modules/room"
+	uc "order-service/internal/modules/room/usecases"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	mocklog "order-service/mocks/pkg/log"
+	mockredis "order-service/mocks/pkg/redis"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdmissionTestSuite struct {
+	suite.Suite
+	mockLogger *mocklog.Logger
+	mockRedis  *mockredis.Collections
+	admission  room.AdmissionController
+	ctx        context.Context
+}
+
+func (suite *AdmissionTestSuite) SetupTest() {
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockRedis = &mockredis.Collections{}
+	suite.ctx = context.Background()
+	suite.admission = uc.NewAdmissionController(
+		suite.mockLogger,
+		suite.mockRedis,
+	)
+	configs.GetConfig().Room = configs.RoomConfig{}
+}
+
+func (suite *AdmissionTestSuite) TearDownTest() {
+	configs.GetConfig().Room = configs.RoomConfig{}
+}
+
+func TestAdmissionTestSuite(t *testing.T) {
+	suite.Run(t, new(AdmissionTestSuite))
+}
+
+func (suite *AdmissionTestSuite) TestOpen() {
+	suite.mockRedis.On("SAdd", mock.Anything, "ORDER:QUEUE-ACTIVE-EVENTS", "id").Return(redis.NewIntResult(1, nil))
+	suite.mockRedis.On("SetNX", mock.Anything, "ORDER:QUEUE-SERVING:{id}", 100, mock.Anything).Return(redis.NewBoolResult(true, nil))
+
+	err := suite.admission.Open(suite.ctx, "id")
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AdmissionTestSuite) TestOpenErr() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("SAdd", mock.Anything, mock.Anything, mock.Anything).Return(redis.NewIntResult(0, errors.InternalServerError("error")))
+
+	err := suite.admission.Open(suite.ctx, "id")
+
+	assert.Error(suite.T(), err)
+
+	suite.mockRedis.ExpectedCalls = nil
+	suite.mockRedis.On("SAdd", mock.Anything, mock.Anything, mock.Anything).Return(redis.NewIntResult(1, nil))
+	suite.mockRedis.On("SetNX", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewBoolResult(false, errors.InternalServerError("error")))
+
+	err2 := suite.admission.Open(suite.ctx, "id")
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *AdmissionTestSuite) TestServingNumber() {
+	suite.mockRedis.On("Get", mock.Anything, "ORDER:QUEUE-SERVING:{id}").Return(redis.NewStringResult("200", nil))
+
+	serving, err := suite.admission.ServingNumber(suite.ctx, "id")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 200, serving)
+}
+
+func (suite *AdmissionTestSuite) TestServingNumberNotStarted() {
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("", redis.Nil))
+
+	serving, err := suite.admission.ServingNumber(suite.ctx, "id")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, serving)
+}
+
+func (suite *AdmissionTestSuite) TestServingNumberErrParse() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("tes", nil))
+
+	_, err := suite.admission.ServingNumber(suite.ctx, "id")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AdmissionTestSuite) TestEstimateWait() {
+	configs.GetConfig().Room.AdmissionBatch = "50"
+	configs.GetConfig().Room.AdmissionInterval = "10"
+
+	assert.Equal(suite.T(), 0, suite.admission.EstimateWait(10, 20))
+	assert.Equal(suite.T(), 10, suite.admission.EstimateWait(70, 20))
+	assert.Equal(suite.T(), 20, suite.admission.EstimateWait(71, 20))
+}
+
+func (suite *AdmissionTestSuite) TestAdmitNextBatch() {
+	waiting := []string{"ORDER:QUEUE-ADVANCED:{waiting}", "ORDER:QUEUE-SERVING:{waiting}", "ORDER:QUEUE-COUNTER:{waiting}"}
+	suite.mockRedis.On("SMembers", mock.Anything, "ORDER:QUEUE-ACTIVE-EVENTS").Return(redis.NewStringSliceResult([]string{"waiting", "drained"}, nil))
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, waiting, 100, int64(27000)).Return(redis.NewCmdResult(int64(1), nil))
+	// drained, or advanced by another replica within the interval
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(int64(0), nil))
+
+	total, err := suite.admission.AdmitNextBatch(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, total)
+	suite.mockRedis.AssertNumberOfCalls(suite.T(), "EvalSha", 2)
+	suite.mockRedis.AssertNotCalled(suite.T(), "IncrBy", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdmissionTestSuite) TestAdmitNextBatchErrAdvance() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("SMembers", mock.Anything, mock.Anything).Return(redis.NewStringSliceResult([]string{"waiting"}, nil))
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(nil, errors.InternalServerError("error")))
+
+	total, err := suite.admission.AdmitNextBatch(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, total)
+}
+
+func (suite *AdmissionTestSuite) TestAdmitNextBatchErr() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("SMembers", mock.Anything, mock.Anything).Return(redis.NewStringSliceResult(nil, errors.InternalServerError("error")))
+
+	_, err := suite.admission.AdmitNextBatch(suite.ctx)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AdmissionTestSuite) TestAdmitNextBatchOrderMode() {
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+
+	total, err := suite.admission.AdmitNextBatch(suite.ctx)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, total)
+	suite.mockRedis.AssertNotCalled(suite.T(), "SMembers", mock.Anything, mock.Anything)
+}
+
+func (suite *AdmissionTestSuite) TestRelease() {
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	keys := []string{"ORDER:QUEUE-RELEASED:{id}:queue", "ORDER:QUEUE-SERVING:{id}"}
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, keys, mock.Anything).Return(redis.NewCmdResult(int64(101), nil))
+
+	err := suite.admission.Release(suite.ctx, "id", "queue")
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, keys, mock.Anything)
+	suite.mockRedis.AssertNotCalled(suite.T(), "IncrBy", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdmissionTestSuite) TestReleaseWithoutQueue() {
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockRedis.On("IncrBy", mock.Anything, "ORDER:QUEUE-SERVING:{id}", int64(1)).Return(redis.NewIntResult(101, nil))
+
+	err := suite.admission.Release(suite.ctx, "id", "")
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "IncrBy", mock.Anything, "ORDER:QUEUE-SERVING:{id}", int64(1))
+}
+
+func (suite *AdmissionTestSuite) TestReleaseErr() {
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(nil, errors.InternalServerError("error")))
+
+	err := suite.admission.Release(suite.ctx, "id", "queue")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AdmissionTestSuite) TestReleaseTimeMode() {
+	err := suite.admission.Release(suite.ctx, "id", "queue")
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertNotCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdmissionTestSuite) TestRevoke() {
+	suite.mockRedis.On("Set", mock.Anything, "ORDER:QUEUE-REVOKED:{id}:queue", 150, mock.Anything).Return(redis.NewStatusResult("OK", nil))
+
+	err := suite.admission.Revoke(suite.ctx, "id", "queue", 150)
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "Set", mock.Anything, "ORDER:QUEUE-REVOKED:{id}:queue", 150, mock.Anything)
+}
+
+func (suite *AdmissionTestSuite) TestRevokeErr() {
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewStatusResult("", errors.InternalServerError("error")))
+
+	err := suite.admission.Revoke(suite.ctx, "id", "queue", 150)
+
+	assert.Error(suite.T(), err)
+}
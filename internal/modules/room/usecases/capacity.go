@@ -0,0 +1,95 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"order-service/internal/modules/room"
+	"order-service/internal/modules/ticket"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+	ticketEntity "order-service/internal/modules/ticket/models/entity"
+)
+
+type fixedCapacity struct{}
+
+// NewFixedCapacity limits the queue to the capacityLimit set on the event.
+func NewFixedCapacity() room.QueueCapacityPolicy {
+	return fixedCapacity{}
+}
+
+func (f fixedCapacity) Limit(ctx context.Context, event eventEntity.Event) (int, error) {
+	if event.Queue.CapacityLimit <= 0 {
+		return 0, errors.InternalServerError("queue capacity limit is not set")
+	}
+	return event.Queue.CapacityLimit, nil
+}
+
+type ratioCapacity struct {
+	ticketRepositoryQuery ticket.MongodbRepositoryQuery
+	logger                log.Logger
+}
+
+// NewRatioCapacity limits the queue to a ratio of the remaining tickets. Events without a
+// capacityRatio keep the former rule, a quarter of the tickets outside Q4 and all of them in Q4.
+func NewRatioCapacity(trq ticket.MongodbRepositoryQuery, log log.Logger) room.QueueCapacityPolicy {
+	return ratioCapacity{
+		ticketRepositoryQuery: trq,
+		logger:                log,
+	}
+}
+
+func (r ratioCapacity) Limit(ctx context.Context, event eventEntity.Event) (int, error) {
+	totalTicket := <-r.ticketRepositoryQuery.FindTotalAvalailableTicket(ctx, event.Country.Code, event.Tag)
+	if totalTicket.Error != nil {
+		msg := "Error DB connection FindTotalAvalailableTicket"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalTicket.Error))
+		return 0, totalTicket.Error
+	}
+
+	aggregateTicket, ok := totalTicket.Data.(*[]ticketEntity.AggregateTotalTicket)
+	if !ok {
+		msg := "cannot parsing data total ticket"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalTicket.Data))
+		return 0, errors.InternalServerError("cannot parsing data")
+	}
+
+	if len(*aggregateTicket) == 0 {
+		return 0, nil
+	}
+
+	ratio := event.Queue.CapacityRatio
+	if ratio <= 0 {
+		ratio = 0.25
+		if helpers.GetCurrentQuartal() == helpers.Q4 {
+			ratio = 1
+		}
+	}
+
+	return int(float64((*aggregateTicket)[0].TotalAvailableTicket) * ratio), nil
+}
+
+type unlimitedCapacity struct{}
+
+// NewUnlimitedCapacity never closes the queue, the admission batches still pace the orders.
+func NewUnlimitedCapacity() room.QueueCapacityPolicy {
+	return unlimitedCapacity{}
+}
+
+func (u unlimitedCapacity) Limit(ctx context.Context, event eventEntity.Event) (int, error) {
+	return math.MaxInt32, nil
+}
+
+// capacityPolicyName returns the policy set on the event, ratio when it is empty or unknown.
+func capacityPolicyName(event eventEntity.Event) string {
+	switch event.Queue.Capacity {
+	case constants.CapacityFixed, constants.CapacityUnlimited:
+		return event.Queue.Capacity
+	default:
+		return constants.CapacityRatio
+	}
+}
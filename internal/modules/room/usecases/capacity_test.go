@@ -0,0 +1,148 @@
+package usecases_test
+
+import (
+	"context"
+	"math"
+	uc "order-service/internal/modules/room/usecases"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	"testing"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+	ticketEntity "order-service/internal/modules/ticket/models/entity"
+	mockcertTicket "order-service/mocks/modules/ticket"
+	mocklog "order-service/mocks/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CapacityTestSuite struct {
+	suite.Suite
+	mockTicketRepositoryQuery *mockcertTicket.MongodbRepositoryQuery
+	mockLogger                *mocklog.Logger
+	ctx                       context.Context
+}
+
+func (suite *CapacityTestSuite) SetupTest() {
+	suite.mockTicketRepositoryQuery = &mockcertTicket.MongodbRepositoryQuery{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.ctx = context.Background()
+}
+
+func TestCapacityTestSuite(t *testing.T) {
+	suite.Run(t, new(CapacityTestSuite))
+}
+
+func (suite *CapacityTestSuite) TestFixedCapacity() {
+	event := eventEntity.Event{
+		Queue: eventEntity.QueueSetting{
+			CapacityLimit: 500,
+		},
+	}
+
+	limit, err := uc.NewFixedCapacity().Limit(suite.ctx, event)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 500, limit)
+
+	_, err2 := uc.NewFixedCapacity().Limit(suite.ctx, eventEntity.Event{})
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *CapacityTestSuite) TestRatioCapacity() {
+	event := eventEntity.Event{
+		Country: eventEntity.Country{
+			Code: "code",
+		},
+		Tag: "tag",
+		Queue: eventEntity.QueueSetting{
+			CapacityRatio: 0.5,
+		},
+	}
+	mockFindTotalAvalailableTicket := helpers.Result{
+		Data: &[]ticketEntity.AggregateTotalTicket{
+			{
+				TotalAvailableTicket: 301,
+			},
+		},
+		Error: nil,
+	}
+
+	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, "code", "tag").Return(mockChannel(mockFindTotalAvalailableTicket))
+
+	limit, err := uc.NewRatioCapacity(suite.mockTicketRepositoryQuery, suite.mockLogger).Limit(suite.ctx, event)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 150, limit)
+}
+
+func (suite *CapacityTestSuite) TestRatioCapacityDefault() {
+	mockFindTotalAvalailableTicket := helpers.Result{
+		Data: &[]ticketEntity.AggregateTotalTicket{
+			{
+				TotalAvailableTicket: 400,
+			},
+		},
+		Error: nil,
+	}
+
+	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindTotalAvalailableTicket))
+
+	limit, err := uc.NewRatioCapacity(suite.mockTicketRepositoryQuery, suite.mockLogger).Limit(suite.ctx, eventEntity.Event{})
+
+	assert.NoError(suite.T(), err)
+	if helpers.GetCurrentQuartal() == helpers.Q4 {
+		assert.Equal(suite.T(), 400, limit)
+	} else {
+		assert.Equal(suite.T(), 100, limit)
+	}
+}
+
+func (suite *CapacityTestSuite) TestRatioCapacityNoTicket() {
+	mockFindTotalAvalailableTicket := helpers.Result{
+		Data:  &[]ticketEntity.AggregateTotalTicket{},
+		Error: nil,
+	}
+
+	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindTotalAvalailableTicket))
+
+	limit, err := uc.NewRatioCapacity(suite.mockTicketRepositoryQuery, suite.mockLogger).Limit(suite.ctx, eventEntity.Event{})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, limit)
+}
+
+func (suite *CapacityTestSuite) TestRatioCapacityErr() {
+	mockFindTotalAvalailableTicket := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindTotalAvalailableTicket))
+
+	_, err := uc.NewRatioCapacity(suite.mockTicketRepositoryQuery, suite.mockLogger).Limit(suite.ctx, eventEntity.Event{})
+
+	assert.Error(suite.T(), err)
+
+	mockFindTotalAvalailableTicket2 := helpers.Result{
+		Data:  "data",
+		Error: nil,
+	}
+	suite.mockTicketRepositoryQuery.ExpectedCalls = nil
+	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindTotalAvalailableTicket2))
+
+	_, err2 := uc.NewRatioCapacity(suite.mockTicketRepositoryQuery, suite.mockLogger).Limit(suite.ctx, eventEntity.Event{})
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *CapacityTestSuite) TestUnlimitedCapacity() {
+	limit, err := uc.NewUnlimitedCapacity().Limit(suite.ctx, eventEntity.Event{})
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), math.MaxInt32, limit)
+}
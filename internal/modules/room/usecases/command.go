@@ -3,8 +3,11 @@ package usecases
 import (
 	"context"
 	"fmt"
-	"order-service/configs"
 	"order-service/internal/modules/event"
+	"order-service/internal/modules/order"
+	"order-service/internal/modules/outbox"
+	outboxRequest "order-service/internal/modules/outbox/models/request"
+	"order-service/internal/modules/promo"
 	"order-service/internal/modules/room"
 	"order-service/internal/modules/room/models/entity"
 	"order-service/internal/modules/room/models/request"
@@ -12,37 +15,55 @@ import (
 	"order-service/internal/modules/ticket"
 	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
-	"order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/eventschema"
 	"order-service/internal/pkg/log"
 	"order-service/internal/pkg/redis"
 	"strconv"
 	"time"
 
 	eventEntity "order-service/internal/modules/event/models/entity"
-	ticketEntity "order-service/internal/modules/ticket/models/entity"
 
+	"github.com/google/uuid"
 	"go.elastic.co/apm"
 )
 
 type commandUsecase struct {
-	roomRepositoryQuery   room.MongodbRepositoryQuery
-	roomRepositoryCommand room.MongodbRepositoryCommand
-	ticketRepositoryQuery ticket.MongodbRepositoryQuery
-	eventRepositoryQuery  event.MongodbRepositoryQuery
-	logger                log.Logger
-	redis                 redis.Collections
+	roomRepositoryQuery     room.MongodbRepositoryQuery
+	roomRepositoryCommand   room.MongodbRepositoryCommand
+	eventRepositoryQuery    event.MongodbRepositoryQuery
+	logger                  log.Logger
+	redis                   redis.Collections
+	admission               room.AdmissionController
+	promoValidator          promo.CodeValidator
+	saleSchedule            event.SaleSchedule
+	outboxRepositoryCommand outbox.MongodbRepositoryCommand
+	capacity                map[string]room.QueueCapacityPolicy
+	queueEntries            room.QueueEntries
+	orderRepositoryQuery    order.MongodbRepositoryQuery
 }
 
 func NewCommandUsecase(
 	rmq room.MongodbRepositoryQuery, rmc room.MongodbRepositoryCommand,
-	trq ticket.MongodbRepositoryQuery, emq event.MongodbRepositoryQuery, log log.Logger, rc redis.Collections) room.UsecaseCommand {
+	trq ticket.MongodbRepositoryQuery, emq event.MongodbRepositoryQuery, log log.Logger, rc redis.Collections,
+	adm room.AdmissionController, pv promo.CodeValidator, ss event.SaleSchedule,
+	obc outbox.MongodbRepositoryCommand, qe room.QueueEntries, omq order.MongodbRepositoryQuery) room.UsecaseCommand {
 	return commandUsecase{
-		roomRepositoryQuery:   rmq,
-		roomRepositoryCommand: rmc,
-		ticketRepositoryQuery: trq,
-		eventRepositoryQuery:  emq,
-		logger:                log,
-		redis:                 rc,
+		roomRepositoryQuery:     rmq,
+		roomRepositoryCommand:   rmc,
+		eventRepositoryQuery:    emq,
+		logger:                  log,
+		redis:                   rc,
+		admission:               adm,
+		promoValidator:          pv,
+		saleSchedule:            ss,
+		outboxRepositoryCommand: obc,
+		capacity: map[string]room.QueueCapacityPolicy{
+			constants.CapacityFixed:     NewFixedCapacity(),
+			constants.CapacityRatio:     NewRatioCapacity(trq, log),
+			constants.CapacityUnlimited: NewUnlimitedCapacity(),
+		},
+		queueEntries:         qe,
+		orderRepositoryQuery: omq,
 	}
 }
 
@@ -54,15 +75,6 @@ func (c commandUsecase) CreateQueueRoom(origCtx context.Context, payload request
 	})
 	defer span.End()
 
-	if configs.GetConfig().DayFlag {
-		day := time.Now().Weekday()
-		if day != time.Saturday && day != time.Sunday {
-			msg := "this day not Saturday or Sunday"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			return nil, errors.BadRequest("this day not Saturday or Sunday")
-		}
-	}
-
 	eventData := <-c.eventRepositoryQuery.FindEventById(ctx, payload.EventId)
 	if eventData.Error != nil {
 		msg := "Error DB connection FindEventById"
@@ -83,6 +95,14 @@ func (c commandUsecase) CreateQueueRoom(origCtx context.Context, payload request
 		return nil, errors.InternalServerError("cannot parsing data event")
 	}
 
+	if inPresale(*event, time.Now()) {
+		if err := c.checkPresaleAccess(ctx, *event, payload); err != nil {
+			return nil, err
+		}
+	} else if err := c.saleSchedule.CheckQueueOpen(ctx, *event); err != nil {
+		return nil, err
+	}
+
 	queueRoom := <-c.roomRepositoryQuery.FindOneQueueByUserId(ctx, payload.UserId, payload.EventId)
 	if queueRoom.Error != nil {
 		msg := "Error DB connection FindOneQueueByUserId"
@@ -90,86 +110,123 @@ func (c commandUsecase) CreateQueueRoom(origCtx context.Context, payload request
 		return nil, queueRoom.Error
 	}
 
+	var previous *entity.QueueRoom
 	if queueRoom.Data != nil {
-		msg := "user already in the queue"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-		return nil, errors.BadRequest("user already in the queue")
-	}
-
-	lastQueue := <-c.roomRepositoryQuery.FindOneLastQueue(ctx, payload.EventId)
-	if lastQueue.Error != nil {
-		msg := "Error DB connection FindOneLastQueue"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", lastQueue.Error))
-		return nil, lastQueue.Error
-	}
-
-	state := 1
-
-	if lastQueue.Data != nil {
-		queue, ok := lastQueue.Data.(*entity.QueueRoom)
+		previous, ok = queueRoom.Data.(*entity.QueueRoom)
 		if !ok {
-			msg := "cannot parsing data last queue"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", lastQueue.Data))
-			return nil, errors.InternalServerError("cannot parsing data")
-		}
-		state = queue.QueueNumber + 1
-	}
-
-	var queueLimit int
-
-	checkedLimit, _ := c.redis.Get(ctx, fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueLimit, event.EventId, event.Tag)).Result()
-	if checkedLimit == "" {
-		totalTicket := <-c.ticketRepositoryQuery.FindTotalAvalailableTicket(ctx, event.Country.Code, event.Tag)
-		if totalTicket.Error != nil {
-			msg := "Error DB connection FindTotalAvalailableTicket"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalTicket.Error))
-			return nil, totalTicket.Error
+			msg := "cannot parsing data queue"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Data))
+			return nil, errors.InternalServerError("cannot parsing data queue")
 		}
 
-		aggregateTicket, ok := totalTicket.Data.(*[]ticketEntity.AggregateTotalTicket)
-		if !ok {
-			msg := "cannot parsing data total ticket"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", totalTicket.Data))
-			return nil, errors.InternalServerError("cannot parsing data")
+		if err := checkReentry(*event, *previous, time.Now()); err != nil {
+			c.logger.Error(ctx, err.Error(), fmt.Sprintf("%+v", payload))
+			return nil, err
 		}
+	}
 
-		availableTicket := (*aggregateTicket)[0]
+	queueLimit, err := c.queueLimit(ctx, *event, false)
+	if err != nil {
+		return nil, err
+	}
 
-		quartal := helpers.GetCurrentQuartal()
-		if quartal != helpers.Q4 {
-			queueLimit = availableTicket.TotalAvailableTicket / 4
-		} else {
-			queueLimit = availableTicket.TotalAvailableTicket
-		}
-		c.redis.Set(ctx, fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueLimit, event.EventId, event.Tag), queueLimit, 4*30*24*time.Hour)
-	} else {
-		limit, err := strconv.Atoi(checkedLimit)
-		queueLimit = limit
-		if err != nil {
-			msg := "cannot parsing redis data"
-			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", checkedLimit))
-			return nil, errors.InternalServerError("cannot parsing redis data")
-		}
+	expiredAt := time.Now().Add(queueEntryTTL())
+	reserved, err := c.queueEntries.Reserve(ctx, event.EventId, payload.UserId, expiredAt, queueLimit)
+	if err != nil {
+		return nil, err
 	}
 
-	if state > queueLimit {
+	if !reserved {
 		msg := "queue is full"
 		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 		return nil, errors.BadRequest("queue is full")
 	}
 
+	// the spot goes back to the queue when the entry is not stored
+	joined := false
+	defer func() {
+		if !joined {
+			c.queueEntries.Remove(ctx, event.EventId, payload.UserId)
+		}
+	}()
+
+	if err := c.admission.Open(ctx, event.EventId); err != nil {
+		return nil, err
+	}
+
+	state, err := c.nextQueueNumber(ctx, event.EventId)
+	if err != nil {
+		return nil, err
+	}
+
 	data := entity.QueueRoom{
 		UserId:      payload.UserId,
 		EventId:     event.EventId,
 		QueueNumber: state,
 		CountryCode: event.Country.Code,
+		Status:      constants.QueueActive,
+		ExpiredAt:   expiredAt,
 	}
 
-	respQueue := <-c.roomRepositoryCommand.InsertOneRoom(ctx, data)
-	if respQueue.Error != nil {
-		msg := "Error DB connection InsertOneRoom"
-		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", respQueue.Error))
-		return nil, respQueue.Error
+	if previous != nil {
+		data.QueueId = previous.QueueId
+	} else {
+		data.QueueId = uuid.NewString()
+	}
+
+	// the entry and its QueueJoined event are stored as one unit
+	transaction := <-c.roomRepositoryCommand.WithTransaction(ctx, func(sessCtx context.Context) error {
+		if previous != nil {
+			respQueue := <-c.roomRepositoryCommand.ReenterQueueRoom(sessCtx, data)
+			if respQueue.Error != nil {
+				msg := "Error DB connection ReenterQueueRoom"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", respQueue.Error))
+				return respQueue.Error
+			}
+		} else {
+			respQueue := <-c.roomRepositoryCommand.InsertOneRoom(sessCtx, data)
+			if respQueue.Error != nil {
+				msg := "Error DB connection InsertOneRoom"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", respQueue.Error))
+				return respQueue.Error
+			}
+		}
+
+		outboxResp := <-c.outboxRepositoryCommand.InsertOutboxEvents(sessCtx, []outboxRequest.OutboxEventReq{
+			{
+				Type:  constants.EventQueueJoined,
+				Topic: constants.TopicQueueJoined,
+				Key:   data.QueueId,
+				Payload: eventschema.QueueJoined{
+					QueueId:     data.QueueId,
+					UserId:      data.UserId,
+					EventId:     data.EventId,
+					QueueNumber: data.QueueNumber,
+					CountryCode: data.CountryCode,
+					Reentry:     previous != nil,
+					JoinedAt:    time.Now(),
+					ExpiredAt:   data.ExpiredAt,
+				},
+			},
+		})
+		if outboxResp.Error != nil {
+			msg := "Error DB connection InsertOutboxEvents"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", outboxResp.Error))
+			return outboxResp.Error
+		}
+		return nil
+	})
+	if transaction.Error != nil {
+		return nil, transaction.Error
+	}
+	joined = true
+
+	if previous != nil {
+		// a new admission of the entry may release its slot again
+		if err := c.redis.Del(ctx, releasedKey(event.EventId, data.QueueId)).Err(); err != nil {
+			msg := "cannot reset queue release"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		}
 	}
 
 	return &response.QueueResp{
@@ -178,3 +235,208 @@ func (c commandUsecase) CreateQueueRoom(origCtx context.Context, payload request
 		CountryCode: data.CountryCode,
 	}, nil
 }
+
+func (c commandUsecase) LeaveQueueRoom(origCtx context.Context, payload request.QueueReq) error {
+	domain := "roomUsecase-LeaveQueueRoom"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	queueRoom := <-c.roomRepositoryCommand.LeaveQueueRoom(ctx, payload.UserId, payload.EventId)
+	if queueRoom.Error != nil {
+		msg := "Error DB connection LeaveQueueRoom"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Error))
+		return queueRoom.Error
+	}
+
+	if queueRoom.Data == nil {
+		msg := "user not in the queue"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return errors.NotFound("user not in the queue")
+	}
+
+	queue, ok := queueRoom.Data.(*entity.QueueRoom)
+	if !ok {
+		msg := "cannot parsing data queue"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Data))
+		return errors.InternalServerError("cannot parsing data queue")
+	}
+
+	if err := c.queueEntries.Remove(ctx, queue.EventId, queue.UserId); err != nil {
+		return err
+	}
+
+	// an admission token issued to the entry must not outlive it
+	if err := c.admission.Revoke(ctx, queue.EventId, queue.QueueId, queue.QueueNumber); err != nil {
+		return err
+	}
+
+	if admissionMode() != constants.AdmissionOrder {
+		return nil
+	}
+
+	serving, err := c.admission.ServingNumber(ctx, queue.EventId)
+	if err != nil {
+		return err
+	}
+	if queue.QueueNumber > serving {
+		return nil
+	}
+
+	// the tickets the user still holds release the slot when they are paid, cancelled or expired
+	heldTickets := <-c.orderRepositoryQuery.CountHeldBankTickets(ctx, queue.UserId, queue.EventId)
+	if heldTickets.Error != nil {
+		msg := "Error DB connection CountHeldBankTickets"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", heldTickets.Error))
+		return heldTickets.Error
+	}
+	if heldTickets.Count > 0 {
+		return nil
+	}
+
+	// an admitted user leaving without ordering hands the slot to the next one
+	return c.admission.Release(ctx, queue.EventId, queue.QueueId)
+}
+
+func (c commandUsecase) RecomputeQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) (*response.QueueCapacityResp, error) {
+	domain := "roomUsecase-RecomputeQueueLimit"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	event, err := c.findEvent(ctx, payload.EventId)
+	if err != nil {
+		return nil, err
+	}
+
+	queueLimit, err := c.queueLimit(ctx, *event, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.QueueCapacityResp{
+		EventId:    event.EventId,
+		Policy:     capacityPolicyName(*event),
+		QueueLimit: queueLimit,
+	}, nil
+}
+
+func (c commandUsecase) InvalidateQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) error {
+	domain := "roomUsecase-InvalidateQueueLimit"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	event, err := c.findEvent(ctx, payload.EventId)
+	if err != nil {
+		return err
+	}
+
+	if err := c.redis.Del(ctx, queueLimitKey(*event)).Err(); err != nil {
+		msg := "cannot delete queue limit"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot invalidate queue limit")
+	}
+
+	return nil
+}
+
+func (c commandUsecase) findEvent(ctx context.Context, eventId string) (*eventEntity.Event, error) {
+	eventData := <-c.eventRepositoryQuery.FindEventById(ctx, eventId)
+	if eventData.Error != nil {
+		msg := "Error DB connection FindEventById"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Error))
+		return nil, eventData.Error
+	}
+
+	if eventData.Data == nil {
+		msg := "event not found"
+		c.logger.Error(ctx, msg, eventId)
+		return nil, errors.NotFound("event not found")
+	}
+
+	event, ok := eventData.Data.(*eventEntity.Event)
+	if !ok {
+		msg := "cannot parsing data event"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", eventData.Data))
+		return nil, errors.InternalServerError("cannot parsing data event")
+	}
+
+	return event, nil
+}
+
+func queueLimitKey(event eventEntity.Event) string {
+	return fmt.Sprintf("%s:%s:%s:%s", constants.ORDER, constants.QueueLimit, redis.HashTag(event.EventId), event.Tag)
+}
+
+// queueLimit returns the cached limit of the event, computing it with the capacity policy of the
+// event when the cache is empty or refresh is set.
+func (c commandUsecase) queueLimit(ctx context.Context, event eventEntity.Event, refresh bool) (int, error) {
+	if !refresh {
+		checkedLimit, _ := c.redis.Get(ctx, queueLimitKey(event)).Result()
+		if checkedLimit != "" {
+			limit, err := strconv.Atoi(checkedLimit)
+			if err != nil {
+				msg := "cannot parsing redis data"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", checkedLimit))
+				return 0, errors.InternalServerError("cannot parsing redis data")
+			}
+			return limit, nil
+		}
+	}
+
+	limit, err := c.capacity[capacityPolicyName(event)].Limit(ctx, event)
+	if err != nil {
+		msg := "cannot compute queue limit"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return 0, err
+	}
+	c.redis.Set(ctx, queueLimitKey(event), limit, 4*30*24*time.Hour)
+
+	return limit, nil
+}
+
+// nextQueueNumber hands out queue numbers from an atomic per-event counter in Redis, so concurrent
+// joins never share a number. When the key is missing, on the first join or after Redis lost it,
+// the counter is seeded from the last queue stored in Mongo before being incremented.
+func (c commandUsecase) nextQueueNumber(ctx context.Context, eventId string) (int, error) {
+	key := queueCounterKey(eventId)
+
+	counter, _ := c.redis.Get(ctx, key).Result()
+	if counter == "" {
+		lastQueue := <-c.roomRepositoryQuery.FindOneLastQueue(ctx, eventId)
+		if lastQueue.Error != nil {
+			msg := "Error DB connection FindOneLastQueue"
+			c.logger.Error(ctx, msg, fmt.Sprintf("%+v", lastQueue.Error))
+			return 0, lastQueue.Error
+		}
+
+		lastNumber := 0
+		if lastQueue.Data != nil {
+			queue, ok := lastQueue.Data.(*entity.QueueRoom)
+			if !ok {
+				msg := "cannot parsing data last queue"
+				c.logger.Error(ctx, msg, fmt.Sprintf("%+v", lastQueue.Data))
+				return 0, errors.InternalServerError("cannot parsing data")
+			}
+			lastNumber = queue.QueueNumber
+		}
+		// only the first caller seeds the counter, the others keep the value it wrote
+		c.redis.SetNX(ctx, key, lastNumber, 4*30*24*time.Hour)
+	}
+
+	number, err := c.redis.Incr(ctx, key).Result()
+	if err != nil {
+		msg := "cannot increment queue counter"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return 0, errors.InternalServerError("cannot generate queue number")
+	}
+
+	return int(number), nil
+}
@@ -2,17 +2,28 @@ package usecases_test
 
 import (
 	"context"
+	"math"
+	"net/http"
+	"order-service/configs"
 	"order-service/internal/modules/room"
+	"order-service/internal/pkg/constants"
 	"order-service/internal/pkg/errors"
 	"order-service/internal/pkg/helpers"
+	"strings"
 	"testing"
+	"time"
 
 	eventEntity "order-service/internal/modules/event/models/entity"
+	outboxRequest "order-service/internal/modules/outbox/models/request"
 	roomEntity "order-service/internal/modules/room/models/entity"
 	"order-service/internal/modules/room/models/request"
 	uc "order-service/internal/modules/room/usecases"
 	ticketEntity "order-service/internal/modules/ticket/models/entity"
+	"order-service/internal/pkg/eventschema"
 	mockcertEvent "order-service/mocks/modules/event"
+	mockcertOrder "order-service/mocks/modules/order"
+	mockcertOutbox "order-service/mocks/modules/outbox"
+	mockcertPromo "order-service/mocks/modules/promo"
 	mockcert "order-service/mocks/modules/room"
 	mockcertTicket "order-service/mocks/modules/ticket"
 	mocklog "order-service/mocks/pkg/log"
@@ -32,6 +43,12 @@ type CommandUsecaseTestSuite struct {
 	mockEventRepositoryQuery  *mockcertEvent.MongodbRepositoryQuery
 	mockLogger                *mocklog.Logger
 	mockRedis                 *mockredis.Collections
+	mockAdmission             *mockcert.AdmissionController
+	mockPromoValidator        *mockcertPromo.CodeValidator
+	mockSaleSchedule          *mockcertEvent.SaleSchedule
+	mockOutboxRepository      *mockcertOutbox.MongodbRepositoryCommand
+	mockQueueEntries          *mockcert.QueueEntries
+	mockOrderRepositoryQuery  *mockcertOrder.MongodbRepositoryQuery
 	usecase                   room.UsecaseCommand
 	ctx                       context.Context
 }
@@ -43,6 +60,21 @@ func (suite *CommandUsecaseTestSuite) SetupTest() {
 	suite.mockEventRepositoryQuery = &mockcertEvent.MongodbRepositoryQuery{}
 	suite.mockLogger = &mocklog.Logger{}
 	suite.mockRedis = &mockredis.Collections{}
+	suite.mockAdmission = &mockcert.AdmissionController{}
+	suite.mockPromoValidator = &mockcertPromo.CodeValidator{}
+	// the queue of every event is open unless a test says otherwise
+	suite.mockSaleSchedule = &mockcertEvent.SaleSchedule{}
+	suite.mockSaleSchedule.On("CheckQueueOpen", mock.Anything, mock.Anything).Return(nil)
+	suite.mockAdmission.On("Open", mock.Anything, mock.Anything).Return(nil)
+	suite.mockAdmission.On("Revoke", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// every join finds room in the queue unless a test says otherwise
+	suite.mockQueueEntries = &mockcert.QueueEntries{}
+	suite.mockQueueEntries.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	suite.mockQueueEntries.On("Remove", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOrderRepositoryQuery = &mockcertOrder.MongodbRepositoryQuery{}
+	suite.mockRoomRepositoryCommand.On("WithTransaction", mock.Anything, mock.Anything).Return(mockTransaction)
+	suite.mockOutboxRepository = &mockcertOutbox.MongodbRepositoryCommand{}
+	suite.mockOutboxRepository.On("InsertOutboxEvents", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
 	suite.ctx = context.Background()
 	suite.usecase = uc.NewCommandUsecase(
 		suite.mockRoomRepositoryQuery,
@@ -51,9 +83,19 @@ func (suite *CommandUsecaseTestSuite) SetupTest() {
 		suite.mockEventRepositoryQuery,
 		suite.mockLogger,
 		suite.mockRedis,
+		suite.mockAdmission,
+		suite.mockPromoValidator,
+		suite.mockSaleSchedule,
+		suite.mockOutboxRepository,
+		suite.mockQueueEntries,
+		suite.mockOrderRepositoryQuery,
 	)
 }
 
+func (suite *CommandUsecaseTestSuite) TearDownTest() {
+	configs.GetConfig().Room.AdmissionMode = ""
+}
+
 func TestCommandUsecaseTestSuite(t *testing.T) {
 	suite.Run(t, new(CommandUsecaseTestSuite))
 }
@@ -102,11 +144,14 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoom() {
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
 	assert.NoError(suite.T(), err)
+	suite.mockQueueEntries.AssertCalled(suite.T(), "Reserve", mock.Anything, "id", "id", mock.Anything, mock.Anything)
+	suite.mockQueueEntries.AssertNotCalled(suite.T(), "Remove", mock.Anything, mock.Anything, mock.Anything)
 
 	mockFindEventById3 := helpers.Result{
 		Data:  nil,
@@ -117,7 +162,7 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoom() {
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 
 	_, err3 := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
@@ -264,7 +309,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrLastQueue() {
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
-	suite.mockRedis.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Get", mock.Anything, queueCounterKey).Return(redis.NewStringResult("", nil))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
@@ -302,7 +348,8 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrLastQueueParse() {
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
-	suite.mockRedis.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Get", mock.Anything, queueCounterKey).Return(redis.NewStringResult("", nil))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
@@ -327,7 +374,9 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrNotNil() {
 	}
 	mockFindOneQueueByUserId := helpers.Result{
 		Data: &roomEntity.QueueRoom{
-			QueueId: "id",
+			QueueId:   "id",
+			Status:    constants.QueueActive,
+			ExpiredAt: time.Now().Add(time.Minute),
 		},
 		Error: nil,
 	}
@@ -338,6 +387,7 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrNotNil() {
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "user already in the queue", err.Error())
 }
 
 func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomEmptyRedis() {
@@ -395,8 +445,10 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomEmptyRedis() {
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("", nil))
 	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(totalAvailableTicket))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 	suite.mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockRedis.On("SetNX", mock.Anything, mock.Anything, 1, mock.Anything).Return(redis.NewBoolResult(true, nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
@@ -453,7 +505,7 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomEmptyRedisErr() {
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("", nil))
 	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(totalAvailableTicket))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 	suite.mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
@@ -513,7 +565,7 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomEmptyRedisErrTotal() {
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("", nil))
 	suite.mockTicketRepositoryQuery.On("FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(totalAvailableTicket))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 	suite.mockRedis.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
@@ -566,7 +618,7 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomEmptyRedisErrParse() {
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("tes", nil))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
@@ -614,15 +666,20 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrLimit() {
 	}
 
 	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockQueueEntries.ExpectedCalls = nil
+	suite.mockQueueEntries.On("Reserve", mock.Anything, "id", "id", mock.Anything, mock.Anything).Return(false, nil)
 	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("1", nil))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
 	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue is full", err.Error())
+	suite.mockRedis.AssertNotCalled(suite.T(), "Incr", mock.Anything, mock.Anything)
 }
 
 func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrInsert() {
@@ -670,21 +727,859 @@ func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrInsert() {
 	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
 	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
 	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
-	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, data).Return(mockChannel(mockInsertOneRoom))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
 
 	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
 
 	assert.Error(suite.T(), err)
+	suite.mockQueueEntries.AssertCalled(suite.T(), "Remove", mock.Anything, "id", "id")
 }
 
-// Helper function to create a channel
-func mockChannel(result helpers.Result) <-chan helpers.Result {
-	responseChan := make(chan helpers.Result)
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrIncr() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
 
-	go func() {
-		responseChan <- result
-		close(responseChan)
-	}()
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(0, errors.InternalServerError("error")))
 
-	return responseChan
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockRoomRepositoryQuery.AssertNotCalled(suite.T(), "FindOneLastQueue", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomRecoverCounter() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	mockFindOneLastQueue := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "id",
+			QueueNumber: 7,
+		},
+		Error: nil,
+	}
+
+	mockInsertOneRoom := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	data := roomEntity.QueueRoom{
+		UserId:      "id",
+		EventId:     "id",
+		QueueNumber: 8,
+		CountryCode: "code",
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRoomRepositoryQuery.On("FindOneLastQueue", mock.Anything, mock.Anything).Return(mockChannel(mockFindOneLastQueue))
+	suite.mockRedis.On("Get", mock.Anything, queueCounterKey).Return(redis.NewStringResult("", nil))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("10", nil))
+	suite.mockRedis.On("SetNX", mock.Anything, mock.Anything, 7, mock.Anything).Return(redis.NewBoolResult(true, nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(8, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockInsertOneRoom))
+
+	result, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 8, result.QueueNumber)
+	suite.mockRedis.AssertCalled(suite.T(), "SetNX", mock.Anything, "ORDER:QUEUE-COUNTER:{id}", 7, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrAdmission() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockAdmission.ExpectedCalls = nil
+	suite.mockAdmission.On("Open", mock.Anything, "id").Return(errors.InternalServerError("error"))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockRedis.AssertNotCalled(suite.T(), "Incr", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrReserve() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockQueueEntries.ExpectedCalls = nil
+	suite.mockQueueEntries.On("Reserve", mock.Anything, "id", "id", mock.Anything, mock.Anything).Return(false, errors.InternalServerError("error"))
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomReentryNever() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			Status:    constants.QueueLeft,
+			LeftAt:    time.Now().Add(-time.Hour),
+			ExpiredAt: time.Now().Add(-time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "re-entry to the queue is not allowed", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomReentryAfterExpiry() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+			Queue: eventEntity.QueueSetting{
+				ReentryPolicy: constants.ReentryAfterExpiry,
+			},
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			Status:    constants.QueueActive,
+			ExpiredAt: time.Now().Add(-time.Minute),
+		},
+		Error: nil,
+	}
+	mockReenterQueueRoom := helpers.Result{
+		Data:  "Success update data",
+		Error: nil,
+	}
+
+	data := roomEntity.QueueRoom{
+		QueueId:     "queue",
+		UserId:      "id",
+		EventId:     "id",
+		QueueNumber: 9,
+		CountryCode: "code",
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("10", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(9, nil))
+	suite.mockRoomRepositoryCommand.On("ReenterQueueRoom", suite.ctx, activeQueue(data)).Return(mockChannel(mockReenterQueueRoom))
+	suite.mockRedis.On("Del", mock.Anything, "ORDER:QUEUE-RELEASED:{id}:queue").Return(redis.NewIntResult(1, nil))
+
+	result, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 9, result.QueueNumber)
+	suite.mockRoomRepositoryCommand.AssertNotCalled(suite.T(), "InsertOneRoom", mock.Anything, mock.Anything)
+	suite.mockRedis.AssertCalled(suite.T(), "Del", mock.Anything, "ORDER:QUEUE-RELEASED:{id}:queue")
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomQueueJoined() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+		},
+	}
+
+	var inserted roomEntity.QueueRoom
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{})).Run(func(args mock.Arguments) {
+		inserted = args.Get(1).(roomEntity.QueueRoom)
+	})
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockOutboxRepository.AssertCalled(suite.T(), "InsertOutboxEvents", mock.Anything, mock.MatchedBy(func(events []outboxRequest.OutboxEventReq) bool {
+		if len(events) != 1 {
+			return false
+		}
+		joined, ok := events[0].Payload.(eventschema.QueueJoined)
+		return ok && events[0].Type == constants.EventQueueJoined && events[0].Topic == constants.TopicQueueJoined &&
+			events[0].Key == inserted.QueueId && joined.QueueId == inserted.QueueId && joined.QueueNumber == 2 && !joined.Reentry
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrOutbox() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+		},
+	}
+
+	suite.mockOutboxRepository.ExpectedCalls = nil
+	suite.mockOutboxRepository.On("InsertOutboxEvents", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+
+	result, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomReentryAfterExpiryLeft() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			Status:    constants.QueueLeft,
+			LeftAt:    time.Now().Add(-time.Minute),
+			ExpiredAt: time.Now().Add(time.Minute),
+		},
+		Error: nil,
+	}
+
+	configs.GetConfig().Room.ReentryPolicy = constants.ReentryAfterExpiry
+	defer func() { configs.GetConfig().Room.ReentryPolicy = "" }()
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(err.Error(), "re-entry allowed after"))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomReentryCooldown() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{
+				Code: "code",
+			},
+			Tag: "tag",
+			Queue: eventEntity.QueueSetting{
+				ReentryPolicy:   constants.ReentryCooldown,
+				ReentryCooldown: 5,
+			},
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			Status:    constants.QueueLeft,
+			LeftAt:    time.Now().Add(-10 * time.Minute),
+			ExpiredAt: time.Now().Add(10 * time.Minute),
+		},
+		Error: nil,
+	}
+	mockReenterQueueRoom := helpers.Result{
+		Data:  "Success update data",
+		Error: nil,
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("10", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(9, nil))
+	suite.mockRoomRepositoryCommand.On("ReenterQueueRoom", suite.ctx, mock.Anything).Return(mockChannel(mockReenterQueueRoom))
+	suite.mockRedis.On("Del", mock.Anything, "ORDER:QUEUE-RELEASED:{id}:queue").Return(redis.NewIntResult(1, nil))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomReentryCooldownWait() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+			Queue: eventEntity.QueueSetting{
+				ReentryPolicy:   constants.ReentryCooldown,
+				ReentryCooldown: 15,
+			},
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			Status:    constants.QueueLeft,
+			LeftAt:    time.Now().Add(-10 * time.Minute),
+			ExpiredAt: time.Now().Add(10 * time.Minute),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(err.Error(), "re-entry allowed after"))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomErrReenter() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+			Queue: eventEntity.QueueSetting{
+				ReentryPolicy: constants.ReentryAfterExpiry,
+			},
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:   "queue",
+			ExpiredAt: time.Now().Add(-time.Minute),
+		},
+		Error: nil,
+	}
+	mockReenterQueueRoom := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("10", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(9, nil))
+	suite.mockRoomRepositoryCommand.On("ReenterQueueRoom", suite.ctx, mock.Anything).Return(mockChannel(mockReenterQueueRoom))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoom() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 150,
+		},
+		Error: nil,
+	}
+
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockQueueEntries.AssertCalled(suite.T(), "Remove", mock.Anything, "id", "id")
+	suite.mockAdmission.AssertCalled(suite.T(), "Revoke", mock.Anything, "id", "queue", 150)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomAdmitted() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+		},
+		Error: nil,
+	}
+
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockOrderRepositoryQuery.On("CountHeldBankTickets", mock.Anything, "id", "id").Return(mockChannel(helpers.Result{Count: 0}))
+	suite.mockAdmission.On("Release", mock.Anything, "id", "queue").Return(nil)
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockAdmission.AssertCalled(suite.T(), "Release", mock.Anything, "id", "queue")
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomHoldingTickets() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+		},
+		Error: nil,
+	}
+
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockOrderRepositoryQuery.On("CountHeldBankTickets", mock.Anything, "id", "id").Return(mockChannel(helpers.Result{Count: 2}))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomErrHeldTickets() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+		},
+		Error: nil,
+	}
+
+	configs.GetConfig().Room.AdmissionMode = constants.AdmissionOrder
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockOrderRepositoryQuery.On("CountHeldBankTickets", mock.Anything, "id", "id").Return(mockChannel(helpers.Result{Error: errors.InternalServerError("error")}))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomTimeMode() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+		},
+		Error: nil,
+	}
+
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockQueueEntries.AssertCalled(suite.T(), "Remove", mock.Anything, "id", "id")
+	suite.mockAdmission.AssertNotCalled(suite.T(), "ServingNumber", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomErrRevoke() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+		},
+		Error: nil,
+	}
+
+	suite.mockAdmission.ExpectedCalls = nil
+	suite.mockAdmission.On("Revoke", mock.Anything, "id", "queue", 50).Return(errors.InternalServerError("error"))
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomNotInQueue() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "user not in the queue", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestLeaveQueueRoomErr() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockLeaveQueueRoom := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom))
+
+	err := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+
+	mockLeaveQueueRoom2 := helpers.Result{
+		Data:  "data",
+		Error: nil,
+	}
+	suite.mockRoomRepositoryCommand.ExpectedCalls = nil
+	suite.mockRoomRepositoryCommand.On("LeaveQueueRoom", mock.Anything, "id", "id").Return(mockChannel(mockLeaveQueueRoom2))
+
+	err2 := suite.usecase.LeaveQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomFixedCapacity() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+			Queue: eventEntity.QueueSetting{
+				Capacity:      constants.CapacityFixed,
+				CapacityLimit: 3,
+			},
+		},
+		Error: nil,
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockQueueEntries.ExpectedCalls = nil
+	suite.mockQueueEntries.On("Reserve", mock.Anything, "id", mock.Anything, mock.Anything, 3).Return(false, nil)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(mockFindEventById))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRedis.On("Get", mock.Anything, "ORDER:QUEUE-LIMIT:{id}:tag").Return(redis.NewStringResult("", redis.Nil))
+	suite.mockRedis.On("Set", mock.Anything, "ORDER:QUEUE-LIMIT:{id}:tag", 3, mock.Anything).Return(redis.NewStatusResult("OK", nil))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue is full", err.Error())
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTotalAvalailableTicket", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestRecomputeQueueLimit() {
+	payload := request.QueueCapacityReq{
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+			Queue: eventEntity.QueueSetting{
+				Capacity: constants.CapacityUnlimited,
+			},
+		},
+		Error: nil,
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "id").Return(mockChannel(mockFindEventById))
+	suite.mockRedis.On("Set", mock.Anything, "ORDER:QUEUE-LIMIT:{id}:tag", math.MaxInt32, mock.Anything).Return(redis.NewStatusResult("OK", nil))
+
+	result, err := suite.usecase.RecomputeQueueLimit(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), constants.CapacityUnlimited, result.Policy)
+	assert.Equal(suite.T(), math.MaxInt32, result.QueueLimit)
+	suite.mockRedis.AssertNotCalled(suite.T(), "Get", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestRecomputeQueueLimitErr() {
+	payload := request.QueueCapacityReq{
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "id").Return(mockChannel(mockFindEventById))
+
+	_, err := suite.usecase.RecomputeQueueLimit(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "event not found", err.Error())
+
+	mockFindEventById2 := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Queue: eventEntity.QueueSetting{
+				Capacity: constants.CapacityFixed,
+			},
+		},
+		Error: nil,
+	}
+	suite.mockEventRepositoryQuery.ExpectedCalls = nil
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "id").Return(mockChannel(mockFindEventById2))
+
+	_, err2 := suite.usecase.RecomputeQueueLimit(suite.ctx, payload)
+
+	assert.Error(suite.T(), err2)
+}
+
+func (suite *CommandUsecaseTestSuite) TestInvalidateQueueLimit() {
+	payload := request.QueueCapacityReq{
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+		},
+		Error: nil,
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "id").Return(mockChannel(mockFindEventById))
+	suite.mockRedis.On("Del", mock.Anything, "ORDER:QUEUE-LIMIT:{id}:tag").Return(redis.NewIntResult(1, nil))
+
+	err := suite.usecase.InvalidateQueueLimit(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "Del", mock.Anything, "ORDER:QUEUE-LIMIT:{id}:tag")
+}
+
+func (suite *CommandUsecaseTestSuite) TestInvalidateQueueLimitErr() {
+	payload := request.QueueCapacityReq{
+		EventId: "id",
+	}
+	mockFindEventById := helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Tag:     "tag",
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, "id").Return(mockChannel(mockFindEventById))
+	suite.mockRedis.On("Del", mock.Anything, mock.Anything).Return(redis.NewIntResult(0, errors.InternalServerError("error")))
+
+	err := suite.usecase.InvalidateQueueLimit(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+// activeQueue matches the entry written on join, its expiry depends on the clock.
+func activeQueue(expected roomEntity.QueueRoom) interface{} {
+	return mock.MatchedBy(func(data roomEntity.QueueRoom) bool {
+		// a new entry gets its id from the usecase
+		sameId := data.QueueId == expected.QueueId || expected.QueueId == "" && data.QueueId != ""
+		return sameId && data.UserId == expected.UserId && data.EventId == expected.EventId &&
+			data.QueueNumber == expected.QueueNumber && data.CountryCode == expected.CountryCode &&
+			data.Status == constants.QueueActive && data.ExpiredAt.After(time.Now())
+	})
+}
+
+var queueCounterKey = mock.MatchedBy(func(key string) bool {
+	return strings.Contains(key, "QUEUE-COUNTER")
+})
+
+func mockTransaction(ctx context.Context, fn func(sessCtx context.Context) error) <-chan helpers.Result {
+	return mockChannel(helpers.Result{
+		Error: fn(ctx),
+	})
+}
+
+// Helper function to create a channel
+func mockChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result)
+
+	go func() {
+		responseChan <- result
+		close(responseChan)
+	}()
+
+	return responseChan
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomQueueNotOpen() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{EventId: "id"},
+	}))
+	suite.mockSaleSchedule.ExpectedCalls = nil
+	suite.mockSaleSchedule.On("CheckQueueOpen", mock.Anything, mock.Anything).Return(
+		errors.CustomErrorRetryAfter("queue opens at 2024-03-01T10:00:00+07:00", constants.ErrCodeSaleNotOpen, http.StatusForbidden, 60))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "queue opens at 2024-03-01T10:00:00+07:00", err.Error())
+	suite.mockRoomRepositoryQuery.AssertNotCalled(suite.T(), "FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything)
 }
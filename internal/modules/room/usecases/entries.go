@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/room"
+	"order-service/internal/modules/room/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"order-service/internal/pkg/redis"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// reserveScript drops the expired entries and adds the user when the queue has room, in one step so
+// concurrent joins cannot all see the same free spot. A user already holding a spot keeps it.
+var reserveScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if not redis.call('ZSCORE', KEYS[1], ARGV[3]) and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+type queueEntries struct {
+	roomRepositoryQuery room.MongodbRepositoryQuery
+	redis               redis.Collections
+	logger              log.Logger
+}
+
+// NewQueueEntries keeps the active entries of every event in a redis sorted set scored by their expiry,
+// an entry frees its spot when the user leaves or when it expires without being refreshed.
+func NewQueueEntries(rmq room.MongodbRepositoryQuery, rc redis.Collections, log log.Logger) room.QueueEntries {
+	return queueEntries{
+		roomRepositoryQuery: rmq,
+		redis:               rc,
+		logger:              log,
+	}
+}
+
+func entriesKey(eventId string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.ORDER, constants.QueueEntries, redis.HashTag(eventId))
+}
+
+func entriesSeededKey(eventId string) string {
+	return fmt.Sprintf("%s:%s:%s", constants.ORDER, constants.QueueEntriesSeeded, redis.HashTag(eventId))
+}
+
+// Reserve takes a spot for the user unless limit entries are already active.
+func (e queueEntries) Reserve(ctx context.Context, eventId string, userId string, expiredAt time.Time, limit int) (bool, error) {
+	if err := e.seed(ctx, eventId); err != nil {
+		return false, err
+	}
+
+	reserved, err := reserveScript.Run(ctx, e.redis, []string{entriesKey(eventId)},
+		time.Now().UnixMilli(), expiredAt.UnixMilli(), userId, limit, int(queueKeyTTL.Seconds())).Int()
+	if err != nil {
+		msg := "cannot reserve queue entry"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return false, errors.InternalServerError("cannot reserve queue entry")
+	}
+
+	return reserved == 1, nil
+}
+
+// Refresh moves the expiry of the spot of the user, it does nothing once the spot is gone.
+func (e queueEntries) Refresh(ctx context.Context, eventId string, userId string, expiredAt time.Time) error {
+	member := &goredis.Z{Score: float64(expiredAt.UnixMilli()), Member: userId}
+	if err := e.redis.ZAddXX(ctx, entriesKey(eventId), member).Err(); err != nil {
+		msg := "cannot refresh queue entry"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot refresh queue entry")
+	}
+
+	return nil
+}
+
+// Remove frees the spot of the user.
+func (e queueEntries) Remove(ctx context.Context, eventId string, userId string) error {
+	if err := e.redis.ZRem(ctx, entriesKey(eventId), userId).Err(); err != nil {
+		msg := "cannot remove queue entry"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot remove queue entry")
+	}
+
+	return nil
+}
+
+// seed copies the active entries stored in Mongo into the set the first time the event is seen, or after
+// redis lost its keys, so the entries joined before stay counted.
+func (e queueEntries) seed(ctx context.Context, eventId string) error {
+	seeded, err := e.redis.SetNX(ctx, entriesSeededKey(eventId), 1, queueKeyTTL).Result()
+	if err != nil {
+		msg := "cannot seed queue entries"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot reserve queue entry")
+	}
+	if !seeded {
+		return nil
+	}
+
+	if err := e.seedFromMongo(ctx, eventId); err != nil {
+		// the next join tries again
+		e.redis.Del(ctx, entriesSeededKey(eventId))
+		return err
+	}
+
+	return nil
+}
+
+func (e queueEntries) seedFromMongo(ctx context.Context, eventId string) error {
+	activeQueues := <-e.roomRepositoryQuery.FindActiveQueues(ctx, eventId)
+	if activeQueues.Error != nil {
+		msg := "Error DB connection FindActiveQueues"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", activeQueues.Error))
+		return activeQueues.Error
+	}
+
+	queues, ok := activeQueues.Data.(*[]entity.QueueRoom)
+	if !ok {
+		msg := "cannot parsing data queue"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", activeQueues.Data))
+		return errors.InternalServerError("cannot parsing data queue")
+	}
+	if len(*queues) == 0 {
+		return nil
+	}
+
+	members := make([]*goredis.Z, 0, len(*queues))
+	for _, queue := range *queues {
+		members = append(members, &goredis.Z{Score: float64(queue.ExpiredAt.UnixMilli()), Member: queue.UserId})
+	}
+	if err := e.redis.ZAdd(ctx, entriesKey(eventId), members...).Err(); err != nil {
+		msg := "cannot seed queue entries"
+		e.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return errors.InternalServerError("cannot reserve queue entry")
+	}
+
+	return nil
+}
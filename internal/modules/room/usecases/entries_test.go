@@ -0,0 +1,136 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/internal/modules/room"
+	roomEntity "order-service/internal/modules/room/models/entity"
+	uc "order-service/internal/modules/room/usecases"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mockcert "order-service/mocks/modules/room"
+	mocklog "order-service/mocks/pkg/log"
+	mockredis "order-service/mocks/pkg/redis"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueueEntriesTestSuite struct {
+	suite.Suite
+	mockRoomRepositoryQuery *mockcert.MongodbRepositoryQuery
+	mockLogger              *mocklog.Logger
+	mockRedis               *mockredis.Collections
+	entries                 room.QueueEntries
+	ctx                     context.Context
+}
+
+func (suite *QueueEntriesTestSuite) SetupTest() {
+	suite.mockRoomRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRedis = &mockredis.Collections{}
+	// the entries of the event are seeded unless a test says otherwise
+	suite.mockRedis.On("SetNX", mock.Anything, "ORDER:QUEUE-ENTRIES-SEEDED:{id}", 1, mock.Anything).Return(redis.NewBoolResult(false, nil))
+	suite.ctx = context.Background()
+	suite.entries = uc.NewQueueEntries(suite.mockRoomRepositoryQuery, suite.mockRedis, suite.mockLogger)
+}
+
+func TestQueueEntriesTestSuite(t *testing.T) {
+	suite.Run(t, new(QueueEntriesTestSuite))
+}
+
+func (suite *QueueEntriesTestSuite) TestReserve() {
+	expiredAt := time.Now().Add(30 * time.Minute)
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, []string{"ORDER:QUEUE-ENTRIES:{id}"},
+		mock.Anything, expiredAt.UnixMilli(), "user", 10, mock.Anything).Return(redis.NewCmdResult(int64(1), nil))
+
+	reserved, err := suite.entries.Reserve(suite.ctx, "id", "user", expiredAt, 10)
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reserved)
+	suite.mockRoomRepositoryQuery.AssertNotCalled(suite.T(), "FindActiveQueues", mock.Anything, mock.Anything)
+}
+
+func (suite *QueueEntriesTestSuite) TestReserveFull() {
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(int64(0), nil))
+
+	reserved, err := suite.entries.Reserve(suite.ctx, "id", "user", time.Now().Add(30*time.Minute), 10)
+
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), reserved)
+}
+
+func (suite *QueueEntriesTestSuite) TestReserveErr() {
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(nil, errors.InternalServerError("error")))
+
+	_, err := suite.entries.Reserve(suite.ctx, "id", "user", time.Now().Add(30*time.Minute), 10)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *QueueEntriesTestSuite) TestReserveSeed() {
+	expiredAt := time.Now().Add(10 * time.Minute)
+	suite.mockRedis.ExpectedCalls = nil
+	suite.mockRedis.On("SetNX", mock.Anything, "ORDER:QUEUE-ENTRIES-SEEDED:{id}", 1, mock.Anything).Return(redis.NewBoolResult(true, nil))
+	suite.mockRoomRepositoryQuery.On("FindActiveQueues", mock.Anything, "id").Return(mockChannel(helpers.Result{
+		Data: &[]roomEntity.QueueRoom{{UserId: "other", ExpiredAt: expiredAt}},
+	}))
+	suite.mockRedis.On("ZAdd", mock.Anything, "ORDER:QUEUE-ENTRIES:{id}",
+		&redis.Z{Score: float64(expiredAt.UnixMilli()), Member: "other"}).Return(redis.NewIntResult(1, nil))
+	suite.mockRedis.On("EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(redis.NewCmdResult(int64(1), nil))
+
+	reserved, err := suite.entries.Reserve(suite.ctx, "id", "user", time.Now().Add(30*time.Minute), 10)
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), reserved)
+	suite.mockRedis.AssertCalled(suite.T(), "ZAdd", mock.Anything, "ORDER:QUEUE-ENTRIES:{id}", mock.Anything)
+}
+
+func (suite *QueueEntriesTestSuite) TestReserveSeedErr() {
+	suite.mockRedis.ExpectedCalls = nil
+	suite.mockRedis.On("SetNX", mock.Anything, "ORDER:QUEUE-ENTRIES-SEEDED:{id}", 1, mock.Anything).Return(redis.NewBoolResult(true, nil))
+	suite.mockRedis.On("Del", mock.Anything, "ORDER:QUEUE-ENTRIES-SEEDED:{id}").Return(redis.NewIntResult(1, nil))
+	suite.mockRoomRepositoryQuery.On("FindActiveQueues", mock.Anything, "id").Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.entries.Reserve(suite.ctx, "id", "user", time.Now().Add(30*time.Minute), 10)
+
+	assert.Error(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "Del", mock.Anything, "ORDER:QUEUE-ENTRIES-SEEDED:{id}")
+	suite.mockRedis.AssertNotCalled(suite.T(), "EvalSha", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *QueueEntriesTestSuite) TestRefresh() {
+	expiredAt := time.Now().Add(30 * time.Minute)
+	member := &redis.Z{Score: float64(expiredAt.UnixMilli()), Member: "user"}
+	suite.mockRedis.On("ZAddXX", mock.Anything, "ORDER:QUEUE-ENTRIES:{id}", member).Return(redis.NewIntResult(0, nil))
+
+	err := suite.entries.Refresh(suite.ctx, "id", "user", expiredAt)
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *QueueEntriesTestSuite) TestRemove() {
+	suite.mockRedis.On("ZRem", mock.Anything, "ORDER:QUEUE-ENTRIES:{id}", "user").Return(redis.NewIntResult(1, nil))
+
+	err := suite.entries.Remove(suite.ctx, "id", "user")
+
+	assert.NoError(suite.T(), err)
+	suite.mockRedis.AssertCalled(suite.T(), "ZRem", mock.Anything, "ORDER:QUEUE-ENTRIES:{id}", "user")
+}
+
+func (suite *QueueEntriesTestSuite) TestRemoveErr() {
+	suite.mockRedis.On("ZRem", mock.Anything, mock.Anything, mock.Anything).Return(redis.NewIntResult(0, errors.InternalServerError("error")))
+
+	err := suite.entries.Remove(suite.ctx, "id", "user")
+
+	assert.Error(suite.T(), err)
+}
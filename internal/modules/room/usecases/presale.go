@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/room/models/request"
+	"order-service/internal/pkg/errors"
+	"time"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+	promoEntity "order-service/internal/modules/promo/models/entity"
+	promoRequest "order-service/internal/modules/promo/models/request"
+)
+
+// inPresale reports whether only presale code holders may join the queue of the event.
+func inPresale(event eventEntity.Event, now time.Time) bool {
+	presale := event.Presale
+	return !presale.StartAt.IsZero() && !now.Before(presale.StartAt) && now.Before(presale.EndAt)
+}
+
+// checkPresaleAccess lets the user join during the presale only with a valid presale code for the event.
+// The code is not redeemed here, a use is counted when the tickets are ordered.
+func (c commandUsecase) checkPresaleAccess(ctx context.Context, event eventEntity.Event, payload request.QueueReq) error {
+	if payload.PromoCode == "" {
+		msg := "presale requires an access code"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return errors.ForbiddenError("presale requires an access code")
+	}
+
+	promoCode, err := c.promoValidator.Validate(ctx, promoRequest.ValidateCodeReq{
+		Code:    payload.PromoCode,
+		EventId: event.EventId,
+		At:      time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if promoCode.Kind != promoEntity.KindPresale {
+		msg := "promo code does not grant presale access"
+		c.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return errors.ForbiddenError("promo code does not grant presale access")
+	}
+
+	return nil
+}
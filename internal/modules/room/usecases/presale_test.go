@@ -0,0 +1,140 @@
+package usecases_test
+
+import (
+	eventEntity "order-service/internal/modules/event/models/entity"
+	promoEntity "order-service/internal/modules/promo/models/entity"
+	promoRequest "order-service/internal/modules/promo/models/request"
+	roomEntity "order-service/internal/modules/room/models/entity"
+	"order-service/internal/modules/room/models/request"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (suite *CommandUsecaseTestSuite) mockPresaleEvent() {
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Country: eventEntity.Country{Code: "code"},
+			Presale: eventEntity.PresaleSetting{
+				StartAt: time.Now().Add(-time.Hour),
+				EndAt:   time.Now().Add(time.Hour),
+			},
+		},
+	}))
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomPresale() {
+	payload := request.QueueReq{
+		UserId:    "id",
+		EventId:   "id",
+		PromoCode: "FANS",
+	}
+
+	suite.mockPresaleEvent()
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code: "FANS",
+		Kind: promoEntity.KindPresale,
+	}, nil)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockRedis.On("Get", mock.Anything, mock.Anything).Return(redis.NewStringResult("5", nil))
+	suite.mockRedis.On("Incr", mock.Anything, mock.Anything).Return(redis.NewIntResult(2, nil))
+	suite.mockRoomRepositoryCommand.On("InsertOneRoom", suite.ctx, activeQueue(roomEntity.QueueRoom{
+		UserId:      "id",
+		EventId:     "id",
+		QueueNumber: 2,
+		CountryCode: "code",
+	})).Return(mockChannel(helpers.Result{}))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockPromoValidator.AssertCalled(suite.T(), "Validate", mock.Anything, mock.MatchedBy(func(req promoRequest.ValidateCodeReq) bool {
+		return req.Code == "FANS" && req.EventId == "id" && len(req.TicketTypes) == 0
+	}))
+	// the presale has its own window, the sale schedule only applies after it
+	suite.mockSaleSchedule.AssertNotCalled(suite.T(), "CheckQueueOpen", mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomPresaleNoCode() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPresaleEvent()
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "presale requires an access code", err.Error())
+	suite.mockRoomRepositoryQuery.AssertNotCalled(suite.T(), "FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomPresaleDiscountCode() {
+	payload := request.QueueReq{
+		UserId:    "id",
+		EventId:   "id",
+		PromoCode: "SALE",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPresaleEvent()
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(&promoEntity.PromoCode{
+		Code: "SALE",
+		Kind: promoEntity.KindDiscount,
+	}, nil)
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code does not grant presale access", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomPresaleInvalidCode() {
+	payload := request.QueueReq{
+		UserId:    "id",
+		EventId:   "id",
+		PromoCode: "FANS",
+	}
+
+	suite.mockPresaleEvent()
+	suite.mockPromoValidator.On("Validate", mock.Anything, mock.Anything).Return(nil, errors.BadRequest("promo code has expired"))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "promo code has expired", err.Error())
+}
+
+func (suite *CommandUsecaseTestSuite) TestCreateQueueRoomAfterPresale() {
+	payload := request.QueueReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockEventRepositoryQuery.On("FindEventById", mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Data: &eventEntity.Event{
+			EventId: "id",
+			Presale: eventEntity.PresaleSetting{
+				StartAt: time.Now().Add(-2 * time.Hour),
+				EndAt:   time.Now().Add(-time.Hour),
+			},
+		},
+	}))
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	_, err := suite.usecase.CreateQueueRoom(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockPromoValidator.AssertNotCalled(suite.T(), "Validate", mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.AssertCalled(suite.T(), "FindOneQueueByUserId", mock.Anything, "id", "id")
+}
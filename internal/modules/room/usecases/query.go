@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/configs"
+	"order-service/internal/modules/room"
+	"order-service/internal/modules/room/models/entity"
+	"order-service/internal/modules/room/models/request"
+	"order-service/internal/modules/room/models/response"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	"order-service/internal/pkg/log"
+	"strconv"
+	"time"
+
+	"go.elastic.co/apm"
+)
+
+const defaultAdmissionTokenTTL = 300
+
+type queryUsecase struct {
+	roomRepositoryQuery   room.MongodbRepositoryQuery
+	roomRepositoryCommand room.MongodbRepositoryCommand
+	admission             room.AdmissionController
+	queueEntries          room.QueueEntries
+	jwt                   helpers.TokenGenerator
+	logger                log.Logger
+}
+
+func NewQueryUsecase(rmq room.MongodbRepositoryQuery, rmc room.MongodbRepositoryCommand, adm room.AdmissionController,
+	qe room.QueueEntries, jwt helpers.TokenGenerator, log log.Logger) room.UsecaseQuery {
+	return queryUsecase{
+		roomRepositoryQuery:   rmq,
+		roomRepositoryCommand: rmc,
+		admission:             adm,
+		queueEntries:          qe,
+		jwt:                   jwt,
+		logger:                log,
+	}
+}
+
+// admissionTokenTTL is how long an admitted user may use the admission token to order.
+func admissionTokenTTL() time.Duration {
+	seconds, err := strconv.Atoi(configs.GetConfig().Room.AdmissionTokenTTL)
+	if err != nil || seconds <= 0 {
+		seconds = defaultAdmissionTokenTTL
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (q queryUsecase) GetQueueStatus(origCtx context.Context, payload request.QueueStatusReq) (*response.QueueStatusResp, error) {
+	domain := "roomUsecase-GetQueueStatus"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	queueRoom := <-q.roomRepositoryQuery.FindOneQueueByUserId(ctx, payload.UserId, payload.EventId)
+	if queueRoom.Error != nil {
+		msg := "Error DB connection FindOneQueueByUserId"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Error))
+		return nil, queueRoom.Error
+	}
+
+	if queueRoom.Data == nil {
+		msg := "user not in the queue"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+		return nil, errors.NotFound("user not in the queue")
+	}
+
+	queue, ok := queueRoom.Data.(*entity.QueueRoom)
+	if !ok {
+		msg := "cannot parsing data queue"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", queueRoom.Data))
+		return nil, errors.InternalServerError("cannot parsing data queue")
+	}
+
+	now := time.Now()
+	if !queueActive(*queue, now) {
+		msg := "user not in the queue"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", queue))
+		return nil, errors.NotFound("user not in the queue")
+	}
+
+	// polling keeps the spot, the entry is extended once half of its ttl is gone
+	if queue.ExpiredAt.Sub(now) < queueEntryTTL()/2 {
+		expiredAt := now.Add(queueEntryTTL())
+		refresh := <-q.roomRepositoryCommand.RefreshQueueRoom(ctx, queue.QueueId, expiredAt)
+		if refresh.Error != nil {
+			msg := "Error DB connection RefreshQueueRoom"
+			q.logger.Error(ctx, msg, fmt.Sprintf("%+v", refresh.Error))
+			return nil, refresh.Error
+		}
+		if err := q.queueEntries.Refresh(ctx, queue.EventId, queue.UserId, expiredAt); err != nil {
+			return nil, err
+		}
+	}
+
+	serving, err := q.admission.ServingNumber(ctx, queue.EventId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := response.QueueStatusResp{
+		EventId:       queue.EventId,
+		QueueNumber:   queue.QueueNumber,
+		ServingNumber: serving,
+		Admitted:      queue.QueueNumber <= serving,
+	}
+	if !result.Admitted {
+		result.PeopleAhead = queue.QueueNumber - serving - 1
+		result.EstimatedWait = q.admission.EstimateWait(queue.QueueNumber, serving)
+		return &result, nil
+	}
+
+	token, expiredAt, err := q.jwt.GenerateAdmissionToken(admissionTokenTTL(), helpers.PayloadAdmission{
+		EventId:     queue.EventId,
+		UserId:      queue.UserId,
+		QueueId:     queue.QueueId,
+		QueueNumber: queue.QueueNumber,
+	})
+	if err != nil {
+		msg := "cannot generate admission token"
+		q.logger.Error(ctx, msg, fmt.Sprintf("%+v", err))
+		return nil, errors.InternalServerError("cannot generate admission token")
+	}
+	result.AdmissionToken = token
+	result.AdmissionExpiredAt = expiredAt
+
+	return &result, nil
+}
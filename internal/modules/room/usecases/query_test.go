@@ -0,0 +1,359 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/internal/modules/room"
+	roomEntity "order-service/internal/modules/room/models/entity"
+	"order-service/internal/modules/room/models/request"
+	uc "order-service/internal/modules/room/usecases"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mockcert "order-service/mocks/modules/room"
+	mockhelpers "order-service/mocks/pkg/helpers"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueryUsecaseTestSuite struct {
+	suite.Suite
+	mockRoomRepositoryQuery   *mockcert.MongodbRepositoryQuery
+	mockRoomRepositoryCommand *mockcert.MongodbRepositoryCommand
+	mockLogger                *mocklog.Logger
+	mockAdmission             *mockcert.AdmissionController
+	mockQueueEntries          *mockcert.QueueEntries
+	mockJwt                   *mockhelpers.TokenGenerator
+	usecase                   room.UsecaseQuery
+	ctx                       context.Context
+}
+
+func (suite *QueryUsecaseTestSuite) SetupTest() {
+	suite.mockRoomRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockRoomRepositoryCommand = &mockcert.MongodbRepositoryCommand{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockAdmission = &mockcert.AdmissionController{}
+	suite.mockQueueEntries = &mockcert.QueueEntries{}
+	suite.mockJwt = &mockhelpers.TokenGenerator{}
+	suite.ctx = context.Background()
+	suite.usecase = uc.NewQueryUsecase(
+		suite.mockRoomRepositoryQuery,
+		suite.mockRoomRepositoryCommand,
+		suite.mockAdmission,
+		suite.mockQueueEntries,
+		suite.mockJwt,
+		suite.mockLogger,
+	)
+}
+
+func TestQueryUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(QueryUsecaseTestSuite))
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusWaiting() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			EventId:     "id",
+			QueueNumber: 250,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockAdmission.On("EstimateWait", 250, 100).Return(60)
+
+	result, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), result.Admitted)
+	assert.Equal(suite.T(), 100, result.ServingNumber)
+	assert.Equal(suite.T(), 149, result.PeopleAhead)
+	assert.Equal(suite.T(), 60, result.EstimatedWait)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusAdmitted() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 50,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockJwt.On("GenerateAdmissionToken", mock.Anything, helpers.PayloadAdmission{
+		EventId:     "id",
+		UserId:      "id",
+		QueueId:     "queue",
+		QueueNumber: 50,
+	}).Return("token", "2026-01-01T00:00:00Z", nil)
+
+	result, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), result.Admitted)
+	assert.Equal(suite.T(), 0, result.PeopleAhead)
+	assert.Equal(suite.T(), 0, result.EstimatedWait)
+	assert.Equal(suite.T(), "token", result.AdmissionToken)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrToken() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			EventId:     "id",
+			QueueNumber: 50,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockJwt.On("GenerateAdmissionToken", mock.Anything, mock.Anything).Return("", "", errors.InternalServerError("error"))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrServing() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			EventId:     "id",
+			QueueNumber: 5,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(0, errors.InternalServerError("error"))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrQueue() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: errors.BadRequest("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusNotInQueue() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  nil,
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "user not in the queue", err.Error())
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrParse() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data:  "data",
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusLeft() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			EventId:     "id",
+			QueueNumber: 5,
+			Status:      constants.QueueLeft,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "user not in the queue", err.Error())
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusExpired() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			EventId:     "id",
+			QueueNumber: 5,
+			Status:      constants.QueueActive,
+			ExpiredAt:   time.Now().Add(-time.Minute),
+		},
+		Error: nil,
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "user not in the queue", err.Error())
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusRefresh() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 250,
+			Status:      constants.QueueActive,
+			ExpiredAt:   time.Now().Add(time.Minute),
+		},
+		Error: nil,
+	}
+	mockRefreshQueueRoom := helpers.Result{
+		Data:  "Success update data",
+		Error: nil,
+	}
+
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRoomRepositoryCommand.On("RefreshQueueRoom", mock.Anything, "queue", mock.Anything).Return(mockChannel(mockRefreshQueueRoom))
+	suite.mockQueueEntries.On("Refresh", mock.Anything, "id", "id", mock.Anything).Return(nil)
+	suite.mockAdmission.On("ServingNumber", mock.Anything, "id").Return(100, nil)
+	suite.mockAdmission.On("EstimateWait", 250, 100).Return(60)
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.NoError(suite.T(), err)
+	suite.mockRoomRepositoryCommand.AssertCalled(suite.T(), "RefreshQueueRoom", mock.Anything, "queue", mock.MatchedBy(func(expiredAt time.Time) bool {
+		return expiredAt.After(time.Now().Add(time.Minute))
+	}))
+	suite.mockQueueEntries.AssertCalled(suite.T(), "Refresh", mock.Anything, "id", "id", mock.Anything)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrRefreshEntries() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 250,
+			Status:      constants.QueueActive,
+			ExpiredAt:   time.Now().Add(time.Minute),
+		},
+		Error: nil,
+	}
+
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRoomRepositoryCommand.On("RefreshQueueRoom", mock.Anything, "queue", mock.Anything).Return(mockChannel(helpers.Result{}))
+	suite.mockQueueEntries.On("Refresh", mock.Anything, "id", "id", mock.Anything).Return(errors.InternalServerError("error"))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+	suite.mockAdmission.AssertNotCalled(suite.T(), "ServingNumber", mock.Anything, mock.Anything)
+}
+
+func (suite *QueryUsecaseTestSuite) TestGetQueueStatusErrRefresh() {
+	payload := request.QueueStatusReq{
+		UserId:  "id",
+		EventId: "id",
+	}
+	mockFindOneQueueByUserId := helpers.Result{
+		Data: &roomEntity.QueueRoom{
+			QueueId:     "queue",
+			UserId:      "id",
+			EventId:     "id",
+			QueueNumber: 250,
+			Status:      constants.QueueActive,
+			ExpiredAt:   time.Now().Add(time.Minute),
+		},
+		Error: nil,
+	}
+	mockRefreshQueueRoom := helpers.Result{
+		Data:  nil,
+		Error: errors.InternalServerError("error"),
+	}
+
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRoomRepositoryQuery.On("FindOneQueueByUserId", mock.Anything, "id", "id").Return(mockChannel(mockFindOneQueueByUserId))
+	suite.mockRoomRepositoryCommand.On("RefreshQueueRoom", mock.Anything, mock.Anything, mock.Anything).Return(mockChannel(mockRefreshQueueRoom))
+
+	_, err := suite.usecase.GetQueueStatus(suite.ctx, payload)
+
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,86 @@
+package usecases
+
+import (
+	"fmt"
+	"order-service/configs"
+	"order-service/internal/modules/room/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"strconv"
+	"time"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+)
+
+const (
+	defaultQueueEntryTTL   = 30
+	defaultReentryCooldown = 10
+)
+
+// queueEntryTTL is how long a queue entry holds its spot without being refreshed.
+func queueEntryTTL() time.Duration {
+	minutes, err := strconv.Atoi(configs.GetConfig().Room.QueueEntryTTL)
+	if err != nil || minutes <= 0 {
+		minutes = defaultQueueEntryTTL
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// reentryPolicy returns the policy of the event, falling back to the service config and then to never.
+func reentryPolicy(event eventEntity.Event) string {
+	policy := event.Queue.ReentryPolicy
+	if policy == "" {
+		policy = configs.GetConfig().Room.ReentryPolicy
+	}
+
+	switch policy {
+	case constants.ReentryAfterExpiry, constants.ReentryCooldown:
+		return policy
+	default:
+		return constants.ReentryNever
+	}
+}
+
+func reentryCooldown(event eventEntity.Event) time.Duration {
+	minutes := event.Queue.ReentryCooldown
+	if minutes <= 0 {
+		minutes, _ = strconv.Atoi(configs.GetConfig().Room.ReentryCooldown)
+	}
+	if minutes <= 0 {
+		minutes = defaultReentryCooldown
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// queueActive reports whether the entry still holds a spot in the queue.
+func queueActive(queue entity.QueueRoom, now time.Time) bool {
+	return queue.Status != constants.QueueLeft && queue.ExpiredAt.After(now)
+}
+
+// checkReentry decides whether a user with a previous entry may join the queue of the event again.
+func checkReentry(event eventEntity.Event, queue entity.QueueRoom, now time.Time) error {
+	if queueActive(queue, now) {
+		return errors.BadRequest("user already in the queue")
+	}
+
+	switch reentryPolicy(event) {
+	case constants.ReentryAfterExpiry:
+		// leaving does not skip the wait, the original entry has to run out first
+		if queue.ExpiredAt.After(now) {
+			return errors.BadRequest(fmt.Sprintf("re-entry allowed after %s", queue.ExpiredAt.Format(time.RFC3339)))
+		}
+	case constants.ReentryCooldown:
+		inactiveAt := queue.ExpiredAt
+		if queue.Status == constants.QueueLeft && queue.LeftAt.Before(inactiveAt) {
+			inactiveAt = queue.LeftAt
+		}
+		allowedAt := inactiveAt.Add(reentryCooldown(event))
+		if allowedAt.After(now) {
+			return errors.BadRequest(fmt.Sprintf("re-entry allowed after %s", allowedAt.Format(time.RFC3339)))
+		}
+	default:
+		return errors.BadRequest("re-entry to the queue is not allowed")
+	}
+
+	return nil
+}
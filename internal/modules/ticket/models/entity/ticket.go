@@ -8,15 +8,17 @@ type Country struct {
 }
 
 type Ticket struct {
-	TicketId       string  `json:"ticketId" bson:"ticketId"`
-	EventId        string  `json:"eventId" bson:"eventId"`
-	TicketType     string  `json:"ticketType" bson:"ticketType"`
-	TicketPrice    int     `json:"ticketPrice" bson:"ticketPrice"`
-	TotalQuota     int     `json:"totalQuota" bson:"totalQuota"`
-	TotalRemaining int     `json:"totalRemaining" bson:"totalRemaining"`
-	ContinentName  string  `json:"continentName" bson:"continentName"`
-	ContinentCode  string  `json:"continentCode" bson:"continentCode"`
-	Country        Country `json:"country" bson:"country"`
+	TicketId       string `json:"ticketId" bson:"ticketId"`
+	EventId        string `json:"eventId" bson:"eventId"`
+	TicketType     string `json:"ticketType" bson:"ticketType"`
+	TicketPrice    int    `json:"ticketPrice" bson:"ticketPrice"`
+	TotalQuota     int    `json:"totalQuota" bson:"totalQuota"`
+	TotalRemaining int    `json:"totalRemaining" bson:"totalRemaining"`
+	// ReservedSeating categories are sold by seat number, users hold the seats they pick before ordering
+	ReservedSeating bool    `json:"reservedSeating" bson:"reservedSeating"`
+	ContinentName   string  `json:"continentName" bson:"continentName"`
+	ContinentCode   string  `json:"continentCode" bson:"continentCode"`
+	Country         Country `json:"country" bson:"country"`
 }
 
 type AggregateTotalTicket struct {
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type commandMongodbRepository struct {
@@ -45,3 +46,65 @@ func (c commandMongodbRepository) UpdateOneTicketDetail(ctx context.Context, pay
 
 	return output
 }
+
+// DecrementTicketDetail takes quantity tickets off totalRemaining only when enough are left,
+// so concurrent orders cannot push the counter below zero. Data is nil when the category is sold out.
+func (c commandMongodbRepository) DecrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var ticket entity.Ticket
+
+	go func() {
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "ticket-detail",
+			Result:         &ticket,
+			Filter: bson.M{
+				"ticketId":       ticketId,
+				"eventId":        eventId,
+				"totalRemaining": bson.M{"$gte": quantity},
+			},
+			Update: bson.M{
+				"$inc": bson.M{
+					"totalRemaining": -quantity,
+				},
+				"$set": bson.M{
+					"updatedAt": time.Now(),
+				},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
+
+// IncrementTicketDetail gives quantity tickets back to totalRemaining when a hold is released.
+func (c commandMongodbRepository) IncrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+	var ticket entity.Ticket
+
+	go func() {
+		resp := <-c.mongoDb.FindOneAndUpdate(mongodb.FindOneAndUpdate{
+			CollectionName: "ticket-detail",
+			Result:         &ticket,
+			Filter: bson.M{
+				"ticketId": ticketId,
+				"eventId":  eventId,
+			},
+			Update: bson.M{
+				"$inc": bson.M{
+					"totalRemaining": quantity,
+				},
+				"$set": bson.M{
+					"updatedAt": time.Now(),
+				},
+			},
+			Upsert: false,
+		}, options.After, ctx)
+		output <- resp
+		close(output)
+	}()
+
+	return output
+}
@@ -63,3 +63,49 @@ func (suite *CommandTestSuite) TestUpsertOneUserTemp() {
 	// Assert UpsertOne
 	suite.mockMongodb.AssertCalled(suite.T(), "UpdateOne", mock.Anything, mock.Anything)
 }
+
+func (suite *CommandTestSuite) TestDecrementTicketDetail() {
+	// Mock FindOneAndUpdate
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.DecrementTicketDetail(suite.ctx, "id", "id", 1)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOneAndUpdate
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *CommandTestSuite) TestIncrementTicketDetail() {
+	// Mock FindOneAndUpdate
+	expectedResult := make(chan helpers.Result)
+	suite.mockMongodb.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+
+	// Act
+	result := suite.repository.IncrementTicketDetail(suite.ctx, "id", "id", 1)
+	// Asset
+	assert.NotNil(suite.T(), result, "Expected a result")
+
+	// Simulate receiving a result from the channel
+	go func() {
+		expectedResult <- helpers.Result{Data: "result not nil", Error: nil}
+		close(expectedResult)
+	}()
+
+	// Wait for the goroutine to complete
+	<-result
+
+	// Assert FindOneAndUpdate
+	suite.mockMongodb.AssertCalled(suite.T(), "FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	address "order-service/internal/modules/ticket"
 	"order-service/internal/modules/ticket/models/entity"
-	"order-service/internal/modules/ticket/models/request"
 	"order-service/internal/pkg/databases/mongodb"
 	wrapper "order-service/internal/pkg/helpers"
 	"order-service/internal/pkg/log"
@@ -68,33 +67,17 @@ func (q queryMongodbRepository) FindTicketByEventId(ctx context.Context, eventId
 	return output
 }
 
-func (q queryMongodbRepository) FindTotalAvalailableTicketByCountry(ctx context.Context, payload request.TicketReq) <-chan wrapper.Result {
-	var ticket []entity.AggregateTotalTicket
+// FindTicketsByEventId returns every ticket category of the event with its remaining stock.
+func (q queryMongodbRepository) FindTicketsByEventId(ctx context.Context, eventId string) <-chan wrapper.Result {
+	var tickets []entity.Ticket
 	output := make(chan wrapper.Result)
 
 	go func() {
-		resp := <-q.mongoDb.Aggregate(mongodb.Aggregate{
-			Result:         &ticket,
+		resp := <-q.mongoDb.FindMany(mongodb.FindMany{
+			Result:         &tickets,
 			CollectionName: "ticket-detail",
-			Filter: []bson.M{
-				{
-					"$match": bson.M{
-						"tag":          payload.Tag,
-						"country.code": payload.CountryCode,
-						"ticketType":   bson.M{"$ne": "Online"},
-					},
-				},
-				{
-					"$group": bson.M{
-						"_id":                  "$country.code",
-						"totalAvailableTicket": bson.M{"$sum": "$totalRemaining"},
-					},
-				},
-				{
-					"$sort": bson.M{
-						"totalAvailableTicket": 1,
-					},
-				},
+			Filter: bson.M{
+				"eventId": eventId,
 			},
 		}, ctx)
 		output <- resp
@@ -3,7 +3,6 @@ package queries_test
 import (
 	"context"
 	"order-service/internal/modules/ticket"
-	"order-service/internal/modules/ticket/models/request"
 	mongoRQ "order-service/internal/modules/ticket/repositories/queries"
 	"order-service/internal/pkg/helpers"
 	mocks "order-service/mocks/pkg/databases/mongodb"
@@ -85,14 +84,14 @@ func (suite *CommandTestSuite) TestFindTicketByEventId() {
 	suite.mockMongodb.AssertCalled(suite.T(), "FindOne", mock.Anything, mock.Anything)
 }
 
-func (suite *CommandTestSuite) TestFindTotalAvalailableTicketByCountry() {
+func (suite *CommandTestSuite) TestFindTicketsByEventId() {
 
-	// Mock FindOne
+	// Mock FindMany
 	expectedResult := make(chan helpers.Result)
-	suite.mockMongodb.On("Aggregate", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
+	suite.mockMongodb.On("FindMany", mock.Anything, mock.Anything).Return((<-chan helpers.Result)(expectedResult))
 
 	// Act
-	result := suite.repository.FindTotalAvalailableTicketByCountry(suite.ctx, request.TicketReq{})
+	result := suite.repository.FindTicketsByEventId(suite.ctx, "id")
 	// Asset
 	assert.NotNil(suite.T(), result, "Expected a result")
 
@@ -105,6 +104,6 @@ func (suite *CommandTestSuite) TestFindTotalAvalailableTicketByCountry() {
 	// Wait for the goroutine to complete
 	<-result
 
-	// Assert FindOne
-	suite.mockMongodb.AssertCalled(suite.T(), "Aggregate", mock.Anything, mock.Anything)
+	// Assert FindMany
+	suite.mockMongodb.AssertCalled(suite.T(), "FindMany", mock.Anything, mock.Anything)
 }
@@ -3,16 +3,24 @@ package ticket
 import (
 	"context"
 	"order-service/internal/modules/ticket/models/entity"
-	"order-service/internal/modules/ticket/models/request"
 	wrapper "order-service/internal/pkg/helpers"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
 )
 
+// ReleasePolicy holds ticket categories back until the release rules of their event let them go on sale.
+type ReleasePolicy interface {
+	CheckReleased(ctx context.Context, event eventEntity.Event, ticketTypes []string) error
+}
+
 type MongodbRepositoryQuery interface {
 	FindTotalAvalailableTicket(ctx context.Context, countryCode string, tag string) <-chan wrapper.Result
-	FindTotalAvalailableTicketByCountry(ctx context.Context, payload request.TicketReq) <-chan wrapper.Result
+	FindTicketsByEventId(ctx context.Context, eventId string) <-chan wrapper.Result
 	FindTicketByEventId(ctx context.Context, eventId string, ticketType string) <-chan wrapper.Result
 }
 
 type MongodbRepositoryCommand interface {
 	UpdateOneTicketDetail(ctx context.Context, payload entity.Ticket) <-chan wrapper.Result
+	DecrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan wrapper.Result
+	IncrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan wrapper.Result
 }
@@ -0,0 +1,167 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/modules/ticket"
+	"order-service/internal/modules/ticket/models/entity"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/log"
+	"time"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+
+	"go.elastic.co/apm"
+)
+
+var Now = time.Now
+
+type releasePolicy struct {
+	ticketRepositoryQuery ticket.MongodbRepositoryQuery
+	logger                log.Logger
+}
+
+// NewReleasePolicy checks the release rules of the event against the stock of its ticket categories.
+// Categories without a rule are released, except Online which falls back to defaultOnlineRule.
+func NewReleasePolicy(trq ticket.MongodbRepositoryQuery, log log.Logger) ticket.ReleasePolicy {
+	return releasePolicy{
+		ticketRepositoryQuery: trq,
+		logger:                log,
+	}
+}
+
+func (r releasePolicy) CheckReleased(origCtx context.Context, event eventEntity.Event, ticketTypes []string) error {
+	domain := "releasePolicy-CheckReleased"
+	span, ctx := apm.StartSpanOptions(origCtx, domain, "function", apm.SpanOptions{
+		Start:  time.Now(),
+		Parent: apm.TraceContext{},
+	})
+	defer span.End()
+
+	// the stock is read once and only for rules that depend on it
+	var stock []entity.Ticket
+	loaded := false
+	for _, rule := range rulesFor(event, ticketTypes) {
+		if holdsNothing(rule) || scheduledRelease(rule, Now()) {
+			continue
+		}
+
+		if len(rule.AfterSoldOut) > 0 || rule.OfflineStockBelow > 0 {
+			if !loaded {
+				tickets, err := r.eventStock(ctx, event.EventId)
+				if err != nil {
+					return err
+				}
+				stock, loaded = tickets, true
+			}
+
+			if soldOutRelease(rule, stock) || offlineStockRelease(rule, stock) {
+				continue
+			}
+		}
+
+		msg := "ticket category not released"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", rule))
+		return errors.BadRequest(fmt.Sprintf("%s tickets are not released yet", rule.TicketType))
+	}
+
+	return nil
+}
+
+func (r releasePolicy) eventStock(ctx context.Context, eventId string) ([]entity.Ticket, error) {
+	ticketData := <-r.ticketRepositoryQuery.FindTicketsByEventId(ctx, eventId)
+	if ticketData.Error != nil {
+		msg := "Error DB connection FindTicketsByEventId"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketData.Error))
+		return nil, ticketData.Error
+	}
+
+	if ticketData.Data == nil {
+		return nil, nil
+	}
+
+	tickets, ok := ticketData.Data.(*[]entity.Ticket)
+	if !ok {
+		msg := "cannot parsing data tickets"
+		r.logger.Error(ctx, msg, fmt.Sprintf("%+v", ticketData.Data))
+		return nil, errors.InternalServerError("cannot parsing data tickets")
+	}
+	return *tickets, nil
+}
+
+// defaultOnlineRule keeps Online closed until the offline tickets are gone, for events without an Online rule.
+// An event opens Online right away with an Online rule without conditions.
+var defaultOnlineRule = eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1}
+
+// rulesFor returns the release rules of the ordered ticket types.
+func rulesFor(event eventEntity.Event, ticketTypes []string) []eventEntity.ReleaseRule {
+	eventRules := event.ReleaseRules
+	if !hasRule(eventRules, constants.Online) {
+		eventRules = append(eventRules[:len(eventRules):len(eventRules)], defaultOnlineRule)
+	}
+
+	rules := make([]eventEntity.ReleaseRule, 0, len(eventRules))
+	for _, rule := range eventRules {
+		for _, ticketType := range ticketTypes {
+			if rule.TicketType == ticketType {
+				rules = append(rules, rule)
+				break
+			}
+		}
+	}
+	return rules
+}
+
+func hasRule(rules []eventEntity.ReleaseRule, ticketType string) bool {
+	for _, rule := range rules {
+		if rule.TicketType == ticketType {
+			return true
+		}
+	}
+	return false
+}
+
+func holdsNothing(rule eventEntity.ReleaseRule) bool {
+	return len(rule.AfterSoldOut) == 0 && rule.ReleaseAt.IsZero() && rule.OfflineStockBelow <= 0
+}
+
+// scheduledRelease opens the category once its release time has passed.
+func scheduledRelease(rule eventEntity.ReleaseRule, now time.Time) bool {
+	return !rule.ReleaseAt.IsZero() && !now.Before(rule.ReleaseAt)
+}
+
+// soldOutRelease opens the category once every listed category is sold out, a category the event does not
+// sell has nothing left either.
+func soldOutRelease(rule eventEntity.ReleaseRule, stock []entity.Ticket) bool {
+	if len(rule.AfterSoldOut) == 0 {
+		return false
+	}
+	for _, ticketType := range rule.AfterSoldOut {
+		for _, ticket := range stock {
+			if ticket.TicketType == ticketType && ticket.TotalRemaining > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// offlineStockRelease opens the category once the other categories sold at the venue, on the continent of the
+// rule when it names one, run low.
+func offlineStockRelease(rule eventEntity.ReleaseRule, stock []entity.Ticket) bool {
+	if rule.OfflineStockBelow <= 0 {
+		return false
+	}
+	remaining := 0
+	for _, ticket := range stock {
+		if ticket.TicketType == rule.TicketType || ticket.TicketType == constants.Online {
+			continue
+		}
+		if rule.ContinentCode != "" && ticket.ContinentCode != rule.ContinentCode {
+			continue
+		}
+		remaining += ticket.TotalRemaining
+	}
+	return remaining < rule.OfflineStockBelow
+}
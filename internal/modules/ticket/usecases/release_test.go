@@ -0,0 +1,251 @@
+package usecases_test
+
+import (
+	"context"
+	"order-service/internal/modules/ticket"
+	"order-service/internal/modules/ticket/models/entity"
+	uc "order-service/internal/modules/ticket/usecases"
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/errors"
+	"order-service/internal/pkg/helpers"
+	mockcert "order-service/mocks/modules/ticket"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+	"time"
+
+	eventEntity "order-service/internal/modules/event/models/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var mockNow = time.Date(2024, time.March, 1, 3, 0, 0, 0, time.UTC)
+
+type ReleasePolicyTestSuite struct {
+	suite.Suite
+	mockTicketRepositoryQuery *mockcert.MongodbRepositoryQuery
+	mockLogger                *mocklog.Logger
+	policy                    ticket.ReleasePolicy
+	ctx                       context.Context
+}
+
+func (suite *ReleasePolicyTestSuite) SetupTest() {
+	suite.mockTicketRepositoryQuery = &mockcert.MongodbRepositoryQuery{}
+	suite.mockLogger = &mocklog.Logger{}
+	suite.mockLogger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.ctx = context.Background()
+	suite.policy = uc.NewReleasePolicy(suite.mockTicketRepositoryQuery, suite.mockLogger)
+	uc.Now = func() time.Time { return mockNow }
+}
+
+func (suite *ReleasePolicyTestSuite) TearDownTest() {
+	uc.Now = time.Now
+}
+
+func TestReleasePolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(ReleasePolicyTestSuite))
+}
+
+func mockChannel(result helpers.Result) <-chan helpers.Result {
+	responseChan := make(chan helpers.Result)
+
+	go func() {
+		responseChan <- result
+		close(responseChan)
+	}()
+
+	return responseChan
+}
+
+func (suite *ReleasePolicyTestSuite) mockStock(remaining map[string]int) {
+	tickets := make([]entity.Ticket, 0, len(remaining))
+	for ticketType, total := range remaining {
+		tickets = append(tickets, entity.Ticket{TicketType: ticketType, TotalRemaining: total})
+	}
+	suite.mockTicketRepositoryQuery.On("FindTicketsByEventId", mock.Anything, "event").Return(mockChannel(helpers.Result{Data: &tickets}))
+}
+
+func releaseEvent(rules ...eventEntity.ReleaseRule) eventEntity.Event {
+	return eventEntity.Event{EventId: "event", ReleaseRules: rules}
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNoRules() {
+	err := suite.policy.CheckReleased(suite.ctx, releaseEvent(), []string{"VIP"})
+	assert.NoError(suite.T(), err)
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketsByEventId", mock.Anything, mock.Anything)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNoRulesOnline() {
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 1, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, releaseEvent(), []string{constants.Online})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online tickets are not released yet", err.Error())
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNoRulesOnlineOfflineSoldOut() {
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 0, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, releaseEvent(), []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNoOnlineRule() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: "Gold", AfterSoldOut: []string{"VIP"}})
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 20, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"Gold", constants.Online})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online tickets are not released yet", err.Error())
+	assert.Len(suite.T(), event.ReleaseRules, 1)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedOtherTicketType() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"VIP"})
+	assert.NoError(suite.T(), err)
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketsByEventId", mock.Anything, mock.Anything)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedEmptyRule() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedScheduled() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, ReleaseAt: mockNow})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketsByEventId", mock.Anything, mock.Anything)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedScheduledNotYet() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, ReleaseAt: mockNow.Add(time.Minute)})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online tickets are not released yet", err.Error())
+	suite.mockTicketRepositoryQuery.AssertNotCalled(suite.T(), "FindTicketsByEventId", mock.Anything, mock.Anything)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedAfterSoldOut() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: "Gold", AfterSoldOut: []string{"VIP", "Platinum"}})
+	suite.mockStock(map[string]int{"VIP": 0, "Platinum": 0, "Gold": 20})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"Gold"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedAfterSoldOutMissingCategory() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: "Gold", AfterSoldOut: []string{"VIP", "Diamond"}})
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 20})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"Gold"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNotSoldOut() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: "Gold", AfterSoldOut: []string{"VIP", "Platinum"}})
+	suite.mockStock(map[string]int{"VIP": 0, "Platinum": 1, "Gold": 20})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"VIP", "Gold"})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Gold tickets are not released yet", err.Error())
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedOfflineStockBelow() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 10})
+	suite.mockStock(map[string]int{"VIP": 4, "Gold": 5, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedOfflineStockLeft() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1})
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 1, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "Online tickets are not released yet", err.Error())
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedOfflineStockContinent() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1, ContinentCode: "AS"})
+	tickets := []entity.Ticket{
+		{TicketType: "VIP", ContinentCode: "AS", TotalRemaining: 0},
+		{TicketType: "Gold", ContinentCode: "EU", TotalRemaining: 30},
+		{TicketType: constants.Online, ContinentCode: "AS", TotalRemaining: 100},
+	}
+	suite.mockTicketRepositoryQuery.On("FindTicketsByEventId", mock.Anything, "event").Return(mockChannel(helpers.Result{Data: &tickets}))
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedOfflineStockOwnCategory() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: "Bronze", OfflineStockBelow: 5})
+	suite.mockStock(map[string]int{"VIP": 2, "Bronze": 300, constants.Online: 100})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"Bronze"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedAnyCondition() {
+	event := releaseEvent(eventEntity.ReleaseRule{
+		TicketType:        constants.Online,
+		AfterSoldOut:      []string{"VIP"},
+		ReleaseAt:         mockNow.Add(time.Hour),
+		OfflineStockBelow: 1,
+	})
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 30})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedReadsStockOnce() {
+	event := releaseEvent(
+		eventEntity.ReleaseRule{TicketType: "Gold", AfterSoldOut: []string{"VIP"}},
+		eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 50},
+	)
+	suite.mockStock(map[string]int{"VIP": 0, "Gold": 20})
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{"Gold", constants.Online})
+	assert.NoError(suite.T(), err)
+	suite.mockTicketRepositoryQuery.AssertNumberOfCalls(suite.T(), "FindTicketsByEventId", 1)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedErrStock() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1})
+	suite.mockTicketRepositoryQuery.On("FindTicketsByEventId", mock.Anything, "event").Return(mockChannel(helpers.Result{
+		Error: errors.InternalServerError("error"),
+	}))
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedErrParse() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1})
+	suite.mockTicketRepositoryQuery.On("FindTicketsByEventId", mock.Anything, "event").Return(mockChannel(helpers.Result{
+		Data: &entity.Ticket{},
+	}))
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "cannot parsing data tickets", err.Error())
+}
+
+func (suite *ReleasePolicyTestSuite) TestCheckReleasedNoStock() {
+	event := releaseEvent(eventEntity.ReleaseRule{TicketType: constants.Online, OfflineStockBelow: 1})
+	suite.mockTicketRepositoryQuery.On("FindTicketsByEventId", mock.Anything, "event").Return(mockChannel(helpers.Result{}))
+
+	err := suite.policy.CheckReleased(suite.ctx, event, []string{constants.Online})
+	assert.NoError(suite.T(), err)
+}
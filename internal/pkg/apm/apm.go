@@ -1,6 +1,7 @@
 package apm
 
 import (
+	"context"
 	"order-service/configs"
 	"os"
 
@@ -19,3 +20,18 @@ func GetTracer() *apm.Tracer {
 	tracer, _ := apm.NewTracer(configs.GetConfig().ServiceName, configs.GetConfig().ServiceVersion)
 	return tracer
 }
+
+// TraceId returns the trace id of the span or transaction in ctx, empty when ctx is not traced.
+func TraceId(ctx context.Context) string {
+	traceContext := apm.TraceContext{}
+	if span := apm.SpanFromContext(ctx); span != nil {
+		traceContext = span.TraceContext()
+	} else if tx := apm.TransactionFromContext(ctx); tx != nil {
+		traceContext = tx.TraceContext()
+	}
+
+	if traceContext.Trace.Validate() != nil {
+		return ""
+	}
+	return traceContext.Trace.String()
+}
@@ -1,12 +1,15 @@
 package apm_test
 
 import (
+	"context"
 	"order-service/internal/pkg/apm"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	elasticapm "go.elastic.co/apm"
+	"go.elastic.co/apm/transport"
 )
 
 type ApmTestSuite struct {
@@ -46,3 +49,17 @@ func (suite *ApmTestSuite) TestGetTracer() {
 	// Assertions
 	assert.NotNil(suite.T(), tracer)
 }
+
+func (suite *ApmTestSuite) TestTraceId() {
+	tracer, err := elasticapm.NewTracerOptions(elasticapm.TracerOptions{Transport: transport.Discard})
+	assert.NoError(suite.T(), err)
+	defer tracer.Close()
+	tx := tracer.StartTransaction("CreateOrderTicket", "request")
+	defer tx.End()
+	span, ctx := elasticapm.StartSpan(elasticapm.ContextWithTransaction(context.Background(), tx), "orderUsecase", "function")
+	defer span.End()
+
+	assert.Equal(suite.T(), tx.TraceContext().Trace.String(), apm.TraceId(ctx))
+	assert.Equal(suite.T(), tx.TraceContext().Trace.String(), apm.TraceId(elasticapm.ContextWithTransaction(context.Background(), tx)))
+	assert.Empty(suite.T(), apm.TraceId(context.Background()))
+}
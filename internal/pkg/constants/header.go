@@ -0,0 +1,8 @@
+package constants
+
+// http header
+const (
+	HeaderAdmissionToken     = "X-Admission-Token"
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
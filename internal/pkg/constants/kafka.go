@@ -0,0 +1,27 @@
+package constants
+
+// kafka broker the service connects to
+const (
+	KafkaBrokerConfluent = `confluent`
+	KafkaBrokerMemory    = `memory`
+)
+
+// kafka topics
+const (
+	TopicQueueJoined    = `queue-joined`
+	TopicOrderHeld      = `order-held`
+	TopicOrderPaid      = `order-paid`
+	TopicOrderExpired   = `order-expired`
+	TopicOrderCancelled = `order-cancelled`
+	TopicPaymentResult  = `payment-result`
+)
+
+// domain event types written to the outbox
+const (
+	EventQueueJoined    = `QueueJoined`
+	EventOrderHeld      = `OrderHeld`
+	EventOrderPaid      = `OrderPaid`
+	EventOrderExpired   = `OrderExpired`
+	EventOrderCancelled = `OrderCancelled`
+	EventPaymentResult  = `PaymentResult`
+)
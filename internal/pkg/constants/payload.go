@@ -8,6 +8,50 @@ type MetaData struct {
 }
 
 const (
-	Online  = "Online"
-	Pending = "pending"
+	Online = "Online"
+)
+
+// payment result reported by the payment gateway
+const (
+	PaymentResultPaid    = "paid"
+	PaymentResultPending = "pending"
+	PaymentResultFailed  = "failed"
+)
+
+// queue entry status
+const (
+	QueueActive = "active"
+	QueueLeft   = "left"
+)
+
+// queue re-entry policy
+const (
+	ReentryNever       = "never"
+	ReentryAfterExpiry = "after_expiry"
+	ReentryCooldown    = "cooldown"
+)
+
+// queue capacity policy
+const (
+	CapacityFixed     = "fixed"
+	CapacityRatio     = "ratio"
+	CapacityUnlimited = "unlimited"
+)
+
+// user role
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// admission mode of the waiting room
+const (
+	AdmissionTime  = "time"
+	AdmissionOrder = "order"
+)
+
+// error code
+const (
+	ErrCodeNotAdmitted = 4291
+	ErrCodeSaleNotOpen = 4031
 )
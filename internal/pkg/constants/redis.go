@@ -11,4 +11,15 @@ const (
 	RedisKeyOtpRegister         = `OTP-REGISTER`
 	RedisKeyOtpLogin            = `OTP-LOGIN`
 	QueueLimit                  = `QUEUE-LIMIT`
+	QueueCounter                = `QUEUE-COUNTER`
+	QueueServing                = `QUEUE-SERVING`
+	QueueActiveEvents           = `QUEUE-ACTIVE-EVENTS`
+	QueueEntries                = `QUEUE-ENTRIES`
+	QueueEntriesSeeded          = `QUEUE-ENTRIES-SEEDED`
+	QueueReleased               = `QUEUE-RELEASED`
+	QueueAdvanced               = `QUEUE-ADVANCED`
+	QueueRevoked                = `QUEUE-REVOKED`
+	RedisKeyIdempotency         = `IDEMPOTENCY`
+	RedisKeySeatHold            = `SEAT-HOLD`
+	RedisKeySeatHolds           = `SEAT-HOLDS`
 )
@@ -3,7 +3,9 @@ package mongodb
 import (
 	"context"
 	"encoding/json"
+	goerrors "errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
 
 	"order-service/internal/pkg/errors"
 	wrapper "order-service/internal/pkg/helpers"
@@ -37,6 +40,12 @@ const (
 	SortDescending = `desc`
 )
 
+// server error codes of a drop that has nothing to drop
+const (
+	codeNamespaceNotFound = 26
+	codeIndexNotFound     = 27
+)
+
 type Sort struct {
 	FieldName string
 	By        string
@@ -87,6 +96,7 @@ func (m MongoDBLogger) FindAllData(payload FindAllData, ctx context.Context) <-c
 		cursor, err := collection.Find(ctx, payload.Filter, findOption)
 
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
@@ -170,6 +180,7 @@ func (m MongoDBLogger) FindOne(payload FindOne, ctx context.Context) <-chan wrap
 					Data: nil,
 				}
 			} else {
+				recordRetryable(ctx, documentReturned.Err())
 				msg := fmt.Sprintf("Error Mongodb Connection %s", documentReturned.Err())
 				m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 				output <- wrapper.Result{
@@ -207,6 +218,8 @@ type FindMany struct {
 	CollectionName string
 	Filter         interface{}
 	Sort           *Sort
+	// Limit caps the number of documents returned, zero returns every match
+	Limit int64
 }
 
 func (m MongoDBLogger) FindMany(payload FindMany, ctx context.Context) <-chan wrapper.Result {
@@ -223,14 +236,20 @@ func (m MongoDBLogger) FindMany(payload FindMany, ctx context.Context) <-chan wr
 			findOption.SetSort(bson.D{{Key: payload.Sort.FieldName, Value: payload.Sort.buildSortBy()}})
 		}
 
+		if payload.Limit > 0 {
+			findOption.SetLimit(payload.Limit)
+		}
+
 		cursor, err := collection.Find(ctx, payload.Filter, findOption)
 
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
 				Error: errors.InternalServerError(msg),
 			}
+			return
 		}
 
 		defer cursor.Close(ctx)
@@ -241,6 +260,7 @@ func (m MongoDBLogger) FindMany(payload FindMany, ctx context.Context) <-chan wr
 			output <- wrapper.Result{
 				Error: errors.InternalServerError(msg),
 			}
+			return
 		}
 		output <- wrapper.Result{
 			Data: payload.Result,
@@ -276,11 +296,13 @@ func (m MongoDBLogger) CountData(payload CountData, ctx context.Context) <-chan
 		countDoc, err := collection.CountDocuments(ctx, payload.Filter)
 
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
 				Error: errors.InternalServerError(msg),
 			}
+			return
 		}
 
 		if payload.Result != nil {
@@ -344,27 +366,12 @@ func (m MongoDBLogger) UpsertOne(payload UpdateOne, ctx context.Context) <-chan
 		callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
 			// Important: You must pass sessCtx as the Context parameter to the operations for them to be executed in the
 			// transaction.
-			_, err = collection.UpdateOne(sessCtx, payload.Filter, doc, opts)
-
-			if err != nil {
-				msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
-				m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-				return nil, errors.InternalServerError("Error mongodb connection")
-			}
-			return nil, nil
+			// the driver error is returned as is, its labels tell the session whether to retry
+			_, err := collection.UpdateOne(sessCtx, payload.Filter, doc, opts)
+			return nil, err
 		}
 
-		session, err := m.mongoClient.StartSession()
-		if err != nil {
-			msg := fmt.Sprintf("Error Mongodb Session : %s", err.Error())
-			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			output <- wrapper.Result{
-				Error: errors.InternalServerError("Error mongodb session"),
-			}
-		}
-		defer session.EndSession(context.Background())
-
-		_, err = session.WithTransaction(ctx, callback, txnOpts)
+		_, err = m.transaction(ctx, callback, txnOpts)
 		if err != nil {
 			msg := fmt.Sprintf("Error Mongodb Transaction : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
@@ -405,11 +412,20 @@ func (m MongoDBLogger) InsertOne(payload InsertOne, ctx context.Context) <-chan
 
 		_, err := collection.InsertOne(ctx, payload.Document)
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			if mongo.IsDuplicateKeyError(err) {
+				// keep the driver message, it names the violated index
+				output <- wrapper.Result{
+					Error: errors.Conflict(err.Error()),
+				}
+				return
+			}
 			output <- wrapper.Result{
 				Error: errors.InternalServerError("Error mongodb connection"),
 			}
+			return
 		}
 
 		finish := time.Now()
@@ -428,6 +444,70 @@ func (m MongoDBLogger) InsertOne(payload InsertOne, ctx context.Context) <-chan
 	return output
 }
 
+type CreateIndexes struct {
+	CollectionName string
+	Indexes        []mongo.IndexModel
+}
+
+func (m MongoDBLogger) CreateIndexes(payload CreateIndexes, ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+
+		collection := m.mongoClient.Database(m.dbName).Collection(payload.CollectionName)
+
+		names, err := collection.Indexes().CreateMany(ctx, payload.Indexes)
+		if err != nil {
+			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
+			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			output <- wrapper.Result{
+				Error: errors.InternalServerError("Error mongodb create indexes"),
+			}
+			return
+		}
+
+		output <- wrapper.Result{
+			Data: names,
+		}
+	}()
+
+	return output
+}
+
+type DropIndex struct {
+	CollectionName string
+	Name           string
+}
+
+// DropIndex removes an index that is no longer wanted, an index or a collection that does not exist is not an error.
+func (m MongoDBLogger) DropIndex(payload DropIndex, ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+
+		collection := m.mongoClient.Database(m.dbName).Collection(payload.CollectionName)
+
+		_, err := collection.Indexes().DropOne(ctx, payload.Name)
+		var commandErr mongo.CommandError
+		if err != nil && !(goerrors.As(err, &commandErr) && (commandErr.Code == codeIndexNotFound || commandErr.Code == codeNamespaceNotFound)) {
+			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
+			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			output <- wrapper.Result{
+				Error: errors.InternalServerError("Error mongodb drop index"),
+			}
+			return
+		}
+
+		output <- wrapper.Result{
+			Data: payload.Name,
+		}
+	}()
+
+	return output
+}
+
 func (m MongoDBLogger) UpdateOne(payload UpdateOne, ctx context.Context) <-chan wrapper.Result {
 	output := make(chan wrapper.Result)
 
@@ -460,6 +540,7 @@ func (m MongoDBLogger) UpdateOne(payload UpdateOne, ctx context.Context) <-chan
 		_, err = collection.UpdateOne(ctx, payload.Filter, doc)
 
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
@@ -500,6 +581,7 @@ func (m MongoDBLogger) Aggregate(payload Aggregate, ctx context.Context) <-chan
 		cursor, err := collection.Aggregate(ctx, payload.Filter)
 
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
@@ -537,6 +619,8 @@ type FindOneAndUpdate struct {
 	Update         interface{}
 	Result         interface{}
 	Upsert         bool
+	// Sort picks the document to update when several match the filter
+	Sort *Sort
 }
 
 // FindOneAndUpdate executes a findAndModify command to update at most one document in the collection and returns the document BEFORE or AFTER updating.
@@ -544,6 +628,7 @@ func (m MongoDBLogger) FindOneAndUpdate(payload FindOneAndUpdate, rd options.Ret
 	output := make(chan wrapper.Result)
 
 	go func() {
+		defer close(output)
 		start := time.Now()
 
 		wc := writeconcern.Majority()
@@ -571,6 +656,9 @@ func (m MongoDBLogger) FindOneAndUpdate(payload FindOneAndUpdate, rd options.Ret
 		}
 
 		opts := options.FindOneAndUpdate().SetUpsert(payload.Upsert).SetReturnDocument(rd)
+		if payload.Sort != nil {
+			opts.SetSort(bson.D{{Key: payload.Sort.FieldName, Value: payload.Sort.buildSortBy()}})
+		}
 
 		callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
 			// Important: You must pass sessCtx as the Context parameter to the operations for them to be executed in the
@@ -584,7 +672,8 @@ func (m MongoDBLogger) FindOneAndUpdate(payload FindOneAndUpdate, rd options.Ret
 					return nil, errors.NotFound(res.Err().Error())
 				}
 				m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-				return nil, errors.InternalServerError("Error mongodb connection")
+				// the driver error is returned as is, its labels tell the session whether to retry
+				return nil, res.Err()
 			}
 
 			if err := res.Decode(payload.Result); err != nil {
@@ -595,23 +684,21 @@ func (m MongoDBLogger) FindOneAndUpdate(payload FindOneAndUpdate, rd options.Ret
 			return payload.Result, nil
 		}
 
-		session, err := m.mongoClient.StartSession()
+		result, err := m.transaction(ctx, callback, txnOpts)
 		if err != nil {
-			msg := fmt.Sprintf("Error Mongodb Session : %s", err.Error())
-			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
-			output <- wrapper.Result{
-				Error: errors.InternalServerError("Error mongodb session"),
+			if errString, ok := err.(*errors.ErrorString); ok && errString.Code() == http.StatusNotFound {
+				// no document matched the filter, callers treat this the same as FindOne
+				output <- wrapper.Result{
+					Data: nil,
+				}
+				return
 			}
-		}
-		defer session.EndSession(context.Background())
-
-		result, err := session.WithTransaction(ctx, callback, txnOpts)
-		if err != nil {
 			msg := fmt.Sprintf("Error Mongodb Transaction : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
 				Error: errors.InternalServerError("Error mongodb transaction"),
 			}
+			return
 		}
 		rs, _ := json.Marshal(result)
 		fmt.Println("-----Result FindOneAndUpdate------")
@@ -633,6 +720,151 @@ func (m MongoDBLogger) FindOneAndUpdate(payload FindOneAndUpdate, rd options.Ret
 	return output
 }
 
+// transaction runs callback inside the session carried by ctx when there is one,
+// so that calls made from WithTransaction join the outer transaction instead of starting their own.
+func (m MongoDBLogger) transaction(ctx context.Context, callback func(sessCtx mongo.SessionContext) (interface{}, error),
+	opts ...*options.TransactionOptions) (interface{}, error) {
+	if session := mongo.SessionFromContext(ctx); session != nil {
+		result, err := callback(mongo.NewSessionContext(ctx, session))
+		recordRetryable(ctx, err)
+		return result, err
+	}
+
+	session, err := m.mongoClient.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(context.Background())
+
+	return session.WithTransaction(ctx, callback, opts...)
+}
+
+// transactionStateKey carries the transactionState of the WithTransaction a context runs in.
+type transactionStateKey struct{}
+
+// transactionState keeps the first driver error of a transaction that is worth retrying. Repositories and usecases
+// replace driver errors with their own, which drops the label session.WithTransaction retries on.
+type transactionState struct {
+	retryable error
+}
+
+// recordRetryable remembers err on the transaction of ctx when the server labelled it as transient.
+func recordRetryable(ctx context.Context, err error) {
+	state, ok := ctx.Value(transactionStateKey{}).(*transactionState)
+	if !ok || err == nil || state.retryable != nil {
+		return
+	}
+	var labeled mongo.LabeledError
+	if goerrors.As(err, &labeled) && labeled.HasErrorLabel(driver.TransientTransactionError) {
+		state.retryable = err
+	}
+}
+
+// WithTransaction executes fn inside a multi-document transaction. Every Collections call made with
+// sessCtx is part of the transaction, which is committed when fn returns nil and aborted otherwise.
+func (m MongoDBLogger) WithTransaction(fn func(sessCtx context.Context) error, ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+		start := time.Now()
+
+		wc := writeconcern.Majority()
+		rc := readconcern.Snapshot()
+		txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+		callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+			state := &transactionState{}
+			err := fn(mongo.NewSessionContext(context.WithValue(sessCtx, transactionStateKey{}, state), sessCtx))
+			if err != nil && state.retryable != nil {
+				// fn reports its own error in place of the driver's, hand the labelled one back so the
+				// session runs fn again on a write conflict
+				return nil, state.retryable
+			}
+			return nil, err
+		}
+
+		_, err := m.transaction(ctx, callback, txnOpts)
+		if err != nil {
+			msg := fmt.Sprintf("Error Mongodb Transaction : %s", err.Error())
+			m.logger.Error(ctx, msg, "")
+			if _, ok := err.(*errors.ErrorString); ok {
+				output <- wrapper.Result{
+					Error: err,
+				}
+				return
+			}
+			output <- wrapper.Result{
+				Error: errors.InternalServerError("Error mongodb transaction"),
+			}
+			return
+		}
+
+		finish := time.Now()
+
+		if finish.Sub(start).Seconds() > 10 {
+			msg := fmt.Sprintf("slow transaction: %v second", finish.Sub(start).Seconds())
+			m.logger.Error(ctx, msg, "")
+		}
+
+		output <- wrapper.Result{
+			Data: "Success commit transaction",
+		}
+	}()
+
+	return output
+}
+
+type BulkWrite struct {
+	CollectionName string
+	Models         []mongo.WriteModel
+}
+
+// BulkWrite sends all models in one ordered command inside a transaction, so either every model is applied or none.
+// Data is the *mongo.BulkWriteResult, callers compare its counts with the number of models they sent.
+func (m MongoDBLogger) BulkWrite(payload BulkWrite, ctx context.Context) <-chan wrapper.Result {
+	output := make(chan wrapper.Result)
+
+	go func() {
+		defer close(output)
+		start := time.Now()
+
+		wc := writeconcern.Majority()
+		rc := readconcern.Majority()
+		txnOpts := options.Transaction().SetWriteConcern(wc).SetReadConcern(rc)
+
+		collection := m.mongoClient.Database(m.dbName).Collection(payload.CollectionName, options.Collection().SetReadPreference(readpref.Primary()))
+		opts := options.BulkWrite().SetOrdered(true)
+
+		callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+			return collection.BulkWrite(sessCtx, payload.Models, opts)
+		}
+
+		result, err := m.transaction(ctx, callback, txnOpts)
+		if err != nil {
+			msg := fmt.Sprintf("Error Mongodb Transaction : %s", err.Error())
+			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
+			output <- wrapper.Result{
+				Error: errors.InternalServerError("Error mongodb transaction"),
+			}
+			return
+		}
+
+		finish := time.Now()
+
+		if finish.Sub(start).Seconds() > 10 {
+			msg := fmt.Sprintf("slow bulk write: %v second, models: %d", finish.Sub(start).Seconds(), len(payload.Models))
+			m.logger.Error(ctx, msg, payload.CollectionName)
+		}
+
+		output <- wrapper.Result{
+			Data: result,
+		}
+	}()
+
+	return output
+}
+
 type DeleteOne struct {
 	CollectionName string
 	Filter         interface{}
@@ -649,6 +881,7 @@ func (m MongoDBLogger) DeleteOne(payload DeleteOne, ctx context.Context) <-chan
 
 		resp, err := collection.DeleteOne(ctx, payload.Filter)
 		if err != nil {
+			recordRetryable(ctx, err)
 			msg := fmt.Sprintf("Error Mongodb Connection : %s", err.Error())
 			m.logger.Error(ctx, msg, fmt.Sprintf("%+v", payload))
 			output <- wrapper.Result{
@@ -684,5 +917,9 @@ type Collections interface {
 	UpdateOne(payload UpdateOne, ctx context.Context) <-chan wrapper.Result
 	Aggregate(payload Aggregate, ctx context.Context) <-chan wrapper.Result
 	DeleteOne(payload DeleteOne, ctx context.Context) <-chan wrapper.Result
+	BulkWrite(payload BulkWrite, ctx context.Context) <-chan wrapper.Result
+	CreateIndexes(payload CreateIndexes, ctx context.Context) <-chan wrapper.Result
+	DropIndex(payload DropIndex, ctx context.Context) <-chan wrapper.Result
+	WithTransaction(fn func(sessCtx context.Context) error, ctx context.Context) <-chan wrapper.Result
 	Close(ctx context.Context) error
 }
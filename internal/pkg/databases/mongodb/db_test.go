@@ -0,0 +1,34 @@
+package mongodb_test
+
+import (
+	"context"
+	"order-service/internal/pkg/databases/mongodb"
+	mocklog "order-service/mocks/pkg/log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestFindManyErr(t *testing.T) {
+	// a client that never connected fails every Find
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	assert.NoError(t, err)
+	logger := &mocklog.Logger{}
+	logger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	collections := mongodb.NewMongoDBLogger(client, "test", logger)
+
+	output := collections.FindMany(mongodb.FindMany{
+		Result:         &[]bson.M{},
+		CollectionName: "test",
+		Filter:         bson.M{},
+	}, context.Background())
+
+	result := <-output
+	assert.Error(t, result.Error)
+	_, open := <-output
+	assert.False(t, open)
+}
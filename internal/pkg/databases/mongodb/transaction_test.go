@@ -0,0 +1,33 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"order-service/internal/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRecordRetryable(t *testing.T) {
+	conflict := mongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+	state := &transactionState{}
+	ctx := context.WithValue(context.Background(), transactionStateKey{}, state)
+
+	recordRetryable(ctx, errors.InternalServerError("Error mongodb connection"))
+	recordRetryable(ctx, mongo.CommandError{Code: 11000, Name: "DuplicateKey"})
+	assert.Nil(t, state.retryable)
+
+	wrapped := fmt.Errorf("claim ticket: %w", conflict)
+	recordRetryable(ctx, wrapped)
+	recordRetryable(ctx, mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}})
+	assert.Equal(t, wrapped, state.retryable)
+}
+
+func TestRecordRetryableOutsideTransaction(t *testing.T) {
+	conflict := mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}}
+
+	assert.NotPanics(t, func() { recordRetryable(context.Background(), conflict) })
+}
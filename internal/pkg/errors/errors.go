@@ -5,9 +5,10 @@ import (
 )
 
 type ErrorString struct {
-	code     int
-	message  string
-	httpCode int
+	code       int
+	message    string
+	httpCode   int
+	retryAfter int
 }
 
 func (e ErrorString) Code() int {
@@ -26,6 +27,11 @@ func (e ErrorString) HttpCode() int {
 	return e.httpCode
 }
 
+// RetryAfter is the number of seconds the client should wait before retrying, zero when unset
+func (e ErrorString) RetryAfter() int {
+	return e.retryAfter
+}
+
 // BadRequest will throw if the given request-body or params is not valid
 func BadRequest(msg string) error {
 	return &ErrorString{
@@ -81,6 +87,16 @@ func CustomError(msg string, code int, codeHttp int) error {
 	}
 }
 
+// CustomErrorRetryAfter works like CustomError and also tells the client when to retry
+func CustomErrorRetryAfter(msg string, code int, codeHttp int, retryAfter int) error {
+	return &ErrorString{
+		code:       code,
+		message:    msg,
+		httpCode:   codeHttp,
+		retryAfter: retryAfter,
+	}
+}
+
 // TooManyRequest will throw if request created very frequently
 func TooManyRequest(msg string) error {
 	return &ErrorString{
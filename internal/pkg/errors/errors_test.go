@@ -115,3 +115,16 @@ func TestTooManyRequestError(t *testing.T) {
 	assert.Equal(t, "Too many request error message", err.Error())
 	assert.Equal(t, "Too many request error message", errString.Message())
 }
+
+func TestCustomErrorRetryAfter(t *testing.T) {
+	// Call the function under test
+	err := errors.CustomErrorRetryAfter("Retry later", 4291, http.StatusTooManyRequests, 30)
+
+	errString, _ := err.(*errors.ErrorString)
+	// Assertions
+	assert.NotNil(t, err)
+	assert.Equal(t, 4291, errString.Code())
+	assert.Equal(t, "Retry later", err.Error())
+	assert.Equal(t, http.StatusTooManyRequests, errString.HttpCode())
+	assert.Equal(t, 30, errString.RetryAfter())
+}
@@ -0,0 +1,64 @@
+package eventschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir is where the schema documents are checked in, relative to the repository root.
+const Dir = "schemas/events"
+
+// Marshal renders a schema the way it is checked in.
+func Marshal(schema *Schema) ([]byte, error) {
+	document, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(document, '\n'), nil
+}
+
+// Load reads the checked-in schema of a definition, it returns os.ErrNotExist when the version has none yet.
+func Load(dir string, definition Definition) (*Schema, error) {
+	document, err := os.ReadFile(filepath.Join(dir, FileName(definition)))
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{}
+	if err := json.Unmarshal(document, schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", FileName(definition), err)
+	}
+	return schema, nil
+}
+
+// Generate writes the schema of every definition to dir. The document of a version that is already checked in
+// is only rewritten by a compatible change, a breaking change needs a new version and leaves the old document.
+func Generate(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, definition := range Definitions {
+		schema := SchemaOf(definition)
+		previous, err := Load(dir, definition)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if previous != nil {
+			if err := Compatible(previous, schema); err != nil {
+				return fmt.Errorf("%s is a breaking change, raise the version of %s: %w", FileName(definition), definition.Type, err)
+			}
+		}
+
+		document, err := Marshal(schema)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, FileName(definition)), document, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
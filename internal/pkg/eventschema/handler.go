@@ -0,0 +1,34 @@
+package eventschema
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+)
+
+// Checked checks a consumed message against the schema of its topic before handing it to handler. A message
+// without a schema version header is taken as the current version. A message of another version or one that
+// does not match the schema is permanent, retrying cannot fix it.
+func Checked(handler kafkaConfluent.HandlerFunc) kafkaConfluent.HandlerFunc {
+	return func(ctx context.Context, message kafkaConfluent.Message) error {
+		definition, ok := LookupTopic(message.Topic)
+		if !ok {
+			return handler(ctx, message)
+		}
+
+		if header, ok := message.Headers[kafkaConfluent.HeaderSchemaVersion]; ok {
+			version, err := strconv.Atoi(header)
+			if err != nil || version != definition.Version {
+				return kafkaConfluent.Permanent(fmt.Errorf("unsupported schema version %q of topic %s, expected %d",
+					header, message.Topic, definition.Version))
+			}
+		}
+
+		if err := SchemaOf(definition).Validate(message.Value); err != nil {
+			return kafkaConfluent.Permanent(fmt.Errorf("message of topic %s does not match %s: %w", message.Topic, FileName(definition), err))
+		}
+		return handler(ctx, message)
+	}
+}
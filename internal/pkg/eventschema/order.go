@@ -0,0 +1,74 @@
+package eventschema
+
+import "time"
+
+// OrderExpired is published when an unpaid order passed its payment window and the ticket went back on sale.
+type OrderExpired struct {
+	OrderId      string    `json:"orderId,omitempty"`
+	TicketNumber string    `json:"ticketNumber"`
+	TicketId     string    `json:"ticketId"`
+	EventId      string    `json:"eventId"`
+	UserId       string    `json:"userId"`
+	QueueId      string    `json:"queueId"`
+	TicketType   string    `json:"ticketType"`
+	Price        int       `json:"price"`
+	OrderTime    time.Time `json:"orderTime"`
+	ExpiredAt    time.Time `json:"expiredAt"`
+}
+
+// OrderCancelled is published when the user cancelled an unpaid order.
+type OrderCancelled struct {
+	OrderId      string    `json:"orderId,omitempty"`
+	TicketNumber string    `json:"ticketNumber"`
+	TicketId     string    `json:"ticketId"`
+	EventId      string    `json:"eventId"`
+	UserId       string    `json:"userId"`
+	QueueId      string    `json:"queueId"`
+	TicketType   string    `json:"ticketType"`
+	Price        int       `json:"price"`
+	OrderTime    time.Time `json:"orderTime"`
+	CancelledAt  time.Time `json:"cancelledAt"`
+}
+
+// OrderHeld is published when a ticket is held for the user until ExpiredAt.
+type OrderHeld struct {
+	OrderId      string    `json:"orderId,omitempty"`
+	TicketNumber string    `json:"ticketNumber"`
+	TicketId     string    `json:"ticketId"`
+	EventId      string    `json:"eventId"`
+	UserId       string    `json:"userId"`
+	QueueId      string    `json:"queueId"`
+	TicketType   string    `json:"ticketType"`
+	SeatNumber   int       `json:"seatNumber,omitempty"`
+	Price        int       `json:"price"`
+	PromoCode    string    `json:"promoCode,omitempty"`
+	OrderTime    time.Time `json:"orderTime"`
+	ExpiredAt    time.Time `json:"expiredAt"`
+}
+
+// OrderPaid is published when the payment of a held ticket settled the order.
+type OrderPaid struct {
+	OrderId      string    `json:"orderId"`
+	PaymentId    string    `json:"paymentId"`
+	TicketNumber string    `json:"ticketNumber"`
+	TicketId     string    `json:"ticketId"`
+	EventId      string    `json:"eventId"`
+	UserId       string    `json:"userId"`
+	QueueId      string    `json:"queueId"`
+	TicketType   string    `json:"ticketType"`
+	SeatNumber   int       `json:"seatNumber,omitempty"`
+	Amount       int       `json:"amount"`
+	OrderTime    time.Time `json:"orderTime"`
+	PaidAt       time.Time `json:"paidAt"`
+}
+
+// PaymentResult is consumed from the payment service, it settles or fails a held order.
+type PaymentResult struct {
+	PaymentId    string `json:"paymentId"`
+	OrderId      string `json:"orderId,omitempty"`
+	TicketNumber string `json:"ticketNumber"`
+	VaNumber     string `json:"vaNumber"`
+	Bank         string `json:"bank"`
+	Amount       int    `json:"amount"`
+	Status       string `json:"status"`
+}
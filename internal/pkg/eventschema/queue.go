@@ -0,0 +1,15 @@
+package eventschema
+
+import "time"
+
+// QueueJoined is published when a user joined or re-entered the waiting room of an event.
+type QueueJoined struct {
+	QueueId     string    `json:"queueId"`
+	UserId      string    `json:"userId"`
+	EventId     string    `json:"eventId"`
+	QueueNumber int       `json:"queueNumber"`
+	CountryCode string    `json:"countryCode"`
+	Reentry     bool      `json:"reentry"`
+	JoinedAt    time.Time `json:"joinedAt"`
+	ExpiredAt   time.Time `json:"expiredAt"`
+}
@@ -0,0 +1,59 @@
+package eventschema
+
+import (
+	"fmt"
+	"order-service/internal/pkg/constants"
+)
+
+// Definition ties an event type to its topic, its payload type and the version of that payload. A change of
+// Payload that old consumers cannot read needs a new Version.
+type Definition struct {
+	Type    string
+	Topic   string
+	Version int
+	Payload interface{}
+	// Consumed marks the events this service reads instead of publishes
+	Consumed bool
+}
+
+// Definitions lists every event the service publishes or consumes.
+var Definitions = []Definition{
+	{Type: constants.EventQueueJoined, Topic: constants.TopicQueueJoined, Version: 1, Payload: QueueJoined{}},
+	{Type: constants.EventOrderHeld, Topic: constants.TopicOrderHeld, Version: 1, Payload: OrderHeld{}},
+	{Type: constants.EventOrderPaid, Topic: constants.TopicOrderPaid, Version: 1, Payload: OrderPaid{}},
+	{Type: constants.EventOrderExpired, Topic: constants.TopicOrderExpired, Version: 1, Payload: OrderExpired{}},
+	{Type: constants.EventOrderCancelled, Topic: constants.TopicOrderCancelled, Version: 1, Payload: OrderCancelled{}},
+	{Type: constants.EventPaymentResult, Topic: constants.TopicPaymentResult, Version: 1, Payload: PaymentResult{}, Consumed: true},
+}
+
+// Lookup returns the definition of an event type.
+func Lookup(eventType string) (Definition, bool) {
+	for _, definition := range Definitions {
+		if definition.Type == eventType {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+// LookupTopic returns the definition of the events of a topic.
+func LookupTopic(topic string) (Definition, bool) {
+	for _, definition := range Definitions {
+		if definition.Topic == topic {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Validate checks a JSON payload of the event type against the schema of its current version.
+func Validate(eventType string, payload []byte) error {
+	definition, ok := Lookup(eventType)
+	if !ok {
+		return fmt.Errorf("unknown event type %s", eventType)
+	}
+	if err := SchemaOf(definition).Validate(payload); err != nil {
+		return fmt.Errorf("%s v%d: %w", eventType, definition.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package eventschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the part of JSON Schema the event payloads need. Properties that are not listed are allowed,
+// so consumers keep reading a payload that gained an optional field.
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Id         string             `json:"$id,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FileName is the name of the schema document of a definition, one per topic and version.
+func FileName(definition Definition) string {
+	return fmt.Sprintf("%s.v%d.json", definition.Topic, definition.Version)
+}
+
+// SchemaOf generates the schema of a definition from its payload type. A field is required unless it is omitempty.
+func SchemaOf(definition Definition) *Schema {
+	schema := schemaOfType(reflect.TypeOf(definition.Payload))
+	schema.Schema = draft
+	schema.Id = FileName(definition)
+	schema.Title = definition.Type
+	return schema
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(schema, t)
+		sort.Strings(schema.Required)
+		return schema
+	}
+	panic(fmt.Sprintf("eventschema: unsupported type %s", t))
+}
+
+// addFields adds the JSON fields of t, fields of embedded structs are promoted like encoding/json does.
+func addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = schemaOfType(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// Validate checks a JSON document against the schema.
+func (s *Schema) Validate(payload []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) error {
+	switch s.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s must be a date-time", path)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+		if _, err := number.Int64(); err != nil {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s must be a number", path)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			// encoding/json writes a nil slice as null
+			if value == nil {
+				return nil
+			}
+			return fmt.Errorf("%s must be an array", path)
+		}
+		for i, item := range items {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			if value == nil && s.Properties == nil {
+				return nil
+			}
+			return fmt.Errorf("%s must be an object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		for name, property := range s.Properties {
+			if field, ok := object[name]; ok {
+				if err := property.validate(path+"."+name, field); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Compatible reports why a consumer of the previous schema could not read payloads of the next one: a property
+// was removed, changed its type or stopped being required, or a new property is required.
+func Compatible(previous *Schema, next *Schema) error {
+	return compatible("$", previous, next)
+}
+
+func compatible(path string, previous *Schema, next *Schema) error {
+	if previous.Type != next.Type || previous.Format != next.Format {
+		return fmt.Errorf("%s changed from %s to %s", path, describe(previous), describe(next))
+	}
+
+	if previous.Items != nil && next.Items != nil {
+		if err := compatible(path+"[]", previous.Items, next.Items); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(previous.Properties))
+	for name := range previous.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nextProperty, ok := next.Properties[name]
+		if !ok {
+			return fmt.Errorf("%s.%s was removed", path, name)
+		}
+		if err := compatible(path+"."+name, previous.Properties[name], nextProperty); err != nil {
+			return err
+		}
+	}
+
+	previousRequired := make(map[string]bool, len(previous.Required))
+	for _, name := range previous.Required {
+		previousRequired[name] = true
+	}
+	nextRequired := make(map[string]bool, len(next.Required))
+	for _, name := range next.Required {
+		nextRequired[name] = true
+		if !previousRequired[name] {
+			return fmt.Errorf("%s.%s became required", path, name)
+		}
+	}
+	for _, name := range previous.Required {
+		if !nextRequired[name] {
+			return fmt.Errorf("%s.%s is no longer required", path, name)
+		}
+	}
+	return nil
+}
+
+func describe(s *Schema) string {
+	if s.Format != "" {
+		return s.Type + " " + s.Format
+	}
+	return s.Type
+}
@@ -0,0 +1,170 @@
+package eventschema_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"order-service/internal/pkg/constants"
+	"order-service/internal/pkg/eventschema"
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// checkedIn is the schema directory seen from this package
+var checkedIn = filepath.Join("..", "..", "..", eventschema.Dir)
+
+type SchemaTestSuite struct {
+	suite.Suite
+}
+
+func TestSchemaTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaTestSuite))
+}
+
+// TestCheckedInSchemas fails the build when a payload type changed in a way its consumers cannot read, or when
+// the checked-in documents are behind the types.
+func (suite *SchemaTestSuite) TestCheckedInSchemas() {
+	for _, definition := range eventschema.Definitions {
+		generated := eventschema.SchemaOf(definition)
+
+		previous, err := eventschema.Load(checkedIn, definition)
+		if !assert.NoError(suite.T(), err, "%s is not checked in, run make event-schema", eventschema.FileName(definition)) {
+			continue
+		}
+		assert.NoError(suite.T(), eventschema.Compatible(previous, generated),
+			"%s changed in a breaking way, raise the version of %s", eventschema.FileName(definition), definition.Type)
+
+		document, _ := eventschema.Marshal(generated)
+		current, _ := os.ReadFile(filepath.Join(checkedIn, eventschema.FileName(definition)))
+		assert.Equal(suite.T(), string(document), string(current), "%s is outdated, run make event-schema", eventschema.FileName(definition))
+	}
+}
+
+func (suite *SchemaTestSuite) TestSchemaOf() {
+	definition, ok := eventschema.Lookup(constants.EventOrderHeld)
+	assert.True(suite.T(), ok)
+
+	schema := eventschema.SchemaOf(definition)
+
+	assert.Equal(suite.T(), "order-held.v1.json", schema.Id)
+	assert.Equal(suite.T(), "object", schema.Type)
+	assert.Equal(suite.T(), &eventschema.Schema{Type: "string", Format: "date-time"}, schema.Properties["expiredAt"])
+	assert.Equal(suite.T(), &eventschema.Schema{Type: "integer"}, schema.Properties["seatNumber"])
+	assert.Contains(suite.T(), schema.Required, "ticketNumber")
+	assert.NotContains(suite.T(), schema.Required, "seatNumber")
+	assert.NotContains(suite.T(), schema.Required, "promoCode")
+}
+
+func (suite *SchemaTestSuite) TestValidate() {
+	payload, _ := json.Marshal(eventschema.OrderHeld{TicketNumber: "111", Price: 500, OrderTime: time.Now(), ExpiredAt: time.Now()})
+	assert.NoError(suite.T(), eventschema.Validate(constants.EventOrderHeld, payload))
+
+	cases := map[string]string{
+		"invalid json":     `{"ticketNumber":`,
+		"not an object":    `[]`,
+		"missing required": `{"ticketNumber":"111"}`,
+		"wrong type":       `{"ticketNumber":111,"ticketId":"","eventId":"","userId":"","queueId":"","ticketType":"","price":500,"orderTime":"2024-03-01T03:00:00Z","expiredAt":"2024-03-01T03:00:00Z"}`,
+		"not an integer":   `{"ticketNumber":"111","ticketId":"","eventId":"","userId":"","queueId":"","ticketType":"","price":5.5,"orderTime":"2024-03-01T03:00:00Z","expiredAt":"2024-03-01T03:00:00Z"}`,
+		"not a date-time":  `{"ticketNumber":"111","ticketId":"","eventId":"","userId":"","queueId":"","ticketType":"","price":500,"orderTime":"yesterday","expiredAt":"2024-03-01T03:00:00Z"}`,
+	}
+	for name, payload := range cases {
+		assert.Error(suite.T(), eventschema.Validate(constants.EventOrderHeld, []byte(payload)), name)
+	}
+	assert.Error(suite.T(), eventschema.Validate("OrderShipped", payload))
+}
+
+func (suite *SchemaTestSuite) TestCompatible() {
+	previous := &eventschema.Schema{
+		Type: "object",
+		Properties: map[string]*eventschema.Schema{
+			"ticketNumber": {Type: "string"},
+			"promoCode":    {Type: "string"},
+		},
+		Required: []string{"ticketNumber"},
+	}
+	next := func(change func(s *eventschema.Schema)) *eventschema.Schema {
+		s := &eventschema.Schema{
+			Type: "object",
+			Properties: map[string]*eventschema.Schema{
+				"ticketNumber": {Type: "string"},
+				"promoCode":    {Type: "string"},
+			},
+			Required: []string{"ticketNumber"},
+		}
+		change(s)
+		return s
+	}
+
+	assert.NoError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {})))
+	assert.NoError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {
+		s.Properties["seatNumber"] = &eventschema.Schema{Type: "integer"}
+	})), "new optional field")
+
+	assert.EqualError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {
+		s.Properties["seatNumber"] = &eventschema.Schema{Type: "integer"}
+		s.Required = append(s.Required, "seatNumber")
+	})), "$.seatNumber became required")
+	assert.EqualError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {
+		delete(s.Properties, "promoCode")
+	})), "$.promoCode was removed")
+	assert.EqualError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {
+		s.Properties["ticketNumber"] = &eventschema.Schema{Type: "integer"}
+	})), "$.ticketNumber changed from string to integer")
+	assert.EqualError(suite.T(), eventschema.Compatible(previous, next(func(s *eventschema.Schema) {
+		s.Required = nil
+	})), "$.ticketNumber is no longer required")
+}
+
+func (suite *SchemaTestSuite) TestGenerate() {
+	dir := suite.T().TempDir()
+
+	assert.NoError(suite.T(), eventschema.Generate(dir))
+	for _, definition := range eventschema.Definitions {
+		assert.FileExists(suite.T(), filepath.Join(dir, eventschema.FileName(definition)))
+	}
+	assert.NoError(suite.T(), eventschema.Generate(dir))
+}
+
+func (suite *SchemaTestSuite) TestGenerateErrBreakingChange() {
+	dir := suite.T().TempDir()
+	definition, _ := eventschema.Lookup(constants.EventOrderHeld)
+	previous := eventschema.SchemaOf(definition)
+	previous.Properties["venue"] = &eventschema.Schema{Type: "string"}
+	document, _ := eventschema.Marshal(previous)
+	assert.NoError(suite.T(), os.WriteFile(filepath.Join(dir, eventschema.FileName(definition)), document, 0o644))
+
+	err := eventschema.Generate(dir)
+
+	assert.ErrorContains(suite.T(), err, "venue was removed")
+	current, _ := os.ReadFile(filepath.Join(dir, eventschema.FileName(definition)))
+	assert.Equal(suite.T(), document, current)
+}
+
+func (suite *SchemaTestSuite) TestChecked() {
+	calls := 0
+	handler := eventschema.Checked(func(ctx context.Context, message kafkaConfluent.Message) error {
+		calls++
+		return nil
+	})
+	payload, _ := json.Marshal(eventschema.PaymentResult{PaymentId: "payment", TicketNumber: "111", Amount: 500, Status: "paid"})
+	message := func(value []byte, headers map[string]string) kafkaConfluent.Message {
+		return kafkaConfluent.Message{Topic: constants.TopicPaymentResult, Value: value, Headers: headers}
+	}
+
+	assert.NoError(suite.T(), handler(context.Background(), message(payload, nil)))
+	assert.NoError(suite.T(), handler(context.Background(), message(payload, map[string]string{kafkaConfluent.HeaderSchemaVersion: "1"})))
+	assert.NoError(suite.T(), handler(context.Background(), kafkaConfluent.Message{Topic: "unknown", Value: []byte("tes")}))
+	assert.Equal(suite.T(), 3, calls)
+
+	err := handler(context.Background(), message(payload, map[string]string{kafkaConfluent.HeaderSchemaVersion: "2"}))
+	assert.True(suite.T(), kafkaConfluent.IsPermanent(err))
+	err = handler(context.Background(), message([]byte(`{"paymentId":"payment"}`), nil))
+	assert.True(suite.T(), kafkaConfluent.IsPermanent(err))
+	assert.Equal(suite.T(), 3, calls)
+}
@@ -74,8 +74,9 @@ func (gs *GracefulShutdown) Cleanup() {
 	ctx, cancel := context.WithTimeout(context.Background(), gs.Timeout)
 	defer cancel()
 
-	for _, c := range gs.closers {
-		gs.triggerClose(ctx, c)
+	// close in reverse order of registration, so workers stop before the connections they use
+	for i := len(gs.closers) - 1; i >= 0; i-- {
+		gs.triggerClose(ctx, gs.closers[i])
 	}
 }
 
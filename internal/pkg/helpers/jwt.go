@@ -215,9 +215,76 @@ func (j *JwtImpl) GenerateTokenRefresh(ttl time.Duration, payload map[string]int
 	return token, nil
 }
 
+// admissionTokenType marks admission tokens so they cannot be replayed as access tokens and the other way around
+const admissionTokenType = "admission"
+
+type PayloadAdmission struct {
+	EventId     string `json:"eventId"`
+	UserId      string `json:"sub"`
+	QueueId     string `json:"queueId"`
+	QueueNumber int    `json:"queueNumber"`
+	Type        string `json:"typ"`
+}
+
+type AdmissionClaims struct {
+	PayloadAdmission
+	ExpiresAt int64 `json:"exp"`
+	IssuedAt  int64 `json:"iat"`
+}
+
+func (c *AdmissionClaims) Valid() error {
+	return (&jwt.StandardClaims{ExpiresAt: c.ExpiresAt, IssuedAt: c.IssuedAt}).Valid()
+}
+
+// GenerateAdmissionToken signs a short-lived token proving the user was admitted from the waiting room.
+// The user is carried in "sub" instead of "userId" so the token is rejected by JWTAuthorization.
+func (j *JwtImpl) GenerateAdmissionToken(ttl time.Duration, payload PayloadAdmission) (string, string, error) {
+	now := time.Now().UTC()
+	expiredAt := now.Add(ttl).Unix()
+	payload.Type = admissionTokenType
+	claims := &AdmissionClaims{
+		PayloadAdmission: payload,
+		ExpiresAt:        expiredAt,
+		IssuedAt:         now.Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(signKey)
+	if err != nil {
+		return "", "", errors.InternalServerError(err.Error())
+	}
+
+	return token, time.Unix(expiredAt, 0).Format(time.RFC3339), nil
+}
+
+func (j *JwtImpl) AdmissionAuthorization(authToken string) (*PayloadAdmission, error) {
+	if len(authToken) == 0 {
+		return nil, errors.ForbiddenError("Admission token required")
+	}
+
+	var parsedTokenClaims = new(AdmissionClaims)
+	parsedToken, err := jwt.ParseWithClaims(authToken, parsedTokenClaims, func(authToken *jwt.Token) (interface{}, error) {
+		if _, ok := authToken.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.UnauthorizedError("Invalid admission token")
+		}
+		return verifyKey, nil
+	})
+	if err != nil || !parsedToken.Valid {
+		return nil, errors.UnauthorizedError("Invalid admission token")
+	}
+
+	claim := parsedTokenClaims.PayloadAdmission
+	if claim.Type != admissionTokenType || claim.UserId == "" || claim.EventId == "" {
+		return nil, errors.ForbiddenError("Invalid admission token")
+	}
+
+	return &claim, nil
+}
+
 type TokenGenerator interface {
 	GenerateToken(ttl time.Duration, payload map[string]interface{}) (string, string, error)
 	GenerateTokenRefresh(ttl time.Duration, payload map[string]interface{}) (string, error)
+	GenerateAdmissionToken(ttl time.Duration, payload PayloadAdmission) (string, string, error)
 	JWTAuthorization(request *fasthttp.Request) (*PayloadJWT, error)
 	JWTRefreshAuthorization(authToken string) (*PayloadJWT, error)
+	AdmissionAuthorization(authToken string) (*PayloadAdmission, error)
 }
@@ -3,6 +3,7 @@ package helpers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"order-service/internal/pkg/constants"
@@ -180,6 +181,9 @@ func RespCustomError(c *fiber.Ctx, log log.Logger, err error) error {
 	errString, ok := err.(*errors.ErrorString)
 	metaErrorCode := 500
 	if ok {
+		if errString.RetryAfter() > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(errString.RetryAfter()))
+		}
 		if errString.HttpCode() != 0 {
 			metaErrorCode = errString.HttpCode()
 		} else {
@@ -3,63 +3,285 @@ package kafka
 import (
 	"context"
 	"fmt"
-	"strings"
+	"strconv"
+	"sync"
+	"time"
 
 	"order-service/internal/pkg/log"
 
 	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
 )
 
+// headers added to a message sent to the dead-letter topic
+const (
+	HeaderError             = "x-error"
+	HeaderAttempts          = "x-attempts"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+const pollTimeout = 500 * time.Millisecond
+
+// MessageReader is the part of the confluent consumer the router uses.
+type MessageReader interface {
+	SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+	CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error)
+	Close() error
+}
+
+// DeadLetterWriter is the part of the confluent producer used to park messages on the dead-letter topics.
+type DeadLetterWriter interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Close()
+}
+
 type consumer struct {
-	handler  ConsumerHandler
-	consumer *kafka.Consumer
-	logger   log.Logger
+	reader     MessageReader
+	deadLetter DeadLetterWriter
+	retry      RetryPolicy
+	logger     log.Logger
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	started  bool
+	stop     chan struct{}
+	done     chan struct{}
 }
 
-// NewConsumer is a constructor of kafka consumer
-func NewConsumer(cfg *kafka.ConfigMap, log log.Logger) (Consumer, error) {
+// NewConsumer is a constructor of kafka consumer. Messages are committed once their handler succeeded or
+// they were written to the dead-letter topic through a producer built from deadLetterCfg.
+func NewConsumer(cfg *kafka.ConfigMap, deadLetterCfg *kafka.ConfigMap, retry RetryPolicy, log log.Logger) (Consumer, error) {
 	c, err := kafka.NewConsumer(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	p, err := kafka.NewProducer(deadLetterCfg)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return newConsumer(c, p, retry, log), nil
+}
+
+// NewConsumerFrom routes the messages of any reader, the in-memory broker uses it to share the retries and
+// dead-letter handling of the real consumer.
+func NewConsumerFrom(reader MessageReader, deadLetter DeadLetterWriter, retry RetryPolicy, log log.Logger) Consumer {
+	return newConsumer(reader, deadLetter, retry, log)
+}
+
+func newConsumer(reader MessageReader, deadLetter DeadLetterWriter, retry RetryPolicy, log log.Logger) *consumer {
 	return &consumer{
-		logger:   log,
-		consumer: c,
-	}, nil
+		reader:     reader,
+		deadLetter: deadLetter,
+		retry:      retry,
+		logger:     log,
+		handlers:   make(map[string]HandlerFunc),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
 }
 
-func (c *consumer) SetHandler(handler ConsumerHandler) {
-	c.handler = handler
+func (c *consumer) Handle(topic string, handler HandlerFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[topic] = handler
 }
 
-func (c *consumer) Subscribe(topics ...string) {
-	if c.handler == nil {
-		joinTopic := strings.Join(topics, ", ")
-		msg := fmt.Sprintf("Kafka Consumer Error: Topics: [%s] There is no consumer handlers to handle message from incoming event", joinTopic)
-		c.logger.Error(context.Background(), msg, fmt.Sprintf("%+v", topics))
-		return
+func (c *consumer) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return fmt.Errorf("kafka consumer already started")
 	}
 
-	c.consumer.SubscribeTopics(topics, nil)
-	go func() {
-		for {
-			msg, err := c.consumer.ReadMessage(-1)
-			if err != nil {
-				msg := fmt.Sprintf("Kafka Consumer Error: %v (%v)\n", err, msg)
-				c.logger.Error(context.Background(), msg, fmt.Sprintf("%+v", topics))
+	topics := make([]string, 0, len(c.handlers))
+	for topic := range c.handlers {
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("kafka consumer has no topic handler")
+	}
+
+	if err := c.reader.SubscribeTopics(topics, nil); err != nil {
+		return err
+	}
+	c.started = true
+	go c.run()
+
+	return nil
+}
+
+func (c *consumer) run() {
+	defer close(c.done)
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		msg, err := c.reader.ReadMessage(pollTimeout)
+		if err != nil {
+			if kafkaErr, ok := err.(kafka.Error); ok && kafkaErr.Code() == kafka.ErrTimedOut {
 				continue
 			}
-			switch topics[0] {
-			default:
-				c.consumer.CommitMessage(msg)
+			c.logger.Error(context.Background(), fmt.Sprintf("Kafka Consumer Error: %v", err), "")
+			continue
+		}
+
+		c.process(msg)
+	}
+}
+
+// process handles one message and commits it, a message interrupted by Close is left uncommitted
+// so it is delivered again after the restart.
+func (c *consumer) process(msg *kafka.Message) {
+	ctx := context.Background()
+	message := MessageOf(msg)
+
+	c.mu.Lock()
+	handler, ok := c.handlers[message.Topic]
+	c.mu.Unlock()
+
+	if ok {
+		attempts, err := c.handle(ctx, handler, message)
+		if err != nil {
+			if c.stopped() {
+				return
+			}
+
+			c.logger.Error(ctx, fmt.Sprintf("Kafka Consumer Error: cannot handle message of topic %s after %d attempts", message.Topic, attempts),
+				fmt.Sprintf("%+v", err))
+			if !c.sendToDeadLetter(ctx, msg, message, err, attempts) {
+				return
 			}
 		}
+	}
+
+	if _, err := c.reader.CommitMessage(msg); err != nil {
+		c.logger.Error(ctx, fmt.Sprintf("Kafka Consumer Error: cannot commit message of topic %s", message.Topic), fmt.Sprintf("%+v", err))
+	}
+}
+
+// handle calls the handler until it succeeds, fails permanently or runs out of retries.
+func (c *consumer) handle(ctx context.Context, handler HandlerFunc, message Message) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := invoke(ctx, handler, message)
+		if err == nil || IsPermanent(err) || attempt > c.retry.MaxRetries {
+			return attempt, err
+		}
+
+		c.logger.Info(ctx, fmt.Sprintf("Kafka Consumer: retrying message of topic %s", message.Topic), fmt.Sprintf("attempt %d: %v", attempt, err))
+		if !c.wait(c.retry.backoffOf(attempt)) {
+			return attempt, err
+		}
+	}
+}
+
+// invoke turns a panicking handler into a permanent error instead of stopping the consumer.
+func invoke(ctx context.Context, handler HandlerFunc, message Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("handler panic: %v", r))
+		}
 	}()
+	return handler(ctx, message)
+}
 
-	return
+// sendToDeadLetter writes the message to the dead-letter topic of its topic and waits for the delivery report.
+// It keeps trying until the write succeeds, the partition must not move past a message that was neither
+// handled nor parked. It returns false when Close interrupted it.
+func (c *consumer) sendToDeadLetter(ctx context.Context, msg *kafka.Message, message Message, cause error, attempts int) bool {
+	topic := DeadLetterTopic(message.Topic)
+	headers := append(append([]kafka.Header{}, msg.Headers...),
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(message.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(int(message.Partition)))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	for attempt := 1; ; attempt++ {
+		err := produceSync(ctx, c.deadLetter, &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Key:            msg.Key,
+			Value:          msg.Value,
+			Headers:        headers,
+		})
+		if err == nil {
+			return true
+		}
+
+		c.logger.Error(ctx, fmt.Sprintf("Kafka Consumer Error: cannot write message to %s", topic), fmt.Sprintf("%+v", err))
+		if !c.wait(c.retry.backoffOf(attempt)) {
+			return false
+		}
+	}
+}
+
+// wait sleeps for d and returns false when Close was called in the meantime.
+func (c *consumer) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-c.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
+func (c *consumer) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// MessageOf converts a confluent message to the message handed to the handlers.
+func MessageOf(msg *kafka.Message) Message {
+	message := Message{
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   make(map[string]string, len(msg.Headers)),
+	}
+	if msg.TopicPartition.Topic != nil {
+		message.Topic = *msg.TopicPartition.Topic
+	}
+	for _, header := range msg.Headers {
+		message.Headers[header.Key] = string(header.Value)
+	}
+	return message
+}
+
+// Close stops reading, waits for the message in flight to be handled and committed, then closes the
+// connections. It is registered on GracefulShutdown.
 func (c *consumer) Close(ctx context.Context) error {
-	return c.consumer.Close()
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+
+	close(c.stop)
+	if started {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.deadLetter.Close()
+	return c.reader.Close()
 }
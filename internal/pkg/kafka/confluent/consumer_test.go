@@ -0,0 +1,285 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	mocklog "order-service/mocks/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
+)
+
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  chan *kafka.Message
+	topics    []string
+	committed []*kafka.Message
+	closed    bool
+}
+
+func (r *fakeReader) SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error {
+	r.topics = topics
+	return nil
+}
+
+func (r *fakeReader) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, kafka.NewError(kafka.ErrTimedOut, "timed out", false)
+	}
+}
+
+func (r *fakeReader) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, m)
+	return nil, nil
+}
+
+func (r *fakeReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeReader) commits() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.committed)
+}
+
+type fakeDeadLetter struct {
+	failures int
+	produced []*kafka.Message
+	closed   bool
+}
+
+func (d *fakeDeadLetter) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	report := *msg
+	if d.failures > 0 {
+		d.failures--
+		report.TopicPartition.Error = errors.New("broker down")
+	} else {
+		d.produced = append(d.produced, msg)
+	}
+	deliveryChan <- &report
+	return nil
+}
+
+func (d *fakeDeadLetter) Close() {
+	d.closed = true
+}
+
+type ConsumerTestSuite struct {
+	suite.Suite
+	reader     *fakeReader
+	deadLetter *fakeDeadLetter
+	logger     *mocklog.Logger
+	consumer   *consumer
+}
+
+func (suite *ConsumerTestSuite) SetupTest() {
+	suite.reader = &fakeReader{messages: make(chan *kafka.Message, 1)}
+	suite.deadLetter = &fakeDeadLetter{}
+	suite.logger = &mocklog.Logger{}
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything)
+	suite.consumer = newConsumer(suite.reader, suite.deadLetter, RetryPolicy{
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 2 * time.Millisecond,
+	}, suite.logger)
+}
+
+func TestConsumerTestSuite(t *testing.T) {
+	suite.Run(t, new(ConsumerTestSuite))
+}
+
+func testMessage(topic string, value string) *kafka.Message {
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 1, Offset: 42},
+		Key:            []byte("key"),
+		Value:          []byte(value),
+		Headers:        []kafka.Header{{Key: "trace", Value: []byte("abc")}},
+	}
+}
+
+func headerOf(msg *kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (suite *ConsumerTestSuite) TestProcess() {
+	var received Message
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		received = message
+		return nil
+	})
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Equal(suite.T(), "payment-result", received.Topic)
+	assert.Equal(suite.T(), int64(42), received.Offset)
+	assert.Equal(suite.T(), "abc", received.Headers["trace"])
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+	assert.Empty(suite.T(), suite.deadLetter.produced)
+}
+
+func (suite *ConsumerTestSuite) TestProcessRetry() {
+	calls := 0
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		calls++
+		if calls < 3 {
+			return errors.New("database down")
+		}
+		return nil
+	})
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Equal(suite.T(), 3, calls)
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+	assert.Empty(suite.T(), suite.deadLetter.produced)
+}
+
+func (suite *ConsumerTestSuite) TestProcessDeadLetter() {
+	calls := 0
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		calls++
+		return errors.New("database down")
+	})
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Equal(suite.T(), 3, calls)
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+	assert.Len(suite.T(), suite.deadLetter.produced, 1)
+	parked := suite.deadLetter.produced[0]
+	assert.Equal(suite.T(), "payment-result.dlq", *parked.TopicPartition.Topic)
+	assert.Equal(suite.T(), []byte("key"), parked.Key)
+	assert.Equal(suite.T(), "database down", headerOf(parked, HeaderError))
+	assert.Equal(suite.T(), "3", headerOf(parked, HeaderAttempts))
+	assert.Equal(suite.T(), "payment-result", headerOf(parked, HeaderOriginalTopic))
+	assert.Equal(suite.T(), "1", headerOf(parked, HeaderOriginalPartition))
+	assert.Equal(suite.T(), "42", headerOf(parked, HeaderOriginalOffset))
+	assert.Equal(suite.T(), "abc", headerOf(parked, "trace"))
+}
+
+func (suite *ConsumerTestSuite) TestProcessPermanent() {
+	type payload struct {
+		Amount int `json:"amount"`
+	}
+	calls := 0
+	suite.consumer.Handle("payment-result", JSONHandler(func(ctx context.Context, p payload, message Message) error {
+		calls++
+		return nil
+	}))
+
+	suite.consumer.process(testMessage("payment-result", "not json"))
+
+	assert.Equal(suite.T(), 0, calls)
+	assert.Len(suite.T(), suite.deadLetter.produced, 1)
+	assert.Equal(suite.T(), "1", headerOf(suite.deadLetter.produced[0], HeaderAttempts))
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+}
+
+func (suite *ConsumerTestSuite) TestProcessPanic() {
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		panic("nil map")
+	})
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Len(suite.T(), suite.deadLetter.produced, 1)
+	assert.Equal(suite.T(), "handler panic: nil map", headerOf(suite.deadLetter.produced[0], HeaderError))
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+}
+
+func (suite *ConsumerTestSuite) TestProcessDeadLetterRetry() {
+	suite.deadLetter.failures = 2
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		return Permanent(errors.New("unknown ticket"))
+	})
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Len(suite.T(), suite.deadLetter.produced, 1)
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+}
+
+func (suite *ConsumerTestSuite) TestProcessStopped() {
+	suite.consumer.retry.Backoff = time.Hour
+	suite.consumer.retry.MaxBackoff = time.Hour
+	calls := 0
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		calls++
+		return errors.New("database down")
+	})
+	close(suite.consumer.stop)
+
+	suite.consumer.process(testMessage("payment-result", "{}"))
+
+	assert.Equal(suite.T(), 1, calls)
+	assert.Equal(suite.T(), 0, suite.reader.commits())
+	assert.Empty(suite.T(), suite.deadLetter.produced)
+}
+
+func (suite *ConsumerTestSuite) TestStartErrNoHandler() {
+	assert.Error(suite.T(), suite.consumer.Start())
+}
+
+func (suite *ConsumerTestSuite) TestStartAndClose() {
+	handled := make(chan struct{}, 1)
+	suite.consumer.Handle("payment-result", func(ctx context.Context, message Message) error {
+		handled <- struct{}{}
+		return nil
+	})
+
+	assert.NoError(suite.T(), suite.consumer.Start())
+	assert.Equal(suite.T(), []string{"payment-result"}, suite.reader.topics)
+	assert.Error(suite.T(), suite.consumer.Start())
+
+	suite.reader.messages <- testMessage("payment-result", "{}")
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		suite.T().Fatal("message was not handled")
+	}
+
+	assert.NoError(suite.T(), suite.consumer.Close(context.Background()))
+	assert.Equal(suite.T(), 1, suite.reader.commits())
+	assert.True(suite.T(), suite.reader.closed)
+	assert.True(suite.T(), suite.deadLetter.closed)
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy := NewRetryPolicy("", "abc", "-1")
+	assert.Equal(t, RetryPolicy{MaxRetries: defaultMaxRetries, Backoff: defaultRetryBackoff, MaxBackoff: defaultRetryMaxBackoff}, policy)
+
+	policy = NewRetryPolicy("5", "100", "300")
+	assert.Equal(t, 5, policy.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, policy.backoffOf(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoffOf(2))
+	assert.Equal(t, 300*time.Millisecond, policy.backoffOf(3))
+	assert.Equal(t, 300*time.Millisecond, policy.backoffOf(10))
+}
+
+func TestPermanent(t *testing.T) {
+	err := errors.New("bad payload")
+
+	assert.Nil(t, Permanent(nil))
+	assert.True(t, IsPermanent(Permanent(err)))
+	assert.ErrorIs(t, Permanent(err), err)
+	assert.False(t, IsPermanent(err))
+}
@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries      = 3
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+// Message is a consumed kafka message as handed to a topic handler.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// HandlerFunc handles the messages of a topic. A returned error is retried, once the retries are used up
+// or the error is Permanent the message is sent to the dead-letter topic.
+type HandlerFunc func(ctx context.Context, message Message) error
+
+// JSONHandler decodes the message value into T before calling fn. A value that cannot be decoded is
+// permanent, retrying it would fail the same way.
+func JSONHandler[T any](fn func(ctx context.Context, payload T, message Message) error) HandlerFunc {
+	return func(ctx context.Context, message Message) error {
+		var payload T
+		if err := json.Unmarshal(message.Value, &payload); err != nil {
+			return Permanent(fmt.Errorf("cannot decode message of topic %s: %w", message.Topic, err))
+		}
+		return fn(ctx, payload, message)
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error that retrying cannot fix, the message goes to the dead-letter topic right away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// DeadLetterTopic is where the messages of topic go once they cannot be handled.
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// RetryPolicy bounds how often a failed message is handled again, the backoff doubles after every attempt.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewRetryPolicy reads the policy from config values, the backoffs are in milliseconds and unset or
+// invalid values fall back to the defaults.
+func NewRetryPolicy(maxRetries string, backoff string, maxBackoff string) RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries: defaultMaxRetries,
+		Backoff:    defaultRetryBackoff,
+		MaxBackoff: defaultRetryMaxBackoff,
+	}
+	if retries, err := strconv.Atoi(maxRetries); err == nil && retries >= 0 {
+		policy.MaxRetries = retries
+	}
+	if ms, err := strconv.Atoi(backoff); err == nil && ms > 0 {
+		policy.Backoff = time.Duration(ms) * time.Millisecond
+	}
+	if ms, err := strconv.Atoi(maxBackoff); err == nil && ms > 0 {
+		policy.MaxBackoff = time.Duration(ms) * time.Millisecond
+	}
+	return policy
+}
+
+// backoffOf is the wait after the given failed attempt.
+func (p RetryPolicy) backoffOf(attempt int) time.Duration {
+	backoff := p.Backoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
@@ -8,25 +8,24 @@ import (
 
 // Producer is collection of function of kafka producer
 type Producer interface {
-	Publish(topic string, message []byte, kafkaPartition *int32)
+	// Publish enqueues the message and returns without waiting for the broker
+	Publish(topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error
+	// PublishSync returns once the broker acknowledged the message or rejected it
+	PublishSync(ctx context.Context, topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error
 
 	Close(ctx context.Context) error
 }
 
 // Consumer is collection of function of kafka consumer
 type Consumer interface {
-	SetHandler(handler ConsumerHandler)
-	Subscribe(topics ...string)
+	// Handle registers the handler of a topic, handlers have to be registered before Start
+	Handle(topic string, handler HandlerFunc)
+	// Start subscribes to every topic with a handler and dispatches the messages until Close
+	Start() error
 
 	Close(ctx context.Context) error
 }
 
-// ConsumerHandler is a collection of function for handling kafka message
-type ConsumerHandler interface {
-	SendEmailOtpRegister(message *k.Message, topic string)
-	SendEmailOtpLogin(message *k.Message, topic string)
-}
-
 ///
 
 type KafkaConfig struct {
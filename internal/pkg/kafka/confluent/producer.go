@@ -3,15 +3,40 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"order-service/internal/pkg/log"
 
 	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
 )
 
+// headers of a published domain event
+const (
+	HeaderEventId       = "x-event-id"
+	HeaderEventType     = "x-event-type"
+	HeaderSchemaVersion = "x-schema-version"
+	HeaderTraceId       = "x-trace-id"
+)
+
+const defaultFlushTimeout = 5 * time.Second
+
+// messageProducer produces a message, its delivery report is sent to deliveryChan or to Events when nil.
+type messageProducer interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+}
+
+// MessageWriter is the part of the confluent producer the producer uses.
+type MessageWriter interface {
+	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
+	Events() chan kafka.Event
+	Flush(timeoutMs int) int
+	Close()
+}
+
 // Producer struct
 type producer struct {
-	producer *kafka.Producer
+	producer MessageWriter
 	logger   log.Logger
 }
 
@@ -22,14 +47,23 @@ func NewProducer(cfg *kafka.ConfigMap, log log.Logger) (Producer, error) {
 		return nil, err
 	}
 
+	return newProducer(p, log), nil
+}
+
+// NewProducerFrom publishes through any writer, the in-memory broker uses it.
+func NewProducerFrom(writer MessageWriter, log log.Logger) Producer {
+	return newProducer(writer, log)
+}
+
+func newProducer(writer MessageWriter, log log.Logger) *producer {
 	prod := &producer{
-		producer: p,
+		producer: writer,
 		logger:   log,
 	}
 
 	go prod.errReporter()
 
-	return prod, nil
+	return prod
 }
 
 func (p *producer) errReporter() {
@@ -44,24 +78,82 @@ func (p *producer) errReporter() {
 	}
 }
 
-func (p *producer) Publish(topic string, message []byte, kafkaPartition *int32) {
+// Publish enqueues the message on the producer, the key picks the partition when no partition is given.
+// Delivery failures are reported by errReporter.
+func (p *producer) Publish(topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error {
+	return p.producer.Produce(newMessage(topic, key, message, headers, kafkaPartition), nil)
+}
+
+// PublishSync sends the message and waits for its delivery report. An error returned after ctx expired
+// does not mean the message was lost, the broker may still acknowledge it.
+func (p *producer) PublishSync(ctx context.Context, topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error {
+	return produceSync(ctx, p.producer, newMessage(topic, key, message, headers, kafkaPartition))
+}
+
+func newMessage(topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) *kafka.Message {
 	partition := kafka.PartitionAny
 
 	if kafkaPartition != nil {
 		partition = *kafkaPartition
 	}
 
-	msgCh := p.producer.ProduceChannel()
-	msgCh <- &kafka.Message{
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{
 			Topic:     &topic,
 			Partition: partition,
 		},
-		Value: message,
+		Key:     key,
+		Value:   message,
+		Headers: headersOf(headers),
 	}
 }
 
+// headersOf sorts the headers by key so the same headers are always written in the same order.
+func headersOf(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kafkaHeaders := make([]kafka.Header, 0, len(keys))
+	for _, key := range keys {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: key, Value: []byte(headers[key])})
+	}
+	return kafkaHeaders
+}
+
+// produceSync produces the message with its own delivery channel and waits for the report or ctx.
+func produceSync(ctx context.Context, writer messageProducer, msg *kafka.Message) error {
+	// buffered so a report arriving after ctx expired does not block the producer
+	delivery := make(chan kafka.Event, 1)
+	if err := writer.Produce(msg, delivery); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case e := <-delivery:
+		report, ok := e.(*kafka.Message)
+		if !ok {
+			return fmt.Errorf("unexpected delivery report %v", e)
+		}
+		return report.TopicPartition.Error
+	}
+}
+
+// Close delivers the messages still queued until ctx expires, then closes the producer.
 func (p *producer) Close(ctx context.Context) error {
+	timeout := defaultFlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	p.producer.Flush(int(timeout.Milliseconds()))
 	p.producer.Close()
 	return nil
 }
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mocklog "order-service/mocks/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
+)
+
+type fakeWriter struct {
+	produceErr  error
+	deliveryErr error
+	silent      bool
+	produced    []*kafka.Message
+	events      chan kafka.Event
+	flushed     int
+	closed      bool
+}
+
+func (w *fakeWriter) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	if w.produceErr != nil {
+		return w.produceErr
+	}
+	w.produced = append(w.produced, msg)
+	if deliveryChan != nil && !w.silent {
+		report := *msg
+		report.TopicPartition.Error = w.deliveryErr
+		deliveryChan <- &report
+	}
+	return nil
+}
+
+func (w *fakeWriter) Events() chan kafka.Event {
+	return w.events
+}
+
+func (w *fakeWriter) Flush(timeoutMs int) int {
+	w.flushed = timeoutMs
+	return 0
+}
+
+func (w *fakeWriter) Close() {
+	w.closed = true
+	close(w.events)
+}
+
+type ProducerTestSuite struct {
+	suite.Suite
+	writer   *fakeWriter
+	logger   *mocklog.Logger
+	producer *producer
+}
+
+func (suite *ProducerTestSuite) SetupTest() {
+	suite.writer = &fakeWriter{events: make(chan kafka.Event)}
+	suite.logger = &mocklog.Logger{}
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.producer = newProducer(suite.writer, suite.logger)
+}
+
+func TestProducerTestSuite(t *testing.T) {
+	suite.Run(t, new(ProducerTestSuite))
+}
+
+func (suite *ProducerTestSuite) TestPublish() {
+	partition := int32(2)
+
+	err := suite.producer.Publish("order-held", []byte("111"), []byte("{}"), map[string]string{
+		HeaderTraceId:       "abc",
+		HeaderSchemaVersion: "1",
+	}, &partition)
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.writer.produced, 1)
+	msg := suite.writer.produced[0]
+	assert.Equal(suite.T(), "order-held", *msg.TopicPartition.Topic)
+	assert.Equal(suite.T(), int32(2), msg.TopicPartition.Partition)
+	assert.Equal(suite.T(), []byte("111"), msg.Key)
+	assert.Equal(suite.T(), []kafka.Header{
+		{Key: HeaderSchemaVersion, Value: []byte("1")},
+		{Key: HeaderTraceId, Value: []byte("abc")},
+	}, msg.Headers)
+}
+
+func (suite *ProducerTestSuite) TestPublishPartitionAny() {
+	err := suite.producer.Publish("order-held", nil, []byte("{}"), nil, nil)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), kafka.PartitionAny, suite.writer.produced[0].TopicPartition.Partition)
+	assert.Nil(suite.T(), suite.writer.produced[0].Headers)
+}
+
+func (suite *ProducerTestSuite) TestPublishSync() {
+	err := suite.producer.PublishSync(context.Background(), "order-held", []byte("111"), []byte("{}"), nil, nil)
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.writer.produced, 1)
+}
+
+func (suite *ProducerTestSuite) TestPublishSyncErrDelivery() {
+	suite.writer.deliveryErr = errors.New("message timed out")
+
+	err := suite.producer.PublishSync(context.Background(), "order-held", []byte("111"), []byte("{}"), nil, nil)
+
+	assert.EqualError(suite.T(), err, "message timed out")
+}
+
+func (suite *ProducerTestSuite) TestPublishSyncErrProduce() {
+	suite.writer.produceErr = errors.New("queue full")
+
+	err := suite.producer.PublishSync(context.Background(), "order-held", []byte("111"), []byte("{}"), nil, nil)
+
+	assert.EqualError(suite.T(), err, "queue full")
+}
+
+func (suite *ProducerTestSuite) TestPublishSyncErrTimeout() {
+	suite.writer.silent = true
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := suite.producer.PublishSync(ctx, "order-held", []byte("111"), []byte("{}"), nil, nil)
+
+	assert.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+}
+
+func (suite *ProducerTestSuite) TestClose() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(suite.T(), suite.producer.Close(ctx))
+	assert.Greater(suite.T(), suite.writer.flushed, 0)
+	assert.True(suite.T(), suite.writer.closed)
+}
@@ -0,0 +1,251 @@
+package memory
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"sync"
+	"time"
+
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	"order-service/internal/pkg/log"
+
+	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
+)
+
+// DefaultPartitions is the number of partitions of every topic of a broker created with no partitions.
+const DefaultPartitions = 3
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type group struct {
+	generation int
+	members    []*reader
+	committed  map[topicPartition]int64
+}
+
+// Broker is an in-process kafka broker for tests and local runs. Topics are created on first use, a keyed
+// message always goes to the same partition, and the consumers of a group share the partitions of their
+// topics and resume from the offsets the group committed. A new group reads every topic from the start.
+// Nothing is persisted, the messages are gone with the process.
+type Broker struct {
+	mu            sync.Mutex
+	partitions    int
+	topics        map[string][][]*kafka.Message
+	groups        map[string]*group
+	nextPartition int32
+	// changed is closed and replaced whenever a message is appended or a group rebalances
+	changed chan struct{}
+}
+
+// NewBroker is a constructor of the in-memory broker.
+func NewBroker(partitions int) *Broker {
+	if partitions <= 0 {
+		partitions = DefaultPartitions
+	}
+	return &Broker{
+		partitions: partitions,
+		topics:     make(map[string][][]*kafka.Message),
+		groups:     make(map[string]*group),
+		changed:    make(chan struct{}),
+	}
+}
+
+// NewProducer returns a producer publishing to the broker.
+func (b *Broker) NewProducer(log log.Logger) kafkaConfluent.Producer {
+	return kafkaConfluent.NewProducerFrom(newWriter(b), log)
+}
+
+// NewConsumer returns a consumer of the group, it handles messages exactly like the confluent consumer.
+func (b *Broker) NewConsumer(groupId string, retry kafkaConfluent.RetryPolicy, log log.Logger) kafkaConfluent.Consumer {
+	return kafkaConfluent.NewConsumerFrom(newReader(b, groupId), newWriter(b), retry, log)
+}
+
+// Messages returns the messages of a topic ordered by partition and offset.
+func (b *Broker) Messages(topic string) []kafkaConfluent.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	messages := make([]kafkaConfluent.Message, 0)
+	for _, partition := range b.topics[topic] {
+		for _, msg := range partition {
+			messages = append(messages, kafkaConfluent.MessageOf(msg))
+		}
+	}
+	return messages
+}
+
+// Committed returns the next offset the group reads from a partition.
+func (b *Broker) Committed(groupId string, topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[groupId]
+	if !ok {
+		return 0
+	}
+	return g.committed[topicPartition{topic: topic, partition: partition}]
+}
+
+func (b *Broker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *Broker) topic(name string) [][]*kafka.Message {
+	partitions, ok := b.topics[name]
+	if !ok {
+		partitions = make([][]*kafka.Message, b.partitions)
+		b.topics[name] = partitions
+	}
+	return partitions
+}
+
+// partitionOf spreads messages without a key round robin, a key is hashed so its messages stay in order.
+func (b *Broker) partitionOf(key []byte) int32 {
+	if len(key) == 0 {
+		partition := b.nextPartition
+		b.nextPartition = (b.nextPartition + 1) % int32(b.partitions)
+		return partition
+	}
+	return int32(crc32.ChecksumIEEE(key) % uint32(b.partitions))
+}
+
+func (b *Broker) append(msg *kafka.Message) (*kafka.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if msg.TopicPartition.Topic == nil || *msg.TopicPartition.Topic == "" {
+		return nil, kafka.NewError(kafka.ErrUnknownTopic, "message without topic", false)
+	}
+	topic := *msg.TopicPartition.Topic
+	partitions := b.topic(topic)
+
+	partition := msg.TopicPartition.Partition
+	if partition == kafka.PartitionAny {
+		partition = b.partitionOf(msg.Key)
+	}
+	if partition < 0 || int(partition) >= len(partitions) {
+		return nil, kafka.NewError(kafka.ErrUnknownPartition, fmt.Sprintf("topic %s has no partition %d", topic, partition), false)
+	}
+
+	stored := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: partition,
+			Offset:    kafka.Offset(len(partitions[partition])),
+		},
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   append([]kafka.Header{}, msg.Headers...),
+		Timestamp: time.Now(),
+	}
+	partitions[partition] = append(partitions[partition], stored)
+	b.notify()
+
+	return stored, nil
+}
+
+func (b *Broker) join(r *reader, topics []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, topic := range topics {
+		b.topic(topic)
+	}
+
+	g, ok := b.groups[r.groupId]
+	if !ok {
+		g = &group{committed: make(map[topicPartition]int64)}
+		b.groups[r.groupId] = g
+	}
+	r.topics = topics
+	g.members = append(g.members, r)
+	g.generation++
+	b.notify()
+}
+
+func (b *Broker) leave(r *reader) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[r.groupId]
+	if !ok {
+		return
+	}
+	for i, member := range g.members {
+		if member == r {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			g.generation++
+			b.notify()
+			return
+		}
+	}
+}
+
+// fetch returns the next message of the partitions assigned to the reader, nil when it is up to date.
+// After a rebalance the reader starts again from the offsets committed by its group.
+func (b *Broker) fetch(r *reader) *kafka.Message {
+	g := b.groups[r.groupId]
+	if r.generation != g.generation {
+		r.generation = g.generation
+		r.assigned = b.assignment(g, r)
+		r.positions = make(map[topicPartition]int64, len(r.assigned))
+		for _, tp := range r.assigned {
+			r.positions[tp] = g.committed[tp]
+		}
+	}
+
+	for i := 0; i < len(r.assigned); i++ {
+		tp := r.assigned[(r.next+i)%len(r.assigned)]
+		partition := b.topics[tp.topic][tp.partition]
+		position := r.positions[tp]
+		if position < int64(len(partition)) {
+			r.positions[tp] = position + 1
+			r.next = (r.next + i + 1) % len(r.assigned)
+			return partition[position]
+		}
+	}
+	return nil
+}
+
+// assignment hands the partitions of every topic round robin to the members subscribed to it.
+func (b *Broker) assignment(g *group, r *reader) []topicPartition {
+	topics := append([]string{}, r.topics...)
+	sort.Strings(topics)
+
+	assigned := make([]topicPartition, 0)
+	for _, topic := range topics {
+		members := make([]*reader, 0, len(g.members))
+		for _, member := range g.members {
+			for _, subscribed := range member.topics {
+				if subscribed == topic {
+					members = append(members, member)
+					break
+				}
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		for partition := range b.topics[topic] {
+			if members[partition%len(members)] == r {
+				assigned = append(assigned, topicPartition{topic: topic, partition: int32(partition)})
+			}
+		}
+	}
+	return assigned
+}
+
+func (b *Broker) commit(groupId string, tp topicPartition, offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if g, ok := b.groups[groupId]; ok {
+		g.committed[tp] = offset
+	}
+}
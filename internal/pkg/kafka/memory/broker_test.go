@@ -0,0 +1,196 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	kafkaConfluent "order-service/internal/pkg/kafka/confluent"
+	"order-service/internal/pkg/kafka/memory"
+	mocklog "order-service/mocks/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type BrokerTestSuite struct {
+	suite.Suite
+	broker   *memory.Broker
+	logger   *mocklog.Logger
+	producer kafkaConfluent.Producer
+	retry    kafkaConfluent.RetryPolicy
+	ctx      context.Context
+}
+
+func (suite *BrokerTestSuite) SetupTest() {
+	suite.broker = memory.NewBroker(2)
+	suite.logger = &mocklog.Logger{}
+	suite.logger.On("Error", mock.Anything, mock.Anything, mock.Anything)
+	suite.logger.On("Info", mock.Anything, mock.Anything, mock.Anything)
+	suite.producer = suite.broker.NewProducer(suite.logger)
+	suite.retry = kafkaConfluent.RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	suite.ctx = context.Background()
+}
+
+func (suite *BrokerTestSuite) TearDownTest() {
+	suite.producer.Close(suite.ctx)
+}
+
+func TestBrokerTestSuite(t *testing.T) {
+	suite.Run(t, new(BrokerTestSuite))
+}
+
+// recorder collects the values handled by the consumers of a test.
+type recorder struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (r *recorder) handle(ctx context.Context, message kafkaConfluent.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = append(r.values, string(message.Value))
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values)
+}
+
+// publish sends count messages of key, the values number the messages of the topic.
+func (suite *BrokerTestSuite) publish(topic string, key string, count int) {
+	for i := 0; i < count; i++ {
+		value := fmt.Sprintf("%s-%d", key, len(suite.broker.Messages(topic)))
+		err := suite.producer.PublishSync(suite.ctx, topic, []byte(key), []byte(value), nil, nil)
+		assert.NoError(suite.T(), err)
+	}
+}
+
+func (suite *BrokerTestSuite) consume(groupId string, topic string, handler kafkaConfluent.HandlerFunc) kafkaConfluent.Consumer {
+	consumer := suite.broker.NewConsumer(groupId, suite.retry, suite.logger)
+	consumer.Handle(topic, handler)
+	assert.NoError(suite.T(), consumer.Start())
+	return consumer
+}
+
+func (suite *BrokerTestSuite) TestPublish() {
+	err := suite.producer.PublishSync(suite.ctx, "order-held", []byte("111"), []byte("{}"), map[string]string{
+		kafkaConfluent.HeaderSchemaVersion: "1",
+	}, nil)
+	assert.NoError(suite.T(), err)
+	suite.publish("order-held", "111", 2)
+
+	messages := suite.broker.Messages("order-held")
+	assert.Len(suite.T(), messages, 3)
+	for i, message := range messages {
+		assert.Equal(suite.T(), messages[0].Partition, message.Partition)
+		assert.Equal(suite.T(), int64(i), message.Offset)
+		assert.Equal(suite.T(), []byte("111"), message.Key)
+	}
+	assert.Equal(suite.T(), "1", messages[0].Headers[kafkaConfluent.HeaderSchemaVersion])
+}
+
+func (suite *BrokerTestSuite) TestPublishWithoutKey() {
+	suite.publish("order-held", "", 4)
+
+	partitions := map[int32]int{}
+	for _, message := range suite.broker.Messages("order-held") {
+		partitions[message.Partition]++
+	}
+	assert.Equal(suite.T(), map[int32]int{0: 2, 1: 2}, partitions)
+}
+
+func (suite *BrokerTestSuite) TestPublishErrPartition() {
+	partition := int32(5)
+
+	err := suite.producer.PublishSync(suite.ctx, "order-held", []byte("111"), []byte("{}"), nil, &partition)
+
+	assert.Error(suite.T(), err)
+	assert.Empty(suite.T(), suite.broker.Messages("order-held"))
+}
+
+func (suite *BrokerTestSuite) TestConsume() {
+	suite.publish("payment-result", "111", 2)
+	suite.publish("payment-result", "222", 2)
+	first, second := &recorder{}, &recorder{}
+
+	firstConsumer := suite.consume("order-service", "payment-result", first.handle)
+	secondConsumer := suite.consume("audit-service", "payment-result", second.handle)
+
+	assert.Eventually(suite.T(), func() bool { return first.count() == 4 && second.count() == 4 }, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), firstConsumer.Close(suite.ctx))
+	assert.NoError(suite.T(), secondConsumer.Close(suite.ctx))
+	// the messages of a key are handled in the order they were published
+	assert.Less(suite.T(), indexOf(first.values, "111-0"), indexOf(first.values, "111-1"))
+	assert.Less(suite.T(), indexOf(first.values, "222-2"), indexOf(first.values, "222-3"))
+
+	committed := int64(0)
+	for partition := int32(0); partition < 2; partition++ {
+		committed += suite.broker.Committed("order-service", "payment-result", partition)
+	}
+	assert.Equal(suite.T(), int64(4), committed)
+}
+
+func (suite *BrokerTestSuite) TestConsumeResume() {
+	suite.publish("payment-result", "111", 2)
+	first := &recorder{}
+	consumer := suite.consume("order-service", "payment-result", first.handle)
+	assert.Eventually(suite.T(), func() bool { return first.count() == 2 }, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), consumer.Close(suite.ctx))
+
+	suite.publish("payment-result", "111", 3)
+	second := &recorder{}
+	consumer = suite.consume("order-service", "payment-result", second.handle)
+	assert.Eventually(suite.T(), func() bool { return second.count() == 3 }, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), consumer.Close(suite.ctx))
+
+	assert.Equal(suite.T(), []string{"111-2", "111-3", "111-4"}, second.values)
+}
+
+func (suite *BrokerTestSuite) TestConsumeShared() {
+	suite.publish("payment-result", "111", 3)
+	suite.publish("payment-result", "333", 3)
+	first, second := &recorder{}, &recorder{}
+
+	firstConsumer := suite.consume("order-service", "payment-result", first.handle)
+	secondConsumer := suite.consume("order-service", "payment-result", second.handle)
+
+	assert.Eventually(suite.T(), func() bool { return first.count()+second.count() == 6 }, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), firstConsumer.Close(suite.ctx))
+	assert.NoError(suite.T(), secondConsumer.Close(suite.ctx))
+	assert.Equal(suite.T(), 6, first.count()+second.count())
+}
+
+func (suite *BrokerTestSuite) TestConsumeDeadLetter() {
+	suite.publish("payment-result", "111", 1)
+	handled := &recorder{}
+
+	consumer := suite.consume("order-service", "payment-result", func(ctx context.Context, message kafkaConfluent.Message) error {
+		handled.handle(ctx, message)
+		return kafkaConfluent.Permanent(errors.New("unknown ticket"))
+	})
+
+	assert.Eventually(suite.T(), func() bool { return len(suite.broker.Messages("payment-result.dlq")) == 1 }, time.Second, time.Millisecond)
+	assert.NoError(suite.T(), consumer.Close(suite.ctx))
+
+	parked := suite.broker.Messages("payment-result.dlq")[0]
+	assert.Equal(suite.T(), []byte("111-0"), parked.Value)
+	assert.Equal(suite.T(), "unknown ticket", parked.Headers[kafkaConfluent.HeaderError])
+	assert.Equal(suite.T(), "payment-result", parked.Headers[kafkaConfluent.HeaderOriginalTopic])
+	assert.Equal(suite.T(), 1, handled.count())
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"time"
+
+	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
+)
+
+// reader is a member of a consumer group of the broker.
+type reader struct {
+	broker  *Broker
+	groupId string
+	topics  []string
+
+	generation int
+	assigned   []topicPartition
+	positions  map[topicPartition]int64
+	next       int
+	joined     bool
+}
+
+func newReader(broker *Broker, groupId string) *reader {
+	return &reader{
+		broker:  broker,
+		groupId: groupId,
+	}
+}
+
+func (r *reader) SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error {
+	if r.joined {
+		r.broker.leave(r)
+	}
+	r.broker.join(r, topics)
+	r.joined = true
+	return nil
+}
+
+// ReadMessage waits up to timeout for a message, like the confluent consumer it returns ErrTimedOut when none came.
+func (r *reader) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	if !r.joined {
+		return nil, kafka.NewError(kafka.ErrState, "consumer is not subscribed", false)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		r.broker.mu.Lock()
+		msg := r.broker.fetch(r)
+		changed := r.broker.changed
+		r.broker.mu.Unlock()
+
+		if msg != nil {
+			return msg, nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil, kafka.NewError(kafka.ErrTimedOut, "no message", false)
+		}
+	}
+}
+
+func (r *reader) CommitMessage(m *kafka.Message) ([]kafka.TopicPartition, error) {
+	tp := topicPartition{topic: *m.TopicPartition.Topic, partition: m.TopicPartition.Partition}
+	offset := m.TopicPartition.Offset + 1
+	r.broker.commit(r.groupId, tp, int64(offset))
+
+	return []kafka.TopicPartition{{Topic: m.TopicPartition.Topic, Partition: tp.partition, Offset: offset}}, nil
+}
+
+// Close leaves the group, its partitions go to the remaining members.
+func (r *reader) Close() error {
+	if r.joined {
+		r.broker.leave(r)
+		r.joined = false
+	}
+	return nil
+}
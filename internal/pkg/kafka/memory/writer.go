@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"sync"
+
+	"gopkg.in/confluentinc/confluent-kafka-go.v1/kafka"
+)
+
+// writer appends messages to the broker and reports the delivery right away.
+type writer struct {
+	broker *Broker
+
+	mu     sync.Mutex
+	events chan kafka.Event
+	closed bool
+}
+
+func newWriter(broker *Broker) *writer {
+	return &writer{
+		broker: broker,
+		events: make(chan kafka.Event, 100),
+	}
+}
+
+func (w *writer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return kafka.NewError(kafka.ErrState, "producer is closed", false)
+	}
+
+	stored, err := w.broker.append(msg)
+	if err != nil {
+		return err
+	}
+
+	report := *stored
+	report.Opaque = msg.Opaque
+	if deliveryChan != nil {
+		deliveryChan <- &report
+	} else {
+		w.events <- &report
+	}
+	return nil
+}
+
+func (w *writer) Events() chan kafka.Event {
+	return w.events
+}
+
+// Flush has nothing to wait for, every message is stored by Produce.
+func (w *writer) Flush(timeoutMs int) int {
+	return 0
+}
+
+func (w *writer) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.events)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"order-service/configs"
@@ -13,14 +14,14 @@ import (
 	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis.v8"
 )
 
-// var redisClient *redis.Client
-
+// RedisClient works against a single node and a cluster alike, cluster mode needs the keys of one
+// multi-key command, pipeline transaction or script to share a slot, see HashTag.
 type RedisClient struct {
-	Client interface{}
+	Client redis.UniversalClient
 }
 
 func InitConnection(redisDB, redisHost, redisPort, redisPassword string, appConfig string) Collections {
-	var client interface{}
+	var client redis.UniversalClient
 
 	if appConfig != "cluster" {
 		// Create Redis Client
@@ -53,6 +54,10 @@ func InitConnection(redisDB, redisHost, redisPort, redisPassword string, appConf
 			Password: redisPassword,
 		})
 
+		if configs.GetConfig().Datadog.DatadogEnabled == "true" {
+			redistrace.WrapClient(c)
+		}
+
 		// Test Connection
 		for _, addr := range hostArray {
 			nodeClient := redis.NewClient(&redis.Options{
@@ -72,48 +77,146 @@ func InitConnection(redisDB, redisHost, redisPort, redisPassword string, appConf
 	return &RedisClient{Client: client}
 }
 
+// HashTag wraps the part of a key redis cluster hashes, keys sharing a tag live on the same slot.
+func HashTag(id string) string {
+	return "{" + id + "}"
+}
+
 type Collections interface {
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
 	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	ScriptLoad(ctx context.Context, script string) *redis.StringCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
-	Conn(ctx context.Context) *redis.Conn
 	Get(ctx context.Context, key string) *redis.StringCmd
+	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	TTL(ctx context.Context, key string) *redis.DurationCmd
+	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
+	ZAdd(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd
+	ZAddXX(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error
 
 	Close() error
 }
 
 func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
-	return r.Client.(*redis.Client).SetNX(ctx, key, value, expiration)
+	return r.Client.SetNX(ctx, key, value, expiration)
 }
 
 func (r *RedisClient) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
-	return r.Client.(*redis.Client).EvalSha(ctx, sha1, keys, args...)
+	return r.Client.EvalSha(ctx, sha1, keys, args...)
 }
 
-func (r *RedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
-	return r.Client.(*redis.Client).Del(ctx, keys...)
+// ScriptLoad loads the script on every master of a cluster, so EvalSha finds it whichever slot its keys hash to.
+func (r *RedisClient) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	return r.Client.ScriptLoad(ctx, script)
 }
 
-func (r *RedisClient) Conn(ctx context.Context) *redis.Conn {
-	return r.Client.(*redis.Client).Conn(ctx)
+func (r *RedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	return r.Client.Del(ctx, keys...)
 }
 
 func (r *RedisClient) Get(ctx context.Context, key string) *redis.StringCmd {
-	return r.Client.(*redis.Client).Get(ctx, key)
+	return r.Client.Get(ctx, key)
+}
+
+func (r *RedisClient) MGet(ctx context.Context, keys ...string) *redis.SliceCmd {
+	return r.Client.MGet(ctx, keys...)
 }
 
 func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
-	return r.Client.(*redis.Client).Set(ctx, key, value, expiration)
+	return r.Client.Set(ctx, key, value, expiration)
 }
 
-func (r *RedisClient) Close() error {
-	switch c := r.Client.(type) {
-	case *redis.Client:
-		return c.Close()
-	case *redis.ClusterClient:
-		return c.Close()
-	default:
-		return fmt.Errorf("unsupported Redis client type")
+func (r *RedisClient) Incr(ctx context.Context, key string) *redis.IntCmd {
+	return r.Client.Incr(ctx, key)
+}
+
+func (r *RedisClient) IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd {
+	return r.Client.IncrBy(ctx, key, value)
+}
+
+func (r *RedisClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	return r.Client.Expire(ctx, key, expiration)
+}
+
+func (r *RedisClient) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	return r.Client.TTL(ctx, key)
+}
+
+func (r *RedisClient) SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return r.Client.SAdd(ctx, key, members...)
+}
+
+func (r *RedisClient) SMembers(ctx context.Context, key string) *redis.StringSliceCmd {
+	return r.Client.SMembers(ctx, key)
+}
+
+func (r *RedisClient) ZAdd(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd {
+	return r.Client.ZAdd(ctx, key, members...)
+}
+
+// ZAddXX only updates the score of members already in the sorted set.
+func (r *RedisClient) ZAddXX(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd {
+	return r.Client.ZAddXX(ctx, key, members...)
+}
+
+func (r *RedisClient) ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return r.Client.ZRem(ctx, key, members...)
+}
+
+// Pipelined sends the commands of fn in one round trip, a cluster splits them per node.
+func (r *RedisClient) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return r.Client.Pipelined(ctx, fn)
+}
+
+// TxPipelined runs the commands of fn in MULTI/EXEC, on a cluster their keys must share a hash tag.
+func (r *RedisClient) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return r.Client.TxPipelined(ctx, fn)
+}
+
+// Scan calls fn with every batch of keys matching the pattern. SCAN only walks the node it is sent to,
+// so on a cluster every master is scanned.
+func (r *RedisClient) Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error {
+	if cluster, ok := r.Client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node, match, count, func(keys []string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return fn(keys)
+			})
+		})
 	}
+	return scan(ctx, r.Client, match, count, fn)
+}
+
+func scan(ctx context.Context, client redis.Cmdable, match string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (r *RedisClient) Close() error {
+	return r.Client.Close()
 }
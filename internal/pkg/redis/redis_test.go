@@ -0,0 +1,135 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"order-service/internal/pkg/redis"
+	mockredis "order-service/mocks/pkg/redis"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// unreachable is an address nothing listens on, the commands fail fast instead of reaching a server
+const unreachable = "127.0.0.1:1"
+
+type RedisSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func (suite *RedisSuite) SetupTest() {
+	suite.ctx = context.Background()
+}
+
+func TestRedisSuite(t *testing.T) {
+	suite.Run(t, new(RedisSuite))
+}
+
+func (suite *RedisSuite) TestHashTag() {
+	assert.Equal(suite.T(), "{id}", redis.HashTag("id"))
+}
+
+// TestClient runs every command against a single node and a cluster client, both must surface the connection
+// error instead of panicking on the client type.
+func (suite *RedisSuite) TestClient() {
+	clients := map[string]goredis.UniversalClient{
+		"single": goredis.NewClient(&goredis.Options{
+			Addr: unreachable, MaxRetries: -1, DialTimeout: 50 * time.Millisecond,
+		}),
+		"cluster": goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs: []string{unreachable}, MaxRetries: -1, MaxRedirects: -1, DialTimeout: 50 * time.Millisecond,
+		}),
+	}
+
+	for name, client := range clients {
+		collections := &redis.RedisClient{Client: client}
+		key := "ORDER:QUEUE-COUNTER:" + redis.HashTag("id")
+
+		assert.Error(suite.T(), collections.Get(suite.ctx, key).Err(), name)
+		assert.Error(suite.T(), collections.Set(suite.ctx, key, 1, time.Minute).Err(), name)
+		assert.Error(suite.T(), collections.SetNX(suite.ctx, key, 1, time.Minute).Err(), name)
+		assert.Error(suite.T(), collections.MGet(suite.ctx, key).Err(), name)
+		assert.Error(suite.T(), collections.Incr(suite.ctx, key).Err(), name)
+		assert.Error(suite.T(), collections.IncrBy(suite.ctx, key, 2).Err(), name)
+		assert.Error(suite.T(), collections.Expire(suite.ctx, key, time.Minute).Err(), name)
+		assert.Error(suite.T(), collections.TTL(suite.ctx, key).Err(), name)
+		assert.Error(suite.T(), collections.SAdd(suite.ctx, key, "id").Err(), name)
+		assert.Error(suite.T(), collections.SMembers(suite.ctx, key).Err(), name)
+		assert.Error(suite.T(), collections.ZAdd(suite.ctx, key, &goredis.Z{Score: 1, Member: "id"}).Err(), name)
+		assert.Error(suite.T(), collections.ZAddXX(suite.ctx, key, &goredis.Z{Score: 1, Member: "id"}).Err(), name)
+		assert.Error(suite.T(), collections.ZRem(suite.ctx, key, "id").Err(), name)
+		// a cluster loads the script on the masters it knows, with no reachable node there are none to fail
+		assert.NotPanics(suite.T(), func() { collections.ScriptLoad(suite.ctx, "return 1") }, name)
+		assert.Error(suite.T(), collections.EvalSha(suite.ctx, "sha", []string{key}).Err(), name)
+		assert.Error(suite.T(), collections.Del(suite.ctx, key).Err(), name)
+
+		_, err := collections.Pipelined(suite.ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Incr(suite.ctx, key)
+			pipe.Expire(suite.ctx, key, time.Minute)
+			return nil
+		})
+		assert.Error(suite.T(), err, name)
+		_, err = collections.TxPipelined(suite.ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Incr(suite.ctx, key)
+			return nil
+		})
+		assert.Error(suite.T(), err, name)
+
+		err = collections.Scan(suite.ctx, "ORDER:*", 100, func(keys []string) error { return nil })
+		assert.Error(suite.T(), err, name)
+
+		assert.NoError(suite.T(), collections.Close(), name)
+	}
+}
+
+func (suite *RedisSuite) TestScript() {
+	script := redis.NewScript("return 1")
+	collections := &mockredis.Collections{}
+	collections.On("EvalSha", mock.Anything, "e0e1f9fabfc9d4800c877a703b823ac0578ff8db", []string{"key"}, "arg").
+		Return(goredis.NewCmdResult(int64(1), nil))
+
+	result, err := script.Run(suite.ctx, collections, []string{"key"}, "arg").Int()
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result)
+	collections.AssertNotCalled(suite.T(), "ScriptLoad", mock.Anything, mock.Anything)
+}
+
+func (suite *RedisSuite) TestScriptNotLoaded() {
+	script := redis.NewScript("return 1")
+	collections := &mockredis.Collections{}
+	collections.On("EvalSha", mock.Anything, mock.Anything, []string{"key"}).
+		Return(goredis.NewCmdResult(nil, goRedisError("NOSCRIPT No matching script. Please use EVAL."))).Once()
+	collections.On("EvalSha", mock.Anything, mock.Anything, []string{"key"}).
+		Return(goredis.NewCmdResult(int64(1), nil)).Once()
+	collections.On("ScriptLoad", mock.Anything, "return 1").Return(goredis.NewStringResult("sha", nil))
+
+	result, err := script.Run(suite.ctx, collections, []string{"key"}).Int()
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result)
+	collections.AssertNumberOfCalls(suite.T(), "EvalSha", 2)
+}
+
+func (suite *RedisSuite) TestScriptErrLoad() {
+	script := redis.NewScript("return 1")
+	collections := &mockredis.Collections{}
+	collections.On("EvalSha", mock.Anything, mock.Anything, []string{"key"}).
+		Return(goredis.NewCmdResult(nil, goRedisError("NOSCRIPT No matching script. Please use EVAL.")))
+	collections.On("ScriptLoad", mock.Anything, "return 1").Return(goredis.NewStringResult("", goRedisError("ERR Error compiling script")))
+
+	err := script.Run(suite.ctx, collections, []string{"key"}).Err()
+
+	assert.EqualError(suite.T(), err, "ERR Error compiling script")
+	collections.AssertNumberOfCalls(suite.T(), "EvalSha", 1)
+}
+
+// goRedisError is an error reply of the server
+type goRedisError string
+
+func (e goRedisError) Error() string { return string(e) }
@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Script is a lua script run by its sha. It is loaded again on a NOSCRIPT reply, so a restarted or failed over
+// redis does not need the service to restart.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript is a constructor of a script, src is only sent to redis when the sha is unknown there.
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Run evaluates the script. It is atomic, on a cluster every key must share a hash tag.
+func (s *Script) Run(ctx context.Context, c Collections, keys []string, args ...interface{}) *redis.Cmd {
+	cmd := c.EvalSha(ctx, s.hash, keys, args...)
+	if err := cmd.Err(); err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return cmd
+	}
+
+	if err := c.ScriptLoad(ctx, s.src).Err(); err != nil {
+		return redis.NewCmdResult(nil, err)
+	}
+	return c.EvalSha(ctx, s.hash, keys, args...)
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// Worker runs a tick function on a fixed interval in its own goroutine until it is closed. A tick running
+// longer than the interval delays the next one instead of overlapping it.
+type Worker struct {
+	Interval time.Duration
+
+	tick func(ctx context.Context)
+	stop chan struct{}
+	done chan struct{}
+}
+
+func Start(interval time.Duration, tick func(ctx context.Context)) *Worker {
+	worker := &Worker{
+		Interval: interval,
+		tick:     tick,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go worker.run()
+
+	return worker
+}
+
+func (w *Worker) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.tick(context.Background())
+		}
+	}
+}
+
+// Close stops the ticker and waits for a running tick to finish, it is registered on GracefulShutdown.
+func (w *Worker) Close(ctx context.Context) error {
+	close(w.stop)
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package worker_test
+
+import (
+	"context"
+	"order-service/internal/pkg/worker"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker(t *testing.T) {
+	ticks := make(chan struct{}, 100)
+	w := worker.Start(10*time.Millisecond, func(ctx context.Context) {
+		ticks <- struct{}{}
+	})
+
+	select {
+	case <-ticks:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not tick")
+	}
+	assert.NoError(t, w.Close(context.Background()))
+
+	// no tick runs once Close returned
+	for len(ticks) > 0 {
+		<-ticks
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, ticks)
+}
+
+func TestWorkerCloseWaitsForTick(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	w := worker.Start(10*time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+	})
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not tick")
+	}
+
+	// the running tick outlives the shutdown timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Close(ctx), context.DeadlineExceeded)
+	close(release)
+}
@@ -0,0 +1,65 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "order-service/internal/modules/event/models/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SaleSchedule is an autogenerated mock type for the SaleSchedule type
+type SaleSchedule struct {
+	mock.Mock
+}
+
+// CheckQueueOpen provides a mock function with given fields: ctx, _a1
+func (_m *SaleSchedule) CheckQueueOpen(ctx context.Context, _a1 entity.Event) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckQueueOpen")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Event) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckSaleOpen provides a mock function with given fields: ctx, _a1, ticketTypes
+func (_m *SaleSchedule) CheckSaleOpen(ctx context.Context, _a1 entity.Event, ticketTypes []string) error {
+	ret := _m.Called(ctx, _a1, ticketTypes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckSaleOpen")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Event, []string) error); ok {
+		r0 = rf(ctx, _a1, ticketTypes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSaleSchedule creates a new instance of SaleSchedule. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSaleSchedule(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SaleSchedule {
+	mock := &SaleSchedule{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	entity "order-service/internal/modules/order/models/entity"
 	helpers "order-service/internal/pkg/helpers"
 
 	mock "github.com/stretchr/testify/mock"
@@ -16,16 +17,16 @@ type MongodbRepositoryCommand struct {
 	mock.Mock
 }
 
-// UpdateBankTicket provides a mock function with given fields: ctx, payload
-func (_m *MongodbRepositoryCommand) UpdateBankTicket(ctx context.Context, payload request.UpdateBankTicketReq) <-chan helpers.Result {
+// ClaimBankTickets provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) ClaimBankTickets(ctx context.Context, payload request.ClaimBankTicketsReq) <-chan helpers.Result {
 	ret := _m.Called(ctx, payload)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateBankTicket")
+		panic("no return value specified for ClaimBankTickets")
 	}
 
 	var r0 <-chan helpers.Result
-	if rf, ok := ret.Get(0).(func(context.Context, request.UpdateBankTicketReq) <-chan helpers.Result); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, request.ClaimBankTicketsReq) <-chan helpers.Result); ok {
 		r0 = rf(ctx, payload)
 	} else {
 		if ret.Get(0) != nil {
@@ -36,6 +37,146 @@ func (_m *MongodbRepositoryCommand) UpdateBankTicket(ctx context.Context, payloa
 	return r0
 }
 
+// CreateOrderIndexes provides a mock function with given fields: ctx
+func (_m *MongodbRepositoryCommand) CreateOrderIndexes(ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrderIndexes")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan helpers.Result); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// InsertOrder provides a mock function with given fields: ctx, _a1
+func (_m *MongodbRepositoryCommand) InsertOrder(ctx context.Context, _a1 entity.Order) <-chan helpers.Result {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertOrder")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Order) <-chan helpers.Result); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// ReleaseBankTicket provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) ReleaseBankTicket(ctx context.Context, payload request.ReleaseBankTicketReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseBankTicket")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.ReleaseBankTicketReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// ReleasePurchaseQuota provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) ReleasePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleasePurchaseQuota")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.PurchaseQuotaReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// ReservePurchaseQuota provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) ReservePurchaseQuota(ctx context.Context, payload request.PurchaseQuotaReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReservePurchaseQuota")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.PurchaseQuotaReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// TransitionBankTicket provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) TransitionBankTicket(ctx context.Context, payload request.TransitionBankTicketReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionBankTicket")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.TransitionBankTicketReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *MongodbRepositoryCommand) WithTransaction(ctx context.Context, fn func(context.Context) error) <-chan helpers.Result {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) <-chan helpers.Result); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // NewMongodbRepositoryCommand creates a new instance of MongodbRepositoryCommand. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMongodbRepositoryCommand(t interface {
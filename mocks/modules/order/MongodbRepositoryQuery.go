@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	request "order-service/internal/modules/order/models/request"
+
+	time "time"
 )
 
 // MongodbRepositoryQuery is an autogenerated mock type for the MongodbRepositoryQuery type
@@ -16,17 +18,37 @@ type MongodbRepositoryQuery struct {
 	mock.Mock
 }
 
-// FindBankTicketByParam provides a mock function with given fields: ctx, queueId, userId
-func (_m *MongodbRepositoryQuery) FindBankTicketByParam(ctx context.Context, queueId string, userId string) <-chan helpers.Result {
-	ret := _m.Called(ctx, queueId, userId)
+// CountHeldBankTickets provides a mock function with given fields: ctx, userId, eventId
+func (_m *MongodbRepositoryQuery) CountHeldBankTickets(ctx context.Context, userId string, eventId string) <-chan helpers.Result {
+	ret := _m.Called(ctx, userId, eventId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindBankTicketByParam")
+		panic("no return value specified for CountHeldBankTickets")
 	}
 
 	var r0 <-chan helpers.Result
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan helpers.Result); ok {
-		r0 = rf(ctx, queueId, userId)
+		r0 = rf(ctx, userId, eventId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// FindBankTicketByTicketNumber provides a mock function with given fields: ctx, ticketNumber
+func (_m *MongodbRepositoryQuery) FindBankTicketByTicketNumber(ctx context.Context, ticketNumber string) <-chan helpers.Result {
+	ret := _m.Called(ctx, ticketNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBankTicketByTicketNumber")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, ticketNumber)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(<-chan helpers.Result)
@@ -56,6 +78,86 @@ func (_m *MongodbRepositoryQuery) FindBankTicketByUser(ctx context.Context, payl
 	return r0
 }
 
+// FindBankTicketsByOrderId provides a mock function with given fields: ctx, orderId
+func (_m *MongodbRepositoryQuery) FindBankTicketsByOrderId(ctx context.Context, orderId string) <-chan helpers.Result {
+	ret := _m.Called(ctx, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBankTicketsByOrderId")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// FindExpiredBankTickets provides a mock function with given fields: ctx, expiredBefore, size
+func (_m *MongodbRepositoryQuery) FindExpiredBankTickets(ctx context.Context, expiredBefore time.Time, size int64) <-chan helpers.Result {
+	ret := _m.Called(ctx, expiredBefore, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindExpiredBankTickets")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int64) <-chan helpers.Result); ok {
+		r0 = rf(ctx, expiredBefore, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// FindFreeSeats provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryQuery) FindFreeSeats(ctx context.Context, payload request.FreeSeatReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindFreeSeats")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.FreeSeatReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// FindOrderByTicketNumber provides a mock function with given fields: ctx, ticketNumber
+func (_m *MongodbRepositoryQuery) FindOrderByTicketNumber(ctx context.Context, ticketNumber string) <-chan helpers.Result {
+	ret := _m.Called(ctx, ticketNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOrderByTicketNumber")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, ticketNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // FindOrderByUser provides a mock function with given fields: ctx, payload
 func (_m *MongodbRepositoryQuery) FindOrderByUser(ctx context.Context, payload request.OrderList) <-chan helpers.Result {
 	ret := _m.Called(ctx, payload)
@@ -17,6 +17,36 @@ type UsecaseCommand struct {
 	mock.Mock
 }
 
+// CancelOrderTicket provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) CancelOrderTicket(origCtx context.Context, payload request.CancelOrderReq) (*response.CancelOrderResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelOrderTicket")
+	}
+
+	var r0 *response.CancelOrderResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.CancelOrderReq) (*response.CancelOrderResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.CancelOrderReq) *response.CancelOrderResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.CancelOrderResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.CancelOrderReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateOrderTicket provides a mock function with given fields: origCtx, payload
 func (_m *UsecaseCommand) CreateOrderTicket(origCtx context.Context, payload request.OrderReq) (*response.OrderResp, error) {
 	ret := _m.Called(origCtx, payload)
@@ -47,6 +77,94 @@ func (_m *UsecaseCommand) CreateOrderTicket(origCtx context.Context, payload req
 	return r0, r1
 }
 
+// ExpireBankTickets provides a mock function with given fields: origCtx
+func (_m *UsecaseCommand) ExpireBankTickets(origCtx context.Context) (int, error) {
+	ret := _m.Called(origCtx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireBankTickets")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(origCtx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(origCtx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(origCtx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HoldSeats provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) HoldSeats(origCtx context.Context, payload request.HoldSeatReq) (*response.HoldSeatResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HoldSeats")
+	}
+
+	var r0 *response.HoldSeatResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.HoldSeatReq) (*response.HoldSeatResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.HoldSeatReq) *response.HoldSeatResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.HoldSeatResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.HoldSeatReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ProcessPaymentResult provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) ProcessPaymentResult(origCtx context.Context, payload request.PaymentResultReq) (*response.PaymentResultResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProcessPaymentResult")
+	}
+
+	var r0 *response.PaymentResultResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.PaymentResultReq) (*response.PaymentResultResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.PaymentResultReq) *response.PaymentResultResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.PaymentResultResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.PaymentResultReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewUsecaseCommand creates a new instance of UsecaseCommand. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUsecaseCommand(t interface {
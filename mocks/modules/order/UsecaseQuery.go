@@ -17,6 +17,66 @@ type UsecaseQuery struct {
 	mock.Mock
 }
 
+// FindFreeSeats provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseQuery) FindFreeSeats(origCtx context.Context, payload request.SeatListReq) (*response.SeatListResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindFreeSeats")
+	}
+
+	var r0 *response.SeatListResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.SeatListReq) (*response.SeatListResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.SeatListReq) *response.SeatListResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.SeatListResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.SeatListReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindOrderDetail provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseQuery) FindOrderDetail(origCtx context.Context, payload request.GetOrderReq) (*response.OrderDetailResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOrderDetail")
+	}
+
+	var r0 *response.OrderDetailResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.GetOrderReq) (*response.OrderDetailResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.GetOrderReq) *response.OrderDetailResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.OrderDetailResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.GetOrderReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindOrderList provides a mock function with given fields: origCtx, payload
 func (_m *UsecaseQuery) FindOrderList(origCtx context.Context, payload request.OrderList) (*response.OrderListResp, error) {
 	ret := _m.Called(origCtx, payload)
@@ -0,0 +1,151 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	helpers "order-service/internal/pkg/helpers"
+
+	mock "github.com/stretchr/testify/mock"
+
+	request "order-service/internal/modules/outbox/models/request"
+)
+
+// MongodbRepositoryCommand is an autogenerated mock type for the MongodbRepositoryCommand type
+type MongodbRepositoryCommand struct {
+	mock.Mock
+}
+
+// ClaimPendingEvent provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) ClaimPendingEvent(ctx context.Context, payload request.ClaimEventReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimPendingEvent")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.ClaimEventReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// CreateOutboxIndexes provides a mock function with given fields: ctx
+func (_m *MongodbRepositoryCommand) CreateOutboxIndexes(ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxIndexes")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan helpers.Result); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// InsertOutboxEvents provides a mock function with given fields: ctx, events
+func (_m *MongodbRepositoryCommand) InsertOutboxEvents(ctx context.Context, events []request.OutboxEventReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, events)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertOutboxEvents")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, []request.OutboxEventReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, events)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// MarkDelivered provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) MarkDelivered(ctx context.Context, payload request.DeliveredEventReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkDelivered")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.DeliveredEventReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// MarkFailed provides a mock function with given fields: ctx, payload
+func (_m *MongodbRepositoryCommand) MarkFailed(ctx context.Context, payload request.FailedEventReq) <-chan helpers.Result {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkFailed")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, request.FailedEventReq) <-chan helpers.Result); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// ReleaseClaim provides a mock function with given fields: ctx, eventId, lockedBy
+func (_m *MongodbRepositoryCommand) ReleaseClaim(ctx context.Context, eventId string, lockedBy string) <-chan helpers.Result {
+	ret := _m.Called(ctx, eventId, lockedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseClaim")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, eventId, lockedBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// NewMongodbRepositoryCommand creates a new instance of MongodbRepositoryCommand. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMongodbRepositoryCommand(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MongodbRepositoryCommand {
+	mock := &MongodbRepositoryCommand{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,61 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "order-service/internal/modules/pricing/models/entity"
+
+	mock "github.com/stretchr/testify/mock"
+
+	request "order-service/internal/modules/pricing/models/request"
+)
+
+// Engine is an autogenerated mock type for the Engine type
+type Engine struct {
+	mock.Mock
+}
+
+// Quote provides a mock function with given fields: ctx, payload
+func (_m *Engine) Quote(ctx context.Context, payload request.QuoteReq) (*entity.Breakdown, error) {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Quote")
+	}
+
+	var r0 *entity.Breakdown
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.QuoteReq) (*entity.Breakdown, error)); ok {
+		return rf(ctx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.QuoteReq) *entity.Breakdown); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Breakdown)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.QuoteReq) error); ok {
+		r1 = rf(ctx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewEngine creates a new instance of Engine. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEngine(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Engine {
+	mock := &Engine{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
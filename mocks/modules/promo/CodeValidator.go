@@ -0,0 +1,61 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "order-service/internal/modules/promo/models/entity"
+
+	mock "github.com/stretchr/testify/mock"
+
+	request "order-service/internal/modules/promo/models/request"
+)
+
+// CodeValidator is an autogenerated mock type for the CodeValidator type
+type CodeValidator struct {
+	mock.Mock
+}
+
+// Validate provides a mock function with given fields: ctx, payload
+func (_m *CodeValidator) Validate(ctx context.Context, payload request.ValidateCodeReq) (*entity.PromoCode, error) {
+	ret := _m.Called(ctx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Validate")
+	}
+
+	var r0 *entity.PromoCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.ValidateCodeReq) (*entity.PromoCode, error)); ok {
+		return rf(ctx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.ValidateCodeReq) *entity.PromoCode); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PromoCode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.ValidateCodeReq) error); ok {
+		r1 = rf(ctx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewCodeValidator creates a new instance of CodeValidator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCodeValidator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CodeValidator {
+	mock := &CodeValidator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,156 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AdmissionController is an autogenerated mock type for the AdmissionController type
+type AdmissionController struct {
+	mock.Mock
+}
+
+// AdmitNextBatch provides a mock function with given fields: ctx
+func (_m *AdmissionController) AdmitNextBatch(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdmitNextBatch")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateWait provides a mock function with given fields: queueNumber, servingNumber
+func (_m *AdmissionController) EstimateWait(queueNumber int, servingNumber int) int {
+	ret := _m.Called(queueNumber, servingNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EstimateWait")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int, int) int); ok {
+		r0 = rf(queueNumber, servingNumber)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Open provides a mock function with given fields: ctx, eventId
+func (_m *AdmissionController) Open(ctx context.Context, eventId string) error {
+	ret := _m.Called(ctx, eventId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Open")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, eventId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, eventId, queueId
+func (_m *AdmissionController) Release(ctx context.Context, eventId string, queueId string) error {
+	ret := _m.Called(ctx, eventId, queueId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Release")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, eventId, queueId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Revoke provides a mock function with given fields: ctx, eventId, queueId, queueNumber
+func (_m *AdmissionController) Revoke(ctx context.Context, eventId string, queueId string, queueNumber int) error {
+	ret := _m.Called(ctx, eventId, queueId, queueNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, eventId, queueId, queueNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ServingNumber provides a mock function with given fields: ctx, eventId
+func (_m *AdmissionController) ServingNumber(ctx context.Context, eventId string) (int, error) {
+	ret := _m.Called(ctx, eventId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServingNumber")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, eventId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, eventId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, eventId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAdmissionController creates a new instance of AdmissionController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAdmissionController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AdmissionController {
+	mock := &AdmissionController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -8,6 +8,8 @@ import (
 	helpers "order-service/internal/pkg/helpers"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MongodbRepositoryCommand is an autogenerated mock type for the MongodbRepositoryCommand type
@@ -15,6 +17,26 @@ type MongodbRepositoryCommand struct {
 	mock.Mock
 }
 
+// CreateQueueIndexes provides a mock function with given fields: ctx
+func (_m *MongodbRepositoryCommand) CreateQueueIndexes(ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateQueueIndexes")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan helpers.Result); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // InsertOneRoom provides a mock function with given fields: ctx, _a1
 func (_m *MongodbRepositoryCommand) InsertOneRoom(ctx context.Context, _a1 entity.QueueRoom) <-chan helpers.Result {
 	ret := _m.Called(ctx, _a1)
@@ -35,6 +57,86 @@ func (_m *MongodbRepositoryCommand) InsertOneRoom(ctx context.Context, _a1 entit
 	return r0
 }
 
+// LeaveQueueRoom provides a mock function with given fields: ctx, userId, eventId
+func (_m *MongodbRepositoryCommand) LeaveQueueRoom(ctx context.Context, userId string, eventId string) <-chan helpers.Result {
+	ret := _m.Called(ctx, userId, eventId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LeaveQueueRoom")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, userId, eventId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// ReenterQueueRoom provides a mock function with given fields: ctx, _a1
+func (_m *MongodbRepositoryCommand) ReenterQueueRoom(ctx context.Context, _a1 entity.QueueRoom) <-chan helpers.Result {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReenterQueueRoom")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, entity.QueueRoom) <-chan helpers.Result); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// RefreshQueueRoom provides a mock function with given fields: ctx, queueId, expiredAt
+func (_m *MongodbRepositoryCommand) RefreshQueueRoom(ctx context.Context, queueId string, expiredAt time.Time) <-chan helpers.Result {
+	ret := _m.Called(ctx, queueId, expiredAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshQueueRoom")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) <-chan helpers.Result); ok {
+		r0 = rf(ctx, queueId, expiredAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *MongodbRepositoryCommand) WithTransaction(ctx context.Context, fn func(context.Context) error) <-chan helpers.Result {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) <-chan helpers.Result); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // NewMongodbRepositoryCommand creates a new instance of MongodbRepositoryCommand. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMongodbRepositoryCommand(t interface {
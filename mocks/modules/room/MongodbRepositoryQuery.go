@@ -14,6 +14,26 @@ type MongodbRepositoryQuery struct {
 	mock.Mock
 }
 
+// FindActiveQueues provides a mock function with given fields: ctx, eventId
+func (_m *MongodbRepositoryQuery) FindActiveQueues(ctx context.Context, eventId string) <-chan helpers.Result {
+	ret := _m.Called(ctx, eventId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindActiveQueues")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, eventId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // FindOneLastQueue provides a mock function with given fields: ctx, eventId
 func (_m *MongodbRepositoryQuery) FindOneLastQueue(ctx context.Context, eventId string) <-chan helpers.Result {
 	ret := _m.Called(ctx, eventId)
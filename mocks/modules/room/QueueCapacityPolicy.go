@@ -0,0 +1,57 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "order-service/internal/modules/event/models/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// QueueCapacityPolicy is an autogenerated mock type for the QueueCapacityPolicy type
+type QueueCapacityPolicy struct {
+	mock.Mock
+}
+
+// Limit provides a mock function with given fields: ctx, event
+func (_m *QueueCapacityPolicy) Limit(ctx context.Context, event entity.Event) (int, error) {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Limit")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Event) (int, error)); ok {
+		return rf(ctx, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Event) int); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.Event) error); ok {
+		r1 = rf(ctx, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewQueueCapacityPolicy creates a new instance of QueueCapacityPolicy. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewQueueCapacityPolicy(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *QueueCapacityPolicy {
+	mock := &QueueCapacityPolicy{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
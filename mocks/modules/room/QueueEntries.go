@@ -0,0 +1,94 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// QueueEntries is an autogenerated mock type for the QueueEntries type
+type QueueEntries struct {
+	mock.Mock
+}
+
+// Refresh provides a mock function with given fields: ctx, eventId, userId, expiredAt
+func (_m *QueueEntries) Refresh(ctx context.Context, eventId string, userId string, expiredAt time.Time) error {
+	ret := _m.Called(ctx, eventId, userId, expiredAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, eventId, userId, expiredAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Remove provides a mock function with given fields: ctx, eventId, userId
+func (_m *QueueEntries) Remove(ctx context.Context, eventId string, userId string) error {
+	ret := _m.Called(ctx, eventId, userId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, eventId, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reserve provides a mock function with given fields: ctx, eventId, userId, expiredAt, limit
+func (_m *QueueEntries) Reserve(ctx context.Context, eventId string, userId string, expiredAt time.Time, limit int) (bool, error) {
+	ret := _m.Called(ctx, eventId, userId, expiredAt, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reserve")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, int) (bool, error)); ok {
+		return rf(ctx, eventId, userId, expiredAt, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, int) bool); ok {
+		r0 = rf(ctx, eventId, userId, expiredAt, limit)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, int) error); ok {
+		r1 = rf(ctx, eventId, userId, expiredAt, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewQueueEntries creates a new instance of QueueEntries. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewQueueEntries(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *QueueEntries {
+	mock := &QueueEntries{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -46,6 +46,72 @@ func (_m *UsecaseCommand) CreateQueueRoom(origCtx context.Context, payload reque
 	return r0, r1
 }
 
+// InvalidateQueueLimit provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) InvalidateQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) error {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InvalidateQueueLimit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueCapacityReq) error); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LeaveQueueRoom provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) LeaveQueueRoom(origCtx context.Context, payload request.QueueReq) error {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LeaveQueueRoom")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueReq) error); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecomputeQueueLimit provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseCommand) RecomputeQueueLimit(origCtx context.Context, payload request.QueueCapacityReq) (*response.QueueCapacityResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecomputeQueueLimit")
+	}
+
+	var r0 *response.QueueCapacityResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueCapacityReq) (*response.QueueCapacityResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueCapacityReq) *response.QueueCapacityResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.QueueCapacityResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.QueueCapacityReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewUsecaseCommand creates a new instance of UsecaseCommand. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUsecaseCommand(t interface {
@@ -2,13 +2,50 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+	request "order-service/internal/modules/room/models/request"
+
+	mock "github.com/stretchr/testify/mock"
+
+	response "order-service/internal/modules/room/models/response"
+)
 
 // UsecaseQuery is an autogenerated mock type for the UsecaseQuery type
 type UsecaseQuery struct {
 	mock.Mock
 }
 
+// GetQueueStatus provides a mock function with given fields: origCtx, payload
+func (_m *UsecaseQuery) GetQueueStatus(origCtx context.Context, payload request.QueueStatusReq) (*response.QueueStatusResp, error) {
+	ret := _m.Called(origCtx, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQueueStatus")
+	}
+
+	var r0 *response.QueueStatusResp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueStatusReq) (*response.QueueStatusResp, error)); ok {
+		return rf(origCtx, payload)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, request.QueueStatusReq) *response.QueueStatusResp); ok {
+		r0 = rf(origCtx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*response.QueueStatusResp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, request.QueueStatusReq) error); ok {
+		r1 = rf(origCtx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewUsecaseQuery creates a new instance of UsecaseQuery. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUsecaseQuery(t interface {
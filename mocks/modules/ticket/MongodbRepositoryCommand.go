@@ -15,6 +15,46 @@ type MongodbRepositoryCommand struct {
 	mock.Mock
 }
 
+// DecrementTicketDetail provides a mock function with given fields: ctx, ticketId, eventId, quantity
+func (_m *MongodbRepositoryCommand) DecrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan helpers.Result {
+	ret := _m.Called(ctx, ticketId, eventId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecrementTicketDetail")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) <-chan helpers.Result); ok {
+		r0 = rf(ctx, ticketId, eventId, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
+// IncrementTicketDetail provides a mock function with given fields: ctx, ticketId, eventId, quantity
+func (_m *MongodbRepositoryCommand) IncrementTicketDetail(ctx context.Context, ticketId string, eventId string, quantity int) <-chan helpers.Result {
+	ret := _m.Called(ctx, ticketId, eventId, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementTicketDetail")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) <-chan helpers.Result); ok {
+		r0 = rf(ctx, ticketId, eventId, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // UpdateOneTicketDetail provides a mock function with given fields: ctx, payload
 func (_m *MongodbRepositoryCommand) UpdateOneTicketDetail(ctx context.Context, payload entity.Ticket) <-chan helpers.Result {
 	ret := _m.Called(ctx, payload)
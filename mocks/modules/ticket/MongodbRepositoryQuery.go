@@ -7,8 +7,6 @@ import (
 	helpers "order-service/internal/pkg/helpers"
 
 	mock "github.com/stretchr/testify/mock"
-
-	request "order-service/internal/modules/ticket/models/request"
 )
 
 // MongodbRepositoryQuery is an autogenerated mock type for the MongodbRepositoryQuery type
@@ -36,17 +34,17 @@ func (_m *MongodbRepositoryQuery) FindTicketByEventId(ctx context.Context, event
 	return r0
 }
 
-// FindTotalAvalailableTicket provides a mock function with given fields: ctx, countryCode, tag
-func (_m *MongodbRepositoryQuery) FindTotalAvalailableTicket(ctx context.Context, countryCode string, tag string) <-chan helpers.Result {
-	ret := _m.Called(ctx, countryCode, tag)
+// FindTicketsByEventId provides a mock function with given fields: ctx, eventId
+func (_m *MongodbRepositoryQuery) FindTicketsByEventId(ctx context.Context, eventId string) <-chan helpers.Result {
+	ret := _m.Called(ctx, eventId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindTotalAvalailableTicket")
+		panic("no return value specified for FindTicketsByEventId")
 	}
 
 	var r0 <-chan helpers.Result
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan helpers.Result); ok {
-		r0 = rf(ctx, countryCode, tag)
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, eventId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(<-chan helpers.Result)
@@ -56,17 +54,17 @@ func (_m *MongodbRepositoryQuery) FindTotalAvalailableTicket(ctx context.Context
 	return r0
 }
 
-// FindTotalAvalailableTicketByCountry provides a mock function with given fields: ctx, payload
-func (_m *MongodbRepositoryQuery) FindTotalAvalailableTicketByCountry(ctx context.Context, payload request.TicketReq) <-chan helpers.Result {
-	ret := _m.Called(ctx, payload)
+// FindTotalAvalailableTicket provides a mock function with given fields: ctx, countryCode, tag
+func (_m *MongodbRepositoryQuery) FindTotalAvalailableTicket(ctx context.Context, countryCode string, tag string) <-chan helpers.Result {
+	ret := _m.Called(ctx, countryCode, tag)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindTotalAvalailableTicketByCountry")
+		panic("no return value specified for FindTotalAvalailableTicket")
 	}
 
 	var r0 <-chan helpers.Result
-	if rf, ok := ret.Get(0).(func(context.Context, request.TicketReq) <-chan helpers.Result); ok {
-		r0 = rf(ctx, payload)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) <-chan helpers.Result); ok {
+		r0 = rf(ctx, countryCode, tag)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(<-chan helpers.Result)
@@ -0,0 +1,47 @@
+// Code generated by mockery v2.40.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "order-service/internal/modules/event/models/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReleasePolicy is an autogenerated mock type for the ReleasePolicy type
+type ReleasePolicy struct {
+	mock.Mock
+}
+
+// CheckReleased provides a mock function with given fields: ctx, event, ticketTypes
+func (_m *ReleasePolicy) CheckReleased(ctx context.Context, event entity.Event, ticketTypes []string) error {
+	ret := _m.Called(ctx, event, ticketTypes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckReleased")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.Event, []string) error); ok {
+		r0 = rf(ctx, event, ticketTypes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewReleasePolicy creates a new instance of ReleasePolicy. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReleasePolicy(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReleasePolicy {
+	mock := &ReleasePolicy{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -38,6 +38,26 @@ func (_m *Collections) Aggregate(payload mongodb.Aggregate, ctx context.Context)
 	return r0
 }
 
+// BulkWrite provides a mock function with given fields: payload, ctx
+func (_m *Collections) BulkWrite(payload mongodb.BulkWrite, ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(payload, ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkWrite")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(mongodb.BulkWrite, context.Context) <-chan helpers.Result); ok {
+		r0 = rf(payload, ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields: ctx
 func (_m *Collections) Close(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -76,6 +96,26 @@ func (_m *Collections) CountData(payload mongodb.CountData, ctx context.Context)
 	return r0
 }
 
+// CreateIndexes provides a mock function with given fields: payload, ctx
+func (_m *Collections) CreateIndexes(payload mongodb.CreateIndexes, ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(payload, ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIndexes")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(mongodb.CreateIndexes, context.Context) <-chan helpers.Result); ok {
+		r0 = rf(payload, ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // DeleteOne provides a mock function with given fields: payload, ctx
 func (_m *Collections) DeleteOne(payload mongodb.DeleteOne, ctx context.Context) <-chan helpers.Result {
 	ret := _m.Called(payload, ctx)
@@ -96,6 +136,26 @@ func (_m *Collections) DeleteOne(payload mongodb.DeleteOne, ctx context.Context)
 	return r0
 }
 
+// DropIndex provides a mock function with given fields: payload, ctx
+func (_m *Collections) DropIndex(payload mongodb.DropIndex, ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(payload, ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DropIndex")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(mongodb.DropIndex, context.Context) <-chan helpers.Result); ok {
+		r0 = rf(payload, ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // FindAllData provides a mock function with given fields: payload, ctx
 func (_m *Collections) FindAllData(payload mongodb.FindAllData, ctx context.Context) <-chan helpers.Result {
 	ret := _m.Called(payload, ctx)
@@ -236,6 +296,26 @@ func (_m *Collections) UpsertOne(payload mongodb.UpdateOne, ctx context.Context)
 	return r0
 }
 
+// WithTransaction provides a mock function with given fields: fn, ctx
+func (_m *Collections) WithTransaction(fn func(context.Context) error, ctx context.Context) <-chan helpers.Result {
+	ret := _m.Called(fn, ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
+	var r0 <-chan helpers.Result
+	if rf, ok := ret.Get(0).(func(func(context.Context) error, context.Context) <-chan helpers.Result); ok {
+		r0 = rf(fn, ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan helpers.Result)
+		}
+	}
+
+	return r0
+}
+
 // NewCollections creates a new instance of Collections. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCollections(t interface {
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.39.1. DO NOT EDIT.
+// Code generated by mockery v2.40.1. DO NOT EDIT.
 
 package mocks
 
@@ -17,6 +17,71 @@ type TokenGenerator struct {
 	mock.Mock
 }
 
+// AdmissionAuthorization provides a mock function with given fields: authToken
+func (_m *TokenGenerator) AdmissionAuthorization(authToken string) (*helpers.PayloadAdmission, error) {
+	ret := _m.Called(authToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdmissionAuthorization")
+	}
+
+	var r0 *helpers.PayloadAdmission
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*helpers.PayloadAdmission, error)); ok {
+		return rf(authToken)
+	}
+	if rf, ok := ret.Get(0).(func(string) *helpers.PayloadAdmission); ok {
+		r0 = rf(authToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*helpers.PayloadAdmission)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(authToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateAdmissionToken provides a mock function with given fields: ttl, payload
+func (_m *TokenGenerator) GenerateAdmissionToken(ttl time.Duration, payload helpers.PayloadAdmission) (string, string, error) {
+	ret := _m.Called(ttl, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateAdmissionToken")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(time.Duration, helpers.PayloadAdmission) (string, string, error)); ok {
+		return rf(ttl, payload)
+	}
+	if rf, ok := ret.Get(0).(func(time.Duration, helpers.PayloadAdmission) string); ok {
+		r0 = rf(ttl, payload)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Duration, helpers.PayloadAdmission) string); ok {
+		r1 = rf(ttl, payload)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(time.Duration, helpers.PayloadAdmission) error); ok {
+		r2 = rf(ttl, payload)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GenerateToken provides a mock function with given fields: ttl, payload
 func (_m *TokenGenerator) GenerateToken(ttl time.Duration, payload map[string]interface{}) (string, string, error) {
 	ret := _m.Called(ttl, payload)
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.39.1. DO NOT EDIT.
+// Code generated by mockery v2.40.1. DO NOT EDIT.
 
 package mocks
 
@@ -32,20 +32,27 @@ func (_m *Consumer) Close(ctx context.Context) error {
 	return r0
 }
 
-// SetHandler provides a mock function with given fields: handler
-func (_m *Consumer) SetHandler(handler kafka.ConsumerHandler) {
-	_m.Called(handler)
+// Handle provides a mock function with given fields: topic, handler
+func (_m *Consumer) Handle(topic string, handler kafka.HandlerFunc) {
+	_m.Called(topic, handler)
 }
 
-// Subscribe provides a mock function with given fields: topics
-func (_m *Consumer) Subscribe(topics ...string) {
-	_va := make([]interface{}, len(topics))
-	for _i := range topics {
-		_va[_i] = topics[_i]
+// Start provides a mock function with no fields
+func (_m *Consumer) Start() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
 	}
-	var _ca []interface{}
-	_ca = append(_ca, _va...)
-	_m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // NewConsumer creates a new instance of Consumer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
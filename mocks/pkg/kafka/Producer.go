@@ -1,4 +1,4 @@
-// Code generated by mockery v2.39.1. DO NOT EDIT.
+// Code generated by mockery v2.40.1. DO NOT EDIT.
 
 package mocks
 
@@ -31,9 +31,40 @@ func (_m *Producer) Close(ctx context.Context) error {
 	return r0
 }
 
-// Publish provides a mock function with given fields: topic, message, kafkaPartition
-func (_m *Producer) Publish(topic string, message []byte, kafkaPartition *int32) {
-	_m.Called(topic, message, kafkaPartition)
+// Publish provides a mock function with given fields: topic, key, message, headers, kafkaPartition
+func (_m *Producer) Publish(topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error {
+	ret := _m.Called(topic, key, message, headers, kafkaPartition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Publish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, []byte, map[string]string, *int32) error); ok {
+		r0 = rf(topic, key, message, headers, kafkaPartition)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PublishSync provides a mock function with given fields: ctx, topic, key, message, headers, kafkaPartition
+func (_m *Producer) PublishSync(ctx context.Context, topic string, key []byte, message []byte, headers map[string]string, kafkaPartition *int32) error {
+	ret := _m.Called(ctx, topic, key, message, headers, kafkaPartition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishSync")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte, map[string]string, *int32) error); ok {
+		r0 = rf(ctx, topic, key, message, headers, kafkaPartition)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // NewProducer creates a new instance of Producer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
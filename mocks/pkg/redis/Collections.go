@@ -1,4 +1,4 @@
-// Code generated by mockery v2.39.1. DO NOT EDIT.
+// Code generated by mockery v2.40.1. DO NOT EDIT.
 
 package mocks
 
@@ -17,7 +17,7 @@ type Collections struct {
 	mock.Mock
 }
 
-// Close provides a mock function with given fields:
+// Close provides a mock function with no fields
 func (_m *Collections) Close() error {
 	ret := _m.Called()
 
@@ -35,26 +35,6 @@ func (_m *Collections) Close() error {
 	return r0
 }
 
-// Conn provides a mock function with given fields: ctx
-func (_m *Collections) Conn(ctx context.Context) *v8.Conn {
-	ret := _m.Called(ctx)
-
-	if len(ret) == 0 {
-		panic("no return value specified for Conn")
-	}
-
-	var r0 *v8.Conn
-	if rf, ok := ret.Get(0).(func(context.Context) *v8.Conn); ok {
-		r0 = rf(ctx)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v8.Conn)
-		}
-	}
-
-	return r0
-}
-
 // Del provides a mock function with given fields: ctx, keys
 func (_m *Collections) Del(ctx context.Context, keys ...string) *v8.IntCmd {
 	_va := make([]interface{}, len(keys))
@@ -105,6 +85,26 @@ func (_m *Collections) EvalSha(ctx context.Context, sha1 string, keys []string,
 	return r0
 }
 
+// Expire provides a mock function with given fields: ctx, key, expiration
+func (_m *Collections) Expire(ctx context.Context, key string, expiration time.Duration) *v8.BoolCmd {
+	ret := _m.Called(ctx, key, expiration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Expire")
+	}
+
+	var r0 *v8.BoolCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) *v8.BoolCmd); ok {
+		r0 = rf(ctx, key, expiration)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.BoolCmd)
+		}
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: ctx, key
 func (_m *Collections) Get(ctx context.Context, key string) *v8.StringCmd {
 	ret := _m.Called(ctx, key)
@@ -125,6 +125,184 @@ func (_m *Collections) Get(ctx context.Context, key string) *v8.StringCmd {
 	return r0
 }
 
+// Incr provides a mock function with given fields: ctx, key
+func (_m *Collections) Incr(ctx context.Context, key string) *v8.IntCmd {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Incr")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v8.IntCmd); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
+// IncrBy provides a mock function with given fields: ctx, key, value
+func (_m *Collections) IncrBy(ctx context.Context, key string, value int64) *v8.IntCmd {
+	ret := _m.Called(ctx, key, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrBy")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *v8.IntCmd); ok {
+		r0 = rf(ctx, key, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
+// MGet provides a mock function with given fields: ctx, keys
+func (_m *Collections) MGet(ctx context.Context, keys ...string) *v8.SliceCmd {
+	_va := make([]interface{}, len(keys))
+	for _i := range keys {
+		_va[_i] = keys[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MGet")
+	}
+
+	var r0 *v8.SliceCmd
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) *v8.SliceCmd); ok {
+		r0 = rf(ctx, keys...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.SliceCmd)
+		}
+	}
+
+	return r0
+}
+
+// Pipelined provides a mock function with given fields: ctx, fn
+func (_m *Collections) Pipelined(ctx context.Context, fn func(v8.Pipeliner) error) ([]v8.Cmder, error) {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pipelined")
+	}
+
+	var r0 []v8.Cmder
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(v8.Pipeliner) error) ([]v8.Cmder, error)); ok {
+		return rf(ctx, fn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, func(v8.Pipeliner) error) []v8.Cmder); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v8.Cmder)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, func(v8.Pipeliner) error) error); ok {
+		r1 = rf(ctx, fn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SAdd provides a mock function with given fields: ctx, key, members
+func (_m *Collections) SAdd(ctx context.Context, key string, members ...interface{}) *v8.IntCmd {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, members...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SAdd")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *v8.IntCmd); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
+// SMembers provides a mock function with given fields: ctx, key
+func (_m *Collections) SMembers(ctx context.Context, key string) *v8.StringSliceCmd {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SMembers")
+	}
+
+	var r0 *v8.StringSliceCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v8.StringSliceCmd); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.StringSliceCmd)
+		}
+	}
+
+	return r0
+}
+
+// Scan provides a mock function with given fields: ctx, match, count, fn
+func (_m *Collections) Scan(ctx context.Context, match string, count int64, fn func([]string) error) error {
+	ret := _m.Called(ctx, match, count, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, func([]string) error) error); ok {
+		r0 = rf(ctx, match, count, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScriptLoad provides a mock function with given fields: ctx, script
+func (_m *Collections) ScriptLoad(ctx context.Context, script string) *v8.StringCmd {
+	ret := _m.Called(ctx, script)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ScriptLoad")
+	}
+
+	var r0 *v8.StringCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v8.StringCmd); ok {
+		r0 = rf(ctx, script)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.StringCmd)
+		}
+	}
+
+	return r0
+}
+
 // Set provides a mock function with given fields: ctx, key, value, expiration
 func (_m *Collections) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *v8.StatusCmd {
 	ret := _m.Called(ctx, key, value, expiration)
@@ -165,6 +343,133 @@ func (_m *Collections) SetNX(ctx context.Context, key string, value interface{},
 	return r0
 }
 
+// TTL provides a mock function with given fields: ctx, key
+func (_m *Collections) TTL(ctx context.Context, key string) *v8.DurationCmd {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TTL")
+	}
+
+	var r0 *v8.DurationCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string) *v8.DurationCmd); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.DurationCmd)
+		}
+	}
+
+	return r0
+}
+
+// TxPipelined provides a mock function with given fields: ctx, fn
+func (_m *Collections) TxPipelined(ctx context.Context, fn func(v8.Pipeliner) error) ([]v8.Cmder, error) {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TxPipelined")
+	}
+
+	var r0 []v8.Cmder
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(v8.Pipeliner) error) ([]v8.Cmder, error)); ok {
+		return rf(ctx, fn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, func(v8.Pipeliner) error) []v8.Cmder); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]v8.Cmder)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, func(v8.Pipeliner) error) error); ok {
+		r1 = rf(ctx, fn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ZAdd provides a mock function with given fields: ctx, key, members
+func (_m *Collections) ZAdd(ctx context.Context, key string, members ...*v8.Z) *v8.IntCmd {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZAdd")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...*v8.Z) *v8.IntCmd); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
+// ZAddXX provides a mock function with given fields: ctx, key, members
+func (_m *Collections) ZAddXX(ctx context.Context, key string, members ...*v8.Z) *v8.IntCmd {
+	_va := make([]interface{}, len(members))
+	for _i := range members {
+		_va[_i] = members[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZAddXX")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...*v8.Z) *v8.IntCmd); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
+// ZRem provides a mock function with given fields: ctx, key, members
+func (_m *Collections) ZRem(ctx context.Context, key string, members ...interface{}) *v8.IntCmd {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key)
+	_ca = append(_ca, members...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ZRem")
+	}
+
+	var r0 *v8.IntCmd
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *v8.IntCmd); ok {
+		r0 = rf(ctx, key, members...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v8.IntCmd)
+		}
+	}
+
+	return r0
+}
+
 // NewCollections creates a new instance of Collections. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCollections(t interface {